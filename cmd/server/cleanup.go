@@ -2,21 +2,40 @@ package main
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"auth-service/internal/service"
 	"auth-service/pkg/logger"
 )
 
-func StartSessionCleanup(authService *service.AuthService, log *logger.Logger, interval time.Duration) {
+const sessionCleanupJob = "session_cleanup"
+
+// StartSessionCleanup periodically deletes expired sessions together with
+// abandoned OIDC login requests, unused magic links and one-time codes, and
+// devices not used for a long time.
+func StartSessionCleanup(authService *service.AuthService, oidcService *service.OIDCService, magicLinkService *service.MagicLinkService, otpService *service.OTPService, loginAlertService *service.LoginAlertService, jobs service.JobReporter, log *logger.Logger, interval time.Duration) {
 	log.WithField("interval", interval).Info("starting session cleanup scheduler")
 
+	cleanup := func(ctx context.Context) error {
+		return errors.Join(
+			authService.CleanupExpiredSessions(ctx),
+			oidcService.CleanupExpiredStates(ctx),
+			magicLinkService.CleanupExpiredLinks(ctx),
+			otpService.CleanupExpiredCodes(ctx),
+			loginAlertService.CleanupStaleDevices(ctx),
+		)
+	}
+
+	jobs.Register(sessionCleanupJob, interval)
 	ticker := time.NewTicker(interval)
 
 	go func() {
 		ctx := context.Background()
 		log.Info("running initial session cleanup")
-		if err := authService.CleanupExpiredSessions(ctx); err != nil {
+		err := cleanup(ctx)
+		jobs.Report(sessionCleanupJob, err)
+		if err != nil {
 			log.WithError(err).Error("initial session cleanup failed")
 		} else {
 			log.Info("initial session cleanup completed successfully")
@@ -30,7 +49,9 @@ func StartSessionCleanup(authService *service.AuthService, log *logger.Logger, i
 			ctx := context.Background()
 			log.Info("running scheduled session cleanup")
 
-			if err := authService.CleanupExpiredSessions(ctx); err != nil {
+			err := cleanup(ctx)
+			jobs.Report(sessionCleanupJob, err)
+			if err != nil {
 				log.WithError(err).Error("scheduled session cleanup failed")
 			} else {
 				log.Info("scheduled session cleanup completed successfully")
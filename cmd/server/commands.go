@@ -0,0 +1,591 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/health"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"auth-service/pkg/logger"
+	"auth-service/pkg/validator"
+
+	"github.com/google/uuid"
+)
+
+const usage = `Usage: auth-service [command]
+
+Commands:
+  serve                      Run the HTTP server (default)
+  migrate up                 Apply pending migrations
+  migrate down [--steps N]   Revert the last N migrations (default 1)
+  migrate status             List migrations and whether they are applied
+  user create                Create a user; the password is read from stdin
+      --username U --email E --name NAME [--admin] [--tenant T]
+  user deactivate            Deactivate a user and revoke their sessions
+      --user ID|USERNAME [--tenant T]
+  sessions revoke            Revoke every session of a user
+      --user ID|USERNAME [--tenant T]
+  sessions cleanup           Delete expired sessions
+  tenant create              Create a tenant
+      --slug S --name NAME [--domain D] [--active=BOOL] [--registration=BOOL]
+      [--email-domains D,...] [--access-expiry DUR] [--refresh-expiry DUR]
+  tenant list                List tenants
+  tenant update              Change the given settings of a tenant
+      --tenant T [--slug S] [--name NAME] [--domain D] [--active=BOOL] ...
+  keys rotate                Print new JWT signing secrets, with the current
+                             ones as the previous secrets
+  config check               Validate the configuration and exit
+`
+
+// errUsage marks errors caused by invalid arguments; they exit with status 2.
+var errUsage = errors.New("invalid usage")
+
+type command func(ctx context.Context, args []string) error
+
+var commands = map[string]command{
+	"migrate up":       migrateUp,
+	"migrate down":     migrateDown,
+	"migrate status":   migrateStatus,
+	"user create":      createUser,
+	"user deactivate":  deactivateUser,
+	"sessions revoke":  revokeSessions,
+	"sessions cleanup": cleanupSessions,
+	"tenant create":    createTenant,
+	"tenant list":      listTenants,
+	"tenant update":    updateTenant,
+	"keys rotate":      rotateKeys,
+	"config check":     checkConfig,
+}
+
+// runCommand executes an operational subcommand and returns the process exit
+// status.
+func runCommand(args []string) int {
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Print(usage)
+		return 0
+	}
+	if len(args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]+" "+args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", args[0]+" "+args[1], usage)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd(ctx, args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// app holds the dependencies shared by the commands that touch the database.
+type app struct {
+	cfg           *config.Config
+	log           *logger.Logger
+	store         *storage
+	userRepo      repository.UserRepository
+	authService   *service.AuthService
+	tenantService *service.TenantService
+}
+
+func newApp() (*app, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	log := logger.New(cfg.Logger.Level, cfg.Logger.Format, cfg.Logger.FilePath)
+
+	store, err := openStorage(&cfg.Database, nil, log)
+	if err != nil {
+		return nil, err
+	}
+
+	jwtService := service.NewJWTService(&cfg.JWT)
+	auditService := service.NewAuditService(store.audit, log)
+	webhookService := service.NewWebhookService(store.webhooks, &cfg.Webhook, health.NewJobs(), log)
+
+	return &app{
+		cfg:           cfg,
+		log:           log,
+		store:         store,
+		userRepo:      store.users,
+		authService:   service.NewAuthService(store.users, store.sessions, store.identities, []service.Authenticator{service.NewPasswordAuthenticator(store.users)}, jwtService, &cfg.Session, nil, auditService, webhookService, log),
+		tenantService: service.NewTenantService(store.tenants, &cfg.Tenant, log),
+	}, nil
+}
+
+func (a *app) Close() {
+	a.store.close()
+}
+
+// findUser resolves --user, which may be a user id or a username, within the
+// tenant given by --tenant (the default tenant when empty).
+func (a *app) findUser(ctx context.Context, tenantFlag, userFlag string) (*domain.Tenant, *domain.User, error) {
+	if userFlag == "" {
+		return nil, nil, fmt.Errorf("%w: --user is required", errUsage)
+	}
+
+	tenant, err := a.tenantService.Resolve(ctx, tenantFlag, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("tenant: %w", err)
+	}
+
+	var user *domain.User
+	if userID, parseErr := uuid.Parse(userFlag); parseErr == nil {
+		user, err = a.userRepo.GetByID(ctx, tenant.TenantID, userID)
+	} else {
+		user, err = a.userRepo.GetByUsername(ctx, tenant.TenantID, userFlag)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("user %q: %w", userFlag, err)
+	}
+
+	return tenant, user, nil
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected argument %q", errUsage, fs.Arg(0))
+	}
+	return nil
+}
+
+func migrateUp(ctx context.Context, args []string) error {
+	if err := parseFlags(newFlagSet("migrate up"), args); err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	migrator := a.store.migrator
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("applied %d migrations, schema is at version %d\n", applied, migrator.Latest())
+	return nil
+}
+
+func migrateDown(ctx context.Context, args []string) error {
+	fs := newFlagSet("migrate down")
+	steps := fs.Int("steps", 1, "number of migrations to revert")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	migrator := a.store.migrator
+	reverted, err := migrator.Down(ctx, *steps)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("reverted %d migrations\n", reverted)
+	return nil
+}
+
+func migrateStatus(ctx context.Context, args []string) error {
+	if err := parseFlags(newFlagSet("migrate status"), args); err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	migrator := a.store.migrator
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		if status.Applied {
+			state = "applied"
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+		}
+		if status.Modified {
+			state = "modified"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	return w.Flush()
+}
+
+func createUser(ctx context.Context, args []string) error {
+	fs := newFlagSet("user create")
+	tenantFlag := fs.String("tenant", "", "tenant slug or id (default tenant when empty)")
+	username := fs.String("username", "", "username")
+	email := fs.String("email", "", "email address")
+	fullName := fs.String("name", "", "full name")
+	admin := fs.Bool("admin", false, "grant the admin role")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	password, err := readPassword(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	req := &domain.RegisterRequest{
+		Username: *username,
+		Email:    *email,
+		Password: password,
+		FullName: *fullName,
+	}
+	if err := validator.Validate(req); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	role := domain.RoleUser
+	if *admin {
+		role = domain.RoleAdmin
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	tenant, err := a.tenantService.Resolve(ctx, *tenantFlag, "")
+	if err != nil {
+		return fmt.Errorf("tenant: %w", err)
+	}
+
+	user, err := a.authService.CreateUser(ctx, tenant, req, role)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created %s %s (%s) in tenant %s\n", user.Role, user.Username, user.UserID, tenant.Slug)
+	return nil
+}
+
+// readPassword reads the password from the first line of r, prompting when r
+// is a terminal.
+func readPassword(r *os.File) (string, error) {
+	if info, err := r.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Password: ")
+	}
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("%w: a password must be provided on stdin", errUsage)
+	}
+	return password, nil
+}
+
+func deactivateUser(ctx context.Context, args []string) error {
+	fs := newFlagSet("user deactivate")
+	tenantFlag := fs.String("tenant", "", "tenant slug or id (default tenant when empty)")
+	userFlag := fs.String("user", "", "user id or username")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	tenant, user, err := a.findUser(ctx, *tenantFlag, *userFlag)
+	if err != nil {
+		return err
+	}
+
+	if err := a.authService.DeactivateUser(ctx, tenant.TenantID, uuid.Nil, user.UserID); err != nil {
+		return err
+	}
+	fmt.Printf("deactivated %s (%s)\n", user.Username, user.UserID)
+	return nil
+}
+
+func revokeSessions(ctx context.Context, args []string) error {
+	fs := newFlagSet("sessions revoke")
+	tenantFlag := fs.String("tenant", "", "tenant slug or id (default tenant when empty)")
+	userFlag := fs.String("user", "", "user id or username")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	tenant, user, err := a.findUser(ctx, *tenantFlag, *userFlag)
+	if err != nil {
+		return err
+	}
+
+	if err := a.authService.RevokeSessions(ctx, tenant.TenantID, uuid.Nil, user.UserID); err != nil {
+		return err
+	}
+	fmt.Printf("revoked all sessions of %s (%s)\n", user.Username, user.UserID)
+	return nil
+}
+
+func cleanupSessions(ctx context.Context, args []string) error {
+	if err := parseFlags(newFlagSet("sessions cleanup"), args); err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	if err := a.authService.CleanupExpiredSessions(ctx); err != nil {
+		return err
+	}
+	fmt.Println("expired sessions deleted")
+	return nil
+}
+
+// tenantFlags are the tenant settings shared by tenant create and update.
+type tenantFlags struct {
+	fs            *flag.FlagSet
+	slug          *string
+	name          *string
+	domain        *string
+	active        *bool
+	registration  *bool
+	emailDomains  *string
+	accessExpiry  *time.Duration
+	refreshExpiry *time.Duration
+}
+
+func newTenantFlags(fs *flag.FlagSet) *tenantFlags {
+	return &tenantFlags{
+		fs:            fs,
+		slug:          fs.String("slug", "", "slug, used in the tenant header"),
+		name:          fs.String("name", "", "display name"),
+		domain:        fs.String("domain", "", "host name that selects the tenant (none when empty)"),
+		active:        fs.Bool("active", true, "whether the tenant accepts requests"),
+		registration:  fs.Bool("registration", true, "whether users may register"),
+		emailDomains:  fs.String("email-domains", "", "comma-separated email domains users may register with (any when empty)"),
+		accessExpiry:  fs.Duration("access-expiry", 0, "access token lifetime (global default when 0)"),
+		refreshExpiry: fs.Duration("refresh-expiry", 0, "refresh token lifetime (global default when 0)"),
+	}
+}
+
+// apply copies the flags given on the command line to tenant, or all of them
+// when all is set.
+func (f *tenantFlags) apply(tenant *domain.Tenant, all bool) {
+	set := func(name string) {
+		switch name {
+		case "slug":
+			tenant.Slug = *f.slug
+		case "name":
+			tenant.Name = *f.name
+		case "domain":
+			tenant.Domain = *f.domain
+		case "active":
+			tenant.IsActive = *f.active
+		case "registration":
+			tenant.Settings.RegistrationEnabled = *f.registration
+		case "email-domains":
+			tenant.Settings.AllowedEmailDomains = nil
+			for _, d := range strings.Split(*f.emailDomains, ",") {
+				if d = strings.TrimSpace(d); d != "" {
+					tenant.Settings.AllowedEmailDomains = append(tenant.Settings.AllowedEmailDomains, d)
+				}
+			}
+		case "access-expiry":
+			tenant.Settings.AccessTokenExpiry = *f.accessExpiry
+		case "refresh-expiry":
+			tenant.Settings.RefreshTokenExpiry = *f.refreshExpiry
+		}
+	}
+	visit := f.fs.Visit
+	if all {
+		visit = f.fs.VisitAll
+	}
+	visit(func(fl *flag.Flag) { set(fl.Name) })
+}
+
+func createTenant(ctx context.Context, args []string) error {
+	fs := newFlagSet("tenant create")
+	settings := newTenantFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	tenant := &domain.Tenant{}
+	settings.apply(tenant, true)
+	if err := a.tenantService.Create(ctx, tenant); err != nil {
+		return err
+	}
+	fmt.Printf("created tenant %s (%s)\n", tenant.Slug, tenant.TenantID)
+	return nil
+}
+
+func listTenants(ctx context.Context, args []string) error {
+	if err := parseFlags(newFlagSet("tenant list"), args); err != nil {
+		return err
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	tenants, err := a.tenantService.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSLUG\tNAME\tDOMAIN\tACTIVE\tREGISTRATION")
+	for _, tenant := range tenants {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%t\n", tenant.TenantID, tenant.Slug, tenant.Name, tenant.Domain, tenant.IsActive, tenant.Settings.RegistrationEnabled)
+	}
+	return w.Flush()
+}
+
+func updateTenant(ctx context.Context, args []string) error {
+	fs := newFlagSet("tenant update")
+	tenantFlag := fs.String("tenant", "", "tenant slug or id")
+	settings := newTenantFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *tenantFlag == "" {
+		return fmt.Errorf("%w: --tenant is required", errUsage)
+	}
+
+	a, err := newApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	tenant, err := a.tenantService.Find(ctx, *tenantFlag)
+	if err != nil {
+		return fmt.Errorf("tenant %q: %w", *tenantFlag, err)
+	}
+
+	settings.apply(tenant, false)
+	if err := a.tenantService.Update(ctx, tenant); err != nil {
+		return err
+	}
+	fmt.Printf("updated tenant %s (%s); running servers pick up the change within TENANT_CACHE_TTL\n", tenant.Slug, tenant.TenantID)
+	return nil
+}
+
+// rotateKeys prints a fresh pair of HMAC signing secrets, and the current
+// ones as the previous secrets. Signing keys are read from the environment,
+// so rotation means deploying these values; tokens signed with the replaced
+// secrets keep verifying for as long as the previous secrets are set.
+func rotateKeys(ctx context.Context, args []string) error {
+	if err := parseFlags(newFlagSet("keys rotate"), args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	access, err := generateSecret()
+	if err != nil {
+		return err
+	}
+	refresh, err := generateSecret()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("JWT_ACCESS_SECRET=%s\n", access)
+	fmt.Printf("JWT_REFRESH_SECRET=%s\n", refresh)
+	fmt.Printf("JWT_ACCESS_PREVIOUS_SECRET=%s\n", cfg.JWT.AccessTokenSecret)
+	fmt.Printf("JWT_REFRESH_PREVIOUS_SECRET=%s\n", cfg.JWT.RefreshTokenSecret)
+	fmt.Fprintln(os.Stderr, "Deploy these values to every replica. Tokens signed with the replaced secrets stay valid while the previous secrets are set;")
+	fmt.Fprintln(os.Stderr, "unset them once the longest refresh token lifetime has passed, or right away to end every existing session.")
+	return nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 48)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func checkConfig(ctx context.Context, args []string) error {
+	if err := parseFlags(newFlagSet("config check"), args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("configuration is valid (environment: %s)\n", cfg.Server.Environment)
+	return nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,18 +11,39 @@ import (
 	"time"
 
 	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/geoip"
+	"auth-service/internal/grpcapi"
 	"auth-service/internal/handler"
+	"auth-service/internal/health"
+	"auth-service/internal/mail"
+	"auth-service/internal/metrics"
 	"auth-service/internal/middleware"
-	"auth-service/internal/repository"
+	"auth-service/internal/ratelimit"
 	"auth-service/internal/service"
+	"auth-service/internal/tracing"
 	"auth-service/pkg/logger"
+	authv1 "auth-service/proto/auth/v1"
 
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 )
 
 func main() {
 	_ = godotenv.Load()
 
+	if len(os.Args) > 1 && os.Args[1] != "serve" {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
+	serve()
+}
+
+// serve runs the HTTP and gRPC servers until SIGINT or SIGTERM.
+func serve() {
 	cfg, err := config.Load()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
@@ -36,39 +58,122 @@ func main() {
 		"port":        cfg.Server.Port,
 	}).Info("starting auth microservice")
 
-	log.Info("connecting to PostgreSQL database")
-	db, err := config.NewPostgresConnection(&cfg.Database)
+	shutdownTracing, err := tracing.Setup(context.Background(), &cfg.Tracing)
+	if err != nil {
+		log.WithError(err).Fatal("failed to initialize tracing")
+	}
+
+	log.WithField("driver", cfg.Database.Driver).Info("connecting to database")
+	store, err := openStorage(&cfg.Database, tracing.NewPgxTracer(), log)
 	if err != nil {
 		log.WithError(err).Fatal("failed to connect to database - service cannot start")
 	}
-	defer db.Close()
+	defer store.close()
 
-	log.Info("successfully connected to PostgreSQL database")
+	log.Info("successfully connected to database")
 
 	log.Info("running database migrations")
-	if err := config.RunMigrations(db); err != nil {
+	applied, err := store.migrator.Up(context.Background())
+	if err != nil {
 		log.WithError(err).Fatal("failed to run database migrations")
 	}
-	log.Info("database migrations completed successfully")
+	log.WithFields(map[string]interface{}{
+		"applied": applied,
+		"version": store.migrator.Latest(),
+	}).Info("database migrations completed successfully")
 
-	userRepo := repository.NewPostgresUserRepository(db)
-	sessionRepo := repository.NewPostgresSessionRepository(db)
+	if cfg.Metrics.Enabled {
+		if store.pool != nil {
+			metrics.RegisterPool(store.pool)
+		}
+		metrics.RegisterActiveSessions(store.sessions.CountActive)
+	}
 
 	jwtService := service.NewJWTService(&cfg.JWT)
-	authService := service.NewAuthService(userRepo, sessionRepo, jwtService, log)
+	auditService := service.NewAuditService(store.audit, log)
+	jobs := health.NewJobs()
+	webhookService := service.NewWebhookService(store.webhooks, &cfg.Webhook, jobs, log)
+	authenticators := []service.Authenticator{service.NewPasswordAuthenticator(store.users)}
+	if cfg.LDAP.Enabled {
+		authenticators = append(authenticators, service.NewLDAPAuthenticator(&cfg.LDAP, log))
+	}
+	templates, err := mail.LoadTemplates(cfg.Mail.TemplatesDir, cfg.Mail.DefaultLocale)
+	if err != nil {
+		log.WithError(err).Fatal("failed to load email templates")
+	}
+	notificationService := service.NewNotificationService(&cfg.Mail, newNotifier(cfg, log), templates, store.notifications, log)
+	var locator service.Locator
+	if cfg.LoginAlert.GeoIPPath != "" {
+		geoDB, err := geoip.Open(cfg.LoginAlert.GeoIPPath)
+		if err != nil {
+			log.WithError(err).Fatal("failed to load GeoIP database")
+		}
+		defer geoDB.Close()
+		locator = geoDB
+	}
+	loginAlertService := service.NewLoginAlertService(&cfg.LoginAlert, store.knownDevices, jwtService, notificationService, locator, log)
+	authService := service.NewAuthService(store.users, store.sessions, store.identities, authenticators, jwtService, &cfg.Session, loginAlertService, auditService, webhookService, log)
+	tenantService := service.NewTenantService(store.tenants, &cfg.Tenant, log)
+	apiKeyService := service.NewAPIKeyService(store.apiKeys, store.users, log)
+	oidcService := service.NewOIDCService(&cfg.OIDC, store.oidcStates, store.identities, store.users, authService, log)
+	identityService := service.NewIdentityService(store.identities, store.users, auditService, log)
+	magicLinkService := service.NewMagicLinkService(&cfg.MagicLink, store.magicLinks, store.users, authService, jwtService, notificationService, log)
+	otpService := service.NewOTPService(&cfg.OTP, store.otpCodes, store.users, authService, jwtService, notificationService, cfg.JWT.AccessTokenKeys(), log)
 
 	authHandler := handler.NewAuthHandler(authService, log)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService, log)
+	auditHandler := handler.NewAuditHandler(auditService, log)
+	adminHandler := handler.NewAdminHandler(authService, log)
+	webhookHandler := handler.NewWebhookHandler(webhookService, log)
+	notificationHandler := handler.NewNotificationHandler(notificationService, log)
+	oidcHandler := handler.NewOIDCHandler(oidcService, log)
+	identityHandler := handler.NewIdentityHandler(identityService, oidcService, log)
+	magicLinkHandler := handler.NewMagicLinkHandler(magicLinkService, log)
+	otpHandler := handler.NewOTPHandler(otpService, log)
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	checker := health.NewChecker(jobs, cfg.Server.HealthCheckTimeout)
+	checker.AddCheck("database", store.ping)
+	checker.AddCheck("migrations", store.migrator.Check)
+	checker.AddCheck("signing_keys", func(context.Context) error {
+		return jwtService.CheckKeys()
+	})
+	healthHandler := handler.NewHealthHandler(checker)
+
+	StartSessionCleanup(authService, oidcService, magicLinkService, otpService, loginAlertService, jobs, log, 24*time.Hour)
+	webhookService.Start(workerCtx)
+	notificationService.Start(workerCtx)
+
+	rateLimitStore, closeRateLimitStore, err := newRateLimitStore(cfg)
+	if err != nil {
+		log.WithError(err).Fatal("failed to initialize rate limit store")
+	}
+	defer closeRateLimitStore()
 
-	StartSessionCleanup(authService, log, 24*time.Hour)
+	corsOrigins := middleware.NewOrigins(cfg.Server.AllowedOrigins)
 
-	router := setupRouter(authHandler, cfg, log)
+	router, rateLimiter := setupRouter(authHandler, apiKeyHandler, auditHandler, adminHandler, webhookHandler, notificationHandler, oidcHandler, identityHandler, magicLinkHandler, otpHandler, healthHandler, tenantService, apiKeyService, authService, rateLimitStore, corsOrigins, cfg, log)
+
+	reloader := &configReloader{
+		current:     cfg,
+		rateLimiter: rateLimiter,
+		corsOrigins: corsOrigins,
+		jwtService:  jwtService,
+		authService: authService,
+		log:         log,
+	}
+	go config.Watch(workerCtx, cfg.File.Path, cfg.File.WatchInterval, reloader.Reload)
+
+	grpcServer := setupGRPCServer(authService, tenantService, apiKeyService, rateLimiter, cfg, log)
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
 		Handler:      router,
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
-		IdleTimeout:  120 * time.Second,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
 	go func() {
@@ -78,60 +183,287 @@ func main() {
 		}
 	}()
 
+	if cfg.GRPC.Enabled {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPC.Port))
+		if err != nil {
+			log.WithError(err).Fatal("grpc server failed to start")
+		}
+		go func() {
+			log.WithField("port", cfg.GRPC.Port).Info("grpc server is listening")
+			if err := grpcServer.Serve(listener); err != nil {
+				log.WithError(err).Fatal("grpc server failed")
+			}
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Info("shutting down server...")
 
+	checker.StartShutdown()
+	if cfg.Server.ShutdownDrainDelay > 0 {
+		log.WithField("delay", cfg.Server.ShutdownDrainDelay).Info("draining: readiness reports not-ready")
+		time.Sleep(cfg.Server.ShutdownDrainDelay)
+	}
+
+	stopWorkers()
+
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
 		log.WithError(err).Error("server forced to shutdown")
 	}
+	stopGRPCServer(ctx, grpcServer, log)
+
+	if err := shutdownTracing(ctx); err != nil {
+		log.WithError(err).Error("failed to flush traces")
+	}
 
 	log.Info("server stopped")
 }
 
-func setupRouter(authHandler *handler.AuthHandler, cfg *config.Config, log *logger.Logger) http.Handler {
+func setupRouter(
+	authHandler *handler.AuthHandler,
+	apiKeyHandler *handler.APIKeyHandler,
+	auditHandler *handler.AuditHandler,
+	adminHandler *handler.AdminHandler,
+	webhookHandler *handler.WebhookHandler,
+	notificationHandler *handler.NotificationHandler,
+	oidcHandler *handler.OIDCHandler,
+	identityHandler *handler.IdentityHandler,
+	magicLinkHandler *handler.MagicLinkHandler,
+	otpHandler *handler.OTPHandler,
+	healthHandler *handler.HealthHandler,
+	tenantService *service.TenantService,
+	apiKeyService *service.APIKeyService,
+	authService *service.AuthService,
+	rateLimitStore middleware.RateLimitStore,
+	corsOrigins *middleware.Origins,
+	cfg *config.Config,
+	log *logger.Logger,
+) (http.Handler, *middleware.RateLimiter) {
 	apiMux := http.NewServeMux()
 
 	apiMux.HandleFunc("POST /api/v1/auth/register", authHandler.Register)
 	apiMux.HandleFunc("POST /api/v1/auth/login", authHandler.Login)
 	apiMux.HandleFunc("POST /api/v1/auth/refresh", authHandler.RefreshToken)
 	apiMux.HandleFunc("POST /api/v1/auth/validate", authHandler.ValidateToken)
+	apiMux.HandleFunc("GET /api/v1/auth/oidc/providers", oidcHandler.Providers)
+	apiMux.HandleFunc("GET /api/v1/auth/oidc/{provider}/authorize", oidcHandler.Authorize)
+	apiMux.HandleFunc("POST /api/v1/auth/oidc/{provider}/callback", oidcHandler.Callback)
+	apiMux.HandleFunc("POST /api/v1/auth/magic-link", magicLinkHandler.Request)
+	apiMux.HandleFunc("POST /api/v1/auth/magic-link/consume", magicLinkHandler.Consume)
+	apiMux.HandleFunc("POST /api/v1/auth/otp", otpHandler.Request)
+	apiMux.HandleFunc("POST /api/v1/auth/otp/verify", otpHandler.Verify)
+	apiMux.HandleFunc("POST /api/v1/auth/login-alerts/report", authHandler.ReportLogin)
 	apiMux.HandleFunc("GET /health", handler.HealthCheck)
 
-	authMiddleware := middleware.Auth(log, cfg.JWT.AccessTokenSecret)
-	apiMux.Handle("POST /api/v1/auth/logout", authMiddleware(http.HandlerFunc(authHandler.Logout)))
-	apiMux.Handle("GET /api/v1/auth/me", authMiddleware(http.HandlerFunc(authHandler.Me)))
+	authMiddleware := middleware.Traced("auth", middleware.Auth(log, cfg.JWT.AccessTokenKeys(), apiKeyService, authService))
+	requireScope := func(scope string, h http.HandlerFunc) http.Handler {
+		return authMiddleware(middleware.RequireScope(log, scope)(h))
+	}
+
+	// Impersonated tokens may look around but not change credentials or end
+	// the user's own sessions.
+	notImpersonated := func(h http.HandlerFunc) http.HandlerFunc {
+		return middleware.RefuseImpersonation(log)(h).ServeHTTP
+	}
+	// Credentials, login methods and new API keys need a session token,
+	// never an API key.
+	notAPIKey := func(h http.HandlerFunc) http.HandlerFunc {
+		return middleware.RefuseAPIKey(log)(h).ServeHTTP
+	}
+	apiMux.Handle("POST /api/v1/auth/logout", requireScope(domain.ScopeSessionsWrite, notImpersonated(authHandler.Logout)))
+	apiMux.Handle("GET /api/v1/auth/me", requireScope(domain.ScopeProfileRead, authHandler.Me))
+	apiMux.Handle("POST /api/v1/auth/password", authMiddleware(notAPIKey(notImpersonated(authHandler.ChangePassword))))
+	apiMux.Handle("POST /api/v1/auth/otp/step-up", authMiddleware(notImpersonated(otpHandler.StepUp)))
+	apiMux.Handle("POST /api/v1/auth/otp/step-up/verify", authMiddleware(notImpersonated(otpHandler.VerifyStepUp)))
+
+	apiMux.Handle("POST /api/v1/auth/api-keys", requireScope(domain.ScopeAPIKeysWrite, notAPIKey(notImpersonated(apiKeyHandler.Create))))
+	apiMux.Handle("GET /api/v1/auth/api-keys", requireScope(domain.ScopeAPIKeysWrite, apiKeyHandler.List))
+	apiMux.Handle("DELETE /api/v1/auth/api-keys/{id}", requireScope(domain.ScopeAPIKeysWrite, notImpersonated(apiKeyHandler.Revoke)))
+	apiMux.Handle("GET /api/v1/auth/me/activity", requireScope(domain.ScopeProfileRead, auditHandler.MyActivity))
+
+	apiMux.Handle("GET /api/v1/auth/identities", requireScope(domain.ScopeProfileRead, identityHandler.List))
+	apiMux.Handle("GET /api/v1/auth/identities/{provider}/authorize", authMiddleware(notAPIKey(notImpersonated(identityHandler.LinkAuthorize))))
+	apiMux.Handle("POST /api/v1/auth/identities/{provider}/callback", authMiddleware(notAPIKey(notImpersonated(identityHandler.LinkCallback))))
+	apiMux.Handle("DELETE /api/v1/auth/identities/{id}", authMiddleware(notAPIKey(notImpersonated(identityHandler.Unlink))))
+	apiMux.Handle("POST /api/v1/auth/identities/password", authMiddleware(notAPIKey(notImpersonated(identityHandler.SetPassword))))
+	apiMux.Handle("DELETE /api/v1/auth/identities/password", authMiddleware(notAPIKey(notImpersonated(identityHandler.RemovePassword))))
+
+	// API keys inherit their owner's role, so the admin API takes session
+	// tokens only.
+	requireAdmin := func(h http.HandlerFunc) http.Handler {
+		return authMiddleware(middleware.RefuseAPIKey(log)(middleware.RequireRole(log, domain.RoleAdmin)(h)))
+	}
+
+	apiMux.Handle("GET /api/v1/admin/audit-events", requireAdmin(auditHandler.ListEvents))
+	apiMux.Handle("POST /api/v1/admin/users/{id}/deactivate", requireAdmin(adminHandler.DeactivateUser))
+	apiMux.Handle("POST /api/v1/admin/token-exchange", requireAdmin(adminHandler.TokenExchange))
+	apiMux.Handle("POST /api/v1/admin/webhooks", requireAdmin(webhookHandler.Create))
+	apiMux.Handle("GET /api/v1/admin/webhooks", requireAdmin(webhookHandler.List))
+	apiMux.Handle("DELETE /api/v1/admin/webhooks/{id}", requireAdmin(webhookHandler.Delete))
+	apiMux.Handle("GET /api/v1/admin/webhooks/{id}/deliveries", requireAdmin(webhookHandler.ListDeliveries))
+	apiMux.Handle("POST /api/v1/admin/webhooks/deliveries/{id}/redeliver", requireAdmin(webhookHandler.Redeliver))
+	apiMux.Handle("GET /api/v1/admin/notifications", requireAdmin(notificationHandler.List))
 
 	var apiHandler http.Handler = apiMux
 
-	apiHandler = middleware.RateLimit(log, cfg.Server.RateLimit)(apiHandler)
+	defaultPolicy, policies := rateLimitPolicies(cfg)
+	rateLimiter := middleware.NewRateLimiter(rateLimitStore, apiMux, cfg.JWT.AccessTokenKeys(), defaultPolicy, policies, cfg.RateLimit.Allowlist)
 
-	apiHandler = middleware.Timeout(log, 30*time.Second)(apiHandler)
+	apiHandler = middleware.Traced("rate_limit", middleware.RateLimit(log, rateLimiter))(apiHandler)
 
-	apiHandler = middleware.MaxBodySize(log, 1<<20)(apiHandler)
+	apiHandler = middleware.Traced("timeout", middleware.Timeout(log, cfg.Server.RequestTimeout))(apiHandler)
 
-	apiHandler = middleware.ValidateContentType(log, "application/json")(apiHandler)
+	apiHandler = middleware.Traced("max_body_size", middleware.MaxBodySize(log, cfg.Server.MaxBodySize))(apiHandler)
 
-	apiHandler = middleware.SessionMetadata(apiHandler)
+	apiHandler = middleware.Traced("content_type", middleware.ValidateContentType(log, "application/json", "POST /api/v1/admin/token-exchange"))(apiHandler)
 
-	apiHandler = middleware.CORS(cfg.Server.AllowedOrigins)(apiHandler)
+	apiHandler = middleware.Traced("tenant", middleware.Tenant(log, tenantService, cfg.Tenant.Header))(apiHandler)
 
-	apiHandler = middleware.SecurityHeaders(apiHandler)
+	apiHandler = middleware.Traced("session_metadata", middleware.SessionMetadata)(apiHandler)
 
-	apiHandler = middleware.Recovery(log)(apiHandler)
+	apiHandler = middleware.Traced("cors", middleware.CORS(corsOrigins, cfg.Tenant.Header, "X-API-Key", middleware.ClientIDHeader))(apiHandler)
 
-	apiHandler = middleware.Logger(log)(apiHandler)
+	apiHandler = middleware.Traced("security_headers", middleware.SecurityHeaders)(apiHandler)
 
-	apiHandler = middleware.RequestID(apiHandler)
+	apiHandler = middleware.Traced("client_ip", middleware.ClientIP(log, middleware.NewClientIPResolver(cfg.Server.TrustedProxies)))(apiHandler)
+
+	apiHandler = middleware.Traced("recovery", middleware.Recovery(log))(apiHandler)
+
+	apiHandler = middleware.Route(apiMux)(apiHandler)
+
+	apiHandler = middleware.Traced("logger", middleware.Logger(log))(apiHandler)
+
+	apiHandler = middleware.Traced("request_id", middleware.RequestID)(apiHandler)
+
+	apiHandler = middleware.Tracing(apiMux)(apiHandler)
 
 	rootMux := http.NewServeMux()
 	rootMux.Handle("/api/", apiHandler)
 	rootMux.Handle("/health", apiHandler)
+	// Probes bypass the API middleware so tenant resolution and rate limits
+	// cannot fail them.
+	rootMux.HandleFunc("GET /health/live", healthHandler.Live)
+	rootMux.HandleFunc("GET /health/ready", healthHandler.Ready)
+	if cfg.Metrics.Enabled {
+		rootMux.Handle("GET "+cfg.Metrics.Path, metrics.Handler())
+	}
+
+	return rootMux, rateLimiter
+}
+
+// setupGRPCServer builds the gRPC server with interceptors mirroring the HTTP
+// middleware chain. It shares rateLimiter with the HTTP API, so reloaded
+// policies and client buckets apply to both.
+func setupGRPCServer(
+	authService *service.AuthService,
+	tenantService *service.TenantService,
+	apiKeyService *service.APIKeyService,
+	rateLimiter *middleware.RateLimiter,
+	cfg *config.Config,
+	log *logger.Logger,
+) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.MaxRecvMsgSize(int(cfg.Server.MaxBodySize)),
+		grpc.ConnectionTimeout(cfg.Server.ReadTimeout),
+		grpc.KeepaliveParams(keepalive.ServerParameters{MaxConnectionIdle: cfg.Server.IdleTimeout}),
+		grpc.ChainUnaryInterceptor(
+			grpcapi.RequestID(),
+			grpcapi.Logging(log),
+			grpcapi.Recovery(log),
+			grpcapi.ClientIP(log, middleware.NewClientIPResolver(cfg.Server.TrustedProxies)),
+			grpcapi.SessionMetadata(),
+			grpcapi.Tenant(log, tenantService, cfg.Tenant.Header),
+			grpcapi.RateLimit(log, rateLimiter),
+			grpcapi.Auth(log, cfg.JWT.AccessTokenKeys(), apiKeyService, authService),
+		),
+	)
+
+	authv1.RegisterAuthServiceServer(server, grpcapi.NewAuthServer(authService, log))
+
+	return server
+}
+
+// stopGRPCServer lets in-flight calls finish until ctx is done, then closes
+// the remaining connections.
+func stopGRPCServer(ctx context.Context, server *grpc.Server, log *logger.Logger) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Error("grpc server forced to shutdown")
+		server.Stop()
+	}
+}
+
+func newNotifier(cfg *config.Config, log *logger.Logger) service.Notifier {
+	switch cfg.Mail.Transport {
+	case "smtp":
+		return mail.NewSMTPSender(&cfg.Mail)
+	case "file":
+		return mail.NewFileSender(cfg.Mail.From, cfg.Mail.FilePath)
+	default:
+		return mail.NewLogSender(cfg.Mail.From, log)
+	}
+}
+
+func newRateLimitStore(cfg *config.Config) (middleware.RateLimitStore, func(), error) {
+	if cfg.RateLimit.Store != "redis" {
+		return ratelimit.NewMemoryStore(cfg.RateLimit.MaxKeys), func() {}, nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	store := ratelimit.NewRedisStore(client, cfg.Redis.KeyPrefix+"ratelimit:")
+	return store, func() { _ = store.Close() }, nil
+}
+
+// rateLimitPolicies builds the default per-IP policy and the per-route
+// policies from cfg.
+func rateLimitPolicies(cfg *config.Config) (middleware.RateLimitPolicy, []middleware.RateLimitPolicy) {
+	defaultLimit := ratelimit.PerMinute(cfg.Server.RateLimit)
+	if cfg.RateLimit.Burst > 0 {
+		defaultLimit.Burst = cfg.RateLimit.Burst
+	}
+	defaultPolicy := middleware.RateLimitPolicy{
+		Name:  "default",
+		Rules: []middleware.RateLimitRule{{Key: config.RateLimitKeyIP, Limit: defaultLimit}},
+	}
+
+	policies := make([]middleware.RateLimitPolicy, 0, len(cfg.RateLimit.Policies))
+	for _, p := range cfg.RateLimit.Policies {
+		policy := middleware.RateLimitPolicy{Name: p.Route}
+		for _, rule := range p.Rules {
+			policy.Rules = append(policy.Rules, middleware.RateLimitRule{
+				Key:   rule.Key,
+				Limit: ratelimit.Limit{Rate: rule.Rate, Period: rule.Period, Burst: rule.Rate},
+			})
+		}
+		policies = append(policies, policy)
+	}
 
-	return rootMux
+	return defaultPolicy, policies
 }
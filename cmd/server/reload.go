@@ -0,0 +1,46 @@
+package main
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/middleware"
+	"auth-service/internal/service"
+	"auth-service/pkg/logger"
+)
+
+// configReloader re-reads the configuration and applies the settings that are
+// safe to change while serving. Anything else is reported and left until the
+// next restart.
+type configReloader struct {
+	current     *config.Config
+	rateLimiter *middleware.RateLimiter
+	corsOrigins *middleware.Origins
+	jwtService  *service.JWTService
+	authService *service.AuthService
+	log         *logger.Logger
+}
+
+// Reload is called from a single goroutine by config.Watch.
+func (r *configReloader) Reload() {
+	next, err := config.Load()
+	if err != nil {
+		r.log.WithError(err).Error("config reload failed, keeping the current configuration")
+		return
+	}
+
+	if sections := r.current.RestartRequired(next); len(sections) > 0 {
+		r.log.WithField("sections", sections).Warn("config changes in these sections take effect after a restart")
+	}
+
+	applied := *r.current
+	applied.ApplyReloadable(next)
+
+	logger.SetLevel(applied.Logger.Level)
+	defaultPolicy, policies := rateLimitPolicies(&applied)
+	r.rateLimiter.Update(defaultPolicy, policies, applied.RateLimit.Allowlist)
+	r.corsOrigins.Set(applied.Server.AllowedOrigins)
+	r.jwtService.SetExpiries(applied.JWT.AccessTokenExpiry, applied.JWT.RefreshTokenExpiry)
+	r.authService.SetSessionLimits(applied.Session.IdleTimeout, applied.Session.MaxLifetime)
+
+	r.current = &applied
+	r.log.Info("configuration reloaded")
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"auth-service/internal/config"
+	"auth-service/internal/migrate"
+	"auth-service/internal/repository"
+	"auth-service/migrations"
+	"auth-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// storage holds the repositories for the configured DB_DRIVER.
+type storage struct {
+	tenants       repository.TenantRepository
+	users         repository.UserRepository
+	sessions      repository.SessionRepository
+	apiKeys       repository.APIKeyRepository
+	audit         repository.AuditRepository
+	webhooks      repository.WebhookRepository
+	identities    repository.IdentityRepository
+	oidcStates    repository.OIDCStateRepository
+	magicLinks    repository.MagicLinkRepository
+	otpCodes      repository.OTPRepository
+	notifications repository.NotificationRepository
+	knownDevices  repository.KnownDeviceRepository
+	migrator      *migrate.Migrator
+
+	pool  *pgxpool.Pool // nil unless DB_DRIVER is postgres
+	ping  func(ctx context.Context) error
+	close func()
+}
+
+// openStorage connects to the configured database. tracer only applies to
+// PostgreSQL and may be nil.
+func openStorage(cfg *config.DatabaseConfig, tracer pgx.QueryTracer, log *logger.Logger) (*storage, error) {
+	switch cfg.Driver {
+	case "sqlite":
+		db, err := config.NewSQLiteConnection(cfg)
+		if err != nil {
+			return nil, err
+		}
+		migrator, err := migrate.NewSQLite(db, migrations.SQLite, log)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		return &storage{
+			tenants:       repository.NewSQLiteTenantRepository(db),
+			users:         repository.NewSQLiteUserRepository(db),
+			sessions:      repository.NewSQLiteSessionRepository(db),
+			apiKeys:       repository.NewSQLiteAPIKeyRepository(db),
+			audit:         repository.NewSQLiteAuditRepository(db),
+			webhooks:      repository.NewSQLiteWebhookRepository(db),
+			identities:    repository.NewSQLiteIdentityRepository(db),
+			oidcStates:    repository.NewSQLiteOIDCStateRepository(db),
+			magicLinks:    repository.NewSQLiteMagicLinkRepository(db),
+			otpCodes:      repository.NewSQLiteOTPRepository(db),
+			notifications: repository.NewSQLiteNotificationRepository(db),
+			knownDevices:  repository.NewSQLiteKnownDeviceRepository(db),
+			migrator:      migrator,
+			ping:          db.PingContext,
+			close:         func() { db.Close() },
+		}, nil
+	case "postgres":
+		pool, err := config.NewPostgresConnection(cfg, tracer)
+		if err != nil {
+			return nil, err
+		}
+		migrator, err := migrate.NewPostgres(pool, migrations.Postgres, log)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		return &storage{
+			tenants:       repository.NewPostgresTenantRepository(pool),
+			users:         repository.NewPostgresUserRepository(pool),
+			sessions:      repository.NewPostgresSessionRepository(pool),
+			apiKeys:       repository.NewPostgresAPIKeyRepository(pool),
+			audit:         repository.NewPostgresAuditRepository(pool),
+			webhooks:      repository.NewPostgresWebhookRepository(pool),
+			identities:    repository.NewPostgresIdentityRepository(pool),
+			oidcStates:    repository.NewPostgresOIDCStateRepository(pool),
+			magicLinks:    repository.NewPostgresMagicLinkRepository(pool),
+			otpCodes:      repository.NewPostgresOTPRepository(pool),
+			notifications: repository.NewPostgresNotificationRepository(pool),
+			knownDevices:  repository.NewPostgresKnownDeviceRepository(pool),
+			migrator:      migrator,
+			pool:          pool,
+			ping:          pool.Ping,
+			close:         pool.Close,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+}
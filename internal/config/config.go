@@ -2,44 +2,140 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Server   ServerConfig
-	JWT      JWTConfig
-	Database DatabaseConfig
-	Logger   LoggerConfig
+	Server     ServerConfig
+	GRPC       GRPCConfig
+	JWT        JWTConfig
+	Session    SessionConfig
+	Database   DatabaseConfig
+	Logger     LoggerConfig
+	Tenant     TenantConfig
+	Webhook    WebhookConfig
+	Metrics    MetricsConfig
+	Tracing    TracingConfig
+	RateLimit  RateLimitConfig
+	Redis      RedisConfig
+	OIDC       OIDCConfig
+	LDAP       LDAPConfig
+	Mail       MailConfig
+	MagicLink  MagicLinkConfig
+	OTP        OTPConfig
+	LoginAlert LoginAlertConfig
+	File       FileConfig
 }
 
 type ServerConfig struct {
-	Port            int
-	ReadTimeout     time.Duration
-	WriteTimeout    time.Duration
-	ShutdownTimeout time.Duration
-	Environment     string
-	AllowedOrigins  []string
-	RateLimit       int
+	Port               int
+	ReadTimeout        time.Duration
+	WriteTimeout       time.Duration
+	IdleTimeout        time.Duration
+	RequestTimeout     time.Duration // handler deadline enforced by the Timeout middleware
+	MaxBodySize        int64
+	ShutdownTimeout    time.Duration
+	ShutdownDrainDelay time.Duration // not-ready period before the listener closes
+	HealthCheckTimeout time.Duration
+	Environment        string
+	AllowedOrigins     []string
+	RateLimit          int
+	TrustedProxies     []*net.IPNet // peers allowed to report the client IP via Forwarded/X-Forwarded-For
+}
+
+type GRPCConfig struct {
+	Enabled bool
+	Port    int
 }
 
 type JWTConfig struct {
 	AccessTokenSecret  string
 	RefreshTokenSecret string
-	AccessTokenExpiry  time.Duration
-	RefreshTokenExpiry time.Duration
-	Issuer             string
-	AllowedAlgorithm   string
+	// The secrets before the last rotation, still accepted for verification
+	// so that tokens issued before it stay valid. Empty when unset.
+	AccessTokenPreviousSecret  string
+	RefreshTokenPreviousSecret string
+	AccessTokenExpiry          time.Duration
+	RefreshTokenExpiry         time.Duration
+	ImpersonationExpiry        time.Duration // lifetime of access tokens issued to impersonating admins
+	RememberMeExpiry           time.Duration // refresh token lifetime of logins with remember_me; 0 ignores it
+	Profiles                   []LifetimeProfile
+	Issuer                     string
+	AllowedAlgorithm           string
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Driver            string // postgres or sqlite
+	SQLitePath        string
+	Host              string
+	Port              int
+	User              string
+	Password          string
+	DBName            string
+	SSLMode           string
+	MaxConns          int
+	MinConns          int
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+}
+
+type TenantConfig struct {
+	Header          string // request header carrying the tenant slug or id
+	DefaultSlug     string // tenant used when none is resolved from the request
+	ResolveFromHost bool
+	CacheTTL        time.Duration
+}
+
+type WebhookConfig struct {
+	Enabled              bool
+	RequireHTTPS         bool
+	AllowPrivateNetworks bool // deliver to loopback, private and link-local addresses
+	RequestTimeout       time.Duration
+	MaxAttempts          int
+	InitialBackoff       time.Duration
+	MaxBackoff           time.Duration
+	PollInterval         time.Duration
+	Workers              int
+}
+
+type MetricsConfig struct {
+	Enabled bool
+	Path    string
+}
+
+type RateLimitConfig struct {
+	Store     string            // memory or redis
+	Burst     int               // bucket capacity; defaults to the per-minute rate
+	MaxKeys   int               // bound on buckets held by the memory store
+	Policies  []RateLimitPolicy // replace the default per-IP limit on the routes they name
+	Allowlist []*net.IPNet      // clients exempt from rate limiting
+}
+
+type RedisConfig struct {
+	Addr      string
+	Password  string
+	DB        int
+	KeyPrefix string
+}
+
+type TracingConfig struct {
+	Exporter     string // none, stdout or otlp
+	ServiceName  string
+	OTLPEndpoint string // host:port of an OTLP/HTTP collector
+	OTLPInsecure bool
+	SampleRatio  float64
+}
+
+// FileConfig locates the optional configuration file. Its settings sit under
+// the environment: a variable that is set always wins.
+type FileConfig struct {
+	Path          string        // YAML (.yaml, .yml) or TOML (.toml)
+	WatchInterval time.Duration // how often Path is checked for changes; 0 disables
 }
 
 type LoggerConfig struct {
@@ -48,40 +144,189 @@ type LoggerConfig struct {
 	FilePath string // path to log file
 }
 
+// Load reads the configuration from the environment, falling back to the
+// file named by CONFIG_FILE and then to built-in defaults.
 func Load() (*Config, error) {
+	src, err := newSource(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
 		Server: ServerConfig{
-			Port:            getEnvAsInt("SERVER_PORT", 8080),
-			ReadTimeout:     10 * time.Second,
-			WriteTimeout:    10 * time.Second,
-			ShutdownTimeout: 5 * time.Second,
-			Environment:     getEnv("ENVIRONMENT", "development"),
-			AllowedOrigins:  getEnvAsSlice("ALLOWED_ORIGINS", []string{"*"}),
-			RateLimit:       getEnvAsInt("RATE_LIMIT", 100),
+			Port:               src.getInt("SERVER_PORT", 8080),
+			ReadTimeout:        src.getDuration("SERVER_READ_TIMEOUT", 10*time.Second),
+			WriteTimeout:       src.getDuration("SERVER_WRITE_TIMEOUT", 10*time.Second),
+			IdleTimeout:        src.getDuration("SERVER_IDLE_TIMEOUT", 120*time.Second),
+			RequestTimeout:     src.getDuration("SERVER_REQUEST_TIMEOUT", 30*time.Second),
+			MaxBodySize:        int64(src.getInt("SERVER_MAX_BODY_SIZE", 1<<20)),
+			ShutdownTimeout:    src.getDuration("SERVER_SHUTDOWN_TIMEOUT", 5*time.Second),
+			ShutdownDrainDelay: src.getDuration("SHUTDOWN_DRAIN_DELAY", 0),
+			HealthCheckTimeout: src.getDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+			Environment:        src.get("ENVIRONMENT", "development"),
+			AllowedOrigins:     src.getSlice("ALLOWED_ORIGINS", []string{"*"}),
+			RateLimit:          src.getInt("RATE_LIMIT", 100),
+		},
+		GRPC: GRPCConfig{
+			Enabled: src.getBool("GRPC_ENABLED", true),
+			Port:    src.getInt("GRPC_PORT", 9090),
 		},
 		JWT: JWTConfig{
-			AccessTokenSecret:  getEnv("JWT_ACCESS_SECRET", ""),
-			RefreshTokenSecret: getEnv("JWT_REFRESH_SECRET", ""),
-			AccessTokenExpiry:  getEnvAsDuration("JWT_ACCESS_EXPIRY", 15*time.Minute),
-			RefreshTokenExpiry: getEnvAsDuration("JWT_REFRESH_EXPIRY", 7*24*time.Hour),
-			Issuer:             "auth-service",
-			AllowedAlgorithm:   "HS256",
+			AccessTokenSecret:          src.get("JWT_ACCESS_SECRET", ""),
+			RefreshTokenSecret:         src.get("JWT_REFRESH_SECRET", ""),
+			AccessTokenPreviousSecret:  src.get("JWT_ACCESS_PREVIOUS_SECRET", ""),
+			RefreshTokenPreviousSecret: src.get("JWT_REFRESH_PREVIOUS_SECRET", ""),
+			AccessTokenExpiry:          src.getDuration("JWT_ACCESS_EXPIRY", 15*time.Minute),
+			RefreshTokenExpiry:         src.getDuration("JWT_REFRESH_EXPIRY", 7*24*time.Hour),
+			ImpersonationExpiry:        src.getDuration("JWT_IMPERSONATION_EXPIRY", 10*time.Minute),
+			RememberMeExpiry:           src.getDuration("JWT_REMEMBER_ME_EXPIRY", 30*24*time.Hour),
+			Issuer:                     src.get("JWT_ISSUER", "auth-service"),
+			AllowedAlgorithm:           "HS256",
+		},
+		Session: SessionConfig{
+			IdleTimeout: src.getDuration("SESSION_IDLE_TIMEOUT", 0),
+			MaxLifetime: src.getDuration("SESSION_MAX_LIFETIME", 0),
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnvAsInt("DB_PORT", 5432),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", ""),
-			DBName:   getEnv("DB_NAME", "authdb"),
-			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+			Driver:            src.get("DB_DRIVER", "postgres"),
+			SQLitePath:        src.get("DB_SQLITE_PATH", "data/auth.db"),
+			Host:              src.get("DB_HOST", "localhost"),
+			Port:              src.getInt("DB_PORT", 5432),
+			User:              src.get("DB_USER", "postgres"),
+			Password:          src.get("DB_PASSWORD", ""),
+			DBName:            src.get("DB_NAME", "authdb"),
+			SSLMode:           src.get("DB_SSL_MODE", "disable"),
+			MaxConns:          src.getInt("DB_MAX_CONNS", 25),
+			MinConns:          src.getInt("DB_MIN_CONNS", 5),
+			MaxConnLifetime:   src.getDuration("DB_MAX_CONN_LIFETIME", time.Hour),
+			MaxConnIdleTime:   src.getDuration("DB_MAX_CONN_IDLE_TIME", 30*time.Minute),
+			HealthCheckPeriod: src.getDuration("DB_HEALTH_CHECK_PERIOD", time.Minute),
 		},
 		Logger: LoggerConfig{
-			Level:    getEnv("LOG_LEVEL", "info"),
-			Format:   "json",
-			FilePath: "logs/app.log",
+			Level:    src.get("LOG_LEVEL", "info"),
+			Format:   src.get("LOG_FORMAT", "json"),
+			FilePath: src.get("LOG_FILE_PATH", "logs/app.log"),
+		},
+		Tenant: TenantConfig{
+			Header:          src.get("TENANT_HEADER", "X-Tenant-ID"),
+			DefaultSlug:     src.get("TENANT_DEFAULT_SLUG", "default"),
+			ResolveFromHost: src.getBool("TENANT_RESOLVE_FROM_HOST", false),
+			CacheTTL:        src.getDuration("TENANT_CACHE_TTL", time.Minute),
+		},
+		Webhook: WebhookConfig{
+			Enabled:              src.getBool("WEBHOOK_ENABLED", true),
+			RequireHTTPS:         src.getBool("WEBHOOK_REQUIRE_HTTPS", false),
+			AllowPrivateNetworks: src.getBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+			RequestTimeout:       src.getDuration("WEBHOOK_REQUEST_TIMEOUT", 10*time.Second),
+			MaxAttempts:          src.getInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			InitialBackoff:       src.getDuration("WEBHOOK_INITIAL_BACKOFF", 10*time.Second),
+			MaxBackoff:           src.getDuration("WEBHOOK_MAX_BACKOFF", time.Hour),
+			PollInterval:         src.getDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+			Workers:              src.getInt("WEBHOOK_WORKERS", 4),
+		},
+		Metrics: MetricsConfig{
+			Enabled: src.getBool("METRICS_ENABLED", true),
+			Path:    src.get("METRICS_PATH", "/metrics"),
+		},
+		RateLimit: RateLimitConfig{
+			Store:   src.get("RATE_LIMIT_STORE", "memory"),
+			Burst:   src.getInt("RATE_LIMIT_BURST", 0),
+			MaxKeys: src.getInt("RATE_LIMIT_MAX_KEYS", 100000),
+		},
+		Redis: RedisConfig{
+			Addr:      src.get("REDIS_ADDR", "localhost:6379"),
+			Password:  src.get("REDIS_PASSWORD", ""),
+			DB:        src.getInt("REDIS_DB", 0),
+			KeyPrefix: src.get("REDIS_KEY_PREFIX", "auth-service:"),
+		},
+		Tracing: TracingConfig{
+			Exporter:     src.get("TRACING_EXPORTER", "none"),
+			ServiceName:  src.get("TRACING_SERVICE_NAME", "auth-service"),
+			OTLPEndpoint: src.get("TRACING_OTLP_ENDPOINT", "localhost:4318"),
+			OTLPInsecure: src.getBool("TRACING_OTLP_INSECURE", false),
+			SampleRatio:  src.getFloat("TRACING_SAMPLE_RATIO", 1.0),
+		},
+		Mail: MailConfig{
+			Transport:      src.get("MAIL_TRANSPORT", "log"),
+			From:           src.get("MAIL_FROM", ""),
+			TemplatesDir:   src.get("MAIL_TEMPLATES_DIR", ""),
+			DefaultLocale:  src.get("MAIL_DEFAULT_LOCALE", "en"),
+			SMTPHost:       src.get("MAIL_SMTP_HOST", ""),
+			SMTPPort:       src.getInt("MAIL_SMTP_PORT", 587),
+			SMTPUsername:   src.get("MAIL_SMTP_USERNAME", ""),
+			SMTPPassword:   src.get("MAIL_SMTP_PASSWORD", ""),
+			SMTPTLS:        src.get("MAIL_SMTP_TLS", "starttls"),
+			SMTPTimeout:    src.getDuration("MAIL_SMTP_TIMEOUT", 10*time.Second),
+			FilePath:       src.get("MAIL_FILE_PATH", "logs/mail.mbox"),
+			QueueSize:      src.getInt("MAIL_QUEUE_SIZE", 1000),
+			Workers:        src.getInt("MAIL_WORKERS", 2),
+			MaxAttempts:    src.getInt("MAIL_MAX_ATTEMPTS", 5),
+			InitialBackoff: src.getDuration("MAIL_INITIAL_BACKOFF", 5*time.Second),
+			MaxBackoff:     src.getDuration("MAIL_MAX_BACKOFF", 5*time.Minute),
+		},
+		MagicLink: MagicLinkConfig{
+			Enabled: src.getBool("MAGIC_LINK_ENABLED", false),
+			URL:     src.get("MAGIC_LINK_URL", ""),
+			TTL:     src.getDuration("MAGIC_LINK_TTL", 15*time.Minute),
+		},
+		OTP: OTPConfig{
+			Enabled:     src.getBool("OTP_ENABLED", false),
+			TTL:         src.getDuration("OTP_TTL", 10*time.Minute),
+			MaxAttempts: src.getInt("OTP_MAX_ATTEMPTS", 5),
+		},
+		LoginAlert: LoginAlertConfig{
+			Enabled:      src.getBool("LOGIN_ALERT_ENABLED", false),
+			URL:          src.get("LOGIN_ALERT_URL", ""),
+			TTL:          src.getDuration("LOGIN_ALERT_TTL", 7*24*time.Hour),
+			GeoIPPath:    src.get("LOGIN_ALERT_GEOIP_DATABASE", ""),
+			DeviceMaxAge: src.getDuration("LOGIN_ALERT_DEVICE_MAX_AGE", 180*24*time.Hour),
+		},
+		File: FileConfig{
+			Path:          src.path,
+			WatchInterval: src.getDuration("CONFIG_WATCH_INTERVAL", 5*time.Second),
 		},
 	}
 
+	policies, err := ParseRateLimitPolicies(src.get("RATE_LIMIT_POLICIES", DefaultRateLimitPolicies))
+	if err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+	cfg.RateLimit.Policies = policies
+
+	trustedProxies, err := ParseCIDRs(src.getSlice("TRUSTED_PROXIES", nil))
+	if err != nil {
+		return nil, fmt.Errorf("config validation failed: TRUSTED_PROXIES: %w", err)
+	}
+	cfg.Server.TrustedProxies = trustedProxies
+
+	allowlist, err := ParseCIDRs(src.getSlice("RATE_LIMIT_ALLOWLIST", nil))
+	if err != nil {
+		return nil, fmt.Errorf("config validation failed: RATE_LIMIT_ALLOWLIST: %w", err)
+	}
+	cfg.RateLimit.Allowlist = allowlist
+
+	profiles, err := loadLifetimeProfiles(src)
+	if err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+	cfg.JWT.Profiles = profiles
+
+	oidc, err := loadOIDC(src)
+	if err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+	cfg.OIDC = oidc
+
+	ldap, err := loadLDAP(src, cfg.Tenant.DefaultSlug)
+	if err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+	cfg.LDAP = ldap
+
+	if err := src.checkUnused(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
@@ -89,6 +334,46 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// AccessTokenKeys returns the secrets access tokens are verified with: the
+// current one, then the previous one when set.
+func (c *JWTConfig) AccessTokenKeys() []string {
+	return withPrevious(c.AccessTokenSecret, c.AccessTokenPreviousSecret)
+}
+
+// RefreshTokenKeys returns the secrets refresh tokens are verified with.
+func (c *JWTConfig) RefreshTokenKeys() []string {
+	return withPrevious(c.RefreshTokenSecret, c.RefreshTokenPreviousSecret)
+}
+
+func withPrevious(current, previous string) []string {
+	if previous == "" {
+		return []string{current}
+	}
+	return []string{current, previous}
+}
+
+func (c *JWTConfig) validatePreviousSecrets() error {
+	secrets := []struct{ name, value string }{
+		{"JWT_ACCESS_PREVIOUS_SECRET", c.AccessTokenPreviousSecret},
+		{"JWT_REFRESH_PREVIOUS_SECRET", c.RefreshTokenPreviousSecret},
+	}
+	for _, secret := range secrets {
+		if secret.value == "" {
+			continue
+		}
+		if len(secret.value) < 32 {
+			return fmt.Errorf("%s must be at least 32 characters for security (current: %d)", secret.name, len(secret.value))
+		}
+		if secret.value == c.AccessTokenSecret || secret.value == c.RefreshTokenSecret {
+			return fmt.Errorf("%s must differ from JWT_ACCESS_SECRET and JWT_REFRESH_SECRET", secret.name)
+		}
+	}
+	if c.AccessTokenPreviousSecret != "" && c.AccessTokenPreviousSecret == c.RefreshTokenPreviousSecret {
+		return fmt.Errorf("JWT_ACCESS_PREVIOUS_SECRET and JWT_REFRESH_PREVIOUS_SECRET must be different")
+	}
+	return nil
+}
+
 func (c *Config) Validate() error {
 	if c.JWT.AccessTokenSecret == "" {
 		return fmt.Errorf("JWT_ACCESS_SECRET is required - must be set in environment")
@@ -105,6 +390,9 @@ func (c *Config) Validate() error {
 	if c.JWT.AccessTokenSecret == c.JWT.RefreshTokenSecret {
 		return fmt.Errorf("JWT_ACCESS_SECRET and JWT_REFRESH_SECRET must be different")
 	}
+	if err := c.JWT.validatePreviousSecrets(); err != nil {
+		return err
+	}
 	if c.JWT.AccessTokenExpiry < 1*time.Minute {
 		return fmt.Errorf("JWT_ACCESS_EXPIRY must be at least 1 minute")
 	}
@@ -114,6 +402,15 @@ func (c *Config) Validate() error {
 	if c.JWT.AccessTokenExpiry >= c.JWT.RefreshTokenExpiry {
 		return fmt.Errorf("JWT_REFRESH_EXPIRY must be longer than JWT_ACCESS_EXPIRY")
 	}
+	if c.JWT.ImpersonationExpiry < 1*time.Minute || c.JWT.ImpersonationExpiry > 1*time.Hour {
+		return fmt.Errorf("JWT_IMPERSONATION_EXPIRY must be between 1 minute and 1 hour")
+	}
+	if err := c.JWT.validateProfiles(c.IsProduction(), c.Session.IdleTimeout); err != nil {
+		return err
+	}
+	if err := c.Session.validate(c.JWT.AccessTokenExpiry); err != nil {
+		return err
+	}
 
 	validEnvs := map[string]bool{"development": true, "staging": true, "production": true}
 	if !validEnvs[c.Server.Environment] {
@@ -122,34 +419,47 @@ func (c *Config) Validate() error {
 	if c.Server.Port < 1 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid port: %d (must be between 1-65535)", c.Server.Port)
 	}
-
-	if c.Database.Host == "" {
-		return fmt.Errorf("DB_HOST is required")
+	if c.GRPC.Enabled {
+		if c.GRPC.Port < 1 || c.GRPC.Port > 65535 {
+			return fmt.Errorf("invalid GRPC_PORT: %d (must be between 1-65535)", c.GRPC.Port)
+		}
+		if c.GRPC.Port == c.Server.Port {
+			return fmt.Errorf("GRPC_PORT must differ from SERVER_PORT")
+		}
 	}
-	if c.Database.Port < 1 || c.Database.Port > 65535 {
-		return fmt.Errorf("invalid DB_PORT: %d (must be between 1-65535)", c.Database.Port)
+	if c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 || c.Server.ShutdownTimeout <= 0 {
+		return fmt.Errorf("SERVER_READ_TIMEOUT, SERVER_WRITE_TIMEOUT, SERVER_IDLE_TIMEOUT and SERVER_SHUTDOWN_TIMEOUT must be positive")
 	}
-	if c.Database.User == "" {
-		return fmt.Errorf("DB_USER is required")
+	if c.Server.RequestTimeout <= 0 {
+		return fmt.Errorf("SERVER_REQUEST_TIMEOUT must be positive")
 	}
-	if c.Database.Password == "" {
-		return fmt.Errorf("DB_PASSWORD is required - database credentials must be set")
+	if c.Server.MaxBodySize < 1 {
+		return fmt.Errorf("SERVER_MAX_BODY_SIZE must be at least 1 byte")
 	}
-	if c.Database.DBName == "" {
-		return fmt.Errorf("DB_NAME is required")
+	if c.JWT.Issuer == "" {
+		return fmt.Errorf("JWT_ISSUER must not be empty")
 	}
-	validSSLModes := map[string]bool{"disable": true, "require": true, "verify-ca": true, "verify-full": true}
-	if !validSSLModes[c.Database.SSLMode] {
-		return fmt.Errorf("invalid DB_SSL_MODE: %s (must be disable, require, verify-ca, or verify-full)", c.Database.SSLMode)
+
+	switch c.Database.Driver {
+	case "postgres":
+		if err := c.Database.validatePostgres(c.IsProduction()); err != nil {
+			return err
+		}
+	case "sqlite":
+		if c.Database.SQLitePath == "" {
+			return fmt.Errorf("DB_SQLITE_PATH is required when DB_DRIVER is sqlite")
+		}
+	default:
+		return fmt.Errorf("invalid DB_DRIVER: %s (must be postgres or sqlite)", c.Database.Driver)
 	}
 
 	if c.IsProduction() {
-		if c.Database.SSLMode == "disable" {
-			return fmt.Errorf("SSL must be enabled in production (DB_SSL_MODE cannot be 'disable')")
-		}
 		if c.JWT.AccessTokenExpiry > 1*time.Hour {
 			return fmt.Errorf("in production, JWT_ACCESS_EXPIRY should not exceed 1 hour for security")
 		}
+		if c.Webhook.Enabled && !c.Webhook.RequireHTTPS {
+			return fmt.Errorf("in production, WEBHOOK_REQUIRE_HTTPS must be enabled")
+		}
 	}
 
 	validLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
@@ -161,6 +471,91 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid log format: %s (must be json or text)", c.Logger.Format)
 	}
 
+	if c.Tenant.Header == "" {
+		return fmt.Errorf("TENANT_HEADER must not be empty")
+	}
+	if c.Tenant.DefaultSlug == "" {
+		return fmt.Errorf("TENANT_DEFAULT_SLUG must not be empty")
+	}
+	if c.Tenant.CacheTTL < 0 {
+		return fmt.Errorf("TENANT_CACHE_TTL must not be negative")
+	}
+
+	if c.Webhook.Enabled {
+		if c.Webhook.MaxAttempts < 1 {
+			return fmt.Errorf("WEBHOOK_MAX_ATTEMPTS must be at least 1")
+		}
+		if c.Webhook.Workers < 1 {
+			return fmt.Errorf("WEBHOOK_WORKERS must be at least 1")
+		}
+		if c.Webhook.RequestTimeout <= 0 || c.Webhook.PollInterval <= 0 || c.Webhook.InitialBackoff <= 0 {
+			return fmt.Errorf("WEBHOOK_REQUEST_TIMEOUT, WEBHOOK_POLL_INTERVAL and WEBHOOK_INITIAL_BACKOFF must be positive")
+		}
+		if c.Webhook.MaxBackoff < c.Webhook.InitialBackoff {
+			return fmt.Errorf("WEBHOOK_MAX_BACKOFF must not be shorter than WEBHOOK_INITIAL_BACKOFF")
+		}
+	}
+
+	if c.Metrics.Enabled {
+		if !strings.HasPrefix(c.Metrics.Path, "/") || strings.HasPrefix(c.Metrics.Path, "/api/") || c.Metrics.Path == "/health" {
+			return fmt.Errorf("METRICS_PATH must be an absolute path outside /api/ and /health")
+		}
+	}
+
+	if c.Server.HealthCheckTimeout <= 0 {
+		return fmt.Errorf("HEALTH_CHECK_TIMEOUT must be positive")
+	}
+	if c.Server.ShutdownDrainDelay < 0 {
+		return fmt.Errorf("SHUTDOWN_DRAIN_DELAY must not be negative")
+	}
+
+	if c.Server.RateLimit < 1 {
+		return fmt.Errorf("RATE_LIMIT must be at least 1")
+	}
+	if c.RateLimit.Store != "memory" && c.RateLimit.Store != "redis" {
+		return fmt.Errorf("invalid RATE_LIMIT_STORE: %s (must be memory or redis)", c.RateLimit.Store)
+	}
+	if c.RateLimit.Burst < 0 {
+		return fmt.Errorf("RATE_LIMIT_BURST must not be negative")
+	}
+	if c.RateLimit.Store == "memory" && c.RateLimit.MaxKeys < 1 {
+		return fmt.Errorf("RATE_LIMIT_MAX_KEYS must be at least 1")
+	}
+	if c.RateLimit.Store == "redis" && c.Redis.Addr == "" {
+		return fmt.Errorf("REDIS_ADDR is required when RATE_LIMIT_STORE is redis")
+	}
+
+	if c.File.WatchInterval < 0 {
+		return fmt.Errorf("CONFIG_WATCH_INTERVAL must not be negative")
+	}
+
+	if err := c.OIDC.validate(); err != nil {
+		return err
+	}
+	if err := c.LDAP.validate(c.IsProduction()); err != nil {
+		return err
+	}
+	if err := c.MagicLink.validate(); err != nil {
+		return err
+	}
+	if err := c.OTP.validate(); err != nil {
+		return err
+	}
+	if err := c.LoginAlert.validate(); err != nil {
+		return err
+	}
+	if err := c.Mail.validate(c.MagicLink.Enabled || c.OTP.Enabled || c.LoginAlert.Enabled, c.IsProduction()); err != nil {
+		return err
+	}
+
+	validExporters := map[string]bool{"none": true, "stdout": true, "otlp": true}
+	if !validExporters[c.Tracing.Exporter] {
+		return fmt.Errorf("invalid TRACING_EXPORTER: %s (must be none, stdout, or otlp)", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1")
+	}
+
 	return nil
 }
 
@@ -168,15 +563,15 @@ func (c *Config) IsProduction() bool {
 	return c.Server.Environment == "production"
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+func (s *source) get(key, defaultValue string) string {
+	if value := s.lookup(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+func (s *source) getInt(key string, defaultValue int) int {
+	if value := s.lookup(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
@@ -184,8 +579,26 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
-func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
+func (s *source) getBool(key string, defaultValue bool) bool {
+	if value := s.lookup(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func (s *source) getFloat(key string, defaultValue float64) float64 {
+	if value := s.lookup(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+func (s *source) getDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := s.lookup(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
 			return duration
 		}
@@ -193,8 +606,8 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
-func getEnvAsSlice(key string, defaultValue []string) []string {
-	if value := os.Getenv(key); value != "" {
+func (s *source) getSlice(key string, defaultValue []string) []string {
+	if value := s.lookup(key); value != "" {
 		var result []string
 		for _, v := range splitAndTrim(value, ",") {
 			if v != "" {
@@ -246,3 +659,39 @@ func trimSpace(s string) string {
 	}
 	return s[start:end]
 }
+
+func (d *DatabaseConfig) validatePostgres(production bool) error {
+	if d.Host == "" {
+		return fmt.Errorf("DB_HOST is required")
+	}
+	if d.Port < 1 || d.Port > 65535 {
+		return fmt.Errorf("invalid DB_PORT: %d (must be between 1-65535)", d.Port)
+	}
+	if d.User == "" {
+		return fmt.Errorf("DB_USER is required")
+	}
+	if d.Password == "" {
+		return fmt.Errorf("DB_PASSWORD is required - database credentials must be set")
+	}
+	if d.DBName == "" {
+		return fmt.Errorf("DB_NAME is required")
+	}
+	if d.MaxConns < 1 {
+		return fmt.Errorf("DB_MAX_CONNS must be at least 1")
+	}
+	if d.MinConns < 0 || d.MinConns > d.MaxConns {
+		return fmt.Errorf("DB_MIN_CONNS must be between 0 and DB_MAX_CONNS")
+	}
+	if d.MaxConnLifetime <= 0 || d.MaxConnIdleTime <= 0 || d.HealthCheckPeriod <= 0 {
+		return fmt.Errorf("DB_MAX_CONN_LIFETIME, DB_MAX_CONN_IDLE_TIME and DB_HEALTH_CHECK_PERIOD must be positive")
+	}
+	validSSLModes := map[string]bool{"disable": true, "require": true, "verify-ca": true, "verify-full": true}
+	if !validSSLModes[d.SSLMode] {
+		return fmt.Errorf("invalid DB_SSL_MODE: %s (must be disable, require, verify-ca, or verify-full)", d.SSLMode)
+	}
+
+	if production && d.SSLMode == "disable" {
+		return fmt.Errorf("SSL must be enabled in production (DB_SSL_MODE cannot be 'disable')")
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// listSeparators overrides the "," used to join list values from the config
+// file for settings whose syntax already uses commas.
+var listSeparators = map[string]string{
+	"RATE_LIMIT_POLICIES": ";",
+}
+
+// source resolves settings from the environment first and the config file
+// second. File keys are the environment variable names, either flat
+// (log_level) or nested by prefix (log: {level: ...}), in any case.
+type source struct {
+	path string
+	file map[string]string
+	used map[string]bool
+}
+
+func newSource(path string) (*source, error) {
+	src := &source{path: path, used: make(map[string]bool)}
+	if path == "" {
+		return src, nil
+	}
+
+	file, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	src.file = file
+	return src, nil
+}
+
+func (s *source) lookup(key string) string {
+	s.used[key] = true
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return s.file[key]
+}
+
+// checkUnused rejects file keys that no setting read, which are almost always
+// typos.
+func (s *source) checkUnused() error {
+	var unknown []string
+	for key := range s.file {
+		if !s.used[key] {
+			unknown = append(unknown, strings.ToLower(key))
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("unknown settings in %s: %s", s.path, strings.Join(unknown, ", "))
+}
+
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var tree map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("unsupported config file %s (must be .yaml, .yml or .toml)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten("", tree, values); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func flatten(prefix string, value interface{}, out map[string]string) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			key := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(k))
+			if prefix != "" {
+				key = prefix + "_" + key
+			}
+			if err := flatten(key, child, out); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := scalar(prefix, item)
+			if err != nil {
+				return err
+			}
+			items = append(items, s)
+		}
+		sep, ok := listSeparators[prefix]
+		if !ok {
+			sep = ","
+		}
+		out[prefix] = strings.Join(items, sep)
+		return nil
+	default:
+		s, err := scalar(prefix, v)
+		if err != nil {
+			return err
+		}
+		out[prefix] = s
+		return nil
+	}
+}
+
+func scalar(key string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("%s: unsupported value %v", strings.ToLower(key), value)
+	}
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// LDAPConfig configures password login against an LDAP or Active Directory
+// server. Users are found with a search and authenticated by binding as
+// their entry.
+type LDAPConfig struct {
+	Enabled       bool
+	URL           string // ldap:// or ldaps://
+	StartTLS      bool
+	TLSSkipVerify bool
+	BindDN        string // account used to search for users; empty searches anonymously
+	BindPassword  string
+	BaseDN        string
+	UserFilter    string // {username} is replaced by the escaped login name
+	Tenant        string // slug of the tenant whose logins are checked against the directory
+	Timeout       time.Duration
+
+	AttrID       string // stable identifier, e.g. entryUUID or objectGUID
+	AttrUsername string
+	AttrEmail    string
+	AttrFullName string
+	AttrGroups   string
+
+	GroupRoles []LDAPGroupRole // empty leaves roles of directory users alone
+}
+
+type LDAPGroupRole struct {
+	GroupDN string
+	Role    string
+}
+
+func loadLDAP(src *source, defaultTenant string) (LDAPConfig, error) {
+	cfg := LDAPConfig{
+		Enabled:       src.getBool("LDAP_ENABLED", false),
+		URL:           src.get("LDAP_URL", ""),
+		StartTLS:      src.getBool("LDAP_START_TLS", false),
+		TLSSkipVerify: src.getBool("LDAP_TLS_SKIP_VERIFY", false),
+		BindDN:        src.get("LDAP_BIND_DN", ""),
+		BindPassword:  src.get("LDAP_BIND_PASSWORD", ""),
+		BaseDN:        src.get("LDAP_BASE_DN", ""),
+		UserFilter:    src.get("LDAP_USER_FILTER", "(uid={username})"),
+		Tenant:        src.get("LDAP_TENANT", defaultTenant),
+		Timeout:       src.getDuration("LDAP_TIMEOUT", 5*time.Second),
+		AttrID:        src.get("LDAP_ATTR_ID", "entryUUID"),
+		AttrUsername:  src.get("LDAP_ATTR_USERNAME", "uid"),
+		AttrEmail:     src.get("LDAP_ATTR_EMAIL", "mail"),
+		AttrFullName:  src.get("LDAP_ATTR_FULL_NAME", "cn"),
+		AttrGroups:    src.get("LDAP_ATTR_GROUPS", "memberOf"),
+	}
+
+	groupRoles, err := ParseLDAPGroupRoles(src.get("LDAP_GROUP_ROLES", ""))
+	if err != nil {
+		return cfg, fmt.Errorf("LDAP_GROUP_ROLES: %w", err)
+	}
+	cfg.GroupRoles = groupRoles
+
+	return cfg, nil
+}
+
+// ParseLDAPGroupRoles parses mappings of the form
+//
+//	<group DN>=<role>[;<group DN>=<role>...]
+//
+// Group DNs contain "=" themselves, so the role follows the last one.
+func ParseLDAPGroupRoles(spec string) ([]LDAPGroupRole, error) {
+	var mappings []LDAPGroupRole
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid mapping %q: expected <group DN>=<role>", entry)
+		}
+		mapping := LDAPGroupRole{
+			GroupDN: strings.TrimSpace(entry[:i]),
+			Role:    strings.TrimSpace(entry[i+1:]),
+		}
+		if mapping.Role != "user" && mapping.Role != "admin" {
+			return nil, fmt.Errorf("invalid role %q for %s (must be user or admin)", mapping.Role, mapping.GroupDN)
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+func (c *LDAPConfig) validate(production bool) error {
+	if !c.Enabled {
+		return nil
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "ldap" && u.Scheme != "ldaps") {
+		return fmt.Errorf("LDAP_URL must be an ldap:// or ldaps:// URL")
+	}
+	if c.StartTLS && u.Scheme == "ldaps" {
+		return fmt.Errorf("LDAP_START_TLS only applies to ldap:// URLs")
+	}
+	if production {
+		if u.Scheme == "ldap" && !c.StartTLS {
+			return fmt.Errorf("in production, LDAP_URL must use ldaps:// or LDAP_START_TLS must be enabled")
+		}
+		if c.TLSSkipVerify {
+			return fmt.Errorf("in production, LDAP_TLS_SKIP_VERIFY must be disabled")
+		}
+	}
+	if c.BaseDN == "" {
+		return fmt.Errorf("LDAP_BASE_DN is required when LDAP_ENABLED is true")
+	}
+	if !strings.Contains(c.UserFilter, "{username}") {
+		return fmt.Errorf("LDAP_USER_FILTER must contain {username}")
+	}
+	if c.BindDN != "" && c.BindPassword == "" {
+		return fmt.Errorf("LDAP_BIND_PASSWORD is required when LDAP_BIND_DN is set")
+	}
+	if c.Tenant == "" {
+		return fmt.Errorf("LDAP_TENANT must not be empty")
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("LDAP_TIMEOUT must be positive")
+	}
+	if c.AttrUsername == "" || c.AttrEmail == "" {
+		return fmt.Errorf("LDAP_ATTR_USERNAME and LDAP_ATTR_EMAIL must not be empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var lifetimeProfileName = regexp.MustCompile(`^[a-z0-9-]{1,50}$`)
+
+// LifetimeProfile overrides token lifetimes for the sessions of some clients
+// or roles. A zero lifetime keeps the default.
+type LifetimeProfile struct {
+	Name               string
+	AccessTokenExpiry  time.Duration
+	RefreshTokenExpiry time.Duration
+	RememberMeExpiry   time.Duration
+	Clients            []string // X-Client-ID values the profile applies to
+	Roles              []string // roles the profile applies to, whatever the client
+}
+
+func (p *LifetimeProfile) envPrefix() string {
+	return "JWT_PROFILE_" + strings.ToUpper(strings.ReplaceAll(p.Name, "-", "_")) + "_"
+}
+
+// loadLifetimeProfiles reads the profiles named by JWT_PROFILES. Each profile
+// is configured under JWT_PROFILE_<NAME>_*, with dashes in the name read as
+// underscores.
+func loadLifetimeProfiles(src *source) ([]LifetimeProfile, error) {
+	var profiles []LifetimeProfile
+	seen := make(map[string]bool)
+	for _, name := range src.getSlice("JWT_PROFILES", nil) {
+		name = strings.ToLower(name)
+		if !lifetimeProfileName.MatchString(name) {
+			return nil, fmt.Errorf("invalid JWT profile name %q (must be lowercase letters, digits and dashes)", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("JWT profile %q is listed twice", name)
+		}
+		seen[name] = true
+
+		profile := LifetimeProfile{Name: name}
+		prefix := profile.envPrefix()
+		profile.AccessTokenExpiry = src.getDuration(prefix+"ACCESS_EXPIRY", 0)
+		profile.RefreshTokenExpiry = src.getDuration(prefix+"REFRESH_EXPIRY", 0)
+		profile.RememberMeExpiry = src.getDuration(prefix+"REMEMBER_ME_EXPIRY", 0)
+		profile.Clients = src.getSlice(prefix+"CLIENTS", nil)
+		profile.Roles = src.getSlice(prefix+"ROLES", nil)
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// lifetimes returns the access, refresh and remember-me lifetimes the profile
+// gives sessions of the default tenant. A profile that shortens the refresh
+// token but leaves REMEMBER_ME_EXPIRY unset keeps remember_me from
+// lengthening it again.
+func (p *LifetimeProfile) lifetimes(c *JWTConfig) (access, refresh, rememberMe time.Duration) {
+	access, refresh, rememberMe = c.AccessTokenExpiry, c.RefreshTokenExpiry, c.RememberMeExpiry
+	if p.AccessTokenExpiry > 0 {
+		access = p.AccessTokenExpiry
+	}
+	if p.RefreshTokenExpiry > 0 {
+		refresh = p.RefreshTokenExpiry
+		if rememberMe > 0 {
+			rememberMe = refresh
+		}
+	}
+	if p.RememberMeExpiry > 0 {
+		rememberMe = p.RememberMeExpiry
+	}
+	return access, refresh, rememberMe
+}
+
+// validateProfiles checks the profiles against each other and against the
+// session idle timeout, which must outlast every access token.
+func (c *JWTConfig) validateProfiles(production bool, idleTimeout time.Duration) error {
+	if c.RememberMeExpiry != 0 && c.RememberMeExpiry < c.RefreshTokenExpiry {
+		return fmt.Errorf("JWT_REMEMBER_ME_EXPIRY must be 0 or at least JWT_REFRESH_EXPIRY")
+	}
+
+	clients := make(map[string]string)
+	roles := make(map[string]string)
+	for _, p := range c.Profiles {
+		prefix := p.envPrefix()
+		if p.AccessTokenExpiry == 0 && p.RefreshTokenExpiry == 0 && p.RememberMeExpiry == 0 {
+			return fmt.Errorf("%s*: set at least one of ACCESS_EXPIRY, REFRESH_EXPIRY and REMEMBER_ME_EXPIRY", prefix)
+		}
+		if p.AccessTokenExpiry != 0 && p.AccessTokenExpiry < time.Minute {
+			return fmt.Errorf("%sACCESS_EXPIRY must be at least 1 minute", prefix)
+		}
+		if production && p.AccessTokenExpiry > time.Hour {
+			return fmt.Errorf("in production, %sACCESS_EXPIRY should not exceed 1 hour for security", prefix)
+		}
+		if p.RefreshTokenExpiry != 0 && p.RefreshTokenExpiry < 15*time.Minute {
+			return fmt.Errorf("%sREFRESH_EXPIRY must be at least 15 minutes", prefix)
+		}
+		if p.AccessTokenExpiry != 0 && p.RefreshTokenExpiry != 0 && p.AccessTokenExpiry >= p.RefreshTokenExpiry {
+			return fmt.Errorf("%sREFRESH_EXPIRY must be longer than %sACCESS_EXPIRY", prefix, prefix)
+		}
+		if p.RememberMeExpiry != 0 && p.RememberMeExpiry < p.RefreshTokenExpiry {
+			return fmt.Errorf("%sREMEMBER_ME_EXPIRY must not be shorter than %sREFRESH_EXPIRY", prefix, prefix)
+		}
+		if idleTimeout > 0 && p.AccessTokenExpiry != 0 && idleTimeout <= p.AccessTokenExpiry {
+			return fmt.Errorf("SESSION_IDLE_TIMEOUT must be longer than %sACCESS_EXPIRY", prefix)
+		}
+		if len(p.Clients) == 0 && len(p.Roles) == 0 {
+			return fmt.Errorf("%sCLIENTS or %sROLES is required", prefix, prefix)
+		}
+
+		for _, client := range p.Clients {
+			if other, ok := clients[client]; ok {
+				return fmt.Errorf("client %q is in JWT profiles %q and %q", client, other, p.Name)
+			}
+			clients[client] = p.Name
+		}
+		for _, role := range p.Roles {
+			if role != "user" && role != "admin" {
+				return fmt.Errorf("%sROLES: invalid role %q (must be user or admin)", prefix, role)
+			}
+			if other, ok := roles[role]; ok {
+				return fmt.Errorf("role %q is in JWT profiles %q and %q", role, other, p.Name)
+			}
+			roles[role] = p.Name
+		}
+	}
+	return c.validateProfilePrecedence()
+}
+
+// validateProfilePrecedence rejects role profiles that would lengthen the
+// sessions of a client with a profile of its own. Role profiles take
+// precedence, so such a client would otherwise lose its shorter lifetimes
+// whenever a user with the role logs in through it.
+func (c *JWTConfig) validateProfilePrecedence() error {
+	for i := range c.Profiles {
+		client := &c.Profiles[i]
+		if len(client.Clients) == 0 {
+			continue
+		}
+		access, refresh, rememberMe := client.lifetimes(c)
+		for j := range c.Profiles {
+			role := &c.Profiles[j]
+			if i == j || len(role.Roles) == 0 {
+				continue
+			}
+			roleAccess, roleRefresh, roleRememberMe := role.lifetimes(c)
+			if roleAccess > access || roleRefresh > refresh || roleRememberMe > rememberMe {
+				return fmt.Errorf("JWT profile %q for roles %s would lengthen the sessions of JWT profile %q for clients %s; role profiles take precedence, so they must not be longer",
+					role.Name, strings.Join(role.Roles, ","), client.Name, strings.Join(client.Clients, ","))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestProfileConfig(profiles ...LifetimeProfile) *JWTConfig {
+	return &JWTConfig{
+		AccessTokenExpiry:  15 * time.Minute,
+		RefreshTokenExpiry: 168 * time.Hour,
+		RememberMeExpiry:   720 * time.Hour,
+		Profiles:           profiles,
+	}
+}
+
+func TestValidateProfiles(t *testing.T) {
+	banking := LifetimeProfile{Name: "banking", AccessTokenExpiry: 5 * time.Minute, RefreshTokenExpiry: 30 * time.Minute, Clients: []string{"banking-web"}}
+	mobile := LifetimeProfile{Name: "mobile", RefreshTokenExpiry: 2160 * time.Hour, Clients: []string{"ios-app"}}
+	admins := LifetimeProfile{Name: "admins", AccessTokenExpiry: 5 * time.Minute, RefreshTokenExpiry: 30 * time.Minute, Roles: []string{"admin"}}
+	longAdmins := LifetimeProfile{Name: "long-admins", AccessTokenExpiry: 30 * time.Minute, Roles: []string{"admin"}}
+
+	tests := []struct {
+		name        string
+		profiles    []LifetimeProfile
+		idleTimeout time.Duration
+		wantErr     string
+	}{
+		{"client profiles", []LifetimeProfile{banking, mobile}, 0, ""},
+		{"shorter role profile", []LifetimeProfile{banking, mobile, admins}, 0, ""},
+		{"longer role profile", []LifetimeProfile{banking, longAdmins}, 0, `JWT profile "long-admins" for roles admin would lengthen the sessions of JWT profile "banking"`},
+		// Remember-me follows banking's refresh lifetime, so the role profile's
+		// default remember-me lifetime would outlast it.
+		{"role profile keeping remember-me", []LifetimeProfile{banking, {Name: "admins", AccessTokenExpiry: 5 * time.Minute, Roles: []string{"admin"}}}, 0, `JWT profile "admins"`},
+		{"idle timeout", []LifetimeProfile{banking, mobile}, 10 * time.Minute, ""},
+		{"idle timeout within access expiry", []LifetimeProfile{banking, longAdmins}, 20 * time.Minute, "SESSION_IDLE_TIMEOUT must be longer than JWT_PROFILE_LONG_ADMINS_ACCESS_EXPIRY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newTestProfileConfig(tt.profiles...).validateProfiles(false, tt.idleTimeout)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("validateProfiles: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("validateProfiles = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoginAlertConfig configures the emails sent when a user logs in from a
+// device or network not seen before.
+type LoginAlertConfig struct {
+	Enabled      bool
+	URL          string        // page of the client app that posts the token from its token query parameter to report the login
+	TTL          time.Duration // how long the "this wasn't me" link works
+	GeoIPPath    string        // optional MaxMind City database used to show an approximate location
+	DeviceMaxAge time.Duration // devices not seen for this long are forgotten
+}
+
+func (c *LoginAlertConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if err := validateSecureURL(c.URL); err != nil {
+		return fmt.Errorf("LOGIN_ALERT_URL: %w", err)
+	}
+	if c.TTL < time.Hour || c.TTL > 30*24*time.Hour {
+		return fmt.Errorf("LOGIN_ALERT_TTL must be between 1 hour and 30 days")
+	}
+	if c.DeviceMaxAge < 24*time.Hour {
+		return fmt.Errorf("LOGIN_ALERT_DEVICE_MAX_AGE must be at least 24 hours")
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// MagicLinkConfig configures passwordless login through emailed links.
+type MagicLinkConfig struct {
+	Enabled bool
+	URL     string // page of the client app that posts the token from its token query parameter
+	TTL     time.Duration
+}
+
+func (c *MagicLinkConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if err := validateSecureURL(c.URL); err != nil {
+		return fmt.Errorf("MAGIC_LINK_URL: %w", err)
+	}
+	if c.TTL < time.Minute || c.TTL > time.Hour {
+		return fmt.Errorf("MAGIC_LINK_TTL must be between 1 minute and 1 hour")
+	}
+	return nil
+}
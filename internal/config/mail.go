@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"time"
+)
+
+// MailConfig selects how outgoing email is delivered and how the send queue
+// retries failures.
+type MailConfig struct {
+	Transport     string // smtp, file (an mbox for development) or log
+	From          string
+	TemplatesDir  string // overrides and additions to the built-in templates
+	DefaultLocale string // used when no template matches the request's Accept-Language
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPTLS      string // starttls, tls (implicit, usually port 465) or none
+	SMTPTimeout  time.Duration
+
+	FilePath string
+
+	QueueSize      int
+	Workers        int
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func (c *MailConfig) validate(required, production bool) error {
+	switch c.Transport {
+	case "smtp":
+		if err := c.validateSMTP(production); err != nil {
+			return err
+		}
+	case "file":
+		if c.FilePath == "" {
+			return fmt.Errorf("MAIL_FILE_PATH is required when MAIL_TRANSPORT is file")
+		}
+	case "log":
+	default:
+		return fmt.Errorf("invalid MAIL_TRANSPORT: %s (must be smtp, file or log)", c.Transport)
+	}
+	if c.DefaultLocale == "" {
+		return fmt.Errorf("MAIL_DEFAULT_LOCALE must not be empty")
+	}
+	if c.QueueSize < 1 || c.Workers < 1 || c.MaxAttempts < 1 {
+		return fmt.Errorf("MAIL_QUEUE_SIZE, MAIL_WORKERS and MAIL_MAX_ATTEMPTS must be at least 1")
+	}
+	if c.InitialBackoff <= 0 {
+		return fmt.Errorf("MAIL_INITIAL_BACKOFF must be positive")
+	}
+	if c.MaxBackoff < c.InitialBackoff {
+		return fmt.Errorf("MAIL_MAX_BACKOFF must not be shorter than MAIL_INITIAL_BACKOFF")
+	}
+
+	if !required && c.Transport != "smtp" {
+		return nil
+	}
+	if production && c.Transport != "smtp" {
+		return fmt.Errorf("in production, MAIL_TRANSPORT must be smtp as %s keeps login links and codes on the server", c.Transport)
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("MAIL_FROM must be a valid address: %w", err)
+	}
+	return nil
+}
+
+func (c *MailConfig) validateSMTP(production bool) error {
+	if c.SMTPHost == "" {
+		return fmt.Errorf("MAIL_SMTP_HOST is required when MAIL_TRANSPORT is smtp")
+	}
+	if c.SMTPPort < 1 || c.SMTPPort > 65535 {
+		return fmt.Errorf("invalid MAIL_SMTP_PORT: %d (must be between 1-65535)", c.SMTPPort)
+	}
+	switch c.SMTPTLS {
+	case "starttls", "tls":
+	case "none":
+		if production {
+			return fmt.Errorf("in production, MAIL_SMTP_TLS must be starttls or tls")
+		}
+	default:
+		return fmt.Errorf("invalid MAIL_SMTP_TLS: %s (must be starttls, tls or none)", c.SMTPTLS)
+	}
+	if (c.SMTPUsername == "") != (c.SMTPPassword == "") {
+		return fmt.Errorf("MAIL_SMTP_USERNAME and MAIL_SMTP_PASSWORD must be set together")
+	}
+	if c.SMTPTimeout <= 0 {
+		return fmt.Errorf("MAIL_SMTP_TIMEOUT must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var oidcProviderName = regexp.MustCompile(`^[a-z0-9-]{1,50}$`)
+
+// OIDCConfig lists the upstream OpenID Connect providers users may sign in
+// with.
+type OIDCConfig struct {
+	Providers []OIDCProvider
+	StateTTL  time.Duration // how long an authorization request may take to complete
+}
+
+type OIDCProvider struct {
+	Name         string // path segment and identity provider key, e.g. "google"
+	DisplayName  string
+	Issuer       string // discovery is performed against <Issuer>/.well-known/openid-configuration
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	AllowSignup  bool // create accounts for unknown identities
+}
+
+// loadOIDC reads the providers named by OIDC_PROVIDERS. Each provider is
+// configured under OIDC_<NAME>_*, with dashes in the name read as
+// underscores.
+func loadOIDC(src *source) (OIDCConfig, error) {
+	cfg := OIDCConfig{
+		StateTTL: src.getDuration("OIDC_STATE_TTL", 10*time.Minute),
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range src.getSlice("OIDC_PROVIDERS", nil) {
+		name = strings.ToLower(name)
+		if !oidcProviderName.MatchString(name) {
+			return cfg, fmt.Errorf("invalid OIDC provider name %q (must be lowercase letters, digits and dashes)", name)
+		}
+		if name == "password" {
+			return cfg, fmt.Errorf("OIDC provider name %q is reserved", name)
+		}
+		if seen[name] {
+			return cfg, fmt.Errorf("OIDC provider %q is listed twice", name)
+		}
+		seen[name] = true
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		cfg.Providers = append(cfg.Providers, OIDCProvider{
+			Name:         name,
+			DisplayName:  src.get(prefix+"DISPLAY_NAME", name),
+			Issuer:       strings.TrimSuffix(src.get(prefix+"ISSUER", ""), "/"),
+			ClientID:     src.get(prefix+"CLIENT_ID", ""),
+			ClientSecret: src.get(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  src.get(prefix+"REDIRECT_URL", ""),
+			Scopes:       src.getSlice(prefix+"SCOPES", []string{"openid", "email", "profile"}),
+			AllowSignup:  src.getBool(prefix+"ALLOW_SIGNUP", true),
+		})
+	}
+
+	return cfg, nil
+}
+
+func (c *OIDCConfig) validate() error {
+	if len(c.Providers) > 0 && c.StateTTL < 1*time.Minute {
+		return fmt.Errorf("OIDC_STATE_TTL must be at least 1 minute")
+	}
+
+	for _, p := range c.Providers {
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(p.Name, "-", "_")) + "_"
+		if p.ClientID == "" {
+			return fmt.Errorf("%sCLIENT_ID is required", prefix)
+		}
+		if err := validateSecureURL(p.Issuer); err != nil {
+			return fmt.Errorf("%sISSUER: %w", prefix, err)
+		}
+		if err := validateSecureURL(p.RedirectURL); err != nil {
+			return fmt.Errorf("%sREDIRECT_URL: %w", prefix, err)
+		}
+
+		hasOpenID := false
+		for _, scope := range p.Scopes {
+			if scope == "openid" {
+				hasOpenID = true
+			}
+		}
+		if !hasOpenID {
+			return fmt.Errorf("%sSCOPES must include openid", prefix)
+		}
+	}
+
+	return nil
+}
+
+// validateSecureURL requires an absolute https URL, allowing plain http only for
+// loopback hosts used in development.
+func validateSecureURL(raw string) error {
+	if raw == "" {
+		return fmt.Errorf("must be set")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("must be an absolute URL")
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("must use https")
+}
+
+// Provider returns the provider with the given name.
+func (c *OIDCConfig) Provider(name string) (OIDCProvider, bool) {
+	for _, p := range c.Providers {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return OIDCProvider{}, false
+}
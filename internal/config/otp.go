@@ -0,0 +1,27 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// OTPConfig configures one-time codes sent by email, used to log in and to
+// step up an existing session.
+type OTPConfig struct {
+	Enabled     bool
+	TTL         time.Duration
+	MaxAttempts int // verification attempts allowed before the code is discarded
+}
+
+func (c *OTPConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.TTL < time.Minute || c.TTL > 30*time.Minute {
+		return fmt.Errorf("OTP_TTL must be between 1 minute and 30 minutes")
+	}
+	if c.MaxAttempts < 1 || c.MaxAttempts > 10 {
+		return fmt.Errorf("OTP_MAX_ATTEMPTS must be between 1 and 10")
+	}
+	return nil
+}
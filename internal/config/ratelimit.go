@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate limit keys identify whose budget a request is charged to.
+const (
+	RateLimitKeyIP       = "ip"
+	RateLimitKeyUser     = "user"     // authenticated user id
+	RateLimitKeyClient   = "client"   // API key presented by the caller
+	RateLimitKeyUsername = "username" // username submitted in the request body
+)
+
+// DefaultRateLimitPolicies keeps login and registration strict, per caller
+// and per target account, while letting gateways call validate at volume.
+const DefaultRateLimitPolicies = "POST /api/v1/auth/login=ip:20/1m,username:5/1m;" +
+	"POST /api/v1/auth/register=ip:5/1m;" +
+	"POST /api/v1/auth/refresh=ip:30/1m;" +
+	"GET /api/v1/auth/oidc/{provider}/authorize=ip:20/1m;" +
+	"POST /api/v1/auth/oidc/{provider}/callback=ip:20/1m;" +
+	"POST /api/v1/auth/magic-link=ip:5/1m;" +
+	"POST /api/v1/auth/magic-link/consume=ip:20/1m;" +
+	"POST /api/v1/auth/otp=ip:5/1m;" +
+	"POST /api/v1/auth/otp/verify=ip:20/1m;" +
+	"POST /api/v1/auth/otp/step-up=user:5/1m;" +
+	"POST /api/v1/auth/login-alerts/report=ip:10/1m;" +
+	"POST /api/v1/auth/validate=client:3000/1m"
+
+type RateLimitRule struct {
+	Key    string
+	Rate   int
+	Period time.Duration
+}
+
+type RateLimitPolicy struct {
+	Route string // ServeMux pattern, e.g. "POST /api/v1/auth/login"
+	Rules []RateLimitRule
+}
+
+// ParseRateLimitPolicies parses policies of the form
+//
+//	<route pattern>=<key>:<rate>/<period>[,<key>:<rate>/<period>...][;...]
+//
+// where period is a Go duration such as 1m or 1h. "none" disables all route
+// policies.
+func ParseRateLimitPolicies(spec string) ([]RateLimitPolicy, error) {
+	var policies []RateLimitPolicy
+	if strings.TrimSpace(spec) == "none" {
+		return policies, nil
+	}
+
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, rules, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit policy %q: expected <route>=<rules>", entry)
+		}
+
+		policy := RateLimitPolicy{Route: strings.TrimSpace(route)}
+		for _, ruleSpec := range strings.Split(rules, ",") {
+			rule, err := parseRateLimitRule(strings.TrimSpace(ruleSpec))
+			if err != nil {
+				return nil, fmt.Errorf("invalid rate limit policy for %q: %w", policy.Route, err)
+			}
+			policy.Rules = append(policy.Rules, rule)
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+func parseRateLimitRule(spec string) (RateLimitRule, error) {
+	key, limit, ok := strings.Cut(spec, ":")
+	if !ok {
+		return RateLimitRule{}, fmt.Errorf("rule %q: expected <key>:<rate>/<period>", spec)
+	}
+
+	switch key {
+	case RateLimitKeyIP, RateLimitKeyUser, RateLimitKeyClient, RateLimitKeyUsername:
+	default:
+		return RateLimitRule{}, fmt.Errorf("rule %q: unknown key %q", spec, key)
+	}
+
+	rateStr, periodStr, ok := strings.Cut(limit, "/")
+	if !ok {
+		return RateLimitRule{}, fmt.Errorf("rule %q: expected <rate>/<period>", spec)
+	}
+
+	rate, err := strconv.Atoi(rateStr)
+	if err != nil || rate < 1 {
+		return RateLimitRule{}, fmt.Errorf("rule %q: rate must be a positive integer", spec)
+	}
+
+	period, err := time.ParseDuration(periodStr)
+	if err != nil || period <= 0 {
+		return RateLimitRule{}, fmt.Errorf("rule %q: period must be a positive duration", spec)
+	}
+
+	return RateLimitRule{Key: key, Rate: rate, Period: period}, nil
+}
+
+// ParseCIDRs accepts CIDRs or bare addresses, which are treated as single
+// hosts.
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, v := range values {
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", v)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", v)
+		}
+		nets = append(nets, network)
+	}
+	return nets, nil
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+)
+
+// Watch calls reload whenever the process receives SIGHUP and, when path is
+// set and interval is positive, whenever the file's size or modification time
+// changes. The file is polled rather than watched so that replaced files and
+// symlink swaps (as used by Kubernetes ConfigMaps) are noticed. Watch returns
+// when ctx is done.
+func Watch(ctx context.Context, path string, interval time.Duration, reload func()) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if path != "" && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	last := fileVersion(path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			last = fileVersion(path)
+			reload()
+		case <-tick:
+			if current := fileVersion(path); current != last {
+				last = current
+				reload()
+			}
+		}
+	}
+}
+
+type version struct {
+	size    int64
+	modTime time.Time
+}
+
+func fileVersion(path string) version {
+	if path == "" {
+		return version{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return version{}
+	}
+	return version{size: info.Size(), modTime: info.ModTime()}
+}
+
+// ApplyReloadable copies the settings that can change without a restart from
+// next into c: the log level, rate limits, CORS origins, token lifetimes and
+// session limits.
+func (c *Config) ApplyReloadable(next *Config) {
+	c.Logger.Level = next.Logger.Level
+	c.Server.RateLimit = next.Server.RateLimit
+	c.Server.AllowedOrigins = next.Server.AllowedOrigins
+	c.RateLimit.Burst = next.RateLimit.Burst
+	c.RateLimit.Policies = next.RateLimit.Policies
+	c.RateLimit.Allowlist = next.RateLimit.Allowlist
+	c.JWT.AccessTokenExpiry = next.JWT.AccessTokenExpiry
+	c.JWT.RefreshTokenExpiry = next.JWT.RefreshTokenExpiry
+	c.Session = next.Session
+}
+
+// RestartRequired lists the sections in which next differs from c in
+// settings that ApplyReloadable does not cover.
+func (c *Config) RestartRequired(next *Config) []string {
+	candidate := *next
+	candidate.ApplyReloadable(c)
+
+	current := reflect.ValueOf(*c)
+	changed := reflect.ValueOf(candidate)
+	var sections []string
+	for i := 0; i < current.NumField(); i++ {
+		if !reflect.DeepEqual(current.Field(i).Interface(), changed.Field(i).Interface()) {
+			sections = append(sections, current.Type().Field(i).Name)
+		}
+	}
+	return sections
+}
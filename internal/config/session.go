@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// SessionConfig bounds how long a login lasts. Zero disables a limit.
+type SessionConfig struct {
+	IdleTimeout time.Duration // ends a session that has not been used for this long
+	MaxLifetime time.Duration // ends a session this long after login, however often it is refreshed
+}
+
+func (c *SessionConfig) validate(accessTokenExpiry time.Duration) error {
+	if c.IdleTimeout < 0 || c.MaxLifetime < 0 {
+		return fmt.Errorf("SESSION_IDLE_TIMEOUT and SESSION_MAX_LIFETIME must not be negative")
+	}
+	// Services that check access tokens themselves report no activity, so
+	// a client only shows up here when it refreshes.
+	if c.IdleTimeout > 0 && c.IdleTimeout <= accessTokenExpiry {
+		return fmt.Errorf("SESSION_IDLE_TIMEOUT must be longer than JWT_ACCESS_EXPIRY")
+	}
+	if c.MaxLifetime > 0 && c.MaxLifetime < time.Hour {
+		return fmt.Errorf("SESSION_MAX_LIFETIME must be at least 1 hour")
+	}
+	if c.MaxLifetime > 0 && c.IdleTimeout > c.MaxLifetime {
+		return fmt.Errorf("SESSION_IDLE_TIMEOUT must not be longer than SESSION_MAX_LIFETIME")
+	}
+	return nil
+}
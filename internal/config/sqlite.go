@@ -0,0 +1,47 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// NewSQLiteConnection opens the database file at cfg.SQLitePath, creating it
+// and its directory if needed. Writes are serialised through a single
+// connection, so callers never see SQLITE_BUSY from their own process.
+func NewSQLiteConnection(cfg *DatabaseConfig) (*sql.DB, error) {
+	if dir := filepath.Dir(cfg.SQLitePath); dir != "." {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Set("_txlock", "immediate")
+	params.Set("_time_format", "sqlite")
+
+	db, err := sql.Open("sqlite", "file:"+cfg.SQLitePath+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return db, nil
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+
+	"auth-service/pkg/logger"
+)
+
+type ContextKey string
+
+// RequestIDKey is owned by the logger so that log lines carry the request id.
+const RequestIDKey = logger.RequestIDKey
+
+const (
+	IPAddressKey  ContextKey = "ip_address"
+	UserAgentKey  ContextKey = "user_agent"
+	DeviceInfoKey ContextKey = "device_info"
+	ClientIDKey   ContextKey = "client_id"
+	ActorKey      ContextKey = "actor"
+	// AcceptLanguageKey holds the request's Accept-Language header, used to
+	// pick the locale of emails sent on its behalf.
+	AcceptLanguageKey ContextKey = "accept_language"
+)
+
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(RequestIDKey).(string)
+	return requestID
+}
+
+// ActorFromContext returns the impersonating user, or nil if the request is
+// made by the token's subject itself.
+func ActorFromContext(ctx context.Context) *Actor {
+	actor, _ := ctx.Value(ActorKey).(*Actor)
+	return actor
+}
+
+func AcceptLanguageFromContext(ctx context.Context) string {
+	acceptLanguage, _ := ctx.Value(AcceptLanguageKey).(string)
+	return acceptLanguage
+}
+
+func SessionMetadataFromContext(ctx context.Context) *SessionMetadata {
+	metadata := &SessionMetadata{}
+
+	if ipAddr, ok := ctx.Value(IPAddressKey).(string); ok {
+		metadata.IPAddress = ipAddr
+	}
+
+	if userAgent, ok := ctx.Value(UserAgentKey).(string); ok {
+		metadata.UserAgent = userAgent
+	}
+
+	if deviceInfo, ok := ctx.Value(DeviceInfoKey).(string); ok {
+		metadata.DeviceInfo = deviceInfo
+	}
+
+	if clientID, ok := ctx.Value(ClientIDKey).(string); ok {
+		metadata.ClientID = clientID
+	}
+
+	return metadata
+}
@@ -1,27 +1,108 @@
 package domain
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var DefaultTenantID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+type Tenant struct {
+	TenantID  uuid.UUID      `json:"tenant_id" db:"tenant_id"`
+	Slug      string         `json:"slug"`
+	Name      string         `json:"name"`
+	Domain    string         `json:"domain,omitempty"`
+	IsActive  bool           `json:"is_active"`
+	Settings  TenantSettings `json:"settings"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type TenantSettings struct {
+	AccessTokenExpiry   time.Duration `json:"access_token_expiry"` // zero means the global JWT config applies
+	RefreshTokenExpiry  time.Duration `json:"refresh_token_expiry"`
+	RegistrationEnabled bool          `json:"registration_enabled"`
+	AllowedEmailDomains []string      `json:"allowed_email_domains,omitempty"`
+}
+
+func (s *TenantSettings) AllowsEmail(email string) bool {
+	if len(s.AllowedEmailDomains) == 0 {
+		return true
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+
+	emailDomain := strings.ToLower(email[at+1:])
+	for _, allowed := range s.AllowedEmailDomains {
+		if strings.ToLower(allowed) == emailDomain {
+			return true
+		}
+	}
+	return false
+}
+
 type User struct {
 	UserID       uuid.UUID `json:"user_id" db:"user_id"`
+	TenantID     uuid.UUID `json:"tenant_id"`
 	Username     string    `json:"username"`
 	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
+	PasswordHash string    `json:"-"` // empty for accounts that sign in only through linked identities
 	FullName     string    `json:"full_name"`
+	Role         string    `json:"role"`
 	IsActive     bool      `json:"is_active"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+func (u *User) HasPassword() bool {
+	return u.PasswordHash != ""
+}
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type Claims struct {
-	UserID   uuid.UUID `json:"user_id"`
-	Username string    `json:"username"`
-	Email    string    `json:"email"`
-	Type     string    `json:"type"` // "access" or "refresh"
+	UserID    uuid.UUID  `json:"user_id"`
+	TenantID  uuid.UUID  `json:"tenant_id"`
+	Username  string     `json:"username"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role,omitempty"`
+	Type      string     `json:"type"`             // "access", "refresh" or "api_key"
+	Scopes    []string   `json:"scopes,omitempty"` // empty means unrestricted
+	Actor     *Actor     `json:"act,omitempty"`    // set when an admin is impersonating UserID
+	AMR       []string   `json:"amr,omitempty"`    // extra authentication methods, e.g. "otp" after a step-up
+	SessionID *uuid.UUID `json:"sid,omitempty"`    // the login session behind an access token
+}
+
+// Actor is the RFC 8693 "act" claim: the user acting on behalf of the
+// token's subject.
+type Actor struct {
+	UserID    uuid.UUID  `json:"sub"`
+	Username  string     `json:"username,omitempty"`
+	SessionID *uuid.UUID `json:"sid,omitempty"` // the actor's session; the token ends with it
+}
+
+func (c *Claims) IsImpersonated() bool {
+	return c.Actor != nil
+}
+
+func (c *Claims) HasScope(scope string) bool {
+	if len(c.Scopes) == 0 {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
 
 type Session struct {
@@ -37,6 +118,8 @@ type Session struct {
 	UpdatedAt      time.Time  `json:"updated_at"`
 	IsRevoked      bool       `json:"is_revoked"`
 	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	// Lifetime is chosen at login and kept when the session is refreshed.
+	Lifetime TokenLifetime `json:"lifetime"`
 }
 
 func (s *Session) IsExpired() bool {
@@ -47,10 +130,27 @@ func (s *Session) IsValid() bool {
 	return !s.IsExpired() && !s.IsRevoked
 }
 
+// IsIdle reports whether the session has not been used for longer than
+// idleTimeout. A zero timeout never expires a session.
+func (s *Session) IsIdle(idleTimeout time.Duration) bool {
+	return idleTimeout > 0 && time.Since(s.LastActivityAt) > idleTimeout
+}
+
 type SessionMetadata struct {
 	DeviceInfo string `json:"device_info,omitempty"`
 	IPAddress  string `json:"ip_address,omitempty"`
 	UserAgent  string `json:"user_agent,omitempty"`
+	ClientID   string `json:"client_id,omitempty"` // from the X-Client-ID header, not verified
+}
+
+// TokenLifetime is how long the tokens of a session last and the settings
+// that chose it. Zero lifetimes mean the defaults, for sessions started
+// before lifetimes were recorded.
+type TokenLifetime struct {
+	Profile            string        `json:"profile,omitempty"` // JWT profile applied, if any
+	RememberMe         bool          `json:"remember_me"`
+	AccessTokenExpiry  time.Duration `json:"-"`
+	RefreshTokenExpiry time.Duration `json:"-"`
 }
 
 type TokenPair struct {
@@ -61,6 +161,8 @@ type TokenPair struct {
 type LoginRequest struct {
 	Username string `json:"username" validate:"required,username"`
 	Password string `json:"password" validate:"required,min=8"`
+	// RememberMe asks for a refresh token that lasts JWT_REMEMBER_ME_EXPIRY.
+	RememberMe bool `json:"remember_me,omitempty"`
 }
 
 type RegisterRequest struct {
@@ -70,6 +172,11 @@ type RegisterRequest struct {
 	FullName string `json:"full_name" validate:"required,min=2,max=100"`
 }
 
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,password,nefield=CurrentPassword"`
+}
+
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
@@ -79,6 +186,17 @@ type UserResponse struct {
 	Username string    `json:"username"`
 	Email    string    `json:"email"`
 	FullName string    `json:"full_name"`
+	Role     string    `json:"role"`
+}
+
+func NewUserResponse(user *User) *UserResponse {
+	return &UserResponse{
+		UserID:   user.UserID,
+		Username: user.Username,
+		Email:    user.Email,
+		FullName: user.FullName,
+		Role:     user.Role,
+	}
 }
 
 type AuthResponse struct {
@@ -94,3 +212,366 @@ type ValidateTokenResponse struct {
 	Valid  bool    `json:"valid"`
 	Claims *Claims `json:"claims,omitempty"`
 }
+
+// RFC 8693 token exchange identifiers. Impersonation exchanges the caller's
+// own access token (the actor) for one whose subject is the user whose id is
+// given as the subject token.
+const (
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	TokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeUserID        = "urn:auth-service:params:oauth:token-type:user_id"
+)
+
+type TokenExchangeRequest struct {
+	GrantType          string `json:"grant_type" validate:"required,eq=urn:ietf:params:oauth:grant-type:token-exchange"`
+	SubjectToken       string `json:"subject_token" validate:"required,uuid"`
+	SubjectTokenType   string `json:"subject_token_type" validate:"required,eq=urn:auth-service:params:oauth:token-type:user_id"`
+	RequestedTokenType string `json:"requested_token_type,omitempty" validate:"omitempty,eq=urn:ietf:params:oauth:token-type:access_token"`
+	Reason             string `json:"reason,omitempty" validate:"max=500"` // recorded in the audit log
+}
+
+type TokenExchangeResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int    `json:"expires_in"`
+}
+
+const APIKeyPrefix = "ask_"
+
+const (
+	ScopeProfileRead   = "profile:read"
+	ScopeSessionsWrite = "sessions:write"
+	ScopeAPIKeysWrite  = "api_keys:write"
+)
+
+type APIKey struct {
+	KeyID      uuid.UUID  `json:"key_id" db:"key_id"`
+	TenantID   uuid.UUID  `json:"-"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (k *APIKey) IsExpired() bool {
+	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
+}
+
+func (k *APIKey) IsValid() bool {
+	return !k.IsExpired() && k.RevokedAt == nil
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" validate:"required,min=1,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=profile:read sessions:write api_keys:write"`
+	ExpiresInDays int      `json:"expires_in_days,omitempty" validate:"omitempty,min=1,max=3650"`
+}
+
+type CreateAPIKeyResponse struct {
+	APIKey *APIKey `json:"api_key"`
+	Key    string  `json:"key"` // plaintext secret, returned only once
+}
+
+// UserIdentity links a user to an account at an external identity provider.
+// Together with the password, identities are the user's login methods.
+type UserIdentity struct {
+	IdentityID uuid.UUID  `json:"identity_id" db:"identity_id"`
+	TenantID   uuid.UUID  `json:"-"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Provider   string     `json:"provider"`
+	Subject    string     `json:"subject"`
+	Email      string     `json:"email,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// OIDCLoginState is an authorization request sent to an upstream provider,
+// kept until the callback consumes it. StateHash is the SHA-256 of the state
+// handed to the browser. UserID is set when the request links an identity to
+// that user instead of logging in.
+type OIDCLoginState struct {
+	StateHash    string
+	TenantID     uuid.UUID
+	UserID       *uuid.UUID
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+type OIDCProvider struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+type OIDCAuthorizeResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required,max=2048"`
+	State string `json:"state" validate:"required,max=128"`
+}
+
+// LoginMethods lists the ways a user can sign in. At least one is always
+// kept.
+type LoginMethods struct {
+	Password   bool            `json:"password"`
+	Identities []*UserIdentity `json:"identities"`
+}
+
+type SetPasswordRequest struct {
+	Password string `json:"password" validate:"required,password"`
+}
+
+// IdentityProviderPassword names the password login method in audit events
+// and identity routes; no OIDC provider may use it.
+const IdentityProviderPassword = "password"
+
+// MagicLink is an emailed login link waiting to be used. NonceHash is the
+// SHA-256 of the nonce handed to the device that requested it; the link only
+// works together with that nonce.
+type MagicLink struct {
+	LinkID    uuid.UUID
+	TenantID  uuid.UUID
+	UserID    uuid.UUID
+	NonceHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// MagicLinkResponse is returned whether or not a link was sent. The client
+// keeps the nonce and presents it with the token from the link.
+type MagicLinkResponse struct {
+	Message   string `json:"message"`
+	Nonce     string `json:"nonce"`
+	ExpiresIn int    `json:"expires_in"` // seconds
+}
+
+type MagicLinkConsumeRequest struct {
+	Token string `json:"token" validate:"required,max=2048"`
+	Nonce string `json:"nonce" validate:"required,max=128"`
+}
+
+// One-time code purposes. A code only verifies for the purpose it was sent
+// for.
+const (
+	OTPPurposeLogin  = "login"
+	OTPPurposeStepUp = "step_up"
+)
+
+// OTPCode is a one-time code sent by email and not yet verified. CodeHash is
+// an HMAC of the code; Attempts counts verifications tried so far.
+type OTPCode struct {
+	OTPID     uuid.UUID
+	TenantID  uuid.UUID
+	UserID    uuid.UUID
+	Purpose   string
+	CodeHash  string
+	Attempts  int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type OTPRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// OTPChallenge identifies a sent code. Login challenges are returned for
+// unknown emails too.
+type OTPChallenge struct {
+	Message     string    `json:"message"`
+	ChallengeID uuid.UUID `json:"challenge_id"`
+	ExpiresIn   int       `json:"expires_in"` // seconds
+}
+
+type OTPVerifyRequest struct {
+	ChallengeID uuid.UUID `json:"challenge_id" validate:"required"`
+	Code        string    `json:"code" validate:"required,len=6,numeric"`
+}
+
+// StepUpResponse carries an access token that records the extra factor in
+// its amr claim. No refresh token is issued with it.
+type StepUpResponse struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// KnownDevice is a device and network a user has logged in from. Fingerprint
+// is a hash of the user agent without version numbers, so that updates do not
+// make a new device, and Network is the client address truncated to its /24
+// (IPv4) or /48 (IPv6).
+type KnownDevice struct {
+	DeviceID    uuid.UUID
+	TenantID    uuid.UUID
+	UserID      uuid.UUID
+	Fingerprint string
+	Network     string
+	DeviceInfo  string
+	IPAddress   string
+	Location    string
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
+
+// LoginReportRequest reports a login from a new device as not made by the
+// user, with the token from the alert email. NewPassword replaces the
+// password and is required when the account has one.
+type LoginReportRequest struct {
+	Token       string `json:"token" validate:"required,max=2048"`
+	NewPassword string `json:"new_password,omitempty" validate:"omitempty,password"`
+}
+
+const (
+	AuditEventRegister       = "user.register"
+	AuditEventLogin          = "user.login"
+	AuditEventLogout         = "user.logout"
+	AuditEventTokenRefresh   = "token.refresh"
+	AuditEventTokenValidate  = "token.validate"
+	AuditEventPasswordChange = "user.password_change"
+	AuditEventDeactivate     = "user.deactivate"
+	AuditEventCreate         = "user.create"
+	AuditEventSessionsRevoke = "user.sessions_revoke"
+	AuditEventImpersonate    = "user.impersonate"
+	AuditEventIdentityLink   = "user.identity_link"
+	AuditEventIdentityUnlink = "user.identity_unlink"
+	AuditEventStepUp         = "user.step_up"
+	AuditEventLoginReported  = "user.login_reported"
+
+	AuditResultSuccess = "success"
+	AuditResultFailure = "failure"
+)
+
+type AuditEvent struct {
+	EventID      uuid.UUID         `json:"event_id" db:"event_id"`
+	TenantID     uuid.UUID         `json:"tenant_id"`
+	EventType    string            `json:"event_type"`
+	Result       string            `json:"result"`
+	Reason       string            `json:"reason,omitempty"`
+	ActorUserID  *uuid.UUID        `json:"actor_user_id,omitempty"`
+	TargetUserID *uuid.UUID        `json:"target_user_id,omitempty"`
+	IPAddress    string            `json:"ip_address,omitempty"`
+	UserAgent    string            `json:"user_agent,omitempty"`
+	RequestID    string            `json:"request_id,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+type AuditEventFilter struct {
+	TenantID   uuid.UUID
+	UserID     *uuid.UUID // matches either actor or target
+	EventTypes []string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+const (
+	WebhookEventUserRegistered  = "user.registered"
+	WebhookEventUserLoggedIn    = "user.logged_in"
+	WebhookEventPasswordChanged = "user.password_changed"
+	WebhookEventUserDeactivated = "user.deactivated"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+type WebhookSubscription struct {
+	SubscriptionID uuid.UUID `json:"subscription_id" db:"subscription_id"`
+	TenantID       uuid.UUID `json:"-"`
+	URL            string    `json:"url"`
+	Secret         string    `json:"-"`
+	EventTypes     []string  `json:"event_types"`
+	Description    string    `json:"description,omitempty"`
+	IsActive       bool      `json:"is_active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (s *WebhookSubscription) Matches(eventType string) bool {
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookEvent struct {
+	EventID   uuid.UUID              `json:"id"`
+	Type      string                 `json:"type"`
+	TenantID  uuid.UUID              `json:"tenant_id"`
+	CreatedAt time.Time              `json:"created_at"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+type WebhookDelivery struct {
+	DeliveryID     uuid.UUID  `json:"delivery_id" db:"delivery_id"`
+	SubscriptionID uuid.UUID  `json:"subscription_id"`
+	TenantID       uuid.UUID  `json:"-"`
+	EventID        uuid.UUID  `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        []byte     `json:"-"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+
+	// Populated when a delivery is claimed for sending.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" validate:"required,url,max=2048"`
+	EventTypes  []string `json:"event_types" validate:"required,min=1,dive,oneof=user.registered user.logged_in user.password_changed user.deactivated"`
+	Description string   `json:"description,omitempty" validate:"max=255"`
+}
+
+type CreateWebhookResponse struct {
+	Subscription *WebhookSubscription `json:"subscription"`
+	Secret       string               `json:"secret"` // signing secret, returned only once
+}
+
+const (
+	NotificationQueued = "queued"
+	NotificationSent   = "sent"
+	NotificationFailed = "failed"
+)
+
+// Notification records an outgoing email. The body is not kept.
+type Notification struct {
+	NotificationID uuid.UUID  `json:"notification_id" db:"notification_id"`
+	TenantID       uuid.UUID  `json:"-"`
+	UserID         *uuid.UUID `json:"user_id,omitempty"`
+	Template       string     `json:"template"`
+	Locale         string     `json:"locale"`
+	Recipient      string     `json:"recipient"`
+	Subject        string     `json:"subject"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+}
@@ -0,0 +1,54 @@
+// Package geoip resolves client addresses to approximate locations with a
+// MaxMind City database.
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// Locator looks up addresses in an open database. It is safe for concurrent
+// use.
+type Locator struct {
+	db *geoip2.Reader
+}
+
+// Open loads the GeoIP2 or GeoLite2 City database at path.
+func Open(path string) (*Locator, error) {
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	return &Locator{db: db}, nil
+}
+
+// Locate returns the city and country of ip in English, such as
+// "Berlin, Germany", or only the country when the city is unknown. It
+// returns "" for addresses the database does not cover, such as private
+// ones.
+func (l *Locator) Locate(ip string) string {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return ""
+	}
+	record, err := l.db.City(addr)
+	if err != nil {
+		return ""
+	}
+
+	var parts []string
+	if city := record.City.Names["en"]; city != "" {
+		parts = append(parts, city)
+	}
+	if country := record.Country.Names["en"]; country != "" {
+		parts = append(parts, country)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (l *Locator) Close() error {
+	return l.db.Close()
+}
@@ -0,0 +1,56 @@
+package grpcapi
+
+import (
+	apperrors "auth-service/pkg/errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain qualifies the ErrorInfo reason attached to every error status.
+const errorDomain = "auth-service"
+
+var grpcCodes = map[apperrors.ErrorCode]codes.Code{
+	apperrors.ErrCodeUnauthorized:       codes.Unauthenticated,
+	apperrors.ErrCodeInvalidCredentials: codes.Unauthenticated,
+	apperrors.ErrCodeTokenExpired:       codes.Unauthenticated,
+	apperrors.ErrCodeTokenInvalid:       codes.Unauthenticated,
+	apperrors.ErrCodeTokenMissing:       codes.Unauthenticated,
+	apperrors.ErrCodeForbidden:          codes.PermissionDenied,
+	apperrors.ErrCodeValidationFailed:   codes.InvalidArgument,
+	apperrors.ErrCodeInvalidInput:       codes.InvalidArgument,
+	apperrors.ErrCodeNotFound:           codes.NotFound,
+	apperrors.ErrCodeAlreadyExists:      codes.AlreadyExists,
+	apperrors.ErrCodeInternal:           codes.Internal,
+	apperrors.ErrCodeServiceUnavailable: codes.Unavailable,
+	apperrors.ErrCodeRateLimitExceeded:  codes.ResourceExhausted,
+}
+
+// Code maps an application error code to the gRPC status code with the same
+// meaning. Unknown codes map to Internal.
+func Code(code apperrors.ErrorCode) codes.Code {
+	if c, ok := grpcCodes[code]; ok {
+		return c
+	}
+	return codes.Internal
+}
+
+// statusError converts an AppError to a gRPC status error carrying the
+// application code as an ErrorInfo reason, so clients can branch on the same
+// codes HTTP clients see. details are attached after the ErrorInfo.
+func statusError(appErr *apperrors.AppError, details ...protoadapt.MessageV1) error {
+	st := status.New(Code(appErr.Code), appErr.Message)
+
+	info := &errdetails.ErrorInfo{
+		Reason:   string(appErr.Code),
+		Domain:   errorDomain,
+		Metadata: appErr.Details,
+	}
+	if detailed, err := st.WithDetails(append([]protoadapt.MessageV1{info}, details...)...); err == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
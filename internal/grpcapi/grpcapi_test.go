@@ -0,0 +1,218 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/middleware"
+	"auth-service/internal/service"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+	authv1 "auth-service/proto/auth/v1"
+
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testAccessSecret = "test-access-secret-0123456789abcdef"
+
+// stubAuthServer fails Login with err, or panics, the way AuthServer
+// reports service errors, and answers GetMe from the caller's claims.
+type stubAuthServer struct {
+	authv1.UnimplementedAuthServiceServer
+
+	server *AuthServer
+	err    error
+	panics bool
+}
+
+func (s *stubAuthServer) Login(ctx context.Context, in *authv1.LoginRequest) (*authv1.LoginResponse, error) {
+	if s.panics {
+		panic("nil map write at 10.0.0.3")
+	}
+	return nil, s.server.fail(ctx, s.err, "login failed")
+}
+
+func (s *stubAuthServer) GetMe(ctx context.Context, _ *authv1.GetMeRequest) (*authv1.GetMeResponse, error) {
+	claims := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	return &authv1.GetMeResponse{User: &authv1.User{UserId: claims.UserID.String(), Username: claims.Username}}, nil
+}
+
+type stubTenantResolver struct{}
+
+func (stubTenantResolver) Resolve(ctx context.Context, identifier, host string) (*domain.Tenant, error) {
+	if identifier == "" || identifier == "default" {
+		return &domain.Tenant{TenantID: domain.DefaultTenantID, Slug: "default"}, nil
+	}
+	return &domain.Tenant{TenantID: uuid.New(), Slug: identifier}, nil
+}
+
+// stubAPIKeys accepts any key, granting only sessions:write.
+type stubAPIKeys struct{}
+
+func (stubAPIKeys) AuthenticateAPIKey(ctx context.Context, rawKey string) (*domain.Claims, error) {
+	return &domain.Claims{UserID: uuid.New(), TenantID: domain.DefaultTenantID, Type: "api_key", Scopes: []string{domain.ScopeSessionsWrite}}, nil
+}
+
+type stubSessions struct{}
+
+func (stubSessions) TouchSession(ctx context.Context, claims *domain.Claims) error {
+	return nil
+}
+
+// newTestClient serves stub over bufconn behind the production interceptors
+// and returns a client for it.
+func newTestClient(t *testing.T, stub *stubAuthServer) authv1.AuthServiceClient {
+	t.Helper()
+	log := logger.New("error", "json", "")
+	stub.server = NewAuthServer(nil, log)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		RequestID(),
+		Logging(log),
+		Recovery(log),
+		Tenant(log, stubTenantResolver{}, "x-tenant-id"),
+		Auth(log, []string{testAccessSecret}, stubAPIKeys{}, stubSessions{}),
+	))
+	authv1.RegisterAuthServiceServer(server, stub)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial bufconn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return authv1.NewAuthServiceClient(conn)
+}
+
+func accessToken(t *testing.T) string {
+	t.Helper()
+	jwtService := service.NewJWTService(&config.JWTConfig{
+		AccessTokenSecret:  testAccessSecret,
+		RefreshTokenSecret: strings.Repeat("r", 32),
+		AccessTokenExpiry:  15 * time.Minute,
+		RefreshTokenExpiry: 24 * time.Hour,
+		Issuer:             "auth-service-test",
+	})
+	user := &domain.User{UserID: uuid.New(), TenantID: domain.DefaultTenantID, Username: "alice", Role: domain.RoleUser}
+	pair, _, err := jwtService.GenerateTokenPair(user, uuid.New(), jwtService.Lifetime(nil, user.Role, "", false), time.Time{})
+	if err != nil {
+		t.Fatalf("GenerateTokenPair: %v", err)
+	}
+	return pair.AccessToken
+}
+
+func reason(st *status.Status) string {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   codes.Code
+		wantReason apperrors.ErrorCode
+		wantMsg    string
+	}{
+		{"unauthorized", apperrors.Unauthorized("invalid credentials"), codes.Unauthenticated, apperrors.ErrCodeUnauthorized, "invalid credentials"},
+		{"not found", apperrors.NotFound("user"), codes.NotFound, apperrors.ErrCodeNotFound, ""},
+		{"forbidden", apperrors.Forbidden("account is locked"), codes.PermissionDenied, apperrors.ErrCodeForbidden, "account is locked"},
+		{"rate limited", apperrors.RateLimitExceeded(), codes.ResourceExhausted, apperrors.ErrCodeRateLimitExceeded, ""},
+		{"unclassified", errors.New("dial tcp 10.0.0.3:5432: connection refused"), codes.Internal, apperrors.ErrCodeInternal, "login failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, &stubAuthServer{err: tt.err})
+
+			_, err := client.Login(context.Background(), &authv1.LoginRequest{Username: "alice", Password: "x"})
+			st := status.Convert(err)
+			if st.Code() != tt.wantCode || reason(st) != string(tt.wantReason) {
+				t.Fatalf("Login = %s (%s), want %s (%s)", st.Code(), reason(st), tt.wantCode, tt.wantReason)
+			}
+			if tt.wantMsg != "" && st.Message() != tt.wantMsg {
+				t.Fatalf("message = %q, want %q", st.Message(), tt.wantMsg)
+			}
+			if strings.Contains(st.Message(), "10.0.0.3") {
+				t.Fatalf("message %q leaks the internal error", st.Message())
+			}
+		})
+	}
+}
+
+func TestCodeDefaultsToInternal(t *testing.T) {
+	if got := Code("SOMETHING_NEW"); got != codes.Internal {
+		t.Fatalf("Code of an unknown error = %s, want Internal", got)
+	}
+}
+
+func TestRecoveryHidesPanics(t *testing.T) {
+	client := newTestClient(t, &stubAuthServer{panics: true})
+
+	_, err := client.Login(context.Background(), &authv1.LoginRequest{Username: "alice", Password: "x"})
+	st := status.Convert(err)
+	if st.Code() != codes.Internal || st.Message() != "internal server error" {
+		t.Fatalf("Login = %s %q, want a bare Internal error", st.Code(), st.Message())
+	}
+
+	// The server keeps serving after the panic.
+	if _, err := client.Login(context.Background(), &authv1.LoginRequest{}); status.Code(err) != codes.Internal {
+		t.Fatalf("second Login = %v", err)
+	}
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	token := accessToken(t)
+
+	tests := []struct {
+		name     string
+		metadata []string
+		wantCode codes.Code
+	}{
+		{"no credentials", nil, codes.Unauthenticated},
+		{"not bearer", []string{"authorization", "Basic " + token}, codes.Unauthenticated},
+		{"invalid token", []string{"authorization", "Bearer not-a-token"}, codes.Unauthenticated},
+		{"other tenant", []string{"authorization", "Bearer " + token, "x-tenant-id", "acme"}, codes.Unauthenticated},
+		{"missing scope", []string{"x-api-key", domain.APIKeyPrefix + "test"}, codes.PermissionDenied},
+		{"access token", []string{"authorization", "Bearer " + token}, codes.OK},
+	}
+	client := newTestClient(t, &stubAuthServer{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.metadata != nil {
+				ctx = metadata.AppendToOutgoingContext(ctx, tt.metadata...)
+			}
+			resp, err := client.GetMe(ctx, &authv1.GetMeRequest{})
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("GetMe = %v, want %s", err, tt.wantCode)
+			}
+			if tt.wantCode == codes.OK && resp.GetUser().GetUsername() != "alice" {
+				t.Fatalf("GetMe answered for %q, want alice", resp.GetUser().GetUsername())
+			}
+		})
+	}
+}
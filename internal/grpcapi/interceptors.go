@@ -0,0 +1,330 @@
+package grpcapi
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/metrics"
+	"auth-service/internal/middleware"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+	authv1 "auth-service/proto/auth/v1"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// rateLimitRoutes charges RPCs to the policy, and so the buckets, of the HTTP
+// route with the same behaviour, so switching transport does not reset a
+// client's allowance. Other RPCs get the default policy.
+var rateLimitRoutes = map[string]string{
+	authv1.AuthService_Register_FullMethodName:      "POST /api/v1/auth/register",
+	authv1.AuthService_Login_FullMethodName:         "POST /api/v1/auth/login",
+	authv1.AuthService_RefreshToken_FullMethodName:  "POST /api/v1/auth/refresh",
+	authv1.AuthService_ValidateToken_FullMethodName: "POST /api/v1/auth/validate",
+}
+
+// methodScopes lists the RPCs that require a caller and the scope each needs.
+var methodScopes = map[string]string{
+	authv1.AuthService_Logout_FullMethodName: domain.ScopeSessionsWrite,
+	authv1.AuthService_GetMe_FullMethodName:  domain.ScopeProfileRead,
+}
+
+// refuseImpersonation lists the RPCs whose effects outlive an impersonation.
+var refuseImpersonation = map[string]bool{
+	authv1.AuthService_Logout_FullMethodName: true,
+}
+
+type callInfoKey struct{}
+
+// callInfo is filled in by inner interceptors and handlers for Logging, which
+// runs outside them.
+type callInfo struct {
+	userID interface{}
+}
+
+func setUserID(ctx context.Context, userID interface{}) {
+	if info, ok := ctx.Value(callInfoKey{}).(*callInfo); ok {
+		info.userID = userID
+	}
+}
+
+// RequestID propagates an incoming x-request-id, or generates one, returns it
+// in the response header and tags the active span with it.
+func RequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		requestID := firstValue(ctx, "x-request-id")
+		if !middleware.ValidRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("request.id", requestID))
+
+		_ = grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestID))
+		ctx = context.WithValue(ctx, middleware.RequestIDKey, requestID)
+		return handler(ctx, req)
+	}
+}
+
+// Logging logs each call and records it in the gRPC request metrics.
+func Logging(log *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		call := &callInfo{}
+
+		resp, err := handler(context.WithValue(ctx, callInfoKey{}, call), req)
+
+		duration := time.Since(start)
+		code := status.Code(err).String()
+		metrics.ObserveGRPCRequest(info.FullMethod, code, duration)
+
+		log.WithContext(ctx).GRPCRequest(
+			info.FullMethod,
+			code,
+			duration.Milliseconds(),
+			peerAddr(ctx),
+			firstValue(ctx, "user-agent"),
+			call.userID,
+		)
+
+		return resp, err
+	}
+}
+
+func Recovery(log *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.WithContext(ctx).WithFields(map[string]interface{}{
+					"error": r,
+					"stack": string(debug.Stack()),
+				}).Error("panic recovered")
+
+				resp, err = nil, statusError(apperrors.Internal("internal server error"))
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// ClientIP resolves the client address from the peer and any forwarding
+// metadata, under the same trust rules as the HTTP middleware.
+func ClientIP(log *logger.Logger, resolver *middleware.ClientIPResolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		remoteAddr := peerAddr(ctx)
+		header := http.Header{}
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			for _, key := range []string{"Forwarded", "X-Forwarded-For", "X-Real-IP"} {
+				for _, value := range md.Get(key) {
+					header.Add(key, value)
+				}
+			}
+		}
+
+		ip, suspicious := resolver.ResolveAddr(remoteAddr, header)
+		if suspicious != "" {
+			log.WithContext(ctx).WithFields(map[string]interface{}{
+				"remote_addr":       remoteAddr,
+				"client_ip":         ip,
+				"forwarded":         header.Get("Forwarded"),
+				"x_forwarded_for":   header.Get("X-Forwarded-For"),
+				"x_real_ip":         header.Get("X-Real-IP"),
+				"spoofing_evidence": suspicious,
+			}).Warn("ignored untrusted client IP headers")
+		}
+
+		ctx = context.WithValue(ctx, middleware.ClientIPKey, ip)
+		return handler(ctx, req)
+	}
+}
+
+// SessionMetadata records the client address, user agent and client ID for
+// sessions.
+// It must run inside ClientIP.
+func SessionMetadata() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = middleware.WithSessionMetadata(ctx, clientIP(ctx), firstValue(ctx, "user-agent"), firstValue(ctx, strings.ToLower(middleware.ClientIDHeader)))
+		return handler(ctx, req)
+	}
+}
+
+// Tenant resolves the tenant from the metadata key named header, falling
+// back to the :authority host.
+func Tenant(log *logger.Logger, resolver middleware.TenantResolver, header string) grpc.UnaryServerInterceptor {
+	header = strings.ToLower(header)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		tenant, err := resolver.Resolve(ctx, firstValue(ctx, header), firstValue(ctx, ":authority"))
+		if err != nil {
+			appErr, ok := err.(*apperrors.AppError)
+			if !ok {
+				appErr = apperrors.Internal("failed to resolve tenant")
+			}
+			log.WithContext(ctx).WithError(err).Warn("tenant resolution failed")
+			return nil, statusError(appErr)
+		}
+
+		ctx = context.WithValue(ctx, middleware.TenantKey, tenant)
+		return handler(ctx, req)
+	}
+}
+
+// RateLimit enforces limiter's policies, reporting the remaining allowance
+// in ratelimit-* response headers. As over HTTP, calls are let through if the
+// store fails.
+func RateLimit(log *logger.Logger, limiter *middleware.RateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ip := clientIP(ctx)
+
+		decision, err := limiter.Allow(ctx, middleware.RateLimitRequest{
+			Route:      rateLimitRoutes[info.FullMethod],
+			IP:         ip,
+			Credential: credential(ctx),
+			Username: func() string {
+				if r, ok := req.(interface{ GetUsername() string }); ok {
+					return r.GetUsername()
+				}
+				return ""
+			},
+		})
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Error("rate limit store unavailable")
+			return handler(ctx, req)
+		}
+		if decision == nil {
+			return handler(ctx, req)
+		}
+
+		result := decision.Result
+		_ = grpc.SetHeader(ctx, metadata.Pairs(
+			"ratelimit-limit", strconv.Itoa(result.Limit),
+			"ratelimit-remaining", strconv.Itoa(result.Remaining),
+			"ratelimit-reset", strconv.Itoa(int(math.Ceil(result.ResetAfter.Seconds()))),
+		))
+
+		if !result.Allowed {
+			log.WithContext(ctx).WithFields(map[string]interface{}{
+				"ip":     ip,
+				"policy": decision.Policy,
+				"key":    decision.Rule.Key,
+			}).Warn("rate limit exceeded")
+			return nil, statusError(apperrors.RateLimitExceeded(), &errdetails.RetryInfo{
+				RetryDelay: durationpb.New(result.RetryAfter),
+			})
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// Auth authenticates callers of the RPCs in methodScopes with an access token
+// or API key and checks the scope the RPC needs. Other RPCs are passed
+// through untouched.
+func Auth(log *logger.Logger, jwtSecrets []string, apiKeys middleware.APIKeyAuthenticator, sessions middleware.SessionTracker) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		scope, ok := methodScopes[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		tokenString := firstValue(ctx, "x-api-key")
+		if tokenString == "" {
+			authorization := firstValue(ctx, "authorization")
+			if authorization == "" {
+				log.WithContext(ctx).Warn("missing authorization header")
+				return nil, statusError(apperrors.Unauthorized("missing authorization header"))
+			}
+
+			scheme, token, ok := strings.Cut(authorization, " ")
+			if !ok || !strings.EqualFold(scheme, "bearer") || token == "" {
+				log.WithContext(ctx).Warn("invalid authorization header format")
+				return nil, statusError(apperrors.Unauthorized("invalid authorization header format"))
+			}
+			tokenString = token
+		}
+
+		claims, appErr := middleware.Authenticate(ctx, tokenString, jwtSecrets, apiKeys, sessions)
+		if appErr != nil {
+			log.WithContext(ctx).Warn(appErr.Message)
+			return nil, statusError(appErr)
+		}
+
+		if tenant, ok := middleware.GetTenant(ctx); ok && tenant.TenantID != claims.TenantID {
+			log.WithContext(ctx).WithField("token_tenant_id", claims.TenantID).Warn("token tenant does not match request tenant")
+			return nil, statusError(apperrors.Unauthorized("invalid or expired token"))
+		}
+
+		if !claims.HasScope(scope) {
+			log.WithContext(ctx).WithField("scope", scope).Warn("missing required scope")
+			return nil, statusError(apperrors.Forbidden("insufficient scope"))
+		}
+
+		if claims.IsImpersonated() && refuseImpersonation[info.FullMethod] {
+			log.WithContext(ctx).WithField("actor_id", claims.Actor.UserID).Warn("impersonated request refused")
+			return nil, statusError(apperrors.Forbidden("not allowed while impersonating"))
+		}
+
+		ctx = middleware.WithClaims(ctx, claims)
+		setUserID(ctx, claims.UserID)
+
+		return handler(ctx, req)
+	}
+}
+
+func firstValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// credential returns the API key or bearer token sent with the call, if any.
+func credential(ctx context.Context) string {
+	if apiKey := firstValue(ctx, "x-api-key"); apiKey != "" {
+		return apiKey
+	}
+
+	scheme, token, ok := strings.Cut(firstValue(ctx, "authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return ""
+	}
+	return token
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// clientIP returns the address resolved by ClientIP, or the direct peer's
+// host if that interceptor did not run.
+func clientIP(ctx context.Context) string {
+	if ip, ok := ctx.Value(middleware.ClientIPKey).(string); ok && ip != "" {
+		return ip
+	}
+	addr := peerAddr(ctx)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,227 @@
+// Package grpcapi serves AuthService over gRPC, backed by the same service
+// layer as the HTTP API.
+package grpcapi
+
+import (
+	"context"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/middleware"
+	"auth-service/internal/service"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+	"auth-service/pkg/validator"
+	authv1 "auth-service/proto/auth/v1"
+)
+
+type AuthServer struct {
+	authv1.UnimplementedAuthServiceServer
+
+	authService *service.AuthService
+	logger      *logger.Logger
+}
+
+func NewAuthServer(authService *service.AuthService, log *logger.Logger) *AuthServer {
+	return &AuthServer{
+		authService: authService,
+		logger:      log,
+	}
+}
+
+func (s *AuthServer) Register(ctx context.Context, in *authv1.RegisterRequest) (*authv1.RegisterResponse, error) {
+	log := s.logger.WithContext(ctx)
+
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		return nil, statusError(apperrors.Internal("tenant not resolved"))
+	}
+
+	req := domain.RegisterRequest{
+		Username: in.GetUsername(),
+		Email:    in.GetEmail(),
+		Password: in.GetPassword(),
+		FullName: in.GetFullName(),
+	}
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("registration validation failed")
+		return nil, statusError(apperrors.ValidationFailed(err.Error()))
+	}
+
+	response, err := s.authService.Register(ctx, tenant, &req)
+	if err != nil {
+		return nil, s.fail(ctx, err, "registration failed")
+	}
+
+	setUserID(ctx, response.User.UserID)
+
+	return &authv1.RegisterResponse{
+		User:   toUser(response.User),
+		Tokens: toTokenPair(response.Tokens),
+	}, nil
+}
+
+func (s *AuthServer) Login(ctx context.Context, in *authv1.LoginRequest) (*authv1.LoginResponse, error) {
+	log := s.logger.WithContext(ctx)
+
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		return nil, statusError(apperrors.Internal("tenant not resolved"))
+	}
+
+	req := domain.LoginRequest{
+		Username:   in.GetUsername(),
+		Password:   in.GetPassword(),
+		RememberMe: in.GetRememberMe(),
+	}
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("login validation failed")
+		return nil, statusError(apperrors.ValidationFailed(err.Error()))
+	}
+
+	response, err := s.authService.Login(ctx, tenant, &req)
+	if err != nil {
+		return nil, s.fail(ctx, err, "login failed")
+	}
+
+	setUserID(ctx, response.User.UserID)
+
+	return &authv1.LoginResponse{
+		User:   toUser(response.User),
+		Tokens: toTokenPair(response.Tokens),
+	}, nil
+}
+
+func (s *AuthServer) RefreshToken(ctx context.Context, in *authv1.RefreshTokenRequest) (*authv1.RefreshTokenResponse, error) {
+	log := s.logger.WithContext(ctx)
+
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		return nil, statusError(apperrors.Internal("tenant not resolved"))
+	}
+
+	req := domain.RefreshTokenRequest{RefreshToken: in.GetRefreshToken()}
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("refresh token validation failed")
+		return nil, statusError(apperrors.ValidationFailed(err.Error()))
+	}
+
+	tokens, err := s.authService.RefreshToken(ctx, tenant, req.RefreshToken)
+	if err != nil {
+		return nil, s.fail(ctx, err, "token refresh failed")
+	}
+
+	return &authv1.RefreshTokenResponse{Tokens: toTokenPair(tokens)}, nil
+}
+
+func (s *AuthServer) ValidateToken(ctx context.Context, in *authv1.ValidateTokenRequest) (*authv1.ValidateTokenResponse, error) {
+	log := s.logger.WithContext(ctx)
+
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		return nil, statusError(apperrors.Internal("tenant not resolved"))
+	}
+
+	req := domain.ValidateTokenRequest{Token: in.GetToken()}
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("validate token request validation failed")
+		return nil, statusError(apperrors.ValidationFailed(err.Error()))
+	}
+
+	claims, err := s.authService.ValidateToken(ctx, tenant, req.Token)
+	if err != nil {
+		return &authv1.ValidateTokenResponse{Valid: false}, nil
+	}
+
+	return &authv1.ValidateTokenResponse{Valid: true, Claims: toClaims(claims)}, nil
+}
+
+func (s *AuthServer) Logout(ctx context.Context, _ *authv1.LogoutRequest) (*authv1.LogoutResponse, error) {
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		s.logger.WithContext(ctx).Error("failed to get claims from context")
+		return nil, statusError(apperrors.Unauthorized("unauthorized"))
+	}
+
+	if err := s.authService.Logout(ctx, claims.TenantID, claims.UserID); err != nil {
+		return nil, s.fail(ctx, err, "logout failed")
+	}
+
+	return &authv1.LogoutResponse{}, nil
+}
+
+func (s *AuthServer) GetMe(ctx context.Context, _ *authv1.GetMeRequest) (*authv1.GetMeResponse, error) {
+	log := s.logger.WithContext(ctx)
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		return nil, statusError(apperrors.Unauthorized("unauthorized"))
+	}
+
+	user, err := s.authService.GetUserByID(ctx, claims.TenantID, claims.UserID)
+	if err != nil {
+		log.WithError(err).Error("failed to get user data")
+		return nil, statusError(apperrors.Internal("failed to get user data"))
+	}
+
+	return &authv1.GetMeResponse{User: toUser(domain.NewUserResponse(user))}, nil
+}
+
+// fail converts a service error to a status, logging errors the service did
+// not classify.
+func (s *AuthServer) fail(ctx context.Context, err error, message string) error {
+	if appErr, ok := err.(*apperrors.AppError); ok {
+		return statusError(appErr)
+	}
+	s.logger.WithContext(ctx).WithError(err).Error(message)
+	return statusError(apperrors.Internal(message))
+}
+
+func toUser(user *domain.UserResponse) *authv1.User {
+	return &authv1.User{
+		UserId:   user.UserID.String(),
+		Username: user.Username,
+		Email:    user.Email,
+		FullName: user.FullName,
+		Role:     user.Role,
+	}
+}
+
+func toTokenPair(tokens *domain.TokenPair) *authv1.TokenPair {
+	return &authv1.TokenPair{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}
+}
+
+func toClaims(claims *domain.Claims) *authv1.Claims {
+	var actor *authv1.Actor
+	if claims.Actor != nil {
+		actor = &authv1.Actor{
+			UserId:   claims.Actor.UserID.String(),
+			Username: claims.Actor.Username,
+		}
+	}
+
+	var sessionID string
+	if claims.SessionID != nil {
+		sessionID = claims.SessionID.String()
+	}
+
+	return &authv1.Claims{
+		UserId:   claims.UserID.String(),
+		TenantId: claims.TenantID.String(),
+		Username: claims.Username,
+		Email:    claims.Email,
+		Role:     claims.Role,
+		Type:     claims.Type,
+		Scopes:   claims.Scopes,
+		Act:      actor,
+		Amr:      claims.AMR,
+		Sid:      sessionID,
+	}
+}
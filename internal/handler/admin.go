@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/middleware"
+	"auth-service/internal/service"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+	"auth-service/pkg/validator"
+
+	"github.com/google/uuid"
+)
+
+type AdminHandler struct {
+	authService *service.AuthService
+	logger      *logger.Logger
+}
+
+func NewAdminHandler(authService *service.AuthService, log *logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		authService: authService,
+		logger:      log,
+	}
+}
+
+func (h *AdminHandler) DeactivateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeAppError(w, apperrors.InvalidInput("invalid user id"))
+		return
+	}
+
+	if userID == claims.UserID {
+		writeAppError(w, apperrors.InvalidInput("cannot deactivate your own account"))
+		return
+	}
+
+	if err := h.authService.DeactivateUser(ctx, claims.TenantID, claims.UserID, userID); err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			writeAppError(w, appErr)
+		} else {
+			log.WithError(err).Error("user deactivation failed")
+			writeAppError(w, apperrors.Internal("user deactivation failed"))
+		}
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, map[string]string{"message": "user deactivated"})
+}
+
+// TokenExchange implements the RFC 8693 token exchange used for
+// impersonation: the calling admin is the actor and subject_token names the
+// user to act as. Unlike the rest of the API it answers in the OAuth shape,
+// not JSend, so that token exchange clients can read the response.
+func (h *AdminHandler) TokenExchange(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", "tenant not resolved")
+		return
+	}
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "unauthorized")
+		return
+	}
+
+	// RFC 8693 clients send a form; JSON is accepted as well.
+	var req domain.TokenExchangeRequest
+	if r.Header.Get("Content-Type") == middleware.FormContentType {
+		if err := r.ParseForm(); err != nil {
+			log.WithError(err).Warn("failed to parse token exchange form")
+			writeOAuthError(w, http.StatusBadRequest, "invalid_request", "invalid request body")
+			return
+		}
+		req = domain.TokenExchangeRequest{
+			GrantType:          r.PostForm.Get("grant_type"),
+			SubjectToken:       r.PostForm.Get("subject_token"),
+			SubjectTokenType:   r.PostForm.Get("subject_token_type"),
+			RequestedTokenType: r.PostForm.Get("requested_token_type"),
+			Reason:             r.PostForm.Get("reason"),
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Warn("failed to decode token exchange request")
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "invalid request body")
+		return
+	}
+
+	if req.GrantType != domain.GrantTypeTokenExchange {
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "grant_type must be "+domain.GrantTypeTokenExchange)
+		return
+	}
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("token exchange validation failed")
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	response, err := h.authService.Impersonate(ctx, tenant, claims, &req)
+	if err != nil {
+		appErr, ok := err.(*apperrors.AppError)
+		if !ok {
+			log.WithError(err).Error("token exchange failed")
+			appErr = apperrors.Internal("token exchange failed")
+		}
+		writeTokenExchangeError(w, appErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("failed to encode token exchange response")
+	}
+}
+
+// writeTokenExchangeError maps an impersonation error to the RFC 6749
+// section 5.2 error codes: refusals of the admin's own token are
+// unauthorized_client, and an unknown or protected subject is invalid_grant.
+func writeTokenExchangeError(w http.ResponseWriter, appErr *apperrors.AppError) {
+	switch {
+	case appErr.HTTPStatus >= 500:
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", appErr.Message)
+	case appErr.Code == apperrors.ErrCodeForbidden && appErr.Details["reason"] == "actor_token_not_allowed":
+		writeOAuthError(w, http.StatusBadRequest, "unauthorized_client", appErr.Message)
+	case appErr.Code == apperrors.ErrCodeForbidden || appErr.Code == apperrors.ErrCodeNotFound:
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", appErr.Message)
+	default:
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", appErr.Message)
+	}
+}
+
+func writeOAuthError(w http.ResponseWriter, statusCode int, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(statusCode)
+
+	response := map[string]string{"error": code, "error_description": description}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "failed to encode error response", http.StatusInternalServerError)
+	}
+}
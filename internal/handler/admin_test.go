@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/middleware"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// memoryUserRepository serves GetByID from a map. Methods the tests do not
+// need are left to the embedded nil interface.
+type memoryUserRepository struct {
+	repository.UserRepository
+	users map[uuid.UUID]*domain.User
+}
+
+func (r *memoryUserRepository) GetByID(ctx context.Context, tenantID, userID uuid.UUID) (*domain.User, error) {
+	user, ok := r.users[userID]
+	if !ok || user.TenantID != tenantID {
+		return nil, apperrors.NotFound("user")
+	}
+	copied := *user
+	return &copied, nil
+}
+
+type discardAuditRepository struct{}
+
+func (discardAuditRepository) Create(ctx context.Context, event *domain.AuditEvent) error {
+	return nil
+}
+
+func (discardAuditRepository) List(ctx context.Context, filter *domain.AuditEventFilter) ([]*domain.AuditEvent, error) {
+	return nil, nil
+}
+
+type tokenExchangeTest struct {
+	handler     *AdminHandler
+	tenant      *domain.Tenant
+	adminClaims *domain.Claims
+	userID      uuid.UUID
+	adminID     uuid.UUID
+}
+
+func newTokenExchangeTest(t *testing.T) *tokenExchangeTest {
+	t.Helper()
+	log := logger.New("error", "json", "")
+	tenant := &domain.Tenant{TenantID: domain.DefaultTenantID, Slug: "default"}
+	admin := &domain.User{UserID: uuid.New(), TenantID: tenant.TenantID, Username: "root", Role: domain.RoleAdmin, IsActive: true}
+	user := &domain.User{UserID: uuid.New(), TenantID: tenant.TenantID, Username: "alice", Role: domain.RoleUser, IsActive: true}
+	users := &memoryUserRepository{users: map[uuid.UUID]*domain.User{admin.UserID: admin, user.UserID: user}}
+
+	jwtService := service.NewJWTService(&config.JWTConfig{
+		AccessTokenSecret:   strings.Repeat("a", 32),
+		RefreshTokenSecret:  strings.Repeat("r", 32),
+		AccessTokenExpiry:   15 * time.Minute,
+		RefreshTokenExpiry:  24 * time.Hour,
+		ImpersonationExpiry: 10 * time.Minute,
+		Issuer:              "auth-service-test",
+	})
+	authService := service.NewAuthService(users, nil, nil, nil, jwtService, &config.SessionConfig{}, nil,
+		service.NewAuditService(discardAuditRepository{}, log), nil, log)
+
+	sessionID := uuid.New()
+	return &tokenExchangeTest{
+		handler:     NewAdminHandler(authService, log),
+		tenant:      tenant,
+		adminClaims: &domain.Claims{UserID: admin.UserID, TenantID: tenant.TenantID, Username: admin.Username, Role: domain.RoleAdmin, Type: "access", SessionID: &sessionID},
+		userID:      user.UserID,
+		adminID:     admin.UserID,
+	}
+}
+
+func (tt *tokenExchangeTest) exchange(claims *domain.Claims, contentType string, params url.Values) *httptest.ResponseRecorder {
+	var body string
+	if contentType == middleware.FormContentType {
+		body = params.Encode()
+	} else {
+		fields := make(map[string]string)
+		for name := range params {
+			fields[name] = params.Get(name)
+		}
+		encoded, _ := json.Marshal(fields)
+		body = string(encoded)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/token-exchange", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	ctx := context.WithValue(req.Context(), middleware.TenantKey, tt.tenant)
+	ctx = context.WithValue(ctx, middleware.ClaimsKey, claims)
+	rec := httptest.NewRecorder()
+	tt.handler.TokenExchange(rec, req.WithContext(ctx))
+	return rec
+}
+
+func exchangeParams(subject string) url.Values {
+	return url.Values{
+		"grant_type":         {domain.GrantTypeTokenExchange},
+		"subject_token":      {subject},
+		"subject_token_type": {domain.TokenTypeUserID},
+	}
+}
+
+func TestTokenExchange(t *testing.T) {
+	for _, contentType := range []string{middleware.FormContentType, "application/json"} {
+		t.Run(contentType, func(t *testing.T) {
+			tt := newTokenExchangeTest(t)
+			rec := tt.exchange(tt.adminClaims, contentType, exchangeParams(tt.userID.String()))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+				t.Fatalf("Cache-Control = %q, want no-store", got)
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response: %v", err)
+			}
+			if _, wrapped := body["status"]; wrapped {
+				t.Fatalf("response is wrapped: %s", rec.Body)
+			}
+			if body["access_token"] == "" || body["issued_token_type"] != domain.TokenTypeAccessToken ||
+				body["token_type"] != "Bearer" || body["expires_in"] != float64(600) {
+				t.Fatalf("response = %s", rec.Body)
+			}
+		})
+	}
+}
+
+func TestTokenExchangeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  func(tt *tokenExchangeTest) *domain.Claims
+		params  func(tt *tokenExchangeTest) url.Values
+		wantErr string
+	}{
+		{
+			name: "wrong grant type",
+			params: func(tt *tokenExchangeTest) url.Values {
+				params := exchangeParams(tt.userID.String())
+				params.Set("grant_type", "password")
+				return params
+			},
+			wantErr: "unsupported_grant_type",
+		},
+		{
+			name:    "malformed subject",
+			params:  func(tt *tokenExchangeTest) url.Values { return exchangeParams("alice") },
+			wantErr: "invalid_request",
+		},
+		{
+			name:    "unknown subject",
+			params:  func(tt *tokenExchangeTest) url.Values { return exchangeParams(uuid.NewString()) },
+			wantErr: "invalid_grant",
+		},
+		{
+			name:    "own account",
+			params:  func(tt *tokenExchangeTest) url.Values { return exchangeParams(tt.adminID.String()) },
+			wantErr: "invalid_request",
+		},
+		{
+			name: "api key actor",
+			claims: func(tt *tokenExchangeTest) *domain.Claims {
+				claims := *tt.adminClaims
+				claims.Type, claims.SessionID = "api_key", nil
+				return &claims
+			},
+			params:  func(tt *tokenExchangeTest) url.Values { return exchangeParams(tt.userID.String()) },
+			wantErr: "unauthorized_client",
+		},
+	}
+	for _, test := range tests {
+		for _, contentType := range []string{middleware.FormContentType, "application/json"} {
+			t.Run(test.name+"/"+contentType, func(t *testing.T) {
+				tt := newTokenExchangeTest(t)
+				claims := tt.adminClaims
+				if test.claims != nil {
+					claims = test.claims(tt)
+				}
+				rec := tt.exchange(claims, contentType, test.params(tt))
+
+				if rec.Code != http.StatusBadRequest {
+					t.Fatalf("status %d, want 400: %s", rec.Code, rec.Body)
+				}
+				var body map[string]string
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+					t.Fatalf("response: %v", err)
+				}
+				if body["error"] != test.wantErr || body["error_description"] == "" {
+					t.Fatalf("response = %s, want error %s", rec.Body, test.wantErr)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/middleware"
+	"auth-service/internal/service"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+	"auth-service/pkg/validator"
+
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+	logger        *logger.Logger
+}
+
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService, log *logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		logger:        log,
+	}
+}
+
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req domain.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Warn("failed to decode create api key request")
+		writeAppError(w, apperrors.InvalidInput("invalid request body"))
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("create api key validation failed")
+		writeAppError(w, apperrors.ValidationFailed(err.Error()))
+		return
+	}
+
+	response, err := h.apiKeyService.Create(ctx, claims, &req)
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			writeAppError(w, appErr)
+		} else {
+			log.WithError(err).Error("api key creation failed")
+			writeAppError(w, apperrors.Internal("api key creation failed"))
+		}
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusCreated, response)
+}
+
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return
+	}
+
+	keys, err := h.apiKeyService.List(ctx, claims.UserID)
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			writeAppError(w, appErr)
+		} else {
+			log.WithError(err).Error("failed to list api keys")
+			writeAppError(w, apperrors.Internal("failed to list api keys"))
+		}
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, map[string]interface{}{"api_keys": keys})
+}
+
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return
+	}
+
+	keyID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeAppError(w, apperrors.InvalidInput("invalid api key id"))
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(ctx, claims.UserID, keyID); err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			writeAppError(w, appErr)
+		} else {
+			log.WithError(err).Error("failed to revoke api key")
+			writeAppError(w, apperrors.Internal("failed to revoke api key"))
+		}
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, map[string]string{"message": "api key revoked"})
+}
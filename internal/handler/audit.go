@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/middleware"
+	"auth-service/internal/service"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+type AuditHandler struct {
+	auditService *service.AuditService
+	logger       *logger.Logger
+}
+
+func NewAuditHandler(auditService *service.AuditService, log *logger.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+		logger:       log,
+	}
+}
+
+// ListEvents is the admin query endpoint. Supported query parameters are
+// user_id, type (comma separated), from and to (RFC 3339), limit and offset.
+func (h *AuditHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return
+	}
+
+	filter, appErr := parseAuditFilter(r)
+	if appErr != nil {
+		writeAppError(w, appErr)
+		return
+	}
+	filter.TenantID = claims.TenantID
+
+	events, err := h.auditService.List(ctx, filter)
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			writeAppError(w, appErr)
+		} else {
+			log.WithError(err).Error("failed to list audit events")
+			writeAppError(w, apperrors.Internal("failed to list audit events"))
+		}
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, map[string]interface{}{"events": events})
+}
+
+func (h *AuditHandler) MyActivity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return
+	}
+
+	limit, err := parseIntQuery(r, "limit", 20)
+	if err != nil {
+		writeAppError(w, apperrors.InvalidInput("limit must be an integer"))
+		return
+	}
+
+	events, err := h.auditService.RecentSignIns(ctx, claims.TenantID, claims.UserID, limit)
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			writeAppError(w, appErr)
+		} else {
+			log.WithError(err).Error("failed to list sign-in activity")
+			writeAppError(w, apperrors.Internal("failed to list sign-in activity"))
+		}
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, map[string]interface{}{"events": events})
+}
+
+func parseAuditFilter(r *http.Request) (*domain.AuditEventFilter, *apperrors.AppError) {
+	query := r.URL.Query()
+	filter := &domain.AuditEventFilter{}
+
+	if v := query.Get("user_id"); v != "" {
+		userID, err := uuid.Parse(v)
+		if err != nil {
+			return nil, apperrors.InvalidInput("user_id must be a valid UUID")
+		}
+		filter.UserID = &userID
+	}
+
+	if v := query.Get("type"); v != "" {
+		for _, eventType := range strings.Split(v, ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				filter.EventTypes = append(filter.EventTypes, eventType)
+			}
+		}
+	}
+
+	if v := query.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, apperrors.InvalidInput("from must be an RFC 3339 timestamp")
+		}
+		filter.From = &from
+	}
+
+	if v := query.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, apperrors.InvalidInput("to must be an RFC 3339 timestamp")
+		}
+		filter.To = &to
+	}
+
+	limit, err := parseIntQuery(r, "limit", 0)
+	if err != nil {
+		return nil, apperrors.InvalidInput("limit must be an integer")
+	}
+	filter.Limit = limit
+
+	offset, err := parseIntQuery(r, "offset", 0)
+	if err != nil {
+		return nil, apperrors.InvalidInput("offset must be an integer")
+	}
+	filter.Offset = offset
+
+	return filter, nil
+}
+
+func parseIntQuery(r *http.Request, key string, defaultValue int) (int, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(v)
+}
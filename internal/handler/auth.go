@@ -29,6 +29,13 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	log := h.logger.WithContext(ctx)
 
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		writeAppError(w, apperrors.Internal("tenant not resolved"))
+		return
+	}
+
 	var req domain.RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.WithError(err).Warn("failed to decode registration request")
@@ -42,7 +49,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.authService.Register(ctx, &req)
+	response, err := h.authService.Register(ctx, tenant, &req)
 	if err != nil {
 		if appErr, ok := err.(*apperrors.AppError); ok {
 			writeAppError(w, appErr)
@@ -64,6 +71,13 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	log := h.logger.WithContext(ctx)
 
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		writeAppError(w, apperrors.Internal("tenant not resolved"))
+		return
+	}
+
 	var req domain.LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.WithError(err).Warn("failed to decode login request")
@@ -77,7 +91,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.authService.Login(ctx, &req)
+	response, err := h.authService.Login(ctx, tenant, &req)
 	if err != nil {
 		if appErr, ok := err.(*apperrors.AppError); ok {
 			writeAppError(w, appErr)
@@ -99,6 +113,13 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	log := h.logger.WithContext(ctx)
 
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		writeAppError(w, apperrors.Internal("tenant not resolved"))
+		return
+	}
+
 	var req domain.RefreshTokenRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.WithError(err).Warn("failed to decode refresh token request")
@@ -112,7 +133,7 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokens, err := h.authService.RefreshToken(ctx, req.RefreshToken)
+	tokens, err := h.authService.RefreshToken(ctx, tenant, req.RefreshToken)
 	if err != nil {
 		if appErr, ok := err.(*apperrors.AppError); ok {
 			writeAppError(w, appErr)
@@ -130,6 +151,13 @@ func (h *AuthHandler) ValidateToken(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	log := h.logger.WithContext(ctx)
 
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		writeAppError(w, apperrors.Internal("tenant not resolved"))
+		return
+	}
+
 	var req domain.ValidateTokenRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.WithError(err).Warn("failed to decode validate token request")
@@ -143,7 +171,7 @@ func (h *AuthHandler) ValidateToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	claims, err := h.authService.ValidateToken(ctx, req.Token)
+	claims, err := h.authService.ValidateToken(ctx, tenant, req.Token)
 	if err != nil {
 		response := &domain.ValidateTokenResponse{
 			Valid:  false,
@@ -171,7 +199,7 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.authService.Logout(ctx, claims.UserID); err != nil {
+	if err := h.authService.Logout(ctx, claims.TenantID, claims.UserID); err != nil {
 		if appErr, ok := err.(*apperrors.AppError); ok {
 			writeAppError(w, appErr)
 		} else {
@@ -184,6 +212,82 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	writeJSendSuccess(w, http.StatusOK, map[string]string{"message": "logged out successfully"})
 }
 
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return
+	}
+
+	if claims.Type != "access" {
+		writeAppError(w, apperrors.Forbidden("password changes require an interactive session"))
+		return
+	}
+
+	var req domain.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Warn("failed to decode change password request")
+		writeAppError(w, apperrors.InvalidInput("invalid request body"))
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("change password validation failed")
+		writeAppError(w, apperrors.ValidationFailed(err.Error()))
+		return
+	}
+
+	if err := h.authService.ChangePassword(ctx, claims.TenantID, claims.UserID, &req); err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			writeAppError(w, appErr)
+		} else {
+			log.WithError(err).Error("password change failed")
+			writeAppError(w, apperrors.Internal("password change failed"))
+		}
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, map[string]string{"message": "password changed successfully"})
+}
+
+// ReportLogin handles the "this wasn't me" link of a new-login email. It is
+// public: the token from the link authenticates the request.
+func (h *AuthHandler) ReportLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		writeAppError(w, apperrors.Internal("tenant not resolved"))
+		return
+	}
+
+	var req domain.LoginReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Warn("failed to decode login report request")
+		writeAppError(w, apperrors.InvalidInput("invalid request body"))
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("login report validation failed")
+		writeAppError(w, apperrors.ValidationFailed(err.Error()))
+		return
+	}
+
+	if err := h.authService.ReportLogin(ctx, tenant, &req); err != nil {
+		writeServiceError(w, log, err, "login report failed")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, map[string]string{"message": "login reported, all sessions have been ended"})
+}
+
 func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	log := h.logger.WithContext(ctx)
@@ -195,19 +299,14 @@ func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.authService.GetUserByID(ctx, claims.UserID)
+	user, err := h.authService.GetUserByID(ctx, claims.TenantID, claims.UserID)
 	if err != nil {
 		log.WithError(err).Error("failed to get user data")
 		writeAppError(w, apperrors.Internal("failed to get user data"))
 		return
 	}
 
-	writeJSendSuccess(w, http.StatusOK, &domain.UserResponse{
-		UserID:   user.UserID,
-		Username: user.Username,
-		Email:    user.Email,
-		FullName: user.FullName,
-	})
+	writeJSendSuccess(w, http.StatusOK, domain.NewUserResponse(user))
 }
 
 func HealthCheck(w http.ResponseWriter, r *http.Request) {
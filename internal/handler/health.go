@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+
+	"auth-service/internal/health"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// Live reports that the process is up and serving HTTP. It deliberately has
+// no dependencies so a database outage does not get the pod restarted.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSendSuccess(w, http.StatusOK, map[string]interface{}{"status": "alive"})
+}
+
+// Ready returns 503 while any check fails or once shutdown has begun.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Ready(r.Context())
+	if !report.Ready {
+		writeJSendFail(w, http.StatusServiceUnavailable, report)
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, report)
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/middleware"
+	"auth-service/internal/service"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+	"auth-service/pkg/validator"
+
+	"github.com/google/uuid"
+)
+
+type IdentityHandler struct {
+	identityService *service.IdentityService
+	oidcService     *service.OIDCService
+	logger          *logger.Logger
+}
+
+func NewIdentityHandler(identityService *service.IdentityService, oidcService *service.OIDCService, log *logger.Logger) *IdentityHandler {
+	return &IdentityHandler{
+		identityService: identityService,
+		oidcService:     oidcService,
+		logger:          log,
+	}
+}
+
+func (h *IdentityHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return
+	}
+
+	methods, err := h.identityService.LoginMethods(ctx, claims.TenantID, claims.UserID)
+	if err != nil {
+		writeServiceError(w, log, err, "failed to list login methods")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, methods)
+}
+
+// LinkAuthorize starts linking an identity at the provider to the caller.
+// The client completes it through LinkCallback with the returned state.
+func (h *IdentityHandler) LinkAuthorize(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	tenant, claims, ok := h.interactive(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.oidcService.AuthorizeLink(ctx, tenant, claims.UserID, r.PathValue("provider"))
+	if err != nil {
+		writeServiceError(w, log, err, "failed to start identity link")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, response)
+}
+
+func (h *IdentityHandler) LinkCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	tenant, claims, ok := h.interactive(w, r)
+	if !ok {
+		return
+	}
+
+	var req domain.OIDCCallbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Warn("failed to decode identity link request")
+		writeAppError(w, apperrors.InvalidInput("invalid request body"))
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("identity link validation failed")
+		writeAppError(w, apperrors.ValidationFailed(err.Error()))
+		return
+	}
+
+	identity, err := h.oidcService.LinkCallback(ctx, tenant, claims.UserID, r.PathValue("provider"), &req)
+	if err != nil {
+		writeServiceError(w, log, err, "failed to link identity")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusCreated, identity)
+}
+
+func (h *IdentityHandler) Unlink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	_, claims, ok := h.interactive(w, r)
+	if !ok {
+		return
+	}
+
+	identityID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeAppError(w, apperrors.InvalidInput("invalid identity id"))
+		return
+	}
+
+	if err := h.identityService.Unlink(ctx, claims.TenantID, claims.UserID, identityID); err != nil {
+		writeServiceError(w, log, err, "failed to unlink identity")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, map[string]string{"message": "identity unlinked"})
+}
+
+func (h *IdentityHandler) SetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	_, claims, ok := h.interactive(w, r)
+	if !ok {
+		return
+	}
+
+	var req domain.SetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Warn("failed to decode set password request")
+		writeAppError(w, apperrors.InvalidInput("invalid request body"))
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("set password validation failed")
+		writeAppError(w, apperrors.ValidationFailed(err.Error()))
+		return
+	}
+
+	if err := h.identityService.SetPassword(ctx, claims.TenantID, claims.UserID, &req); err != nil {
+		writeServiceError(w, log, err, "failed to set password")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusCreated, map[string]string{"message": "password set"})
+}
+
+func (h *IdentityHandler) RemovePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	_, claims, ok := h.interactive(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.identityService.RemovePassword(ctx, claims.TenantID, claims.UserID); err != nil {
+		writeServiceError(w, log, err, "failed to remove password")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, map[string]string{"message": "password removed"})
+}
+
+// interactive returns the tenant and claims of a caller signed in with an
+// access token. Like password changes, login methods cannot be changed with
+// an API key.
+func (h *IdentityHandler) interactive(w http.ResponseWriter, r *http.Request) (*domain.Tenant, *domain.Claims, bool) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		writeAppError(w, apperrors.Internal("tenant not resolved"))
+		return nil, nil, false
+	}
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return nil, nil, false
+	}
+
+	if claims.Type != "access" {
+		writeAppError(w, apperrors.Forbidden("login method changes require an interactive session"))
+		return nil, nil, false
+	}
+
+	return tenant, claims, true
+}
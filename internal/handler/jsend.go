@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
 )
 
 type SuccessResponse struct {
@@ -83,3 +84,13 @@ func writeAppError(w http.ResponseWriter, appErr *apperrors.AppError) {
 
 	writeJSendFail(w, appErr.HTTPStatus, failData)
 }
+
+func writeServiceError(w http.ResponseWriter, log *logger.Logger, err error, message string) {
+	if appErr, ok := err.(*apperrors.AppError); ok {
+		writeAppError(w, appErr)
+		return
+	}
+
+	log.WithError(err).Error(message)
+	writeAppError(w, apperrors.Internal(message))
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/middleware"
+	"auth-service/internal/service"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+	"auth-service/pkg/validator"
+)
+
+type MagicLinkHandler struct {
+	magicLinkService *service.MagicLinkService
+	logger           *logger.Logger
+}
+
+func NewMagicLinkHandler(magicLinkService *service.MagicLinkService, log *logger.Logger) *MagicLinkHandler {
+	return &MagicLinkHandler{
+		magicLinkService: magicLinkService,
+		logger:           log,
+	}
+}
+
+// Request sends a login link. It answers 202 for unknown emails too.
+func (h *MagicLinkHandler) Request(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		writeAppError(w, apperrors.Internal("tenant not resolved"))
+		return
+	}
+
+	var req domain.MagicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Warn("failed to decode magic link request")
+		writeAppError(w, apperrors.InvalidInput("invalid request body"))
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("magic link request validation failed")
+		writeAppError(w, apperrors.ValidationFailed(err.Error()))
+		return
+	}
+
+	response, err := h.magicLinkService.Request(ctx, tenant, &req)
+	if err != nil {
+		writeServiceError(w, log, err, "failed to send login link")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusAccepted, response)
+}
+
+func (h *MagicLinkHandler) Consume(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		writeAppError(w, apperrors.Internal("tenant not resolved"))
+		return
+	}
+
+	var req domain.MagicLinkConsumeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Warn("failed to decode magic link consume request")
+		writeAppError(w, apperrors.InvalidInput("invalid request body"))
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("magic link consume validation failed")
+		writeAppError(w, apperrors.ValidationFailed(err.Error()))
+		return
+	}
+
+	response, err := h.magicLinkService.Consume(ctx, tenant, &req)
+	if err != nil {
+		writeServiceError(w, log, err, "login failed")
+		return
+	}
+
+	if rw := middleware.GetResponseWriter(w); rw != nil {
+		rw.SetUserID(response.User.UserID)
+	}
+
+	writeJSendSuccess(w, http.StatusOK, response)
+}
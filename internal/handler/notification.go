@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/middleware"
+	"auth-service/internal/service"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+	logger              *logger.Logger
+}
+
+func NewNotificationHandler(notificationService *service.NotificationService, log *logger.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		logger:              log,
+	}
+}
+
+// List returns the record of sent email, newest first. Supported query
+// parameters are user_id and limit.
+func (h *NotificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return
+	}
+
+	var userID *uuid.UUID
+	if v := r.URL.Query().Get("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			writeAppError(w, apperrors.InvalidInput("user_id must be a valid UUID"))
+			return
+		}
+		userID = &id
+	}
+
+	limit, err := parseIntQuery(r, "limit", 0)
+	if err != nil {
+		writeAppError(w, apperrors.InvalidInput("limit must be an integer"))
+		return
+	}
+
+	notifications, err := h.notificationService.List(ctx, claims.TenantID, userID, limit)
+	if err != nil {
+		writeServiceError(w, log, err, "failed to list notifications")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, map[string]interface{}{"notifications": notifications})
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/middleware"
+	"auth-service/internal/service"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+	"auth-service/pkg/validator"
+)
+
+type OIDCHandler struct {
+	oidcService *service.OIDCService
+	logger      *logger.Logger
+}
+
+func NewOIDCHandler(oidcService *service.OIDCService, log *logger.Logger) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+		logger:      log,
+	}
+}
+
+func (h *OIDCHandler) Providers(w http.ResponseWriter, r *http.Request) {
+	writeJSendSuccess(w, http.StatusOK, map[string]interface{}{
+		"providers": h.oidcService.Providers(),
+	})
+}
+
+// Authorize returns the URL to send the browser to. The client keeps the
+// state and returns it with the code the provider redirects back with.
+func (h *OIDCHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		writeAppError(w, apperrors.Internal("tenant not resolved"))
+		return
+	}
+
+	response, err := h.oidcService.Authorize(ctx, tenant, r.PathValue("provider"))
+	if err != nil {
+		writeServiceError(w, log, err, "failed to start login")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, response)
+}
+
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		writeAppError(w, apperrors.Internal("tenant not resolved"))
+		return
+	}
+
+	var req domain.OIDCCallbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Warn("failed to decode oidc callback request")
+		writeAppError(w, apperrors.InvalidInput("invalid request body"))
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("oidc callback validation failed")
+		writeAppError(w, apperrors.ValidationFailed(err.Error()))
+		return
+	}
+
+	response, err := h.oidcService.Callback(ctx, tenant, r.PathValue("provider"), &req)
+	if err != nil {
+		writeServiceError(w, log, err, "login failed")
+		return
+	}
+
+	if rw := middleware.GetResponseWriter(w); rw != nil {
+		rw.SetUserID(response.User.UserID)
+	}
+
+	writeJSendSuccess(w, http.StatusOK, response)
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/middleware"
+	"auth-service/internal/service"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+	"auth-service/pkg/validator"
+)
+
+type OTPHandler struct {
+	otpService *service.OTPService
+	logger     *logger.Logger
+}
+
+func NewOTPHandler(otpService *service.OTPService, log *logger.Logger) *OTPHandler {
+	return &OTPHandler{
+		otpService: otpService,
+		logger:     log,
+	}
+}
+
+// Request sends a login code. It answers 202 for unknown emails too.
+func (h *OTPHandler) Request(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		writeAppError(w, apperrors.Internal("tenant not resolved"))
+		return
+	}
+
+	var req domain.OTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Warn("failed to decode otp request")
+		writeAppError(w, apperrors.InvalidInput("invalid request body"))
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("otp request validation failed")
+		writeAppError(w, apperrors.ValidationFailed(err.Error()))
+		return
+	}
+
+	response, err := h.otpService.RequestLogin(ctx, tenant, &req)
+	if err != nil {
+		writeServiceError(w, log, err, "failed to send login code")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusAccepted, response)
+}
+
+func (h *OTPHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		writeAppError(w, apperrors.Internal("tenant not resolved"))
+		return
+	}
+
+	req, ok := h.decodeVerify(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.otpService.VerifyLogin(ctx, tenant, req)
+	if err != nil {
+		writeServiceError(w, log, err, "login failed")
+		return
+	}
+
+	if rw := middleware.GetResponseWriter(w); rw != nil {
+		rw.SetUserID(response.User.UserID)
+	}
+
+	writeJSendSuccess(w, http.StatusOK, response)
+}
+
+// StepUp sends a code to the caller for VerifyStepUp.
+func (h *OTPHandler) StepUp(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	tenant, claims, ok := h.interactive(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.otpService.RequestStepUp(ctx, tenant, claims.UserID)
+	if err != nil {
+		writeServiceError(w, log, err, "failed to send verification code")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusAccepted, response)
+}
+
+func (h *OTPHandler) VerifyStepUp(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	tenant, claims, ok := h.interactive(w, r)
+	if !ok {
+		return
+	}
+
+	req, ok := h.decodeVerify(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.otpService.VerifyStepUp(ctx, tenant, claims.UserID, claims.SessionID, req)
+	if err != nil {
+		writeServiceError(w, log, err, "step-up verification failed")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, response)
+}
+
+func (h *OTPHandler) decodeVerify(w http.ResponseWriter, r *http.Request) (*domain.OTPVerifyRequest, bool) {
+	log := h.logger.WithContext(r.Context())
+
+	var req domain.OTPVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Warn("failed to decode otp verify request")
+		writeAppError(w, apperrors.InvalidInput("invalid request body"))
+		return nil, false
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("otp verify validation failed")
+		writeAppError(w, apperrors.ValidationFailed(err.Error()))
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// interactive returns the tenant and claims of a caller signed in with an
+// access token. A step-up proves the user is present, which an API key
+// cannot.
+func (h *OTPHandler) interactive(w http.ResponseWriter, r *http.Request) (*domain.Tenant, *domain.Claims, bool) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	tenant, ok := middleware.GetTenant(ctx)
+	if !ok {
+		log.Error("failed to get tenant from context")
+		writeAppError(w, apperrors.Internal("tenant not resolved"))
+		return nil, nil, false
+	}
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return nil, nil, false
+	}
+
+	if claims.Type != "access" {
+		writeAppError(w, apperrors.Forbidden("step-up requires an interactive session"))
+		return nil, nil, false
+	}
+
+	return tenant, claims, true
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/middleware"
+	"auth-service/internal/service"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+	"auth-service/pkg/validator"
+
+	"github.com/google/uuid"
+)
+
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+	logger         *logger.Logger
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService, log *logger.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		logger:         log,
+	}
+}
+
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req domain.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Warn("failed to decode create webhook request")
+		writeAppError(w, apperrors.InvalidInput("invalid request body"))
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		log.WithError(err).Warn("create webhook validation failed")
+		writeAppError(w, apperrors.ValidationFailed(err.Error()))
+		return
+	}
+
+	response, err := h.webhookService.CreateSubscription(ctx, claims.TenantID, &req)
+	if err != nil {
+		writeServiceError(w, log, err, "webhook creation failed")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusCreated, response)
+}
+
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return
+	}
+
+	subs, err := h.webhookService.ListSubscriptions(ctx, claims.TenantID)
+	if err != nil {
+		writeServiceError(w, log, err, "failed to list webhooks")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, map[string]interface{}{"subscriptions": subs})
+}
+
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return
+	}
+
+	subscriptionID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeAppError(w, apperrors.InvalidInput("invalid webhook id"))
+		return
+	}
+
+	if err := h.webhookService.DeleteSubscription(ctx, claims.TenantID, subscriptionID); err != nil {
+		writeServiceError(w, log, err, "failed to delete webhook")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, map[string]string{"message": "webhook deleted"})
+}
+
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return
+	}
+
+	subscriptionID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeAppError(w, apperrors.InvalidInput("invalid webhook id"))
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(ctx, claims.TenantID, subscriptionID)
+	if err != nil {
+		writeServiceError(w, log, err, "failed to list webhook deliveries")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusOK, map[string]interface{}{"deliveries": deliveries})
+}
+
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.logger.WithContext(ctx)
+
+	claims, ok := ctx.Value(middleware.ClaimsKey).(*domain.Claims)
+	if !ok {
+		log.Error("failed to get claims from context")
+		writeAppError(w, apperrors.Unauthorized("unauthorized"))
+		return
+	}
+
+	deliveryID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeAppError(w, apperrors.InvalidInput("invalid delivery id"))
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(ctx, claims.TenantID, deliveryID)
+	if err != nil {
+		writeServiceError(w, log, err, "failed to redeliver webhook")
+		return
+	}
+
+	writeJSendSuccess(w, http.StatusAccepted, delivery)
+}
@@ -0,0 +1,212 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+	StatusPending = "pending" // registered but not yet run
+	StatusStale   = "stale"   // has not run within twice its interval
+)
+
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type Report struct {
+	Ready        bool                   `json:"ready"`
+	ShuttingDown bool                   `json:"shutting_down"`
+	Checks       map[string]CheckResult `json:"checks"`
+	Jobs         []JobStatus            `json:"jobs"`
+}
+
+// Checker runs the readiness checks. Once StartShutdown is called it reports
+// not-ready without running them, so load balancers drain the instance while
+// in-flight requests complete.
+type Checker struct {
+	mu           sync.RWMutex
+	checks       map[string]Check
+	jobs         *Jobs
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(jobs *Jobs, timeout time.Duration) *Checker {
+	return &Checker{
+		checks:  make(map[string]Check),
+		jobs:    jobs,
+		timeout: timeout,
+	}
+}
+
+func (c *Checker) AddCheck(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+func (c *Checker) StartShutdown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) ShuttingDown() bool {
+	return c.shuttingDown.Load()
+}
+
+// Ready runs every check concurrently, each bounded by the checker timeout.
+// Background job status is reported but does not affect readiness.
+func (c *Checker) Ready(ctx context.Context) *Report {
+	report := &Report{
+		Checks: make(map[string]CheckResult),
+		Jobs:   c.jobs.Snapshot(),
+	}
+
+	if c.ShuttingDown() {
+		report.ShuttingDown = true
+		return report
+	}
+
+	c.mu.RLock()
+	checks := make(map[string]Check, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	c.mu.RUnlock()
+
+	var (
+		wg      sync.WaitGroup
+		resultM sync.Mutex
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(checkCtx)
+			result := CheckResult{Status: StatusOK, DurationMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = StatusFailing
+				result.Error = err.Error()
+			}
+
+			resultM.Lock()
+			report.Checks[name] = result
+			resultM.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	report.Ready = true
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Ready = false
+		}
+	}
+
+	return report
+}
+
+type JobStatus struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Interval    string     `json:"interval"`
+	LastRun     *time.Time `json:"last_run,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// Jobs tracks the outcome of periodic background work such as session
+// cleanup and webhook delivery.
+type Jobs struct {
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+type job struct {
+	interval    time.Duration
+	lastRun     time.Time
+	lastSuccess time.Time
+	lastError   error
+}
+
+func NewJobs() *Jobs {
+	return &Jobs{jobs: make(map[string]*job)}
+}
+
+func (j *Jobs) Register(name string, interval time.Duration) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jobs[name] = &job{interval: interval}
+}
+
+// Report records a run of the named job; err is nil on success.
+func (j *Jobs) Report(name string, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry, ok := j.jobs[name]
+	if !ok {
+		entry = &job{}
+		j.jobs[name] = entry
+	}
+
+	now := time.Now()
+	entry.lastRun = now
+	entry.lastError = err
+	if err == nil {
+		entry.lastSuccess = now
+	}
+}
+
+func (j *Jobs) Snapshot() []JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]JobStatus, 0, len(j.jobs))
+	for name, entry := range j.jobs {
+		status := JobStatus{
+			Name:     name,
+			Status:   StatusOK,
+			Interval: entry.interval.String(),
+		}
+
+		switch {
+		case entry.lastRun.IsZero():
+			status.Status = StatusPending
+		case entry.lastError != nil:
+			status.Status = StatusFailing
+			status.LastError = entry.lastError.Error()
+		case entry.interval > 0 && now.Sub(entry.lastRun) > 2*entry.interval:
+			status.Status = StatusStale
+		}
+
+		if !entry.lastRun.IsZero() {
+			lastRun := entry.lastRun
+			status.LastRun = &lastRun
+		}
+		if !entry.lastSuccess.IsZero() {
+			lastSuccess := entry.lastSuccess
+			status.LastSuccess = &lastSuccess
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(a, b int) bool { return statuses[a].Name < statuses[b].Name })
+	return statuses
+}
@@ -0,0 +1,60 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// FileSender appends messages to an mbox file that mail clients can open.
+// Like LogSender it is meant for development.
+type FileSender struct {
+	from string
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileSender(from, path string) *FileSender {
+	return &FileSender{from: from, path: path}
+}
+
+// fromLine matches body lines that readers would take for the start of the
+// next message; they are quoted with another ">" as in mboxrd.
+var fromLine = regexp.MustCompile(`(?m)^(>*From )`)
+
+func (s *FileSender) Send(ctx context.Context, msg *Message) error {
+	sender, _, data, err := build(s.from, msg)
+	if err != nil {
+		return err
+	}
+
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	data = fromLine.ReplaceAll(data, []byte(">$1"))
+
+	var entry bytes.Buffer
+	fmt.Fprintf(&entry, "From %s %s\n", sender, time.Now().UTC().Format(time.ANSIC))
+	entry.Write(bytes.TrimRight(data, "\n"))
+	entry.WriteString("\n\n")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	// Messages carry login links and codes, so only the service user may read them.
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail file: %w", err)
+	}
+	if _, err := f.Write(entry.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return f.Close()
+}
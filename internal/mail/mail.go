@@ -0,0 +1,39 @@
+// Package mail renders and delivers outgoing email.
+package mail
+
+import (
+	"context"
+
+	"auth-service/pkg/logger"
+)
+
+// Message is a rendered email. HTML is optional; when set, the message is
+// sent as multipart/alternative with Text as the plain-text part.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// LogSender writes messages to the application log instead of sending them.
+// It is meant for development, where it makes links and codes easy to find;
+// config validation rejects it in production.
+type LogSender struct {
+	from   string
+	logger *logger.Logger
+}
+
+func NewLogSender(from string, log *logger.Logger) *LogSender {
+	return &LogSender{from: from, logger: log}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg *Message) error {
+	s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"from":    s.from,
+		"to":      msg.To,
+		"subject": msg.Subject,
+		"body":    msg.Text,
+	}).Info("email not sent: MAIL_TRANSPORT is log")
+	return nil
+}
@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+// MemorySender keeps sent messages in memory, for tests of code that sends
+// email.
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (s *MemorySender) Send(ctx context.Context, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, *msg)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+func (s *MemorySender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
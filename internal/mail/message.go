@@ -0,0 +1,96 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// build encodes msg as an RFC 5322 message with CRLF line endings. The
+// envelope sender and recipient are returned alongside it.
+func build(from string, msg *Message) (sender, recipient string, data []byte, err error) {
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+	toAddr, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("invalid recipient address: %w", err)
+	}
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return "", "", nil, fmt.Errorf("subject must be a single line")
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", fromAddr.String())
+	header("To", toAddr.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(fromAddr.Address))
+	header("MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, msg.Text); err != nil {
+			return "", "", nil, err
+		}
+		return fromAddr.Address, toAddr.Address, buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return "", "", nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return "", "", nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return "", "", nil, err
+	}
+
+	return fromAddr.Address, toAddr.Address, buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
@@ -0,0 +1,108 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"auth-service/internal/config"
+)
+
+// SMTPSender delivers messages to an SMTP relay, opening a connection per
+// message.
+type SMTPSender struct {
+	cfg *config.MailConfig
+}
+
+func NewSMTPSender(cfg *config.MailConfig) *SMTPSender {
+	return &SMTPSender{cfg: cfg}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	sender, recipient, data, err := build(s.cfg.From, msg)
+	if err != nil {
+		return err
+	}
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(s.cfg.SMTPTimeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake: %w", err)
+	}
+	defer client.Close()
+
+	if s.cfg.SMTPTLS == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(s.tlsConfig()); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+
+	if s.cfg.SMTPUsername != "" {
+		auth := smtp.PlainAuth("", s.cfg.SMTPUsername, s.cfg.SMTPPassword, s.cfg.SMTPHost)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(sender); err != nil {
+		return fmt.Errorf("smtp MAIL FROM: %w", err)
+	}
+	if err := client.Rcpt(recipient); err != nil {
+		return fmt.Errorf("smtp RCPT TO: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (s *SMTPSender) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(s.cfg.SMTPHost, strconv.Itoa(s.cfg.SMTPPort))
+	dialer := &net.Dialer{Timeout: s.cfg.SMTPTimeout}
+
+	if s.cfg.SMTPTLS == "tls" {
+		conn, err := (&tls.Dialer{NetDialer: dialer, Config: s.tlsConfig()}).DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("smtp dial: %w", err)
+		}
+		return conn, nil
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("smtp dial: %w", err)
+	}
+	return conn, nil
+}
+
+func (s *SMTPSender) tlsConfig() *tls.Config {
+	return &tls.Config{
+		ServerName: s.cfg.SMTPHost,
+		MinVersion: tls.VersionTLS12,
+	}
+}
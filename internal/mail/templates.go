@@ -0,0 +1,221 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed templates
+var builtinTemplates embed.FS
+
+// Template parts, by file extension.
+const (
+	partSubject = "subject"
+	partText    = "txt"
+	partHTML    = "html"
+)
+
+// Templates renders messages from template files laid out by locale:
+//
+//	<locale>/<name>.subject  text/template, a single line
+//	<locale>/<name>.txt      text/template, the plain-text body
+//	<locale>/<name>.html     html/template, optional
+//
+// Every template must exist in the default locale, which is used when none
+// of its translations matches the recipient's languages.
+type Templates struct {
+	byName map[string]*localizedTemplate
+}
+
+type localizedTemplate struct {
+	locales  []string // default locale first
+	matcher  language.Matcher
+	variants map[string]*templateVariant
+}
+
+type templateVariant struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// LoadTemplates parses the built-in templates and, if dir is not empty,
+// files under dir on top of them. A file in dir replaces the built-in file
+// with the same path, so operators can rebrand one part or add locales.
+func LoadTemplates(dir, defaultLocale string) (*Templates, error) {
+	defaultTag, err := language.Parse(defaultLocale)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default locale %q: %w", defaultLocale, err)
+	}
+
+	// sources maps name -> locale -> part -> template text.
+	sources := make(map[string]map[string]map[string]string)
+
+	builtin, err := fs.Sub(builtinTemplates, "templates")
+	if err != nil {
+		return nil, err
+	}
+	if err := readTemplateSources(builtin, sources); err != nil {
+		return nil, fmt.Errorf("built-in templates: %w", err)
+	}
+	if dir != "" {
+		if err := readTemplateSources(os.DirFS(dir), sources); err != nil {
+			return nil, fmt.Errorf("templates in %s: %w", dir, err)
+		}
+	}
+
+	t := &Templates{byName: make(map[string]*localizedTemplate)}
+	for name, locales := range sources {
+		lt, err := parseLocalized(name, locales, defaultTag.String())
+		if err != nil {
+			return nil, err
+		}
+		t.byName[name] = lt
+	}
+
+	return t, nil
+}
+
+func readTemplateSources(fsys fs.FS, sources map[string]map[string]map[string]string) error {
+	localeDirs, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return err
+	}
+
+	for _, localeDir := range localeDirs {
+		if !localeDir.IsDir() {
+			continue
+		}
+		tag, err := language.Parse(localeDir.Name())
+		if err != nil {
+			return fmt.Errorf("directory %s is not a locale: %w", localeDir.Name(), err)
+		}
+		locale := tag.String()
+
+		files, err := fs.ReadDir(fsys, localeDir.Name())
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			ext := path.Ext(file.Name())
+			name, part := strings.TrimSuffix(file.Name(), ext), strings.TrimPrefix(ext, ".")
+			if part != partSubject && part != partText && part != partHTML {
+				continue
+			}
+
+			content, err := fs.ReadFile(fsys, path.Join(localeDir.Name(), file.Name()))
+			if err != nil {
+				return err
+			}
+
+			if sources[name] == nil {
+				sources[name] = make(map[string]map[string]string)
+			}
+			if sources[name][locale] == nil {
+				sources[name][locale] = make(map[string]string)
+			}
+			sources[name][locale][part] = string(content)
+		}
+	}
+
+	return nil
+}
+
+func parseLocalized(name string, locales map[string]map[string]string, defaultLocale string) (*localizedTemplate, error) {
+	if _, ok := locales[defaultLocale]; !ok {
+		return nil, fmt.Errorf("template %s has no %s variant for the default locale", name, defaultLocale)
+	}
+
+	lt := &localizedTemplate{
+		locales:  []string{defaultLocale},
+		variants: make(map[string]*templateVariant),
+	}
+	var others []string
+	for locale := range locales {
+		if locale != defaultLocale {
+			others = append(others, locale)
+		}
+	}
+	sort.Strings(others)
+	lt.locales = append(lt.locales, others...)
+
+	tags := make([]language.Tag, 0, len(lt.locales))
+	for _, locale := range lt.locales {
+		parts := locales[locale]
+		if parts[partSubject] == "" || parts[partText] == "" {
+			return nil, fmt.Errorf("template %s/%s needs both a .subject and a .txt file", locale, name)
+		}
+
+		file := locale + "/" + name
+		variant := &templateVariant{}
+		var err error
+		if variant.subject, err = texttemplate.New(file + ".subject").Option("missingkey=error").Parse(parts[partSubject]); err != nil {
+			return nil, err
+		}
+		if variant.text, err = texttemplate.New(file + ".txt").Option("missingkey=error").Parse(parts[partText]); err != nil {
+			return nil, err
+		}
+		if parts[partHTML] != "" {
+			if variant.html, err = htmltemplate.New(file + ".html").Option("missingkey=error").Parse(parts[partHTML]); err != nil {
+				return nil, err
+			}
+		}
+
+		lt.variants[locale] = variant
+		tags = append(tags, language.MustParse(locale))
+	}
+	lt.matcher = language.NewMatcher(tags)
+
+	return lt, nil
+}
+
+// Render renders the named template in the locale that best matches
+// acceptLanguage, an Accept-Language header value, and returns the message
+// without a recipient together with the locale used.
+func (t *Templates) Render(name, acceptLanguage string, data any) (*Message, string, error) {
+	lt, ok := t.byName[name]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown email template %q", name)
+	}
+
+	locale := lt.locales[0]
+	if desired, _, err := language.ParseAcceptLanguage(acceptLanguage); err == nil && len(desired) > 0 {
+		if _, i, confidence := lt.matcher.Match(desired...); confidence != language.No {
+			locale = lt.locales[i]
+		}
+	}
+	variant := lt.variants[locale]
+
+	var subject, text, html bytes.Buffer
+	if err := variant.subject.Execute(&subject, data); err != nil {
+		return nil, "", err
+	}
+	if err := variant.text.Execute(&text, data); err != nil {
+		return nil, "", err
+	}
+	if variant.html != nil {
+		if err := variant.html.Execute(&html, data); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return &Message{
+		// Header values must stay on one line whatever the template produces.
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, locale, nil
+}
@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "auth_service"
+
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route pattern.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"method", "route"})
+
+	GRPCRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "gRPC requests by full method name and status code.",
+	}, []string{"method", "code"})
+
+	GRPCRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "gRPC request latency by full method name.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"method"})
+
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by result.",
+	}, []string{"result"})
+
+	LoginFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_failures_total",
+		Help:      "Failed login attempts by reason.",
+	}, []string{"reason"})
+
+	TokenRefreshes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_refreshes_total",
+		Help:      "Refresh token exchanges by result.",
+	}, []string{"result"})
+
+	Registrations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_total",
+		Help:      "User registrations by result.",
+	}, []string{"result"})
+
+	RateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Requests rejected by the rate limiter, by policy and key.",
+	}, []string{"policy", "key"})
+
+	Notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Notification send attempts by template and outcome (sent, retry or failed).",
+	}, []string{"template", "outcome"})
+
+	PasswordHashDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "password_hash_duration_seconds",
+		Help:      "Time spent in bcrypt by operation (hash or verify).",
+		Buckets:   []float64{.01, .025, .05, .075, .1, .15, .2, .3, .5, 1},
+	}, []string{"operation"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		GRPCRequests,
+		GRPCRequestDuration,
+		Logins,
+		LoginFailures,
+		TokenRefreshes,
+		Registrations,
+		RateLimitRejections,
+		Notifications,
+		PasswordHashDuration,
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	HTTPRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	HTTPRequestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+func ObserveGRPCRequest(method, code string, duration time.Duration) {
+	GRPCRequests.WithLabelValues(method, code).Inc()
+	GRPCRequestDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+func ObservePasswordHash(operation string, start time.Time) {
+	PasswordHashDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// RegisterActiveSessions exposes a gauge evaluated at scrape time by count.
+func RegisterActiveSessions(count func(ctx context.Context) (int64, error)) {
+	Registry.MustRegister(&activeSessionsCollector{
+		count: count,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "active_sessions"),
+			"Sessions that are neither revoked nor expired.",
+			nil, nil,
+		),
+	})
+}
+
+func RegisterPool(pool *pgxpool.Pool) {
+	Registry.MustRegister(newPoolCollector(pool))
+}
+
+type activeSessionsCollector struct {
+	count func(ctx context.Context) (int64, error)
+	desc  *prometheus.Desc
+}
+
+func (c *activeSessionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *activeSessionsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	n, err := c.count(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n))
+}
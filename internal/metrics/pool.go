@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	newConnsCount        *prometheus.Desc
+	maxLifetimeDestroy   *prometheus.Desc
+	maxIdleDestroy       *prometheus.Desc
+}
+
+func newPoolCollector(pool *pgxpool.Pool) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return &poolCollector{
+		pool:                 pool,
+		acquiredConns:        desc("acquired_connections", "Connections currently checked out of the pool."),
+		idleConns:            desc("idle_connections", "Idle connections in the pool."),
+		totalConns:           desc("total_connections", "Total connections in the pool."),
+		maxConns:             desc("max_connections", "Maximum size of the pool."),
+		constructingConns:    desc("constructing_connections", "Connections currently being established."),
+		acquireCount:         desc("acquires_total", "Successful connection acquires."),
+		acquireDuration:      desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+		emptyAcquireCount:    desc("empty_acquires_total", "Acquires that had to wait for a connection."),
+		canceledAcquireCount: desc("canceled_acquires_total", "Acquires canceled by their context."),
+		newConnsCount:        desc("new_connections_total", "Connections opened."),
+		maxLifetimeDestroy:   desc("max_lifetime_destroys_total", "Connections closed for exceeding MaxConnLifetime."),
+		maxIdleDestroy:       desc("max_idle_destroys_total", "Connections closed for exceeding MaxConnIdleTime."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	gauge := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
+	}
+	counter := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v)
+	}
+
+	gauge(c.acquiredConns, float64(stat.AcquiredConns()))
+	gauge(c.idleConns, float64(stat.IdleConns()))
+	gauge(c.totalConns, float64(stat.TotalConns()))
+	gauge(c.maxConns, float64(stat.MaxConns()))
+	gauge(c.constructingConns, float64(stat.ConstructingConns()))
+	counter(c.acquireCount, float64(stat.AcquireCount()))
+	counter(c.acquireDuration, stat.AcquireDuration().Seconds())
+	counter(c.emptyAcquireCount, float64(stat.EmptyAcquireCount()))
+	counter(c.canceledAcquireCount, float64(stat.CanceledAcquireCount()))
+	counter(c.newConnsCount, float64(stat.NewConnsCount()))
+	counter(c.maxLifetimeDestroy, float64(stat.MaxLifetimeDestroyCount()))
+	counter(c.maxIdleDestroy, float64(stat.MaxIdleDestroyCount()))
+}
@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"auth-service/pkg/logger"
+)
+
+// ClientIPResolver determines the originating client address. Forwarding
+// headers are honoured only when the direct peer is a trusted proxy, and are
+// walked right to left, stopping at the first hop that is not itself trusted.
+type ClientIPResolver struct {
+	trusted []*net.IPNet
+}
+
+func NewClientIPResolver(trustedProxies []*net.IPNet) *ClientIPResolver {
+	return &ClientIPResolver{trusted: trustedProxies}
+}
+
+// ClientIP resolves the client address once per request and stores it for
+// SessionMetadata, RateLimit and the handlers.
+func ClientIP(log *logger.Logger, resolver *ClientIPResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, suspicious := resolver.Resolve(r)
+			if suspicious != "" {
+				log.WithContext(r.Context()).WithFields(map[string]interface{}{
+					"remote_addr":       r.RemoteAddr,
+					"client_ip":         ip,
+					"forwarded":         r.Header.Get("Forwarded"),
+					"x_forwarded_for":   r.Header.Get("X-Forwarded-For"),
+					"x_real_ip":         r.Header.Get("X-Real-IP"),
+					"spoofing_evidence": suspicious,
+				}).Warn("ignored untrusted client IP headers")
+			}
+
+			ctx := context.WithValue(r.Context(), ClientIPKey, ip)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Resolve returns the client IP and, when forwarding headers had to be
+// ignored, a short description of why.
+func (c *ClientIPResolver) Resolve(r *http.Request) (string, string) {
+	return c.ResolveAddr(r.RemoteAddr, r.Header)
+}
+
+// ResolveAddr is Resolve for transports other than net/http, given the
+// direct peer's address and the forwarding headers it sent.
+func (c *ClientIPResolver) ResolveAddr(remoteAddr string, header http.Header) (string, string) {
+	peer := remoteHost(remoteAddr)
+
+	hops, source := forwardedHops(header)
+	if len(hops) == 0 {
+		if xri := strings.TrimSpace(header.Get("X-Real-IP")); xri != "" {
+			if !c.isTrusted(peer) {
+				return peer, "x_real_ip_from_untrusted_peer"
+			}
+			if ip := net.ParseIP(xri); ip != nil {
+				return ip.String(), ""
+			}
+			return peer, "malformed_x_real_ip"
+		}
+		return peer, ""
+	}
+
+	if !c.isTrusted(peer) {
+		return peer, source + "_from_untrusted_peer"
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			if obfuscatedNode(hops[i]) {
+				return client, ""
+			}
+			return client, "malformed_" + source + "_entry"
+		}
+		client = ip.String()
+		if !c.isTrusted(client) {
+			return client, ""
+		}
+	}
+
+	return client, ""
+}
+
+func (c *ClientIPResolver) isTrusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range c.trusted {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedHops returns the client chain from the RFC 7239 Forwarded header,
+// or from X-Forwarded-For if Forwarded is absent, ordered client first.
+func forwardedHops(header http.Header) ([]string, string) {
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		var hops []string
+		for _, value := range values {
+			for _, element := range strings.Split(value, ",") {
+				hops = append(hops, forwardedFor(element))
+			}
+		}
+		return hops, "forwarded"
+	}
+
+	if values := header.Values("X-Forwarded-For"); len(values) > 0 {
+		var hops []string
+		for _, value := range values {
+			hops = append(hops, splitAndTrim(value, ",")...)
+		}
+		return hops, "x_forwarded_for"
+	}
+
+	return nil, ""
+}
+
+// forwardedFor extracts the node of the for= parameter from one
+// forwarded-element, stripping quotes, brackets and any port. Elements
+// without a for= parameter yield "".
+func forwardedFor(element string) string {
+	for _, pair := range strings.Split(element, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || !strings.EqualFold(key, "for") {
+			continue
+		}
+
+		node := strings.Trim(strings.TrimSpace(value), `"`)
+		if strings.HasPrefix(node, "[") {
+			if end := strings.Index(node, "]"); end > 0 {
+				return node[1:end]
+			}
+			return ""
+		}
+		if host, _, err := net.SplitHostPort(node); err == nil {
+			return host
+		}
+		return node
+	}
+	return ""
+}
+
+// obfuscatedNode reports RFC 7239 "unknown" and obfuscated ("_...") nodes,
+// which a proxy may legitimately send in place of an address.
+func obfuscatedNode(node string) bool {
+	return strings.EqualFold(node, "unknown") || strings.HasPrefix(node, "_")
+}
+
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// getClientIP returns the address resolved by ClientIP, or the direct peer if
+// that middleware did not run.
+func getClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(ClientIPKey).(string); ok && ip != "" {
+		return ip
+	}
+	return remoteHost(r.RemoteAddr)
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"auth-service/pkg/logger"
+)
+
+func newTestClientIPResolver(t *testing.T) *ClientIPResolver {
+	t.Helper()
+	var trusted []*net.IPNet
+	for _, cidr := range []string{"10.0.0.0/8", "fd00::/8"} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatalf("parse %s: %v", cidr, err)
+		}
+		trusted = append(trusted, network)
+	}
+	return NewClientIPResolver(trusted)
+}
+
+func TestResolveClientIP(t *testing.T) {
+	resolver := newTestClientIPResolver(t)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		wantIP     string
+		suspicious string
+	}{
+		// Direct clients.
+		{"no headers", "203.0.113.5:1234", nil, "203.0.113.5", ""},
+		{"ipv6 peer", "[2001:db8::1]:1234", nil, "2001:db8::1", ""},
+		{"untrusted x-forwarded-for", "203.0.113.5:1234", http.Header{"X-Forwarded-For": {"198.51.100.7"}}, "203.0.113.5", "x_forwarded_for_from_untrusted_peer"},
+		{"untrusted forwarded", "203.0.113.5:1234", http.Header{"Forwarded": {"for=198.51.100.7"}}, "203.0.113.5", "forwarded_from_untrusted_peer"},
+		{"untrusted x-real-ip", "203.0.113.5:1234", http.Header{"X-Real-Ip": {"198.51.100.7"}}, "203.0.113.5", "x_real_ip_from_untrusted_peer"},
+
+		// X-Forwarded-For through trusted proxies.
+		{"one hop", "10.0.0.1:443", http.Header{"X-Forwarded-For": {"198.51.100.7"}}, "198.51.100.7", ""},
+		{"trusted hops walked right to left", "10.0.0.1:443", http.Header{"X-Forwarded-For": {"192.0.2.66, 198.51.100.7, 10.0.0.2"}}, "198.51.100.7", ""},
+		{"only trusted hops", "10.0.0.1:443", http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}}, "10.0.0.3", ""},
+		{"several header lines", "10.0.0.1:443", http.Header{"X-Forwarded-For": {"192.0.2.66", "198.51.100.7"}}, "198.51.100.7", ""},
+		{"ipv6 proxy", "[fd00::1]:443", http.Header{"X-Forwarded-For": {"2001:db8::17"}}, "2001:db8::17", ""},
+		{"malformed entry", "10.0.0.1:443", http.Header{"X-Forwarded-For": {"not-an-ip"}}, "10.0.0.1", "malformed_x_forwarded_for_entry"},
+		{"malformed entry behind a trusted hop", "10.0.0.1:443", http.Header{"X-Forwarded-For": {"198.51.100.7:80, 10.0.0.2"}}, "10.0.0.2", "malformed_x_forwarded_for_entry"},
+		{"malformed entry left of the client", "10.0.0.1:443", http.Header{"X-Forwarded-For": {"garbage, 198.51.100.7"}}, "198.51.100.7", ""},
+
+		// RFC 7239 Forwarded.
+		{"forwarded", "10.0.0.1:443", http.Header{"Forwarded": {"for=198.51.100.7;proto=https"}}, "198.51.100.7", ""},
+		{"forwarded parameter case", "10.0.0.1:443", http.Header{"Forwarded": {"proto=https;FOR=198.51.100.7"}}, "198.51.100.7", ""},
+		{"forwarded quoted with port", "10.0.0.1:443", http.Header{"Forwarded": {`for="198.51.100.7:4711"`}}, "198.51.100.7", ""},
+		{"forwarded bracketed ipv6", "10.0.0.1:443", http.Header{"Forwarded": {`for="[2001:db8:cafe::17]"`}}, "2001:db8:cafe::17", ""},
+		{"forwarded bracketed ipv6 with port", "10.0.0.1:443", http.Header{"Forwarded": {`for="[2001:db8:cafe::17]:4711"`}}, "2001:db8:cafe::17", ""},
+		{"forwarded hops", "10.0.0.1:443", http.Header{"Forwarded": {"for=192.0.2.66, for=198.51.100.7", `for="[fd00::2]"`}}, "198.51.100.7", ""},
+		{"forwarded wins over x-forwarded-for", "10.0.0.1:443", http.Header{"Forwarded": {"for=198.51.100.7"}, "X-Forwarded-For": {"192.0.2.66"}}, "198.51.100.7", ""},
+		{"obfuscated client", "10.0.0.1:443", http.Header{"Forwarded": {"for=_hidden, for=10.0.0.2"}}, "10.0.0.2", ""},
+		{"unknown client", "10.0.0.1:443", http.Header{"Forwarded": {"for=unknown"}}, "10.0.0.1", ""},
+		{"unknown client in x-forwarded-for", "10.0.0.1:443", http.Header{"X-Forwarded-For": {"unknown"}}, "10.0.0.1", ""},
+		{"unclosed bracket", "10.0.0.1:443", http.Header{"Forwarded": {`for="[2001:db8::17"`}}, "10.0.0.1", "malformed_forwarded_entry"},
+		{"element without for", "10.0.0.1:443", http.Header{"Forwarded": {"proto=https;by=10.0.0.1"}}, "10.0.0.1", "malformed_forwarded_entry"},
+		{"empty element", "10.0.0.1:443", http.Header{"Forwarded": {"for=198.51.100.7,"}}, "10.0.0.1", "malformed_forwarded_entry"},
+
+		// X-Real-IP, used only without the other headers.
+		{"x-real-ip", "10.0.0.1:443", http.Header{"X-Real-Ip": {"198.51.100.7"}}, "198.51.100.7", ""},
+		{"malformed x-real-ip", "10.0.0.1:443", http.Header{"X-Real-Ip": {"198.51.100.7, 192.0.2.66"}}, "10.0.0.1", "malformed_x_real_ip"},
+		{"x-forwarded-for wins over x-real-ip", "10.0.0.1:443", http.Header{"X-Forwarded-For": {"198.51.100.7"}, "X-Real-Ip": {"192.0.2.66"}}, "198.51.100.7", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, suspicious := resolver.ResolveAddr(tt.remoteAddr, tt.header)
+			if ip != tt.wantIP || suspicious != tt.suspicious {
+				t.Fatalf("ResolveAddr = %q, %q, want %q, %q", ip, suspicious, tt.wantIP, tt.suspicious)
+			}
+		})
+	}
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	var got string
+	h := ClientIP(logger.New("error", "json", ""), newTestClientIPResolver(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = getClientIP(r)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:443"
+	req.Header.Set("X-Forwarded-For", "198.51.100.7")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if got != "198.51.100.7" {
+		t.Fatalf("client IP = %q, want the forwarded address", got)
+	}
+}
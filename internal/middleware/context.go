@@ -4,24 +4,36 @@ import (
 	"fmt"
 	"net/http"
 
+	"auth-service/internal/domain"
 	apperrors "auth-service/pkg/errors"
 )
 
 type contextKey string
 
 const (
-	RequestIDKey  contextKey = "request_id"
-	UserIDKey     contextKey = "user_id"
-	ClaimsKey     contextKey = "claims"
-	IPAddressKey  contextKey = "ip_address"
-	UserAgentKey  contextKey = "user_agent"
-	DeviceInfoKey contextKey = "device_info"
+	UserIDKey   contextKey = "user_id"
+	ClaimsKey   contextKey = "claims"
+	TenantKey   contextKey = "tenant"
+	ClientIPKey contextKey = "client_ip"
+)
+
+// Request metadata keys are shared with the service layer.
+const (
+	RequestIDKey  = domain.RequestIDKey
+	IPAddressKey  = domain.IPAddressKey
+	UserAgentKey  = domain.UserAgentKey
+	DeviceInfoKey = domain.DeviceInfoKey
+	ClientIDKey   = domain.ClientIDKey
+	ActorKey      = domain.ActorKey
+
+	AcceptLanguageKey = domain.AcceptLanguageKey
 )
 
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
 	userID     interface{}
+	route      string
 }
 
 func (rw *responseWriter) WriteHeader(statusCode int) {
@@ -33,6 +45,10 @@ func (rw *responseWriter) SetUserID(userID interface{}) {
 	rw.userID = userID
 }
 
+func (rw *responseWriter) SetRoute(route string) {
+	rw.route = route
+}
+
 func GetResponseWriter(w http.ResponseWriter) *responseWriter {
 	if rw, ok := w.(*responseWriter); ok {
 		return rw
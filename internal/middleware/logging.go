@@ -6,19 +6,28 @@ import (
 	"runtime/debug"
 	"time"
 
+	"auth-service/internal/metrics"
 	apperrors "auth-service/pkg/errors"
 	"auth-service/pkg/logger"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const maxRequestIDLength = 128
+
+// RequestID propagates an incoming X-Request-ID, or generates one, and tags
+// the active span with it so the request id and trace id can be correlated.
 func RequestID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		requestID := r.Header.Get("X-Request-ID")
-		if requestID == "" {
+		if !ValidRequestID(requestID) {
 			requestID = uuid.New().String()
 		}
 
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("request.id", requestID))
+
 		w.Header().Set("X-Request-ID", requestID)
 		ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -34,28 +43,38 @@ func Logger(log *logger.Logger) func(http.Handler) http.Handler {
 			next.ServeHTTP(wrapped, r)
 
 			duration := time.Since(start)
+			metrics.ObserveHTTPRequest(r.Method, wrapped.route, wrapped.statusCode, duration)
 
-			var requestID string
-			if rid := r.Context().Value(RequestIDKey); rid != nil {
-				if str, ok := rid.(string); ok {
-					requestID = str
-				}
-			}
-
-			log.HTTPRequest(
+			log.WithContext(r.Context()).HTTPRequest(
 				r.Method,
 				r.URL.Path,
 				wrapped.statusCode,
 				duration.Milliseconds(),
 				r.RemoteAddr,
 				r.UserAgent(),
-				requestID,
 				wrapped.userID,
 			)
 		})
 	}
 }
 
+// Route records the mux pattern matching the request on the Logger's
+// responseWriter so metrics are labelled by route rather than raw path. The
+// pattern is looked up before the request is served because middleware such
+// as Timeout runs the handler on another goroutine.
+func Route(mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rw := GetResponseWriter(w); rw != nil {
+				_, pattern := mux.Handler(r)
+				rw.SetRoute(pattern)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func Recovery(log *logger.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -75,3 +94,17 @@ func Recovery(log *logger.Logger) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// ValidRequestID accepts caller-supplied ids of printable ASCII only, so they
+// cannot inject into log lines or response headers.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -1,81 +1,313 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
 	"net"
 	"net/http"
-	"sync"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/metrics"
+	"auth-service/internal/ratelimit"
 	apperrors "auth-service/pkg/errors"
 	"auth-service/pkg/logger"
 )
 
-type client struct {
-	tokens    int
-	lastReset time.Time
+// RateLimitStore holds token buckets keyed by client. Implementations must be
+// safe for concurrent use.
+type RateLimitStore interface {
+	Allow(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error)
 }
 
-var (
-	clients = make(map[string]*client)
-	mu      sync.Mutex
-)
+// RateLimitRule charges requests to a bucket per identity. Key is one of the
+// config.RateLimitKey* values.
+type RateLimitRule struct {
+	Key   string
+	Limit ratelimit.Limit
+}
+
+// RateLimitPolicy is a set of rules that must all admit a request.
+type RateLimitPolicy struct {
+	Name  string
+	Rules []RateLimitRule
+}
+
+// RateLimiter picks the policy for a request from the route pattern that will
+// serve it, falling back to a default policy shared by all other routes.
+type RateLimiter struct {
+	store      RateLimitStore
+	mux        *http.ServeMux
+	jwtSecrets []string
+	rules      atomic.Pointer[rateLimitRules]
+}
+
+type rateLimitRules struct {
+	defaultPolicy RateLimitPolicy
+	policies      map[string]RateLimitPolicy
+	allowlist     []*net.IPNet
+}
+
+func NewRateLimiter(
+	store RateLimitStore,
+	mux *http.ServeMux,
+	jwtSecrets []string,
+	defaultPolicy RateLimitPolicy,
+	policies []RateLimitPolicy,
+	allowlist []*net.IPNet,
+) *RateLimiter {
+	l := &RateLimiter{
+		store:      store,
+		mux:        mux,
+		jwtSecrets: jwtSecrets,
+	}
+	l.Update(defaultPolicy, policies, allowlist)
+	return l
+}
+
+// Update replaces the policies and allowlist. Existing buckets are kept, so
+// clients are not handed a fresh allowance by a reload.
+func (l *RateLimiter) Update(defaultPolicy RateLimitPolicy, policies []RateLimitPolicy, allowlist []*net.IPNet) {
+	byRoute := make(map[string]RateLimitPolicy, len(policies))
+	for _, p := range policies {
+		byRoute[p.Name] = p
+	}
+
+	l.rules.Store(&rateLimitRules{
+		defaultPolicy: defaultPolicy,
+		policies:      byRoute,
+		allowlist:     allowlist,
+	})
+}
+
+// RateLimitRequest describes a request to the limiter independently of the
+// transport that received it.
+type RateLimitRequest struct {
+	// Route names the policy to apply; unknown routes get the default policy.
+	Route      string
+	IP         string
+	Credential string
+	// Username is called only if a rule is keyed by username.
+	Username func() string
+}
+
+// RateLimitDecision is the outcome of the tightest rule that was charged.
+type RateLimitDecision struct {
+	Policy string
+	Rule   RateLimitRule
+	Result ratelimit.Result
+}
 
-func RateLimit(log *logger.Logger, requestsPerMinute int) func(http.Handler) http.Handler {
+// Allow charges the request to every rule of its policy. It returns nil when
+// the client is allowlisted or the policy has no rules.
+func (l *RateLimiter) Allow(ctx context.Context, req RateLimitRequest) (*RateLimitDecision, error) {
+	if l.allowlisted(req.IP) {
+		return nil, nil
+	}
+
+	policy := l.policyFor(req.Route)
+
+	var decision *RateLimitDecision
+	for _, rule := range policy.Rules {
+		identity := l.identify(ctx, req, rule.Key)
+
+		result, err := l.store.Allow(ctx, policy.Name+"|"+rule.Key+"|"+identity, rule.Limit)
+		if err != nil {
+			return nil, err
+		}
+
+		if decision == nil || !result.Allowed || (decision.Result.Allowed && result.Remaining < decision.Result.Remaining) {
+			decision = &RateLimitDecision{Policy: policy.Name, Rule: rule, Result: result}
+		}
+		if !result.Allowed {
+			metrics.RateLimitRejections.WithLabelValues(policy.Name, rule.Key).Inc()
+			break
+		}
+	}
+
+	return decision, nil
+}
+
+// RateLimit enforces the limiter's policies. If the store fails the request
+// is let through, so an outage of a shared store does not take the API down.
+func RateLimit(log *logger.Logger, limiter *RateLimiter) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, route := limiter.mux.Handler(r)
 			ip := getClientIP(r)
 
-			mu.Lock()
-			c, exists := clients[ip]
-			if !exists {
-				c = &client{
-					tokens:    requestsPerMinute,
-					lastReset: time.Now(),
-				}
-				clients[ip] = c
+			decision, err := limiter.Allow(r.Context(), RateLimitRequest{
+				Route:      route,
+				IP:         ip,
+				Credential: requestCredential(r),
+				Username:   func() string { return usernameFromBody(r) },
+			})
+			if err != nil {
+				log.WithContext(r.Context()).WithError(err).Error("rate limit store unavailable")
+				next.ServeHTTP(w, r)
+				return
 			}
-
-			if time.Since(c.lastReset) > time.Minute {
-				c.tokens = requestsPerMinute
-				c.lastReset = time.Now()
+			if decision == nil {
+				next.ServeHTTP(w, r)
+				return
 			}
 
-			if c.tokens <= 0 {
-				mu.Unlock()
+			setRateLimitHeaders(w, decision.Rule.Limit, decision.Result)
+
+			if !decision.Result.Allowed {
 				log.WithContext(r.Context()).WithFields(map[string]interface{}{
-					"ip": ip,
+					"ip":     ip,
+					"policy": decision.Policy,
+					"key":    decision.Rule.Key,
 				}).Warn("rate limit exceeded")
+				w.Header().Set("Retry-After", ceilSeconds(decision.Result.RetryAfter))
 				appErr := apperrors.RateLimitExceeded()
 				writeJSONError(w, appErr)
 				return
 			}
 
-			c.tokens--
-			mu.Unlock()
-
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-func getClientIP(r *http.Request) string {
-	xff := r.Header.Get("X-Forwarded-For")
-	if xff != "" {
-		ips := splitAndTrim(xff, ",")
-		if len(ips) > 0 {
-			return ips[0]
+func (l *RateLimiter) allowlisted(ip string) bool {
+	allowlist := l.rules.Load().allowlist
+	if len(allowlist) == 0 {
+		return false
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range allowlist {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *RateLimiter) policyFor(route string) RateLimitPolicy {
+	rules := l.rules.Load()
+	if route != "" {
+		if policy, ok := rules.policies[route]; ok {
+			return policy
+		}
+	}
+	return rules.defaultPolicy
+}
+
+// identify returns the identity a rule charges the request to. Requests that
+// lack the identity a rule asks for are charged by IP instead.
+func (l *RateLimiter) identify(ctx context.Context, req RateLimitRequest, key string) string {
+	tenantPrefix := ""
+	if tenant, ok := GetTenant(ctx); ok {
+		tenantPrefix = tenant.TenantID.String() + ":"
+	}
+
+	switch key {
+	case config.RateLimitKeyUser:
+		if userID := l.userFromCredential(ctx, req.Credential); userID != "" {
+			return "user:" + tenantPrefix + userID
 		}
+	case config.RateLimitKeyClient:
+		if strings.HasPrefix(req.Credential, domain.APIKeyPrefix) {
+			sum := sha256.Sum256([]byte(req.Credential))
+			return "client:" + hex.EncodeToString(sum[:16])
+		}
+	case config.RateLimitKeyUsername:
+		if req.Username != nil {
+			if username := strings.ToLower(strings.TrimSpace(req.Username())); username != "" {
+				return "username:" + tenantPrefix + username
+			}
+		}
+	}
+
+	return "ip:" + req.IP
+}
+
+// userFromCredential verifies a bearer access token, if any, without
+// consulting the database. API keys are not resolved here.
+func (l *RateLimiter) userFromCredential(ctx context.Context, credential string) string {
+	if claims, ok := ctx.Value(ClaimsKey).(*domain.Claims); ok {
+		return claims.UserID.String()
+	}
+
+	if credential == "" || strings.HasPrefix(credential, domain.APIKeyPrefix) {
+		return ""
 	}
 
-	xri := r.Header.Get("X-Real-IP")
-	if xri != "" {
-		return xri
+	claims, appErr := parseAccessToken(credential, l.jwtSecrets)
+	if appErr != nil {
+		return ""
+	}
+	return claims.UserID.String()
+}
+
+func requestCredential(r *http.Request) string {
+	if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+		return apiKey
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return ""
+	}
+	return token
+}
+
+// usernameFromBody peeks at a JSON body's username field and restores the
+// body, including any read error, for the handler.
+func usernameFromBody(r *http.Request) string {
+	if r.Body == nil || r.Body == http.NoBody {
+		return ""
 	}
 
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	body, err := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errorReader{err}))
 	if err != nil {
-		return r.RemoteAddr
+		return ""
+	}
+
+	var payload struct {
+		Username string `json:"username"`
 	}
+	if json.Unmarshal(body, &payload) != nil {
+		return ""
+	}
+	return payload.Username
+}
+
+type errorReader struct {
+	err error
+}
+
+func (e errorReader) Read([]byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	return 0, io.EOF
+}
+
+// setRateLimitHeaders writes the IETF RateLimit header fields.
+func setRateLimitHeaders(w http.ResponseWriter, limit ratelimit.Limit, result ratelimit.Result) {
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	h.Set("RateLimit-Reset", ceilSeconds(result.ResetAfter))
+	h.Set("RateLimit-Policy", strconv.Itoa(result.Limit)+";w="+ceilSeconds(limit.Period))
+}
 
-	return ip
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
 }
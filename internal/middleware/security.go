@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"sync/atomic"
 
 	"auth-service/internal/domain"
 	apperrors "auth-service/pkg/errors"
@@ -13,93 +14,295 @@ import (
 	"github.com/google/uuid"
 )
 
-func Auth(log *logger.Logger, jwtSecret string) func(http.Handler) http.Handler {
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (*domain.Claims, error)
+}
+
+// SessionTracker records activity on the session behind an access token and
+// fails once that session has ended.
+type SessionTracker interface {
+	TouchSession(ctx context.Context, claims *domain.Claims) error
+}
+
+func Auth(log *logger.Logger, jwtSecrets []string, apiKeys APIKeyAuthenticator, sessions SessionTracker) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
-				log.WithContext(r.Context()).Warn("missing authorization header")
-				appErr := apperrors.Unauthorized("missing authorization header")
+			var tokenString string
+
+			if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+				tokenString = apiKey
+			} else {
+				authHeader := r.Header.Get("Authorization")
+				if authHeader == "" {
+					log.WithContext(r.Context()).Warn("missing authorization header")
+					appErr := apperrors.Unauthorized("missing authorization header")
+					writeJSONError(w, appErr)
+					return
+				}
+
+				bearerToken := strings.Split(authHeader, " ")
+				if len(bearerToken) != 2 || strings.ToLower(bearerToken[0]) != "bearer" {
+					log.WithContext(r.Context()).Warn("invalid authorization header format")
+					appErr := apperrors.Unauthorized("invalid authorization header format")
+					writeJSONError(w, appErr)
+					return
+				}
+
+				tokenString = bearerToken[1]
+			}
+
+			domainClaims, appErr := Authenticate(r.Context(), tokenString, jwtSecrets, apiKeys, sessions)
+			if appErr != nil {
+				log.WithContext(r.Context()).Warn(appErr.Message)
 				writeJSONError(w, appErr)
 				return
 			}
 
-			bearerToken := strings.Split(authHeader, " ")
-			if len(bearerToken) != 2 || strings.ToLower(bearerToken[0]) != "bearer" {
-				log.WithContext(r.Context()).Warn("invalid authorization header format")
-				appErr := apperrors.Unauthorized("invalid authorization header format")
+			if tenant, ok := GetTenant(r.Context()); ok && tenant.TenantID != domainClaims.TenantID {
+				log.WithContext(r.Context()).WithField("token_tenant_id", domainClaims.TenantID).Warn("token tenant does not match request tenant")
+				appErr := apperrors.Unauthorized("invalid or expired token")
 				writeJSONError(w, appErr)
 				return
 			}
 
-			tokenString := bearerToken[1]
-			claims := jwt.MapClaims{}
+			ctx := WithClaims(r.Context(), domainClaims)
 
-			token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, apperrors.Unauthorized("invalid signing method")
-				}
-				return []byte(jwtSecret), nil
-			})
+			if rw := GetResponseWriter(w); rw != nil {
+				rw.SetUserID(domainClaims.UserID)
+			}
 
-			if err != nil || !token.Valid {
-				log.WithContext(r.Context()).Warn("invalid or expired token")
-				appErr := apperrors.Unauthorized("invalid or expired token")
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Authenticate resolves a bearer credential, either an API key or an access
+// token signed with any of jwtSecrets, to the caller's claims. It does not
+// check the request tenant.
+func Authenticate(ctx context.Context, credential string, jwtSecrets []string, apiKeys APIKeyAuthenticator, sessions SessionTracker) (*domain.Claims, *apperrors.AppError) {
+	if apiKeys != nil && strings.HasPrefix(credential, domain.APIKeyPrefix) {
+		claims, err := apiKeys.AuthenticateAPIKey(ctx, credential)
+		if err != nil {
+			return nil, apperrors.Unauthorized("invalid or expired api key")
+		}
+		return claims, nil
+	}
+
+	claims, appErr := parseAccessToken(credential, jwtSecrets)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if sessions != nil {
+		if err := sessions.TouchSession(ctx, claims); err != nil {
+			return nil, apperrors.Unauthorized("session has ended")
+		}
+	}
+	return claims, nil
+}
+
+// WithClaims stores an authenticated caller's claims, user id and, for
+// impersonated tokens, the actor behind them.
+func WithClaims(ctx context.Context, claims *domain.Claims) context.Context {
+	ctx = context.WithValue(ctx, ClaimsKey, claims)
+	ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
+	if claims.Actor != nil {
+		ctx = context.WithValue(ctx, ActorKey, claims.Actor)
+	}
+	return ctx
+}
+
+// RefuseImpersonation rejects impersonated tokens on routes whose effects
+// outlive the impersonation, such as changing credentials. It must run
+// inside Auth.
+func RefuseImpersonation(log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if actor := domain.ActorFromContext(r.Context()); actor != nil {
+				log.WithContext(r.Context()).WithField("actor_id", actor.UserID).Warn("impersonated request refused")
+				appErr := apperrors.Forbidden("not allowed while impersonating")
 				writeJSONError(w, appErr)
 				return
 			}
 
-			userIDStr, ok := claims["user_id"].(string)
-			if !ok {
-				log.WithContext(r.Context()).Warn("user_id not found in token claims")
-				appErr := apperrors.Unauthorized("invalid token claims")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RefuseAPIKey rejects requests authenticated with an API key. Keys carry
+// their owner's role, so routes a key must not reach whatever its scopes,
+// such as the admin API, are guarded with this.
+func RefuseAPIKey(log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(ClaimsKey).(*domain.Claims)
+			if !ok || claims.Type == "api_key" {
+				log.WithContext(r.Context()).Warn("API key request refused")
+				appErr := apperrors.Forbidden("not allowed with an API key")
 				writeJSONError(w, appErr)
 				return
 			}
 
-			userID, err := uuid.Parse(userIDStr)
-			if err != nil {
-				log.WithContext(r.Context()).Warn("invalid user_id format in token")
-				appErr := apperrors.Unauthorized("invalid token claims")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func RequireScope(log *logger.Logger, scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(ClaimsKey).(*domain.Claims)
+			if !ok || !claims.HasScope(scope) {
+				log.WithContext(r.Context()).WithField("scope", scope).Warn("missing required scope")
+				appErr := apperrors.Forbidden("insufficient scope")
 				writeJSONError(w, appErr)
 				return
 			}
 
-			username, _ := claims["username"].(string)
-			email, _ := claims["email"].(string)
-			tokenType, _ := claims["type"].(string)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
-			domainClaims := &domain.Claims{
-				UserID:   userID,
-				Username: username,
-				Email:    email,
-				Type:     tokenType,
+func RequireRole(log *logger.Logger, role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(ClaimsKey).(*domain.Claims)
+			if !ok || claims.Role != role {
+				log.WithContext(r.Context()).WithField("role", role).Warn("missing required role")
+				appErr := apperrors.Forbidden("insufficient privileges")
+				writeJSONError(w, appErr)
+				return
 			}
 
-			ctx := context.WithValue(r.Context(), ClaimsKey, domainClaims)
-			ctx = context.WithValue(ctx, UserIDKey, userID)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func parseAccessToken(tokenString string, jwtSecrets []string) (*domain.Claims, *apperrors.AppError) {
+	claims := jwt.MapClaims{}
 
-			if rw := GetResponseWriter(w); rw != nil {
-				rw.SetUserID(userID)
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, apperrors.Unauthorized("invalid signing method")
+		}
+		keys := jwt.VerificationKeySet{}
+		for _, secret := range jwtSecrets {
+			keys.Keys = append(keys.Keys, []byte(secret))
+		}
+		return keys, nil
+	})
+
+	if err != nil || !token.Valid {
+		return nil, apperrors.Unauthorized("invalid or expired token")
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		return nil, apperrors.Unauthorized("invalid token claims")
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return nil, apperrors.Unauthorized("invalid token claims")
+	}
+
+	tenantID := domain.DefaultTenantID
+	if tenantIDStr, ok := claims["tenant_id"].(string); ok {
+		if parsed, err := uuid.Parse(tenantIDStr); err == nil && parsed != uuid.Nil {
+			tenantID = parsed
+		}
+	}
+
+	username, _ := claims["username"].(string)
+	email, _ := claims["email"].(string)
+	role, _ := claims["role"].(string)
+	tokenType, _ := claims["type"].(string)
+
+	var amr []string
+	if methods, ok := claims["amr"].([]interface{}); ok {
+		for _, method := range methods {
+			if m, ok := method.(string); ok {
+				amr = append(amr, m)
 			}
+		}
+	}
 
-			next.ServeHTTP(w, r.WithContext(ctx))
-		})
+	var sessionID *uuid.UUID
+	if sid, ok := claims["sid"].(string); ok {
+		id, err := uuid.Parse(sid)
+		if err != nil {
+			return nil, apperrors.Unauthorized("invalid token claims")
+		}
+		sessionID = &id
+	}
+
+	var actor *domain.Actor
+	if act, ok := claims["act"].(map[string]interface{}); ok {
+		actorIDStr, _ := act["sub"].(string)
+		actorID, err := uuid.Parse(actorIDStr)
+		if err != nil {
+			return nil, apperrors.Unauthorized("invalid token claims")
+		}
+		actorUsername, _ := act["username"].(string)
+		actor = &domain.Actor{UserID: actorID, Username: actorUsername}
+		if sid, ok := act["sid"].(string); ok {
+			id, err := uuid.Parse(sid)
+			if err != nil {
+				return nil, apperrors.Unauthorized("invalid token claims")
+			}
+			actor.SessionID = &id
+		}
 	}
+
+	return &domain.Claims{
+		UserID:    userID,
+		TenantID:  tenantID,
+		Username:  username,
+		Email:     email,
+		Role:      role,
+		Type:      tokenType,
+		Actor:     actor,
+		AMR:       amr,
+		SessionID: sessionID,
+	}, nil
+}
+
+// Origins is the CORS origin allowlist. It can be replaced while the server
+// is running.
+type Origins struct {
+	origins atomic.Pointer[[]string]
+}
+
+func NewOrigins(origins []string) *Origins {
+	o := &Origins{}
+	o.Set(origins)
+	return o
 }
 
-func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
+func (o *Origins) Set(origins []string) {
+	o.origins.Store(&origins)
+}
+
+func (o *Origins) Get() []string {
+	return *o.origins.Load()
+}
+
+func CORS(allowedOrigins *Origins, extraHeaders ...string) func(http.Handler) http.Handler {
+	allowedHeaders := strings.Join(append([]string{"Content-Type", "Authorization"}, extraHeaders...), ", ")
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			origin := r.Header.Get("Origin")
 
-			if origin != "" && contains(allowedOrigins, origin) {
+			if origin != "" && contains(allowedOrigins.Get(), origin) {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
 			}
 
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
 			w.Header().Set("Access-Control-Max-Age", "3600")
 
 			if r.Method == http.MethodOptions {
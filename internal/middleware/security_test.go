@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auth-service/internal/domain"
+	"auth-service/pkg/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func serveWithClaims(h http.Handler, claims *domain.Claims) int {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit-events", nil)
+	if claims != nil {
+		req = req.WithContext(context.WithValue(req.Context(), ClaimsKey, claims))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAdminGuardRefusesAPIKeys(t *testing.T) {
+	log := logger.New("error", "json", "")
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	guard := RefuseAPIKey(log)(RequireRole(log, domain.RoleAdmin)(ok))
+
+	tests := []struct {
+		name   string
+		claims *domain.Claims
+		want   int
+	}{
+		{"admin session", &domain.Claims{Type: "access", Role: domain.RoleAdmin}, http.StatusNoContent},
+		{"admin API key", &domain.Claims{Type: "api_key", Role: domain.RoleAdmin}, http.StatusForbidden},
+		{"scoped admin API key", &domain.Claims{Type: "api_key", Role: domain.RoleAdmin, Scopes: []string{domain.ScopeProfileRead}}, http.StatusForbidden},
+		{"user session", &domain.Claims{Type: "access", Role: domain.RoleUser}, http.StatusForbidden},
+		{"no claims", nil, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serveWithClaims(guard, tt.claims); got != tt.want {
+				t.Fatalf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAccessTokenWithPreviousSecret(t *testing.T) {
+	current, previous := strings.Repeat("c", 32), strings.Repeat("p", 32)
+	userID := uuid.New()
+	sign := func(secret string) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id": userID.String(),
+			"type":    "access",
+			"exp":     time.Now().Add(time.Minute).Unix(),
+		}).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return token
+	}
+
+	for _, secret := range []string{current, previous} {
+		claims, appErr := parseAccessToken(sign(secret), []string{current, previous})
+		if appErr != nil {
+			t.Fatalf("parseAccessToken: %v", appErr)
+		}
+		if claims.UserID != userID {
+			t.Fatalf("user id = %s, want %s", claims.UserID, userID)
+		}
+	}
+
+	if _, appErr := parseAccessToken(sign(previous), []string{current}); appErr == nil {
+		t.Fatal("token signed with a retired secret accepted")
+	}
+	if _, appErr := parseAccessToken(sign(strings.Repeat("x", 32)), []string{current, previous}); appErr == nil {
+		t.Fatal("token signed with an unknown secret accepted")
+	}
+}
@@ -8,21 +8,26 @@ import (
 
 func SessionMetadata(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
-
-		ipAddress := getClientIP(r)
-		ctx = context.WithValue(ctx, IPAddressKey, ipAddress)
-
-		userAgent := r.Header.Get("User-Agent")
-		ctx = context.WithValue(ctx, UserAgentKey, userAgent)
-
-		deviceInfo := parseDeviceInfo(userAgent)
-		ctx = context.WithValue(ctx, DeviceInfoKey, deviceInfo)
-
+		ctx := WithSessionMetadata(r.Context(), getClientIP(r), r.Header.Get("User-Agent"), r.Header.Get(ClientIDHeader))
+		ctx = context.WithValue(ctx, AcceptLanguageKey, r.Header.Get("Accept-Language"))
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// ClientIDHeader names the client app making a request, which selects its
+// JWT lifetime profile. Any caller can send any value.
+const ClientIDHeader = "X-Client-ID"
+
+// WithSessionMetadata stores the client address, user agent and the device
+// derived from it, and the client ID, for the service layer to record on
+// sessions.
+func WithSessionMetadata(ctx context.Context, ipAddress, userAgent, clientID string) context.Context {
+	ctx = context.WithValue(ctx, IPAddressKey, ipAddress)
+	ctx = context.WithValue(ctx, UserAgentKey, userAgent)
+	ctx = context.WithValue(ctx, ClientIDKey, clientID)
+	return context.WithValue(ctx, DeviceInfoKey, parseDeviceInfo(userAgent))
+}
+
 func parseDeviceInfo(userAgent string) string {
 	if userAgent == "" {
 		return "Unknown"
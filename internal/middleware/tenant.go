@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+)
+
+type TenantResolver interface {
+	Resolve(ctx context.Context, identifier, host string) (*domain.Tenant, error)
+}
+
+func Tenant(log *logger.Logger, resolver TenantResolver, header string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant, err := resolver.Resolve(r.Context(), r.Header.Get(header), r.Host)
+			if err != nil {
+				appErr, ok := err.(*apperrors.AppError)
+				if !ok {
+					appErr = apperrors.Internal("failed to resolve tenant")
+				}
+				log.WithContext(r.Context()).WithError(err).Warn("tenant resolution failed")
+				writeJSONError(w, appErr)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), TenantKey, tenant)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func GetTenant(ctx context.Context) (*domain.Tenant, bool) {
+	tenant, ok := ctx.Value(TenantKey).(*domain.Tenant)
+	return tenant, ok
+}
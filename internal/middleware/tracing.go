@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"auth-service/internal/tracing"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Tracing starts the server span for each request, continuing any trace
+// supplied in an incoming traceparent header. Spans are named after the mux
+// pattern that will serve the request.
+func Tracing(mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return otelhttp.NewHandler(next, "http.server",
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				if _, pattern := mux.Handler(r); pattern != "" {
+					return pattern
+				}
+				return r.Method + " unmatched"
+			}),
+		)
+	}
+}
+
+// Traced wraps a middleware so that it, and everything it calls, runs inside
+// a span named after the stage.
+func Traced(name string, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		handler := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := tracing.Start(r.Context(), "middleware."+name)
+			defer span.End()
+
+			handler.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
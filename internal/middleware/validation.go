@@ -3,17 +3,28 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"slices"
 	"time"
 
 	apperrors "auth-service/pkg/errors"
 	"auth-service/pkg/logger"
 )
 
-func ValidateContentType(log *logger.Logger, contentType string) func(http.Handler) http.Handler {
+// FormContentType is the body encoding of OAuth endpoints.
+const FormContentType = "application/x-www-form-urlencoded"
+
+// ValidateContentType requires request bodies to be of contentType. The
+// routes in formRoutes, given as "METHOD /path", accept form-encoded bodies
+// as well.
+func ValidateContentType(log *logger.Logger, contentType string, formRoutes ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch {
 				ct := r.Header.Get("Content-Type")
+				if ct == FormContentType && slices.Contains(formRoutes, r.Method+" "+r.URL.Path) {
+					next.ServeHTTP(w, r)
+					return
+				}
 				if ct != contentType {
 					log.WithContext(r.Context()).WithFields(map[string]interface{}{
 						"received": ct,
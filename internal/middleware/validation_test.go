@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"auth-service/pkg/logger"
+)
+
+func TestValidateContentTypeFormRoutes(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	h := ValidateContentType(logger.New("error", "json", ""), "application/json", "POST /api/v1/admin/token-exchange")(ok)
+
+	tests := []struct {
+		path        string
+		contentType string
+		want        int
+	}{
+		{"/api/v1/admin/token-exchange", FormContentType, http.StatusNoContent},
+		{"/api/v1/admin/token-exchange", "application/json", http.StatusNoContent},
+		{"/api/v1/admin/token-exchange", "text/plain", http.StatusBadRequest},
+		{"/api/v1/auth/login", FormContentType, http.StatusBadRequest},
+		{"/api/v1/auth/login", "application/json", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(""))
+		req.Header.Set("Content-Type", tt.contentType)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("POST %s as %s: status %d, want %d", tt.path, tt.contentType, rec.Code, tt.want)
+		}
+	}
+}
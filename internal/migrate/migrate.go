@@ -0,0 +1,338 @@
+// Package migrate applies the versioned SQL files in migrations/ and records
+// them in a schema_migrations table.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"auth-service/pkg/logger"
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // sha256 of Up
+}
+
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Modified  bool       `json:"modified"` // applied checksum differs from the embedded file
+}
+
+// Load reads NNN_name.up.sql and NNN_name.down.sql pairs from fsys, ordered by
+// version. Down files are optional; Down fails on migrations without one.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		sum := sha256.Sum256([]byte(m.Up))
+		m.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(a, b int) bool { return migrations[a].Version < migrations[b].Version })
+
+	return migrations, nil
+}
+
+// driver holds the database-specific parts of migrating.
+type driver interface {
+	// lock serialises migrators sharing the database until unlock is called.
+	lock(ctx context.Context) (unlock func(), err error)
+	// prepare creates the tracking table if it does not exist.
+	prepare(ctx context.Context) error
+	// records returns the applied migrations by version; a database that has
+	// never been migrated has none.
+	records(ctx context.Context) (map[int64]record, error)
+	setChecksum(ctx context.Context, migration Migration) error
+	// apply runs script and inserts (up) or deletes (down) the migration's
+	// record in one transaction, so a failed migration leaves neither schema
+	// changes nor a schema_migrations row behind.
+	apply(ctx context.Context, script string, migration Migration, up bool) error
+}
+
+type record struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+type Migrator struct {
+	driver     driver
+	migrations []Migration
+	log        *logger.Logger
+}
+
+func newMigrator(d driver, fsys fs.FS, log *logger.Logger) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{driver: d, migrations: migrations, log: log}, nil
+}
+
+// Latest returns the highest version embedded in this build.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration in version order, each in its own
+// transaction, and returns how many were applied. It refuses to run if an
+// applied migration no longer matches its file.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func() error {
+		records, err := m.prepare(ctx)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(records); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := records[migration.Version]; ok {
+				continue
+			}
+
+			start := time.Now()
+			if err := m.driver.apply(ctx, migration.Up, migration, true); err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			applied++
+			m.log.WithFields(map[string]interface{}{
+				"version":     migration.Version,
+				"name":        migration.Name,
+				"duration_ms": time.Since(start).Milliseconds(),
+			}).Info("applied migration")
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the most recently applied steps migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	if steps <= 0 {
+		return 0, fmt.Errorf("steps must be positive")
+	}
+
+	reverted := 0
+	err := m.withLock(ctx, func() error {
+		records, err := m.prepare(ctx)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(records))
+		for version := range records {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(a, b int) bool { return versions[a] > versions[b] })
+
+		for _, version := range versions {
+			if reverted == steps {
+				break
+			}
+
+			migration, ok := m.find(version)
+			if !ok {
+				return fmt.Errorf("migration %d is applied but not included in this build", version)
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+
+			if err := m.driver.apply(ctx, migration.Down, migration, false); err != nil {
+				return fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			reverted++
+			m.log.WithFields(map[string]interface{}{
+				"version": migration.Version,
+				"name":    migration.Name,
+			}).Info("reverted migration")
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every embedded migration alongside its applied state, followed
+// by any applied versions this build does not know about.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	records, err := m.driver.records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if record, ok := records[migration.Version]; ok {
+			appliedAt := record.appliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			status.Modified = record.checksum != "" && record.checksum != migration.Checksum
+			delete(records, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for version, record := range records {
+		appliedAt := record.appliedAt
+		statuses = append(statuses, Status{Version: version, Name: record.name, Applied: true, AppliedAt: &appliedAt})
+	}
+	sort.Slice(statuses, func(a, b int) bool { return statuses[a].Version < statuses[b].Version })
+
+	return statuses, nil
+}
+
+// Check returns an error while any embedded migration is unapplied or has been
+// modified since it was applied. Versions newer than this build are allowed so
+// older replicas stay ready during a rolling deploy.
+func (m *Migrator) Check(ctx context.Context) error {
+	records, err := m.driver.records(ctx)
+	if err != nil {
+		return err
+	}
+	if err := m.verify(records); err != nil {
+		return err
+	}
+
+	pending := 0
+	for _, migration := range m.migrations {
+		if _, ok := records[migration.Version]; !ok {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d pending migrations", pending)
+	}
+	return nil
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// verify compares applied checksums with the embedded files. Rows without a
+// checksum were recorded before checksums existed and are adopted by prepare.
+func (m *Migrator) verify(records map[int64]record) error {
+	for _, migration := range m.migrations {
+		record, ok := records[migration.Version]
+		if !ok || record.checksum == "" {
+			continue
+		}
+		if record.checksum != migration.Checksum {
+			return fmt.Errorf("%w: %d_%s was modified after it was applied", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	unlock, err := m.driver.lock(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer unlock()
+
+	return fn()
+}
+
+// prepare creates the tracking table and fills in checksums missing from rows
+// recorded before checksums existed.
+func (m *Migrator) prepare(ctx context.Context) (map[int64]record, error) {
+	if err := m.driver.prepare(ctx); err != nil {
+		return nil, err
+	}
+
+	records, err := m.driver.records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range m.migrations {
+		record, ok := records[migration.Version]
+		if !ok || record.checksum != "" {
+			continue
+		}
+		if err := m.driver.setChecksum(ctx, migration); err != nil {
+			return nil, fmt.Errorf("failed to record checksum for migration %d: %w", migration.Version, err)
+		}
+		record.name = migration.Name
+		record.checksum = migration.Checksum
+		records[migration.Version] = record
+	}
+
+	return records, nil
+}
@@ -0,0 +1,147 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"auth-service/internal/config"
+	"auth-service/pkg/logger"
+)
+
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"001_create_widgets.up.sql":   {Data: []byte(`CREATE TABLE widgets (id INTEGER PRIMARY KEY, name TEXT NOT NULL);`)},
+		"001_create_widgets.down.sql": {Data: []byte(`DROP TABLE widgets;`)},
+		"002_seed_widgets.up.sql":     {Data: []byte(`INSERT INTO widgets (name) VALUES ('first');`)},
+		"002_seed_widgets.down.sql":   {Data: []byte(`DELETE FROM widgets;`)},
+	}
+}
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := config.NewSQLiteConnection(&config.DatabaseConfig{
+		SQLitePath: filepath.Join(t.TempDir(), "migrate.db"),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func newTestMigrator(t *testing.T, db *sql.DB, fsys fstest.MapFS) *Migrator {
+	t.Helper()
+	migrator, err := NewSQLite(db, fsys, logger.New("error", "json", ""))
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	return migrator
+}
+
+func count(t *testing.T, db *sql.DB, table string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&n); err != nil {
+		t.Fatalf("count %s: %v", table, err)
+	}
+	return n
+}
+
+func TestUpIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	migrator := newTestMigrator(t, db, testMigrations())
+
+	applied, err := migrator.Up(ctx)
+	if err != nil || applied != 2 {
+		t.Fatalf("Up = %d, %v, want 2 applied", applied, err)
+	}
+	before, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+
+	// A second run, and a fresh migrator as after a restart, change nothing.
+	for _, m := range []*Migrator{migrator, newTestMigrator(t, db, testMigrations())} {
+		applied, err := m.Up(ctx)
+		if err != nil || applied != 0 {
+			t.Fatalf("Up again = %d, %v, want nothing applied", applied, err)
+		}
+	}
+	if n := count(t, db, "widgets"); n != 1 {
+		t.Fatalf("have %d widgets, want the seed applied once", n)
+	}
+	if n := count(t, db, "schema_migrations"); n != 2 {
+		t.Fatalf("have %d schema_migrations rows, want 2", n)
+	}
+	after, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for i := range after {
+		if !after[i].Applied || after[i].Modified || !after[i].AppliedAt.Equal(*before[i].AppliedAt) {
+			t.Fatalf("status of %d changed from %+v to %+v", after[i].Version, before[i], after[i])
+		}
+	}
+	if err := migrator.Check(ctx); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+}
+
+func TestUpRefusesModifiedMigration(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	if _, err := newTestMigrator(t, db, testMigrations()).Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	modified := testMigrations()
+	modified["002_seed_widgets.up.sql"] = &fstest.MapFile{Data: []byte(`INSERT INTO widgets (name) VALUES ('changed');`)}
+	modified["003_add_widget_color.up.sql"] = &fstest.MapFile{Data: []byte(`ALTER TABLE widgets ADD COLUMN color TEXT;`)}
+	migrator := newTestMigrator(t, db, modified)
+
+	applied, err := migrator.Up(ctx)
+	if !errors.Is(err, ErrChecksumMismatch) || applied != 0 {
+		t.Fatalf("Up with a modified migration = %d, %v, want ErrChecksumMismatch", applied, err)
+	}
+	if err := migrator.Check(ctx); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Check = %v, want ErrChecksumMismatch", err)
+	}
+	if n := count(t, db, "schema_migrations"); n != 2 {
+		t.Fatalf("have %d schema_migrations rows, want migration 3 left unapplied", n)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for _, status := range statuses {
+		if wantModified := status.Version == 2; status.Modified != wantModified {
+			t.Fatalf("status %+v, want modified %t", status, wantModified)
+		}
+	}
+}
+
+func TestUpRollsBackFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	broken := testMigrations()
+	broken["003_broken.up.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE gadgets (id INTEGER PRIMARY KEY); INSERT INTO missing_table VALUES (1);`)}
+	applied, err := newTestMigrator(t, db, broken).Up(ctx)
+	if err == nil || applied != 2 {
+		t.Fatalf("Up = %d, %v, want migration 3 to fail after 2 applied", applied, err)
+	}
+
+	if n := count(t, db, "schema_migrations"); n != 2 {
+		t.Fatalf("have %d schema_migrations rows, want 2", n)
+	}
+	var tables int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'gadgets'`).Scan(&tables); err != nil || tables != 0 {
+		t.Fatalf("gadgets table left behind by the failed migration (%d, %v)", tables, err)
+	}
+}
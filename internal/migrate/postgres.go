@@ -0,0 +1,149 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"auth-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockID is the pg_advisory_lock key held while migrating, so replicas that
+// boot together apply each migration exactly once.
+const lockID int64 = 0x617574685f6d6967 // "auth_mig"
+
+// NewPostgres returns a Migrator recording applied versions in
+// public.schema_migrations, outside the users schema that migration 001
+// creates and drops.
+func NewPostgres(pool *pgxpool.Pool, fsys fs.FS, log *logger.Logger) (*Migrator, error) {
+	return newMigrator(&postgresDriver{pool: pool, log: log}, fsys, log)
+}
+
+type postgresDriver struct {
+	pool *pgxpool.Pool
+	log  *logger.Logger
+}
+
+func (d *postgresDriver) lock(ctx context.Context) (func(), error) {
+	conn, err := d.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	return func() {
+		// The lock is session scoped; unlock even if ctx has been cancelled.
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.Exec(unlockCtx, `SELECT pg_advisory_unlock($1)`, lockID); err != nil {
+			d.log.WithError(err).Error("failed to release migration lock")
+			conn.Conn().Close(unlockCtx)
+		}
+		conn.Release()
+	}, nil
+}
+
+// prepare also imports versions recorded by earlier releases in
+// users.schema_migrations.
+func (d *postgresDriver) prepare(ctx context.Context) error {
+	if _, err := d.pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS public.schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL DEFAULT '',
+			checksum VARCHAR(64) NOT NULL DEFAULT '',
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var legacy bool
+	if err := d.pool.QueryRow(ctx, `SELECT to_regclass('users.schema_migrations') IS NOT NULL`).Scan(&legacy); err != nil {
+		return fmt.Errorf("failed to look up legacy schema_migrations: %w", err)
+	}
+	if legacy {
+		if _, err := d.pool.Exec(ctx, `
+			INSERT INTO public.schema_migrations (version, applied_at)
+			SELECT version, applied_at FROM users.schema_migrations
+			ON CONFLICT (version) DO NOTHING;
+			DROP TABLE users.schema_migrations`); err != nil {
+			return fmt.Errorf("failed to import legacy schema_migrations: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (d *postgresDriver) records(ctx context.Context) (map[int64]record, error) {
+	var exists bool
+	if err := d.pool.QueryRow(ctx, `SELECT to_regclass('public.schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to look up schema_migrations: %w", err)
+	}
+	if !exists {
+		return map[int64]record{}, nil
+	}
+
+	rows, err := d.pool.Query(ctx, `
+		SELECT version, name, checksum, applied_at FROM public.schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	records := make(map[int64]record)
+	for rows.Next() {
+		var (
+			version int64
+			r       record
+		)
+		if err := rows.Scan(&version, &r.name, &r.checksum, &r.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		records[version] = r
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	return records, nil
+}
+
+func (d *postgresDriver) setChecksum(ctx context.Context, migration Migration) error {
+	_, err := d.pool.Exec(ctx, `
+		UPDATE public.schema_migrations SET name = $2, checksum = $3 WHERE version = $1`,
+		migration.Version, migration.Name, migration.Checksum,
+	)
+	return err
+}
+
+func (d *postgresDriver) apply(ctx context.Context, script string, migration Migration, up bool) error {
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return err
+	}
+	if up {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO public.schema_migrations (version, name, checksum)
+			VALUES ($1, $2, $3)`,
+			migration.Version, migration.Name, migration.Checksum,
+		)
+	} else {
+		_, err = tx.Exec(ctx, `DELETE FROM public.schema_migrations WHERE version = $1`, migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,111 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sync"
+	"time"
+
+	"auth-service/pkg/logger"
+)
+
+// NewSQLite returns a Migrator recording applied versions in the database's
+// schema_migrations table. SQLite databases are owned by a single process, so
+// the lock only serialises migrators within it.
+func NewSQLite(db *sql.DB, fsys fs.FS, log *logger.Logger) (*Migrator, error) {
+	return newMigrator(&sqliteDriver{db: db}, fsys, log)
+}
+
+type sqliteDriver struct {
+	db *sql.DB
+	mu sync.Mutex
+}
+
+func (d *sqliteDriver) lock(ctx context.Context) (func(), error) {
+	d.mu.Lock()
+	return d.mu.Unlock, nil
+}
+
+func (d *sqliteDriver) prepare(ctx context.Context) error {
+	if _, err := d.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL DEFAULT '',
+			checksum TEXT NOT NULL DEFAULT '',
+			applied_at TIMESTAMP NOT NULL
+		)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func (d *sqliteDriver) records(ctx context.Context) (map[int64]record, error) {
+	var exists bool
+	if err := d.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations')`,
+	).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to look up schema_migrations: %w", err)
+	}
+	if !exists {
+		return map[int64]record{}, nil
+	}
+
+	rows, err := d.db.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	records := make(map[int64]record)
+	for rows.Next() {
+		var (
+			version int64
+			r       record
+		)
+		if err := rows.Scan(&version, &r.name, &r.checksum, &r.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		records[version] = r
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	return records, nil
+}
+
+func (d *sqliteDriver) setChecksum(ctx context.Context, migration Migration) error {
+	_, err := d.db.ExecContext(ctx, `
+		UPDATE schema_migrations SET name = ?, checksum = ? WHERE version = ?`,
+		migration.Name, migration.Checksum, migration.Version,
+	)
+	return err
+}
+
+func (d *sqliteDriver) apply(ctx context.Context, script string, migration Migration, up bool) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO schema_migrations (version, name, checksum, applied_at)
+			VALUES (?, ?, ?, ?)`,
+			migration.Version, migration.Name, migration.Checksum, time.Now().UTC(),
+		)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+const memoryShards = 32
+
+// MemoryStore keeps token buckets in process memory. Keys are spread over
+// shards to reduce lock contention, and each shard evicts its least recently
+// used bucket once full, so memory is bounded by maxKeys.
+type MemoryStore struct {
+	shards [memoryShards]*memoryShard
+	now    func() time.Time
+}
+
+type memoryShard struct {
+	mu      sync.Mutex
+	maxKeys int
+	items   map[string]*list.Element
+	lru     *list.List
+}
+
+type bucket struct {
+	key     string
+	tokens  float64
+	updated time.Time
+}
+
+func NewMemoryStore(maxKeys int) *MemoryStore {
+	perShard := maxKeys / memoryShards
+	if perShard < 1 {
+		perShard = 1
+	}
+
+	s := &MemoryStore{now: time.Now}
+	for i := range s.shards {
+		s.shards[i] = &memoryShard{
+			maxKeys: perShard,
+			items:   make(map[string]*list.Element),
+			lru:     list.New(),
+		}
+	}
+	return s
+}
+
+func (s *MemoryStore) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	shard := s.shard(key)
+	now := s.now()
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	var b *bucket
+	if el, ok := shard.items[key]; ok {
+		b = el.Value.(*bucket)
+		shard.lru.MoveToFront(el)
+	} else {
+		if shard.lru.Len() >= shard.maxKeys {
+			oldest := shard.lru.Back()
+			shard.lru.Remove(oldest)
+			delete(shard.items, oldest.Value.(*bucket).key)
+		}
+		b = &bucket{key: key, tokens: limit.capacity(), updated: now}
+		shard.items[key] = shard.lru.PushFront(b)
+	}
+
+	tokens, result := take(limit, b.tokens, now.Sub(b.updated))
+	b.tokens = tokens
+	b.updated = now
+
+	return result, nil
+}
+
+func (s *MemoryStore) shard(key string) *memoryShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return s.shards[h.Sum32()%memoryShards]
+}
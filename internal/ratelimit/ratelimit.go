@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Limit describes a token bucket: Burst tokens of capacity, refilled at Rate
+// tokens per Period.
+type Limit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+func PerMinute(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Minute, Burst: rate}
+}
+
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Rate)
+}
+
+// tokensPerSecond is the refill rate.
+func (l Limit) tokensPerSecond() float64 {
+	return float64(l.Rate) / l.Period.Seconds()
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next request would be allowed; zero when allowed
+}
+
+// take refills a bucket holding tokens, last updated elapsed ago, and tries to
+// consume one token. It returns the new token count and the outcome.
+func take(limit Limit, tokens float64, elapsed time.Duration) (float64, Result) {
+	if elapsed > 0 {
+		tokens = math.Min(limit.capacity(), tokens+elapsed.Seconds()*limit.tokensPerSecond())
+	}
+
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+
+	return tokens, resultFor(limit, tokens, allowed)
+}
+
+// resultFor describes a bucket left holding tokens after a request.
+func resultFor(limit Limit, tokens float64, allowed bool) Result {
+	capacity := limit.capacity()
+	rate := limit.tokensPerSecond()
+
+	result := Result{
+		Allowed:    allowed,
+		Limit:      int(capacity),
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: secondsToDuration((capacity - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
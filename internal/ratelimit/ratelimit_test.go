@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// clock is a settable time source shared by a store under test.
+type clock interface {
+	advance(d time.Duration)
+}
+
+type memoryClock struct{ store *MemoryStore }
+
+func (c memoryClock) advance(d time.Duration) {
+	now := c.store.now()
+	c.store.now = func() time.Time { return now.Add(d) }
+}
+
+type redisClock struct {
+	server *miniredis.Miniredis
+	now    *time.Time
+}
+
+func (c redisClock) advance(d time.Duration) {
+	*c.now = c.now.Add(d)
+	c.server.SetTime(*c.now)
+	c.server.FastForward(d)
+}
+
+type store interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+func newMemoryTestStore(t *testing.T) (store, clock) {
+	s := NewMemoryStore(1000)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	return s, memoryClock{store: s}
+}
+
+func newRedisTestStore(t *testing.T) (store, clock) {
+	server := miniredis.RunT(t)
+	now := time.Now()
+	server.SetTime(now)
+
+	s := NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr()}), "rl:")
+	t.Cleanup(func() { _ = s.Close() })
+	return s, redisClock{server: server, now: &now}
+}
+
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) (store, clock){
+		"memory": newMemoryTestStore,
+		"redis":  newRedisTestStore,
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Run("Burst", func(t *testing.T) { testBurst(t, newStore) })
+			t.Run("Refill", func(t *testing.T) { testRefill(t, newStore) })
+			t.Run("KeysAreIndependent", func(t *testing.T) { testKeysAreIndependent(t, newStore) })
+		})
+	}
+}
+
+func testBurst(t *testing.T, newStore func(t *testing.T) (store, clock)) {
+	ctx := context.Background()
+	s, _ := newStore(t)
+	limit := Limit{Rate: 6, Period: time.Minute, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		result, err := s.Allow(ctx, "ip:1", limit)
+		if err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		if !result.Allowed || result.Remaining != 2-i || result.Limit != 3 {
+			t.Fatalf("request %d: %+v, want allowed with %d remaining", i+1, result, 2-i)
+		}
+	}
+
+	result, err := s.Allow(ctx, "ip:1", limit)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if result.Allowed {
+		t.Fatalf("request 4: %+v, want refused", result)
+	}
+	// One token comes back every 10 seconds.
+	if result.RetryAfter <= 0 || result.RetryAfter > 10*time.Second {
+		t.Fatalf("retry after %s, want at most 10s", result.RetryAfter)
+	}
+}
+
+func testRefill(t *testing.T, newStore func(t *testing.T) (store, clock)) {
+	ctx := context.Background()
+	s, clk := newStore(t)
+	limit := PerMinute(2)
+
+	for i := 0; i < 2; i++ {
+		if result, err := s.Allow(ctx, "user:1", limit); err != nil || !result.Allowed {
+			t.Fatalf("request %d: %+v, %v", i+1, result, err)
+		}
+	}
+	if result, _ := s.Allow(ctx, "user:1", limit); result.Allowed {
+		t.Fatal("request 3 allowed before the refill")
+	}
+
+	clk.advance(31 * time.Second)
+	if result, err := s.Allow(ctx, "user:1", limit); err != nil || !result.Allowed {
+		t.Fatalf("after 31s: %+v, %v, want one token back", result, err)
+	}
+	if result, _ := s.Allow(ctx, "user:1", limit); result.Allowed {
+		t.Fatal("two tokens back after 31s")
+	}
+
+	clk.advance(5 * time.Minute)
+	result, err := s.Allow(ctx, "user:1", limit)
+	if err != nil || !result.Allowed || result.Remaining != 1 {
+		t.Fatalf("after 5m: %+v, %v, want a full bucket", result, err)
+	}
+}
+
+func testKeysAreIndependent(t *testing.T, newStore func(t *testing.T) (store, clock)) {
+	ctx := context.Background()
+	s, _ := newStore(t)
+	limit := PerMinute(1)
+
+	if result, _ := s.Allow(ctx, "a", limit); !result.Allowed {
+		t.Fatal("first request for a refused")
+	}
+	if result, _ := s.Allow(ctx, "a", limit); result.Allowed {
+		t.Fatal("second request for a allowed")
+	}
+	if result, _ := s.Allow(ctx, "b", limit); !result.Allowed {
+		t.Fatal("first request for b refused")
+	}
+}
+
+func TestRedisStoreSharedBetweenReplicas(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	limit := PerMinute(2)
+
+	replicas := []*RedisStore{
+		NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr()}), "rl:"),
+		NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr()}), "rl:"),
+	}
+	for _, s := range replicas {
+		defer s.Close()
+	}
+
+	for i, s := range replicas {
+		if result, err := s.Allow(ctx, "ip:1", limit); err != nil || !result.Allowed {
+			t.Fatalf("replica %d: %+v, %v", i, result, err)
+		}
+	}
+	for i, s := range replicas {
+		if result, _ := s.Allow(ctx, "ip:1", limit); result.Allowed {
+			t.Fatalf("replica %d allowed a third request", i)
+		}
+	}
+
+	// Buckets expire once they would be full again.
+	if ttl := server.TTL("rl:ip:1"); ttl <= 0 || ttl > 62*time.Second {
+		t.Fatalf("bucket ttl %s, want about a minute", ttl)
+	}
+}
+
+func TestRedisStoreUnavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	s := NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1}), "rl:")
+	defer s.Close()
+	server.Close()
+
+	if _, err := s.Allow(context.Background(), "ip:1", PerMinute(1)); err == nil {
+		t.Fatal("Allow succeeded without a server")
+	}
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript mirrors take() atomically on the server. The bucket is a
+// hash of tokens and last update time (milliseconds, from the server clock so
+// replicas agree); it expires once it would have refilled completely.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1])
+local updated = tonumber(state[2])
+if tokens == nil then
+	tokens = capacity
+	updated = now
+end
+
+local elapsed = math.max(0, now - updated) / 1000
+tokens = math.min(capacity, tokens + elapsed * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', string.format('%.6f', tokens), 'updated', string.format('%d', now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((capacity - tokens) / rate * 1000) + 1000)
+
+return {allowed, string.format('%.6f', tokens)}
+`)
+
+// RedisStore shares token buckets between replicas through any server that
+// speaks the Redis protocol and supports EVAL.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	values, err := tokenBucketScript.Run(ctx, s.client, []string{s.prefix + key},
+		limit.capacity(), limit.tokensPerSecond(),
+	).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to evaluate rate limit: %w", err)
+	}
+	if len(values) != 2 {
+		return Result{}, fmt.Errorf("unexpected rate limit script reply: %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	str, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid token count %q: %w", str, err)
+	}
+
+	return resultFor(limit, tokens, allowed == 1), nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
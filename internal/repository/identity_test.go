@@ -0,0 +1,143 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/repository"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// testLoginMethods checks that Delete and DeletePassword never leave a user
+// without a login method, even when called concurrently.
+func testLoginMethods(t *testing.T, users repository.UserRepository, identities repository.IdentityRepository) {
+	ctx := context.Background()
+
+	t.Run("LastIdentity", func(t *testing.T) {
+		user := createLoginMethodUser(t, users, false)
+		first := linkIdentity(t, identities, user)
+		second := linkIdentity(t, identities, user)
+
+		if err := identities.Delete(ctx, user.TenantID, user.UserID, first.IdentityID); err != nil {
+			t.Fatalf("Delete first identity: %v", err)
+		}
+		if err := identities.Delete(ctx, user.TenantID, user.UserID, second.IdentityID); !errors.Is(err, repository.ErrLastLoginMethod) {
+			t.Fatalf("Delete last identity = %v, want ErrLastLoginMethod", err)
+		}
+		if err := identities.Delete(ctx, user.TenantID, user.UserID, first.IdentityID); !isNotFound(err) {
+			t.Fatalf("Delete deleted identity = %v, want not found", err)
+		}
+	})
+
+	t.Run("LastIdentityWithPassword", func(t *testing.T) {
+		user := createLoginMethodUser(t, users, true)
+		identity := linkIdentity(t, identities, user)
+
+		if err := identities.Delete(ctx, user.TenantID, user.UserID, identity.IdentityID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+	})
+
+	t.Run("Password", func(t *testing.T) {
+		user := createLoginMethodUser(t, users, true)
+		if err := identities.DeletePassword(ctx, user.TenantID, user.UserID); !errors.Is(err, repository.ErrLastLoginMethod) {
+			t.Fatalf("DeletePassword without identities = %v, want ErrLastLoginMethod", err)
+		}
+
+		linkIdentity(t, identities, user)
+		if err := identities.DeletePassword(ctx, user.TenantID, user.UserID); err != nil {
+			t.Fatalf("DeletePassword: %v", err)
+		}
+		stored, err := users.GetByID(ctx, user.TenantID, user.UserID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if stored.HasPassword() {
+			t.Fatal("password kept")
+		}
+		if err := identities.DeletePassword(ctx, user.TenantID, user.UserID); !isNotFound(err) {
+			t.Fatalf("DeletePassword again = %v, want not found", err)
+		}
+		if err := identities.DeletePassword(ctx, user.TenantID, uuid.New()); !isNotFound(err) {
+			t.Fatalf("DeletePassword of unknown user = %v, want not found", err)
+		}
+	})
+
+	t.Run("ConcurrentRemovals", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			user := createLoginMethodUser(t, users, true)
+			identity := linkIdentity(t, identities, user)
+
+			errs := make([]error, 2)
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				errs[0] = identities.Delete(ctx, user.TenantID, user.UserID, identity.IdentityID)
+			}()
+			go func() {
+				defer wg.Done()
+				errs[1] = identities.DeletePassword(ctx, user.TenantID, user.UserID)
+			}()
+			wg.Wait()
+
+			removed := 0
+			for _, err := range errs {
+				switch {
+				case err == nil:
+					removed++
+				case !errors.Is(err, repository.ErrLastLoginMethod):
+					t.Fatalf("removal failed: %v", err)
+				}
+			}
+			if removed != 1 {
+				t.Fatalf("%d of the user's 2 login methods removed, want 1", removed)
+			}
+		}
+	})
+}
+
+func createLoginMethodUser(t *testing.T, users repository.UserRepository, withPassword bool) *domain.User {
+	t.Helper()
+	suffix := strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+	user := &domain.User{
+		TenantID: domain.DefaultTenantID,
+		Username: "user_" + suffix,
+		Email:    suffix + "@example.com",
+		FullName: "Test User",
+		Role:     domain.RoleUser,
+		IsActive: true,
+	}
+	if withPassword {
+		user.PasswordHash = "$2a$10$" + suffix
+	}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	return user
+}
+
+func linkIdentity(t *testing.T, identities repository.IdentityRepository, user *domain.User) *domain.UserIdentity {
+	t.Helper()
+	identity := &domain.UserIdentity{
+		TenantID: user.TenantID,
+		UserID:   user.UserID,
+		Provider: "test",
+		Subject:  uuid.NewString(),
+	}
+	if err := identities.Create(context.Background(), identity); err != nil {
+		t.Fatalf("Create identity: %v", err)
+	}
+	return identity
+}
+
+func isNotFound(err error) bool {
+	var appErr *apperrors.AppError
+	return errors.As(err, &appErr) && appErr.Code == apperrors.ErrCodeNotFound
+}
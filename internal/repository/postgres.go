@@ -11,6 +11,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -24,30 +25,39 @@ func NewPostgresUserRepository(db *pgxpool.Pool) *PostgresUserRepository {
 
 func (r *PostgresUserRepository) Create(ctx context.Context, user *domain.User) error {
 	query := `
-		INSERT INTO users (username, email, password_hash, full_name, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO users (tenant_id, username, email, password_hash, full_name, role, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9)
 		RETURNING user_id, created_at, updated_at
 	`
 
+	if user.TenantID == uuid.Nil {
+		user.TenantID = domain.DefaultTenantID
+	}
+	if user.Role == "" {
+		user.Role = domain.RoleUser
+	}
+
 	now := time.Now()
 	user.UserID = uuid.New()
 	err := r.db.QueryRow(
 		ctx,
 		query,
+		user.TenantID,
 		user.Username,
 		user.Email,
 		user.PasswordHash,
 		user.FullName,
+		user.Role,
 		user.IsActive,
 		now,
 		now,
 	).Scan(&user.UserID, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
-		if err.Error() == "ERROR: duplicate key value violates unique constraint \"users_username_key\" (SQLSTATE 23505)" {
+		if isUniqueViolation(err, "users_tenant_username_key") {
 			return apperrors.AlreadyExists("username")
 		}
-		if err.Error() == "ERROR: duplicate key value violates unique constraint \"users_email_key\" (SQLSTATE 23505)" {
+		if isUniqueViolation(err, "users_tenant_email_key") {
 			return apperrors.AlreadyExists("email")
 		}
 		return fmt.Errorf("failed to create user: %w", err)
@@ -56,25 +66,14 @@ func (r *PostgresUserRepository) Create(ctx context.Context, user *domain.User)
 	return nil
 }
 
-func (r *PostgresUserRepository) GetByID(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+func (r *PostgresUserRepository) GetByID(ctx context.Context, tenantID, userID uuid.UUID) (*domain.User, error) {
 	query := `
-		SELECT user_id, username, email, password_hash, full_name, is_active, created_at, updated_at
+		SELECT user_id, tenant_id, username, email, COALESCE(password_hash, ''), full_name, role, is_active, created_at, updated_at
 		FROM users
-		WHERE user_id = $1
+		WHERE tenant_id = $1 AND user_id = $2
 	`
 
-	user := &domain.User{}
-	err := r.db.QueryRow(ctx, query, userID).Scan(
-		&user.UserID,
-		&user.Username,
-		&user.Email,
-		&user.PasswordHash,
-		&user.FullName,
-		&user.IsActive,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
+	user, err := scanUser(r.db.QueryRow(ctx, query, tenantID, userID))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperrors.NotFound("user")
@@ -85,25 +84,14 @@ func (r *PostgresUserRepository) GetByID(ctx context.Context, userID uuid.UUID)
 	return user, nil
 }
 
-func (r *PostgresUserRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+func (r *PostgresUserRepository) GetByUsername(ctx context.Context, tenantID uuid.UUID, username string) (*domain.User, error) {
 	query := `
-		SELECT user_id, username, email, password_hash, full_name, is_active, created_at, updated_at
+		SELECT user_id, tenant_id, username, email, COALESCE(password_hash, ''), full_name, role, is_active, created_at, updated_at
 		FROM users
-		WHERE username = $1
+		WHERE tenant_id = $1 AND username = $2
 	`
 
-	user := &domain.User{}
-	err := r.db.QueryRow(ctx, query, username).Scan(
-		&user.UserID,
-		&user.Username,
-		&user.Email,
-		&user.PasswordHash,
-		&user.FullName,
-		&user.IsActive,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
+	user, err := scanUser(r.db.QueryRow(ctx, query, tenantID, username))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperrors.NotFound("user")
@@ -114,25 +102,14 @@ func (r *PostgresUserRepository) GetByUsername(ctx context.Context, username str
 	return user, nil
 }
 
-func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+func (r *PostgresUserRepository) GetByEmail(ctx context.Context, tenantID uuid.UUID, email string) (*domain.User, error) {
 	query := `
-		SELECT user_id, username, email, password_hash, full_name, is_active, created_at, updated_at
+		SELECT user_id, tenant_id, username, email, COALESCE(password_hash, ''), full_name, role, is_active, created_at, updated_at
 		FROM users
-		WHERE email = $1
+		WHERE tenant_id = $1 AND email = $2
 	`
 
-	user := &domain.User{}
-	err := r.db.QueryRow(ctx, query, email).Scan(
-		&user.UserID,
-		&user.Username,
-		&user.Email,
-		&user.PasswordHash,
-		&user.FullName,
-		&user.IsActive,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
+	user, err := scanUser(r.db.QueryRow(ctx, query, tenantID, email))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperrors.NotFound("user")
@@ -146,8 +123,8 @@ func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (
 func (r *PostgresUserRepository) Update(ctx context.Context, user *domain.User) error {
 	query := `
 		UPDATE users
-		SET username = $1, email = $2, password_hash = $3, full_name = $4, is_active = $5, updated_at = $6
-		WHERE user_id = $7
+		SET username = $1, email = $2, password_hash = NULLIF($3, ''), full_name = $4, role = $5, is_active = $6, updated_at = $7
+		WHERE tenant_id = $8 AND user_id = $9
 	`
 
 	result, err := r.db.Exec(
@@ -157,12 +134,20 @@ func (r *PostgresUserRepository) Update(ctx context.Context, user *domain.User)
 		user.Email,
 		user.PasswordHash,
 		user.FullName,
+		user.Role,
 		user.IsActive,
 		time.Now(),
+		user.TenantID,
 		user.UserID,
 	)
 
 	if err != nil {
+		if isUniqueViolation(err, "users_tenant_username_key") {
+			return apperrors.AlreadyExists("username")
+		}
+		if isUniqueViolation(err, "users_tenant_email_key") {
+			return apperrors.AlreadyExists("email")
+		}
 		return fmt.Errorf("failed to update user: %w", err)
 	}
 
@@ -173,10 +158,10 @@ func (r *PostgresUserRepository) Update(ctx context.Context, user *domain.User)
 	return nil
 }
 
-func (r *PostgresUserRepository) Delete(ctx context.Context, userID uuid.UUID) error {
-	query := `DELETE FROM users WHERE user_id = $1`
+func (r *PostgresUserRepository) Delete(ctx context.Context, tenantID, userID uuid.UUID) error {
+	query := `DELETE FROM users WHERE tenant_id = $1 AND user_id = $2`
 
-	result, err := r.db.Exec(ctx, query, userID)
+	result, err := r.db.Exec(ctx, query, tenantID, userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -188,6 +173,70 @@ func (r *PostgresUserRepository) Delete(ctx context.Context, userID uuid.UUID) e
 	return nil
 }
 
+func scanUser(row pgx.Row) (*domain.User, error) {
+	user := &domain.User{}
+	err := row.Scan(
+		&user.UserID,
+		&user.TenantID,
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
+		&user.FullName,
+		&user.Role,
+		&user.IsActive,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+	}
+	return false
+}
+
+const sessionColumns = `
+	session_id, user_id, refresh_token, COALESCE(device_info, ''),
+	COALESCE(host(ip_address), ''), COALESCE(user_agent, ''), last_activity_at, expires_at,
+	created_at, updated_at, is_revoked, revoked_at,
+	lifetime_profile, remember_me, access_token_expiry, refresh_token_expiry
+`
+
+func scanPostgresSession(row pgx.Row) (*domain.Session, error) {
+	session := &domain.Session{}
+	var accessExpirySeconds, refreshExpirySeconds int
+	err := row.Scan(
+		&session.SessionID,
+		&session.UserID,
+		&session.RefreshToken,
+		&session.DeviceInfo,
+		&session.IPAddress,
+		&session.UserAgent,
+		&session.LastActivityAt,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+		&session.UpdatedAt,
+		&session.IsRevoked,
+		&session.RevokedAt,
+		&session.Lifetime.Profile,
+		&session.Lifetime.RememberMe,
+		&accessExpirySeconds,
+		&refreshExpirySeconds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	session.Lifetime.AccessTokenExpiry = time.Duration(accessExpirySeconds) * time.Second
+	session.Lifetime.RefreshTokenExpiry = time.Duration(refreshExpirySeconds) * time.Second
+	return session, nil
+}
+
 type PostgresSessionRepository struct {
 	db *pgxpool.Pool
 }
@@ -199,16 +248,22 @@ func NewPostgresSessionRepository(db *pgxpool.Pool) *PostgresSessionRepository {
 func (r *PostgresSessionRepository) Create(ctx context.Context, session *domain.Session) error {
 	query := `
 		INSERT INTO sessions (
-			user_id, refresh_token, device_info, 
-			ip_address, user_agent, last_activity_at, expires_at, 
-			created_at, updated_at, is_revoked
+			session_id, user_id, refresh_token, device_info,
+			ip_address, user_agent, last_activity_at, expires_at,
+			created_at, updated_at, is_revoked, lifetime_profile,
+			remember_me, access_token_expiry, refresh_token_expiry
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		RETURNING session_id, created_at, updated_at
 	`
 
 	now := time.Now()
 
+	// The service may pick the ID up front to put it in the access token.
+	if session.SessionID == uuid.Nil {
+		session.SessionID = uuid.New()
+	}
+
 	// Convert empty strings to nil for nullable fields
 	var ipAddress interface{} = session.IPAddress
 	if session.IPAddress == "" {
@@ -228,6 +283,7 @@ func (r *PostgresSessionRepository) Create(ctx context.Context, session *domain.
 	err := r.db.QueryRow(
 		ctx,
 		query,
+		session.SessionID,
 		session.UserID,
 		session.RefreshToken,
 		deviceInfo,
@@ -238,6 +294,10 @@ func (r *PostgresSessionRepository) Create(ctx context.Context, session *domain.
 		now,
 		now,
 		false,
+		session.Lifetime.Profile,
+		session.Lifetime.RememberMe,
+		int(session.Lifetime.AccessTokenExpiry/time.Second),
+		int(session.Lifetime.RefreshTokenExpiry/time.Second),
 	).Scan(&session.SessionID, &session.CreatedAt, &session.UpdatedAt)
 
 	if err != nil {
@@ -249,28 +309,12 @@ func (r *PostgresSessionRepository) Create(ctx context.Context, session *domain.
 
 func (r *PostgresSessionRepository) GetByRefreshToken(ctx context.Context, refreshToken string) (*domain.Session, error) {
 	query := `
-		SELECT session_id, user_id, refresh_token, device_info, 
-		       ip_address, user_agent, last_activity_at, expires_at, 
-		       created_at, updated_at, is_revoked, revoked_at
+		SELECT ` + sessionColumns + `
 		FROM sessions
 		WHERE refresh_token = $1 AND expires_at > $2
 	`
 
-	session := &domain.Session{}
-	err := r.db.QueryRow(ctx, query, refreshToken, time.Now()).Scan(
-		&session.SessionID,
-		&session.UserID,
-		&session.RefreshToken,
-		&session.DeviceInfo,
-		&session.IPAddress,
-		&session.UserAgent,
-		&session.LastActivityAt,
-		&session.ExpiresAt,
-		&session.CreatedAt,
-		&session.UpdatedAt,
-		&session.IsRevoked,
-		&session.RevokedAt,
-	)
+	session, err := scanPostgresSession(r.db.QueryRow(ctx, query, refreshToken, time.Now()))
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -284,30 +328,14 @@ func (r *PostgresSessionRepository) GetByRefreshToken(ctx context.Context, refre
 
 func (r *PostgresSessionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.Session, error) {
 	query := `
-		SELECT session_id, user_id, refresh_token, device_info, 
-		       ip_address, user_agent, last_activity_at, expires_at, 
-		       created_at, updated_at, is_revoked, revoked_at
+		SELECT ` + sessionColumns + `
 		FROM sessions
 		WHERE user_id = $1 AND expires_at > $2
 		ORDER BY created_at DESC
 		LIMIT 1
 	`
 
-	session := &domain.Session{}
-	err := r.db.QueryRow(ctx, query, userID, time.Now()).Scan(
-		&session.SessionID,
-		&session.UserID,
-		&session.RefreshToken,
-		&session.DeviceInfo,
-		&session.IPAddress,
-		&session.UserAgent,
-		&session.LastActivityAt,
-		&session.ExpiresAt,
-		&session.CreatedAt,
-		&session.UpdatedAt,
-		&session.IsRevoked,
-		&session.RevokedAt,
-	)
+	session, err := scanPostgresSession(r.db.QueryRow(ctx, query, userID, time.Now()))
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -321,9 +349,7 @@ func (r *PostgresSessionRepository) GetByUserID(ctx context.Context, userID uuid
 
 func (r *PostgresSessionRepository) GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Session, error) {
 	query := `
-		SELECT session_id, user_id, refresh_token, device_info, 
-		       ip_address, user_agent, last_activity_at, expires_at, 
-		       created_at, updated_at, is_revoked, revoked_at
+		SELECT ` + sessionColumns + `
 		FROM sessions
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -337,21 +363,7 @@ func (r *PostgresSessionRepository) GetAllByUserID(ctx context.Context, userID u
 
 	var sessions []*domain.Session
 	for rows.Next() {
-		session := &domain.Session{}
-		err := rows.Scan(
-			&session.SessionID,
-			&session.UserID,
-			&session.RefreshToken,
-			&session.DeviceInfo,
-			&session.IPAddress,
-			&session.UserAgent,
-			&session.LastActivityAt,
-			&session.ExpiresAt,
-			&session.CreatedAt,
-			&session.UpdatedAt,
-			&session.IsRevoked,
-			&session.RevokedAt,
-		)
+		session, err := scanPostgresSession(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan session: %w", err)
 		}
@@ -381,6 +393,57 @@ func (r *PostgresSessionRepository) UpdateLastActivity(ctx context.Context, sess
 	return nil
 }
 
+func (r *PostgresSessionRepository) Touch(ctx context.Context, sessionID uuid.UUID, activeSince time.Time) error {
+	query := `
+		UPDATE sessions
+		SET last_activity_at = $1, updated_at = $1
+		WHERE session_id = $2 AND is_revoked = false AND expires_at > $1 AND last_activity_at > $3
+	`
+
+	result, err := r.db.Exec(ctx, query, time.Now(), sessionID, activeSince)
+	if err != nil {
+		return fmt.Errorf("failed to update session activity: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.NotFound("session")
+	}
+
+	return nil
+}
+
+func (r *PostgresSessionRepository) Rotate(ctx context.Context, session *domain.Session, previousRefreshToken string) error {
+	query := `
+		UPDATE sessions
+		SET refresh_token = $1, device_info = $2, ip_address = $3, user_agent = $4,
+			expires_at = $5, last_activity_at = $6, updated_at = $6
+		WHERE session_id = $7 AND refresh_token = $8 AND is_revoked = false
+		RETURNING last_activity_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		session.RefreshToken,
+		nullableString(session.DeviceInfo),
+		nullableString(session.IPAddress),
+		nullableString(session.UserAgent),
+		session.ExpiresAt,
+		time.Now(),
+		session.SessionID,
+		previousRefreshToken,
+	).Scan(&session.LastActivityAt, &session.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperrors.NotFound("session")
+		}
+		return fmt.Errorf("failed to rotate session: %w", err)
+	}
+
+	return nil
+}
+
 func (r *PostgresSessionRepository) Revoke(ctx context.Context, sessionID uuid.UUID) error {
 	query := `
 		UPDATE sessions
@@ -454,6 +517,28 @@ func (r *PostgresSessionRepository) DeleteExpired(ctx context.Context) error {
 	return nil
 }
 
+func (r *PostgresSessionRepository) DeleteIdle(ctx context.Context, lastActiveBefore time.Time) error {
+	query := `DELETE FROM sessions WHERE last_activity_at < $1`
+
+	_, err := r.db.Exec(ctx, query, lastActiveBefore)
+	if err != nil {
+		return fmt.Errorf("failed to delete idle sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresSessionRepository) CountActive(ctx context.Context) (int64, error) {
+	query := `SELECT COUNT(*) FROM sessions WHERE is_revoked = false AND expires_at > $1`
+
+	var count int64
+	if err := r.db.QueryRow(ctx, query, time.Now()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count active sessions: %w", err)
+	}
+
+	return count, nil
+}
+
 func (r *PostgresSessionRepository) ReplaceUserSession(ctx context.Context, session *domain.Session) error {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
@@ -471,16 +556,22 @@ func (r *PostgresSessionRepository) ReplaceUserSession(ctx context.Context, sess
 
 	insertQuery := `
 		INSERT INTO sessions (
-			user_id, refresh_token, device_info, 
-			ip_address, user_agent, last_activity_at, expires_at, 
-			created_at, updated_at, is_revoked
+			session_id, user_id, refresh_token, device_info,
+			ip_address, user_agent, last_activity_at, expires_at,
+			created_at, updated_at, is_revoked, lifetime_profile,
+			remember_me, access_token_expiry, refresh_token_expiry
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		RETURNING session_id, created_at, updated_at
 	`
 
 	now := time.Now()
 
+	// The service may pick the ID up front to put it in the access token.
+	if session.SessionID == uuid.Nil {
+		session.SessionID = uuid.New()
+	}
+
 	// Convert empty strings to nil for nullable fields
 	var ipAddress interface{} = session.IPAddress
 	if session.IPAddress == "" {
@@ -500,6 +591,7 @@ func (r *PostgresSessionRepository) ReplaceUserSession(ctx context.Context, sess
 	err = tx.QueryRow(
 		ctx,
 		insertQuery,
+		session.SessionID,
 		session.UserID,
 		session.RefreshToken,
 		deviceInfo,
@@ -510,6 +602,10 @@ func (r *PostgresSessionRepository) ReplaceUserSession(ctx context.Context, sess
 		now,
 		now,
 		false,
+		session.Lifetime.Profile,
+		session.Lifetime.RememberMe,
+		int(session.Lifetime.AccessTokenExpiry/time.Second),
+		int(session.Lifetime.RefreshTokenExpiry/time.Second),
 	).Scan(&session.SessionID, &session.CreatedAt, &session.UpdatedAt)
 
 	if err != nil {
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const apiKeyColumns = `
+	key_id, tenant_id, user_id, name, key_prefix, key_hash, scopes,
+	expires_at, last_used_at, created_at, revoked_at
+`
+
+type PostgresAPIKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresAPIKeyRepository(db *pgxpool.Pool) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{db: db}
+}
+
+func (r *PostgresAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	query := `
+		INSERT INTO api_keys (tenant_id, user_id, name, key_prefix, key_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING key_id, created_at
+	`
+
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		key.TenantID,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		key.Scopes,
+		key.ExpiresAt,
+		time.Now(),
+	).Scan(&key.KeyID, &key.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+
+	key, err := scanAPIKey(r.db.QueryRow(ctx, query, keyHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("api key")
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (r *PostgresAPIKeyRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*domain.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *PostgresAPIKeyRepository) UpdateLastUsed(ctx context.Context, keyID uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $1 WHERE key_id = $2`
+
+	if _, err := r.db.Exec(ctx, query, usedAt, keyID); err != nil {
+		return fmt.Errorf("failed to update api key last used: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresAPIKeyRepository) Revoke(ctx context.Context, userID, keyID uuid.UUID) error {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = $1
+		WHERE key_id = $2 AND user_id = $3 AND revoked_at IS NULL
+	`
+
+	result, err := r.db.Exec(ctx, query, time.Now(), keyID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.NotFound("api key")
+	}
+
+	return nil
+}
+
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	key := &domain.APIKey{}
+	err := row.Scan(
+		&key.KeyID,
+		&key.TenantID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.Scopes,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
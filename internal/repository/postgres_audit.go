@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"auth-service/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresAuditRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresAuditRepository(db *pgxpool.Pool) *PostgresAuditRepository {
+	return &PostgresAuditRepository{db: db}
+}
+
+func (r *PostgresAuditRepository) Create(ctx context.Context, event *domain.AuditEvent) error {
+	query := `
+		INSERT INTO audit_events (
+			tenant_id, event_type, result, reason, actor_user_id, target_user_id,
+			ip_address, user_agent, request_id, metadata, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING event_id, created_at
+	`
+
+	metadata := event.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		event.TenantID,
+		event.EventType,
+		event.Result,
+		nullableString(event.Reason),
+		event.ActorUserID,
+		event.TargetUserID,
+		nullableString(event.IPAddress),
+		nullableString(event.UserAgent),
+		nullableString(event.RequestID),
+		metadata,
+		time.Now(),
+	).Scan(&event.EventID, &event.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create audit event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresAuditRepository) List(ctx context.Context, filter *domain.AuditEventFilter) ([]*domain.AuditEvent, error) {
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{filter.TenantID}
+
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.UserID != nil {
+		placeholder := addArg(*filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("(actor_user_id = %s OR target_user_id = %s)", placeholder, placeholder))
+	}
+	if len(filter.EventTypes) > 0 {
+		conditions = append(conditions, "event_type = ANY("+addArg(filter.EventTypes)+")")
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= "+addArg(*filter.From))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < "+addArg(*filter.To))
+	}
+
+	query := `
+		SELECT event_id, tenant_id, event_type, result, COALESCE(reason, ''),
+		       actor_user_id, target_user_id, COALESCE(host(ip_address), ''),
+		       COALESCE(user_agent, ''), COALESCE(request_id, ''), metadata, created_at
+		FROM audit_events
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY created_at DESC
+		LIMIT ` + addArg(filter.Limit) + ` OFFSET ` + addArg(filter.Offset)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*domain.AuditEvent{}
+	for rows.Next() {
+		event := &domain.AuditEvent{}
+		err := rows.Scan(
+			&event.EventID,
+			&event.TenantID,
+			&event.EventType,
+			&event.Result,
+			&event.Reason,
+			&event.ActorUserID,
+			&event.TargetUserID,
+			&event.IPAddress,
+			&event.UserAgent,
+			&event.RequestID,
+			&event.Metadata,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	return events, nil
+}
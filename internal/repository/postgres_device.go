@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresKnownDeviceRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresKnownDeviceRepository(db *pgxpool.Pool) *PostgresKnownDeviceRepository {
+	return &PostgresKnownDeviceRepository{db: db}
+}
+
+func (r *PostgresKnownDeviceRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.KnownDevice, error) {
+	query := `
+		SELECT device_id, tenant_id, user_id, fingerprint, network, COALESCE(device_info, ''),
+			COALESCE(host(ip_address), ''), COALESCE(location, ''), first_seen_at, last_seen_at
+		FROM known_devices
+		WHERE user_id = $1
+		ORDER BY last_seen_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list known devices: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []*domain.KnownDevice
+	for rows.Next() {
+		device := &domain.KnownDevice{}
+		err := rows.Scan(
+			&device.DeviceID,
+			&device.TenantID,
+			&device.UserID,
+			&device.Fingerprint,
+			&device.Network,
+			&device.DeviceInfo,
+			&device.IPAddress,
+			&device.Location,
+			&device.FirstSeenAt,
+			&device.LastSeenAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan known device: %w", err)
+		}
+		devices = append(devices, device)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list known devices: %w", err)
+	}
+
+	return devices, nil
+}
+
+func (r *PostgresKnownDeviceRepository) Upsert(ctx context.Context, device *domain.KnownDevice) error {
+	query := `
+		INSERT INTO known_devices (
+			device_id, tenant_id, user_id, fingerprint, network,
+			device_info, ip_address, location, first_seen_at, last_seen_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT (user_id, fingerprint, network) DO UPDATE
+		SET device_info = EXCLUDED.device_info, ip_address = EXCLUDED.ip_address,
+			location = EXCLUDED.location, last_seen_at = EXCLUDED.last_seen_at
+		RETURNING device_id, first_seen_at, last_seen_at
+	`
+
+	if device.DeviceID == uuid.Nil {
+		device.DeviceID = uuid.New()
+	}
+
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		device.DeviceID,
+		device.TenantID,
+		device.UserID,
+		device.Fingerprint,
+		device.Network,
+		nullableString(device.DeviceInfo),
+		nullableString(device.IPAddress),
+		nullableString(device.Location),
+		time.Now(),
+	).Scan(&device.DeviceID, &device.FirstSeenAt, &device.LastSeenAt)
+	if err != nil {
+		return fmt.Errorf("failed to record known device: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresKnownDeviceRepository) Delete(ctx context.Context, tenantID, deviceID uuid.UUID) error {
+	query := `DELETE FROM known_devices WHERE device_id = $1 AND tenant_id = $2`
+
+	result, err := r.db.Exec(ctx, query, deviceID, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete known device: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return apperrors.NotFound("device")
+	}
+
+	return nil
+}
+
+func (r *PostgresKnownDeviceRepository) DeleteStale(ctx context.Context, lastSeenBefore time.Time) error {
+	query := `DELETE FROM known_devices WHERE last_seen_at < $1`
+
+	if _, err := r.db.Exec(ctx, query, lastSeenBefore); err != nil {
+		return fmt.Errorf("failed to delete stale known devices: %w", err)
+	}
+
+	return nil
+}
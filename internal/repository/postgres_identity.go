@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const identityColumns = `
+	identity_id, tenant_id, user_id, provider, subject, COALESCE(email, ''), created_at, last_used_at
+`
+
+type PostgresIdentityRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresIdentityRepository(db *pgxpool.Pool) *PostgresIdentityRepository {
+	return &PostgresIdentityRepository{db: db}
+}
+
+func (r *PostgresIdentityRepository) Create(ctx context.Context, identity *domain.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (tenant_id, user_id, provider, subject, email, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		RETURNING identity_id, created_at
+	`
+
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		identity.TenantID,
+		identity.UserID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+		time.Now(),
+	).Scan(&identity.IdentityID, &identity.CreatedAt)
+
+	if err != nil {
+		if isUniqueViolation(err, "user_identities_provider_subject_key") {
+			return apperrors.AlreadyExists("identity")
+		}
+		return fmt.Errorf("failed to create identity: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresIdentityRepository) GetByProviderSubject(ctx context.Context, tenantID uuid.UUID, provider, subject string) (*domain.UserIdentity, error) {
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE tenant_id = $1 AND provider = $2 AND subject = $3`
+
+	identity, err := scanIdentity(r.db.QueryRow(ctx, query, tenantID, provider, subject))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("identity")
+		}
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	return identity, nil
+}
+
+func (r *PostgresIdentityRepository) ListByUserID(ctx context.Context, tenantID, userID uuid.UUID) ([]*domain.UserIdentity, error) {
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE tenant_id = $1 AND user_id = $2 ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+	defer rows.Close()
+
+	identities := []*domain.UserIdentity{}
+	for rows.Next() {
+		identity, err := scanIdentity(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan identity: %w", err)
+		}
+		identities = append(identities, identity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+
+	return identities, nil
+}
+
+func (r *PostgresIdentityRepository) UpdateLastUsed(ctx context.Context, identityID uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE user_identities SET last_used_at = $1 WHERE identity_id = $2`
+
+	if _, err := r.db.Exec(ctx, query, usedAt, identityID); err != nil {
+		return fmt.Errorf("failed to update identity last used: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresIdentityRepository) Delete(ctx context.Context, tenantID, userID, identityID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	hasPassword, err := lockLoginMethods(ctx, tx, tenantID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperrors.NotFound("identity")
+		}
+		return err
+	}
+
+	deleteQuery := `DELETE FROM user_identities WHERE tenant_id = $1 AND user_id = $2 AND identity_id = $3`
+	result, err := tx.Exec(ctx, deleteQuery, tenantID, userID, identityID)
+	if err != nil {
+		return fmt.Errorf("failed to delete identity: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return apperrors.NotFound("identity")
+	}
+
+	if !hasPassword {
+		remaining, err := countIdentities(ctx, tx, tenantID, userID)
+		if err != nil {
+			return err
+		}
+		if remaining == 0 {
+			return ErrLastLoginMethod
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresIdentityRepository) DeletePassword(ctx context.Context, tenantID, userID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	hasPassword, err := lockLoginMethods(ctx, tx, tenantID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperrors.NotFound("user")
+		}
+		return err
+	}
+	if !hasPassword {
+		return apperrors.NotFound("password")
+	}
+
+	identities, err := countIdentities(ctx, tx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	if identities == 0 {
+		return ErrLastLoginMethod
+	}
+
+	updateQuery := `UPDATE users SET password_hash = NULL, updated_at = $1 WHERE tenant_id = $2 AND user_id = $3`
+	if _, err := tx.Exec(ctx, updateQuery, time.Now(), tenantID, userID); err != nil {
+		return fmt.Errorf("failed to delete password: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// lockLoginMethods locks the user's row for the rest of tx, so that
+// concurrent removals of their login methods run one after the other, and
+// reports whether the user has a password.
+func lockLoginMethods(ctx context.Context, tx pgx.Tx, tenantID, userID uuid.UUID) (bool, error) {
+	query := `SELECT password_hash IS NOT NULL FROM users WHERE tenant_id = $1 AND user_id = $2 FOR UPDATE`
+
+	var hasPassword bool
+	if err := tx.QueryRow(ctx, query, tenantID, userID).Scan(&hasPassword); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, err
+		}
+		return false, fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	return hasPassword, nil
+}
+
+func countIdentities(ctx context.Context, tx pgx.Tx, tenantID, userID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM user_identities WHERE tenant_id = $1 AND user_id = $2`
+
+	var count int
+	if err := tx.QueryRow(ctx, query, tenantID, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count identities: %w", err)
+	}
+
+	return count, nil
+}
+
+func scanIdentity(row pgx.Row) (*domain.UserIdentity, error) {
+	identity := &domain.UserIdentity{}
+	err := row.Scan(
+		&identity.IdentityID,
+		&identity.TenantID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		&identity.CreatedAt,
+		&identity.LastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+type PostgresOIDCStateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresOIDCStateRepository(db *pgxpool.Pool) *PostgresOIDCStateRepository {
+	return &PostgresOIDCStateRepository{db: db}
+}
+
+func (r *PostgresOIDCStateRepository) Create(ctx context.Context, state *domain.OIDCLoginState) error {
+	query := `
+		INSERT INTO oidc_login_states (state_hash, tenant_id, user_id, provider, nonce, code_verifier, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	state.CreatedAt = time.Now()
+	_, err := r.db.Exec(
+		ctx,
+		query,
+		state.StateHash,
+		state.TenantID,
+		state.UserID,
+		state.Provider,
+		state.Nonce,
+		state.CodeVerifier,
+		state.ExpiresAt,
+		state.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create oidc login state: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresOIDCStateRepository) Consume(ctx context.Context, stateHash string) (*domain.OIDCLoginState, error) {
+	query := `
+		DELETE FROM oidc_login_states
+		WHERE state_hash = $1
+		RETURNING state_hash, tenant_id, user_id, provider, nonce, code_verifier, expires_at, created_at
+	`
+
+	state := &domain.OIDCLoginState{}
+	err := r.db.QueryRow(ctx, query, stateHash).Scan(
+		&state.StateHash,
+		&state.TenantID,
+		&state.UserID,
+		&state.Provider,
+		&state.Nonce,
+		&state.CodeVerifier,
+		&state.ExpiresAt,
+		&state.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("login state")
+		}
+		return nil, fmt.Errorf("failed to consume oidc login state: %w", err)
+	}
+
+	return state, nil
+}
+
+func (r *PostgresOIDCStateRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM oidc_login_states WHERE expires_at < $1`
+
+	if _, err := r.db.Exec(ctx, query, time.Now()); err != nil {
+		return fmt.Errorf("failed to delete expired oidc login states: %w", err)
+	}
+
+	return nil
+}
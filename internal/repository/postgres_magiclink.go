@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresMagicLinkRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresMagicLinkRepository(db *pgxpool.Pool) *PostgresMagicLinkRepository {
+	return &PostgresMagicLinkRepository{db: db}
+}
+
+func (r *PostgresMagicLinkRepository) Create(ctx context.Context, link *domain.MagicLink) error {
+	query := `
+		INSERT INTO users.magic_links (link_id, tenant_id, user_id, nonce_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	link.LinkID = uuid.New()
+	link.CreatedAt = time.Now()
+	_, err := r.db.Exec(
+		ctx,
+		query,
+		link.LinkID,
+		link.TenantID,
+		link.UserID,
+		link.NonceHash,
+		link.ExpiresAt,
+		link.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create magic link: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresMagicLinkRepository) Consume(ctx context.Context, tenantID, linkID uuid.UUID) (*domain.MagicLink, error) {
+	query := `
+		DELETE FROM users.magic_links
+		WHERE link_id = $1 AND tenant_id = $2
+		RETURNING link_id, tenant_id, user_id, nonce_hash, expires_at, created_at
+	`
+
+	link := &domain.MagicLink{}
+	err := r.db.QueryRow(ctx, query, linkID, tenantID).Scan(
+		&link.LinkID,
+		&link.TenantID,
+		&link.UserID,
+		&link.NonceHash,
+		&link.ExpiresAt,
+		&link.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("magic link")
+		}
+		return nil, fmt.Errorf("failed to consume magic link: %w", err)
+	}
+
+	return link, nil
+}
+
+func (r *PostgresMagicLinkRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM users.magic_links WHERE expires_at < $1`
+
+	if _, err := r.db.Exec(ctx, query, time.Now()); err != nil {
+		return fmt.Errorf("failed to delete expired magic links: %w", err)
+	}
+
+	return nil
+}
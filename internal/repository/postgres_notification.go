@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const notificationColumns = `
+	notification_id, tenant_id, user_id, template, locale, recipient, subject,
+	status, attempts, COALESCE(last_error, ''), created_at, updated_at, sent_at
+`
+
+type PostgresNotificationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresNotificationRepository(db *pgxpool.Pool) *PostgresNotificationRepository {
+	return &PostgresNotificationRepository{db: db}
+}
+
+func (r *PostgresNotificationRepository) Create(ctx context.Context, notification *domain.Notification) error {
+	query := `
+		INSERT INTO notifications (
+			tenant_id, user_id, template, locale, recipient, subject,
+			status, attempts, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 0, $8, $9)
+		RETURNING notification_id, created_at, updated_at
+	`
+
+	now := time.Now()
+	if notification.Status == "" {
+		notification.Status = domain.NotificationQueued
+	}
+
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		notification.TenantID,
+		notification.UserID,
+		notification.Template,
+		notification.Locale,
+		notification.Recipient,
+		notification.Subject,
+		notification.Status,
+		now,
+		now,
+	).Scan(&notification.NotificationID, &notification.CreatedAt, &notification.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresNotificationRepository) Update(ctx context.Context, notification *domain.Notification) error {
+	query := `
+		UPDATE notifications
+		SET status = $1, attempts = $2, last_error = $3, sent_at = $4, updated_at = $5
+		WHERE notification_id = $6
+	`
+
+	result, err := r.db.Exec(
+		ctx,
+		query,
+		notification.Status,
+		notification.Attempts,
+		nullableString(notification.LastError),
+		notification.SentAt,
+		time.Now(),
+		notification.NotificationID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update notification: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.NotFound("notification")
+	}
+
+	return nil
+}
+
+func (r *PostgresNotificationRepository) List(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID, limit int) ([]*domain.Notification, error) {
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM notifications
+		WHERE tenant_id = $1 AND ($2::uuid IS NULL OR user_id = $2)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, tenantID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []*domain.Notification{}
+	for rows.Next() {
+		notification := &domain.Notification{}
+		if err := rows.Scan(notificationFields(notification)...); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, notification)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+func notificationFields(n *domain.Notification) []interface{} {
+	return []interface{}{
+		&n.NotificationID,
+		&n.TenantID,
+		&n.UserID,
+		&n.Template,
+		&n.Locale,
+		&n.Recipient,
+		&n.Subject,
+		&n.Status,
+		&n.Attempts,
+		&n.LastError,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+		&n.SentAt,
+	}
+}
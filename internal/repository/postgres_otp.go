@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const otpColumns = `otp_id, tenant_id, user_id, purpose, code_hash, attempts, expires_at, created_at`
+
+type PostgresOTPRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresOTPRepository(db *pgxpool.Pool) *PostgresOTPRepository {
+	return &PostgresOTPRepository{db: db}
+}
+
+func (r *PostgresOTPRepository) Create(ctx context.Context, otp *domain.OTPCode) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	deleteQuery := `DELETE FROM users.otp_codes WHERE tenant_id = $1 AND user_id = $2 AND purpose = $3`
+	if _, err := tx.Exec(ctx, deleteQuery, otp.TenantID, otp.UserID, otp.Purpose); err != nil {
+		return fmt.Errorf("failed to delete pending otp codes: %w", err)
+	}
+
+	insertQuery := `
+		INSERT INTO users.otp_codes (` + otpColumns + `)
+		VALUES ($1, $2, $3, $4, $5, 0, $6, $7)
+	`
+
+	otp.Attempts = 0
+	otp.CreatedAt = time.Now()
+	_, err = tx.Exec(
+		ctx,
+		insertQuery,
+		otp.OTPID,
+		otp.TenantID,
+		otp.UserID,
+		otp.Purpose,
+		otp.CodeHash,
+		otp.ExpiresAt,
+		otp.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create otp code: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresOTPRepository) RecordAttempt(ctx context.Context, tenantID, otpID uuid.UUID) (*domain.OTPCode, error) {
+	query := `
+		UPDATE users.otp_codes
+		SET attempts = attempts + 1
+		WHERE otp_id = $1 AND tenant_id = $2
+		RETURNING ` + otpColumns
+
+	otp := &domain.OTPCode{}
+	err := r.db.QueryRow(ctx, query, otpID, tenantID).Scan(
+		&otp.OTPID,
+		&otp.TenantID,
+		&otp.UserID,
+		&otp.Purpose,
+		&otp.CodeHash,
+		&otp.Attempts,
+		&otp.ExpiresAt,
+		&otp.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("otp code")
+		}
+		return nil, fmt.Errorf("failed to record otp attempt: %w", err)
+	}
+
+	return otp, nil
+}
+
+func (r *PostgresOTPRepository) Delete(ctx context.Context, tenantID, otpID uuid.UUID) error {
+	query := `DELETE FROM users.otp_codes WHERE otp_id = $1 AND tenant_id = $2`
+
+	result, err := r.db.Exec(ctx, query, otpID, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete otp code: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return apperrors.NotFound("otp code")
+	}
+
+	return nil
+}
+
+func (r *PostgresOTPRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM users.otp_codes WHERE expires_at < $1`
+
+	if _, err := r.db.Exec(ctx, query, time.Now()); err != nil {
+		return fmt.Errorf("failed to delete expired otp codes: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const tenantColumns = `
+	tenant_id, slug, name, COALESCE(domain, ''), is_active,
+	access_token_expiry_seconds, refresh_token_expiry_seconds,
+	registration_enabled, allowed_email_domains, created_at, updated_at
+`
+
+type PostgresTenantRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresTenantRepository(db *pgxpool.Pool) *PostgresTenantRepository {
+	return &PostgresTenantRepository{db: db}
+}
+
+func (r *PostgresTenantRepository) Create(ctx context.Context, tenant *domain.Tenant) error {
+	query := `
+		INSERT INTO tenants (
+			slug, name, domain, is_active,
+			access_token_expiry_seconds, refresh_token_expiry_seconds,
+			registration_enabled, allowed_email_domains, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING tenant_id, created_at, updated_at
+	`
+
+	now := time.Now()
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		tenant.Slug,
+		tenant.Name,
+		nullableString(tenant.Domain),
+		tenant.IsActive,
+		int(tenant.Settings.AccessTokenExpiry/time.Second),
+		int(tenant.Settings.RefreshTokenExpiry/time.Second),
+		tenant.Settings.RegistrationEnabled,
+		emailDomains(tenant.Settings.AllowedEmailDomains),
+		now,
+		now,
+	).Scan(&tenant.TenantID, &tenant.CreatedAt, &tenant.UpdatedAt)
+
+	if err != nil {
+		if isUniqueViolation(err, "tenants_slug_key") {
+			return apperrors.AlreadyExists("tenant slug")
+		}
+		if isUniqueViolation(err, "tenants_domain_key") {
+			return apperrors.AlreadyExists("tenant domain")
+		}
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresTenantRepository) GetByID(ctx context.Context, tenantID uuid.UUID) (*domain.Tenant, error) {
+	query := `SELECT ` + tenantColumns + ` FROM tenants WHERE tenant_id = $1`
+
+	tenant, err := scanTenant(r.db.QueryRow(ctx, query, tenantID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("tenant")
+		}
+		return nil, fmt.Errorf("failed to get tenant by id: %w", err)
+	}
+
+	return tenant, nil
+}
+
+func (r *PostgresTenantRepository) GetBySlug(ctx context.Context, slug string) (*domain.Tenant, error) {
+	query := `SELECT ` + tenantColumns + ` FROM tenants WHERE slug = $1`
+
+	tenant, err := scanTenant(r.db.QueryRow(ctx, query, slug))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("tenant")
+		}
+		return nil, fmt.Errorf("failed to get tenant by slug: %w", err)
+	}
+
+	return tenant, nil
+}
+
+func (r *PostgresTenantRepository) GetByDomain(ctx context.Context, host string) (*domain.Tenant, error) {
+	query := `SELECT ` + tenantColumns + ` FROM tenants WHERE domain = $1`
+
+	tenant, err := scanTenant(r.db.QueryRow(ctx, query, host))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("tenant")
+		}
+		return nil, fmt.Errorf("failed to get tenant by domain: %w", err)
+	}
+
+	return tenant, nil
+}
+
+func (r *PostgresTenantRepository) Update(ctx context.Context, tenant *domain.Tenant) error {
+	query := `
+		UPDATE tenants
+		SET slug = $1, name = $2, domain = $3, is_active = $4,
+		    access_token_expiry_seconds = $5, refresh_token_expiry_seconds = $6,
+		    registration_enabled = $7, allowed_email_domains = $8, updated_at = $9
+		WHERE tenant_id = $10
+	`
+
+	result, err := r.db.Exec(
+		ctx,
+		query,
+		tenant.Slug,
+		tenant.Name,
+		nullableString(tenant.Domain),
+		tenant.IsActive,
+		int(tenant.Settings.AccessTokenExpiry/time.Second),
+		int(tenant.Settings.RefreshTokenExpiry/time.Second),
+		tenant.Settings.RegistrationEnabled,
+		emailDomains(tenant.Settings.AllowedEmailDomains),
+		time.Now(),
+		tenant.TenantID,
+	)
+
+	if err != nil {
+		if isUniqueViolation(err, "tenants_slug_key") {
+			return apperrors.AlreadyExists("tenant slug")
+		}
+		if isUniqueViolation(err, "tenants_domain_key") {
+			return apperrors.AlreadyExists("tenant domain")
+		}
+		return fmt.Errorf("failed to update tenant: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.NotFound("tenant")
+	}
+
+	return nil
+}
+
+func (r *PostgresTenantRepository) List(ctx context.Context) ([]*domain.Tenant, error) {
+	query := `SELECT ` + tenantColumns + ` FROM tenants ORDER BY slug`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	tenants := []*domain.Tenant{}
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		tenants = append(tenants, tenant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	return tenants, nil
+}
+
+func scanTenant(row pgx.Row) (*domain.Tenant, error) {
+	tenant := &domain.Tenant{}
+	var accessExpirySeconds, refreshExpirySeconds int
+
+	err := row.Scan(
+		&tenant.TenantID,
+		&tenant.Slug,
+		&tenant.Name,
+		&tenant.Domain,
+		&tenant.IsActive,
+		&accessExpirySeconds,
+		&refreshExpirySeconds,
+		&tenant.Settings.RegistrationEnabled,
+		&tenant.Settings.AllowedEmailDomains,
+		&tenant.CreatedAt,
+		&tenant.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant.Settings.AccessTokenExpiry = time.Duration(accessExpirySeconds) * time.Second
+	tenant.Settings.RefreshTokenExpiry = time.Duration(refreshExpirySeconds) * time.Second
+
+	return tenant, nil
+}
+
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func emailDomains(domains []string) []string {
+	if domains == nil {
+		return []string{}
+	}
+	return domains
+}
@@ -0,0 +1,58 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"auth-service/internal/migrate"
+	"auth-service/internal/repository"
+	"auth-service/internal/repository/repotest"
+	"auth-service/migrations"
+	"auth-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestPostgres runs against the database named by TEST_POSTGRES_DSN, e.g.
+// postgres://postgres@localhost:5432/authdb_test?sslmode=disable, and is
+// skipped when it is unset. Migrations are applied to it first.
+func TestPostgres(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	ctx := context.Background()
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("parse TEST_POSTGRES_DSN: %v", err)
+	}
+	if _, ok := poolConfig.ConnConfig.RuntimeParams["search_path"]; !ok {
+		poolConfig.ConnConfig.RuntimeParams["search_path"] = "users"
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	migrator, err := migrate.NewPostgres(pool, migrations.Postgres, logger.New("error", "json", ""))
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repotest.Run(t, func(t *testing.T) repotest.Repos {
+		return repotest.Repos{
+			Users:    repository.NewPostgresUserRepository(pool),
+			Sessions: repository.NewPostgresSessionRepository(pool),
+		}
+	})
+
+	t.Run("LoginMethods", func(t *testing.T) {
+		testLoginMethods(t, repository.NewPostgresUserRepository(pool), repository.NewPostgresIdentityRepository(pool))
+	})
+}
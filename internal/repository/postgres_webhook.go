@@ -0,0 +1,340 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const webhookSubscriptionColumns = `
+	subscription_id, tenant_id, url, secret, event_types,
+	COALESCE(description, ''), is_active, created_at, updated_at
+`
+
+const webhookDeliveryColumns = `
+	d.delivery_id, d.subscription_id, d.tenant_id, d.event_id, d.event_type, d.payload,
+	d.status, d.attempts, d.next_attempt_at, COALESCE(d.last_status_code, 0),
+	COALESCE(d.last_error, ''), d.created_at, d.updated_at, d.delivered_at
+`
+
+type PostgresWebhookRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresWebhookRepository(db *pgxpool.Pool) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{db: db}
+}
+
+func (r *PostgresWebhookRepository) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (tenant_id, url, secret, event_types, description, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING subscription_id, created_at, updated_at
+	`
+
+	now := time.Now()
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		sub.TenantID,
+		sub.URL,
+		sub.Secret,
+		sub.EventTypes,
+		nullableString(sub.Description),
+		sub.IsActive,
+		now,
+		now,
+	).Scan(&sub.SubscriptionID, &sub.CreatedAt, &sub.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresWebhookRepository) GetSubscription(ctx context.Context, tenantID, subscriptionID uuid.UUID) (*domain.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE tenant_id = $1 AND subscription_id = $2`
+
+	sub, err := scanWebhookSubscription(r.db.QueryRow(ctx, query, tenantID, subscriptionID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("webhook subscription")
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+func (r *PostgresWebhookRepository) ListSubscriptions(ctx context.Context, tenantID uuid.UUID) ([]*domain.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE tenant_id = $1 ORDER BY created_at DESC`
+
+	return r.querySubscriptions(ctx, query, tenantID)
+}
+
+func (r *PostgresWebhookRepository) ListSubscriptionsForEvent(ctx context.Context, tenantID uuid.UUID, eventType string) ([]*domain.WebhookSubscription, error) {
+	query := `
+		SELECT ` + webhookSubscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE tenant_id = $1 AND is_active = true AND $2 = ANY(event_types)
+	`
+
+	return r.querySubscriptions(ctx, query, tenantID, eventType)
+}
+
+func (r *PostgresWebhookRepository) DeleteSubscription(ctx context.Context, tenantID, subscriptionID uuid.UUID) error {
+	query := `DELETE FROM webhook_subscriptions WHERE tenant_id = $1 AND subscription_id = $2`
+
+	result, err := r.db.Exec(ctx, query, tenantID, subscriptionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.NotFound("webhook subscription")
+	}
+
+	return nil
+}
+
+func (r *PostgresWebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (
+			subscription_id, tenant_id, event_id, event_type, payload,
+			status, attempts, next_attempt_at, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, 0, $7, $8, $9)
+		RETURNING delivery_id, created_at, updated_at
+	`
+
+	now := time.Now()
+	if delivery.Status == "" {
+		delivery.Status = domain.WebhookDeliveryPending
+	}
+	if delivery.NextAttemptAt.IsZero() {
+		delivery.NextAttemptAt = now
+	}
+
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		delivery.SubscriptionID,
+		delivery.TenantID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Payload,
+		delivery.Status,
+		delivery.NextAttemptAt,
+		now,
+		now,
+	).Scan(&delivery.DeliveryID, &delivery.CreatedAt, &delivery.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresWebhookRepository) GetDelivery(ctx context.Context, tenantID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries d WHERE d.tenant_id = $1 AND d.delivery_id = $2`
+
+	delivery, err := scanWebhookDelivery(r.db.QueryRow(ctx, query, tenantID, deliveryID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("webhook delivery")
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+func (r *PostgresWebhookRepository) ListDeliveries(ctx context.Context, tenantID, subscriptionID uuid.UUID, limit int) ([]*domain.WebhookDelivery, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE d.tenant_id = $1 AND d.subscription_id = $2
+		ORDER BY d.created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, tenantID, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*domain.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries leases pending deliveries whose next attempt is due by
+// pushing next_attempt_at forward, so concurrent workers on other replicas
+// skip them until the lease runs out.
+func (r *PostgresWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT delivery_id
+			FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = $3, updated_at = $1
+		FROM due, webhook_subscriptions s
+		WHERE d.delivery_id = due.delivery_id AND s.subscription_id = d.subscription_id
+		RETURNING ` + webhookDeliveryColumns + `, s.url, s.secret
+	`
+
+	now := time.Now()
+	rows, err := r.db.Query(ctx, query, now, limit, now.Add(lease))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		delivery := &domain.WebhookDelivery{}
+		err := rows.Scan(append(webhookDeliveryFields(delivery), &delivery.URL, &delivery.Secret)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *PostgresWebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_status_code = $4,
+		    last_error = $5, delivered_at = $6, updated_at = $7
+		WHERE delivery_id = $8
+	`
+
+	var statusCode interface{}
+	if delivery.LastStatusCode != 0 {
+		statusCode = delivery.LastStatusCode
+	}
+
+	result, err := r.db.Exec(
+		ctx,
+		query,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		statusCode,
+		nullableString(delivery.LastError),
+		delivery.DeliveredAt,
+		time.Now(),
+		delivery.DeliveryID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.NotFound("webhook delivery")
+	}
+
+	return nil
+}
+
+func (r *PostgresWebhookRepository) querySubscriptions(ctx context.Context, query string, args ...interface{}) ([]*domain.WebhookSubscription, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []*domain.WebhookSubscription{}
+	for rows.Next() {
+		sub, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+func scanWebhookSubscription(row pgx.Row) (*domain.WebhookSubscription, error) {
+	sub := &domain.WebhookSubscription{}
+	err := row.Scan(
+		&sub.SubscriptionID,
+		&sub.TenantID,
+		&sub.URL,
+		&sub.Secret,
+		&sub.EventTypes,
+		&sub.Description,
+		&sub.IsActive,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func scanWebhookDelivery(row pgx.Row) (*domain.WebhookDelivery, error) {
+	delivery := &domain.WebhookDelivery{}
+	if err := row.Scan(webhookDeliveryFields(delivery)...); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func webhookDeliveryFields(delivery *domain.WebhookDelivery) []interface{} {
+	return []interface{}{
+		&delivery.DeliveryID,
+		&delivery.SubscriptionID,
+		&delivery.TenantID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+		&delivery.DeliveredAt,
+	}
+}
@@ -3,17 +3,28 @@ package repository
 import (
 	"auth-service/internal/domain"
 	"context"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+type TenantRepository interface {
+	Create(ctx context.Context, tenant *domain.Tenant) error
+	GetByID(ctx context.Context, tenantID uuid.UUID) (*domain.Tenant, error)
+	GetBySlug(ctx context.Context, slug string) (*domain.Tenant, error)
+	GetByDomain(ctx context.Context, domain string) (*domain.Tenant, error)
+	Update(ctx context.Context, tenant *domain.Tenant) error
+	List(ctx context.Context) ([]*domain.Tenant, error)
+}
+
 type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
-	GetByID(ctx context.Context, userID uuid.UUID) (*domain.User, error)
-	GetByUsername(ctx context.Context, username string) (*domain.User, error)
-	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	GetByID(ctx context.Context, tenantID, userID uuid.UUID) (*domain.User, error)
+	GetByUsername(ctx context.Context, tenantID uuid.UUID, username string) (*domain.User, error)
+	GetByEmail(ctx context.Context, tenantID uuid.UUID, email string) (*domain.User, error)
 	Update(ctx context.Context, user *domain.User) error
-	Delete(ctx context.Context, userID uuid.UUID) error
+	Delete(ctx context.Context, tenantID, userID uuid.UUID) error
 }
 
 type SessionRepository interface {
@@ -22,10 +33,112 @@ type SessionRepository interface {
 	GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.Session, error)
 	GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Session, error)
 	UpdateLastActivity(ctx context.Context, sessionID uuid.UUID) error
+	// Touch updates the last activity of a session that is neither revoked
+	// nor expired and was last active after activeSince. It returns
+	// NotFound for any other session.
+	Touch(ctx context.Context, sessionID uuid.UUID, activeSince time.Time) error
+	// Rotate stores the new refresh token, expiry and client details of
+	// session if previousRefreshToken is still its current token, and
+	// returns NotFound otherwise.
+	Rotate(ctx context.Context, session *domain.Session, previousRefreshToken string) error
 	Revoke(ctx context.Context, sessionID uuid.UUID) error
 	RevokeAllByUserID(ctx context.Context, userID uuid.UUID) error
 	DeleteByID(ctx context.Context, sessionID uuid.UUID) error
 	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
 	DeleteExpired(ctx context.Context) error
+	DeleteIdle(ctx context.Context, lastActiveBefore time.Time) error
+	CountActive(ctx context.Context) (int64, error)
 	ReplaceUserSession(ctx context.Context, session *domain.Session) error
 }
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *domain.APIKey) error
+	GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.APIKey, error)
+	UpdateLastUsed(ctx context.Context, keyID uuid.UUID, usedAt time.Time) error
+	Revoke(ctx context.Context, userID, keyID uuid.UUID) error
+}
+
+// ErrLastLoginMethod is returned when removing a login method would leave
+// the user unable to sign in.
+var ErrLastLoginMethod = errors.New("cannot remove the last login method")
+
+// IdentityRepository holds the identities users link at external providers.
+// Delete and DeletePassword remove a login method only while the user keeps
+// another one, checking and removing atomically; otherwise they return
+// ErrLastLoginMethod.
+type IdentityRepository interface {
+	Create(ctx context.Context, identity *domain.UserIdentity) error
+	GetByProviderSubject(ctx context.Context, tenantID uuid.UUID, provider, subject string) (*domain.UserIdentity, error)
+	ListByUserID(ctx context.Context, tenantID, userID uuid.UUID) ([]*domain.UserIdentity, error)
+	UpdateLastUsed(ctx context.Context, identityID uuid.UUID, usedAt time.Time) error
+	Delete(ctx context.Context, tenantID, userID, identityID uuid.UUID) error
+	DeletePassword(ctx context.Context, tenantID, userID uuid.UUID) error
+}
+
+// OIDCStateRepository holds in-flight upstream authorization requests.
+// Consume deletes the state it returns, so each can be redeemed once.
+type OIDCStateRepository interface {
+	Create(ctx context.Context, state *domain.OIDCLoginState) error
+	Consume(ctx context.Context, stateHash string) (*domain.OIDCLoginState, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+// MagicLinkRepository holds emailed login links. Consume deletes the link it
+// returns, so each can be used once.
+type MagicLinkRepository interface {
+	Create(ctx context.Context, link *domain.MagicLink) error
+	Consume(ctx context.Context, tenantID, linkID uuid.UUID) (*domain.MagicLink, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+// OTPRepository holds one-time codes. Create replaces the user's pending
+// code for the same purpose. RecordAttempt counts a verification attempt and
+// returns the code as updated; Delete consumes it and fails with NotFound if
+// it is already gone, so only one verification can succeed.
+type OTPRepository interface {
+	Create(ctx context.Context, otp *domain.OTPCode) error
+	RecordAttempt(ctx context.Context, tenantID, otpID uuid.UUID) (*domain.OTPCode, error)
+	Delete(ctx context.Context, tenantID, otpID uuid.UUID) error
+	DeleteExpired(ctx context.Context) error
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, event *domain.AuditEvent) error
+	List(ctx context.Context, filter *domain.AuditEventFilter) ([]*domain.AuditEvent, error)
+}
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error
+	GetSubscription(ctx context.Context, tenantID, subscriptionID uuid.UUID) (*domain.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, tenantID uuid.UUID) ([]*domain.WebhookSubscription, error)
+	ListSubscriptionsForEvent(ctx context.Context, tenantID uuid.UUID, eventType string) ([]*domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, tenantID, subscriptionID uuid.UUID) error
+
+	CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	GetDelivery(ctx context.Context, tenantID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, tenantID, subscriptionID uuid.UUID, limit int) ([]*domain.WebhookDelivery, error)
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+}
+
+// NotificationRepository keeps the record of outgoing notifications.
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *domain.Notification) error
+	Update(ctx context.Context, notification *domain.Notification) error
+	// List returns the most recent notifications of the tenant, or of one of
+	// its users when userID is not nil.
+	List(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID, limit int) ([]*domain.Notification, error)
+}
+
+// KnownDeviceRepository remembers the devices and networks users have logged
+// in from.
+type KnownDeviceRepository interface {
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.KnownDevice, error)
+	// Upsert stores device, or refreshes the last seen time and client
+	// details of the stored device with the same user, fingerprint and
+	// network. DeviceID and FirstSeenAt are set to those of the stored row.
+	Upsert(ctx context.Context, device *domain.KnownDevice) error
+	Delete(ctx context.Context, tenantID, deviceID uuid.UUID) error
+	DeleteStale(ctx context.Context, lastSeenBefore time.Time) error
+}
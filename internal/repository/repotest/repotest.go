@@ -0,0 +1,493 @@
+// Package repotest is a conformance suite for repository implementations.
+// Every backend must pass it so that services behave the same whichever
+// DB_DRIVER is configured. Call Run from a test in the backend's package:
+//
+//	func TestSQLite(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) repotest.Repos {
+//			db := openMigratedDatabase(t)
+//			return repotest.Repos{
+//				Users:    repository.NewSQLiteUserRepository(db),
+//				Sessions: repository.NewSQLiteSessionRepository(db),
+//			}
+//		})
+//	}
+//
+// The suite only uses the default tenant and generates unique usernames, so
+// a factory may share one database between subtests.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/repository"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+type Repos struct {
+	Users    repository.UserRepository
+	Sessions repository.SessionRepository
+}
+
+// Factory returns repositories backed by a migrated database.
+type Factory func(t *testing.T) Repos
+
+func Run(t *testing.T, newRepos Factory) {
+	t.Run("Users", func(t *testing.T) { runUsers(t, newRepos) })
+	t.Run("Sessions", func(t *testing.T) { runSessions(t, newRepos) })
+}
+
+func runUsers(t *testing.T, newRepos Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		ctx := context.Background()
+		users := newRepos(t).Users
+		user := newUser()
+
+		if err := users.Create(ctx, user); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if user.UserID == uuid.Nil || user.CreatedAt.IsZero() || user.UpdatedAt.IsZero() {
+			t.Fatalf("Create did not populate id and timestamps: %+v", user)
+		}
+
+		byID, err := users.GetByID(ctx, user.TenantID, user.UserID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		assertUser(t, byID, user)
+
+		byUsername, err := users.GetByUsername(ctx, user.TenantID, user.Username)
+		if err != nil {
+			t.Fatalf("GetByUsername: %v", err)
+		}
+		assertUser(t, byUsername, user)
+
+		byEmail, err := users.GetByEmail(ctx, user.TenantID, user.Email)
+		if err != nil {
+			t.Fatalf("GetByEmail: %v", err)
+		}
+		assertUser(t, byEmail, user)
+	})
+
+	t.Run("CreateDefaults", func(t *testing.T) {
+		ctx := context.Background()
+		users := newRepos(t).Users
+		user := newUser()
+		user.TenantID = uuid.Nil
+		user.Role = ""
+
+		if err := users.Create(ctx, user); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if user.TenantID != domain.DefaultTenantID {
+			t.Errorf("TenantID = %s, want the default tenant", user.TenantID)
+		}
+		if user.Role != domain.RoleUser {
+			t.Errorf("Role = %q, want %q", user.Role, domain.RoleUser)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		ctx := context.Background()
+		users := newRepos(t).Users
+
+		_, err := users.GetByID(ctx, domain.DefaultTenantID, uuid.New())
+		assertCode(t, err, apperrors.ErrCodeNotFound, "user not found")
+		_, err = users.GetByUsername(ctx, domain.DefaultTenantID, "missing")
+		assertCode(t, err, apperrors.ErrCodeNotFound, "user not found")
+		_, err = users.GetByEmail(ctx, domain.DefaultTenantID, "missing@example.com")
+		assertCode(t, err, apperrors.ErrCodeNotFound, "user not found")
+
+		missing := newUser()
+		missing.UserID = uuid.New()
+		assertCode(t, users.Update(ctx, missing), apperrors.ErrCodeNotFound, "user not found")
+		assertCode(t, users.Delete(ctx, domain.DefaultTenantID, missing.UserID), apperrors.ErrCodeNotFound, "user not found")
+	})
+
+	t.Run("DuplicateUsername", func(t *testing.T) {
+		ctx := context.Background()
+		users := newRepos(t).Users
+		existing := mustCreateUser(t, users)
+
+		duplicate := newUser()
+		duplicate.Username = existing.Username
+		assertCode(t, users.Create(ctx, duplicate), apperrors.ErrCodeAlreadyExists, "username already exists")
+	})
+
+	t.Run("DuplicateEmail", func(t *testing.T) {
+		ctx := context.Background()
+		users := newRepos(t).Users
+		existing := mustCreateUser(t, users)
+
+		duplicate := newUser()
+		duplicate.Email = existing.Email
+		assertCode(t, users.Create(ctx, duplicate), apperrors.ErrCodeAlreadyExists, "email already exists")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		ctx := context.Background()
+		users := newRepos(t).Users
+		user := mustCreateUser(t, users)
+
+		user.FullName = "Renamed User"
+		user.Role = domain.RoleAdmin
+		user.IsActive = false
+		if err := users.Update(ctx, user); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		got, err := users.GetByID(ctx, user.TenantID, user.UserID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		assertUser(t, got, user)
+		if !got.UpdatedAt.After(got.CreatedAt) && !got.UpdatedAt.Equal(got.CreatedAt) {
+			t.Errorf("UpdatedAt %v is before CreatedAt %v", got.UpdatedAt, got.CreatedAt)
+		}
+	})
+
+	t.Run("UpdateDuplicate", func(t *testing.T) {
+		ctx := context.Background()
+		users := newRepos(t).Users
+		first := mustCreateUser(t, users)
+		second := mustCreateUser(t, users)
+
+		second.Username = first.Username
+		assertCode(t, users.Update(ctx, second), apperrors.ErrCodeAlreadyExists, "username already exists")
+
+		second = mustCreateUser(t, users)
+		second.Email = first.Email
+		assertCode(t, users.Update(ctx, second), apperrors.ErrCodeAlreadyExists, "email already exists")
+	})
+
+	t.Run("TenantScoped", func(t *testing.T) {
+		ctx := context.Background()
+		users := newRepos(t).Users
+		user := mustCreateUser(t, users)
+		other := uuid.New()
+
+		_, err := users.GetByID(ctx, other, user.UserID)
+		assertCode(t, err, apperrors.ErrCodeNotFound, "user not found")
+		_, err = users.GetByUsername(ctx, other, user.Username)
+		assertCode(t, err, apperrors.ErrCodeNotFound, "user not found")
+		_, err = users.GetByEmail(ctx, other, user.Email)
+		assertCode(t, err, apperrors.ErrCodeNotFound, "user not found")
+		assertCode(t, users.Delete(ctx, other, user.UserID), apperrors.ErrCodeNotFound, "user not found")
+
+		moved := *user
+		moved.TenantID = other
+		assertCode(t, users.Update(ctx, &moved), apperrors.ErrCodeNotFound, "user not found")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		user := mustCreateUser(t, repos.Users)
+		session := mustCreateSession(t, repos.Sessions, user.UserID)
+
+		if err := repos.Users.Delete(ctx, user.TenantID, user.UserID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		_, err := repos.Users.GetByID(ctx, user.TenantID, user.UserID)
+		assertCode(t, err, apperrors.ErrCodeNotFound, "user not found")
+
+		_, err = repos.Sessions.GetByRefreshToken(ctx, session.RefreshToken)
+		assertCode(t, err, apperrors.ErrCodeNotFound, "session not found")
+	})
+}
+
+func runSessions(t *testing.T, newRepos Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		user := mustCreateUser(t, repos.Users)
+		session := mustCreateSession(t, repos.Sessions, user.UserID)
+
+		if session.SessionID == uuid.Nil || session.CreatedAt.IsZero() {
+			t.Fatalf("Create did not populate id and timestamps: %+v", session)
+		}
+
+		byToken, err := repos.Sessions.GetByRefreshToken(ctx, session.RefreshToken)
+		if err != nil {
+			t.Fatalf("GetByRefreshToken: %v", err)
+		}
+		assertSession(t, byToken, session)
+
+		byUser, err := repos.Sessions.GetByUserID(ctx, user.UserID)
+		if err != nil {
+			t.Fatalf("GetByUserID: %v", err)
+		}
+		assertSession(t, byUser, session)
+	})
+
+	t.Run("EmptyMetadata", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		user := mustCreateUser(t, repos.Users)
+		session := &domain.Session{
+			UserID:       user.UserID,
+			RefreshToken: uuid.NewString(),
+			ExpiresAt:    time.Now().Add(time.Hour),
+		}
+		if err := repos.Sessions.Create(ctx, session); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		got, err := repos.Sessions.GetByRefreshToken(ctx, session.RefreshToken)
+		if err != nil {
+			t.Fatalf("GetByRefreshToken: %v", err)
+		}
+		if got.DeviceInfo != "" || got.IPAddress != "" || got.UserAgent != "" {
+			t.Errorf("empty metadata read back as %q, %q, %q", got.DeviceInfo, got.IPAddress, got.UserAgent)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		ctx := context.Background()
+		sessions := newRepos(t).Sessions
+
+		_, err := sessions.GetByRefreshToken(ctx, uuid.NewString())
+		assertCode(t, err, apperrors.ErrCodeNotFound, "session not found")
+		_, err = sessions.GetByUserID(ctx, uuid.New())
+		assertCode(t, err, apperrors.ErrCodeNotFound, "session not found")
+		assertCode(t, sessions.UpdateLastActivity(ctx, uuid.New()), apperrors.ErrCodeNotFound, "session not found")
+		assertCode(t, sessions.Revoke(ctx, uuid.New()), apperrors.ErrCodeNotFound, "session not found")
+		assertCode(t, sessions.DeleteByID(ctx, uuid.New()), apperrors.ErrCodeNotFound, "session not found")
+
+		all, err := sessions.GetAllByUserID(ctx, uuid.New())
+		if err != nil {
+			t.Fatalf("GetAllByUserID: %v", err)
+		}
+		if len(all) != 0 {
+			t.Errorf("GetAllByUserID returned %d sessions for an unknown user", len(all))
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		user := mustCreateUser(t, repos.Users)
+		session := &domain.Session{
+			UserID:       user.UserID,
+			RefreshToken: uuid.NewString(),
+			ExpiresAt:    time.Now().Add(-time.Minute),
+		}
+		if err := repos.Sessions.Create(ctx, session); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		_, err := repos.Sessions.GetByRefreshToken(ctx, session.RefreshToken)
+		assertCode(t, err, apperrors.ErrCodeNotFound, "session not found")
+		_, err = repos.Sessions.GetByUserID(ctx, user.UserID)
+		assertCode(t, err, apperrors.ErrCodeNotFound, "session not found")
+
+		if err := repos.Sessions.DeleteExpired(ctx); err != nil {
+			t.Fatalf("DeleteExpired: %v", err)
+		}
+		all, err := repos.Sessions.GetAllByUserID(ctx, user.UserID)
+		if err != nil {
+			t.Fatalf("GetAllByUserID: %v", err)
+		}
+		if len(all) != 0 {
+			t.Errorf("DeleteExpired left %d sessions", len(all))
+		}
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		user := mustCreateUser(t, repos.Users)
+		session := mustCreateSession(t, repos.Sessions, user.UserID)
+
+		before, err := repos.Sessions.CountActive(ctx)
+		if err != nil {
+			t.Fatalf("CountActive: %v", err)
+		}
+
+		if err := repos.Sessions.Revoke(ctx, session.SessionID); err != nil {
+			t.Fatalf("Revoke: %v", err)
+		}
+		got, err := repos.Sessions.GetByRefreshToken(ctx, session.RefreshToken)
+		if err != nil {
+			t.Fatalf("GetByRefreshToken: %v", err)
+		}
+		if !got.IsRevoked || got.RevokedAt == nil || got.IsValid() {
+			t.Errorf("revoked session read back as %+v", got)
+		}
+
+		after, err := repos.Sessions.CountActive(ctx)
+		if err != nil {
+			t.Fatalf("CountActive: %v", err)
+		}
+		if after != before-1 {
+			t.Errorf("CountActive = %d after revoking, want %d", after, before-1)
+		}
+
+		// Only one unrevoked session per user is allowed; a revoked one
+		// no longer counts.
+		mustCreateSession(t, repos.Sessions, user.UserID)
+		if err := repos.Sessions.RevokeAllByUserID(ctx, user.UserID); err != nil {
+			t.Fatalf("RevokeAllByUserID: %v", err)
+		}
+		all, err := repos.Sessions.GetAllByUserID(ctx, user.UserID)
+		if err != nil {
+			t.Fatalf("GetAllByUserID: %v", err)
+		}
+		if len(all) != 2 {
+			t.Fatalf("GetAllByUserID returned %d sessions, want 2", len(all))
+		}
+		for _, s := range all {
+			if !s.IsRevoked {
+				t.Errorf("session %s not revoked by RevokeAllByUserID", s.SessionID)
+			}
+		}
+	})
+
+	t.Run("UpdateLastActivity", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		user := mustCreateUser(t, repos.Users)
+		session := mustCreateSession(t, repos.Sessions, user.UserID)
+
+		if err := repos.Sessions.UpdateLastActivity(ctx, session.SessionID); err != nil {
+			t.Fatalf("UpdateLastActivity: %v", err)
+		}
+		got, err := repos.Sessions.GetByRefreshToken(ctx, session.RefreshToken)
+		if err != nil {
+			t.Fatalf("GetByRefreshToken: %v", err)
+		}
+		if got.LastActivityAt.Before(session.CreatedAt.Truncate(time.Microsecond)) {
+			t.Errorf("LastActivityAt %v is before CreatedAt %v", got.LastActivityAt, session.CreatedAt)
+		}
+	})
+
+	t.Run("ReplaceUserSession", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		user := mustCreateUser(t, repos.Users)
+		old := mustCreateSession(t, repos.Sessions, user.UserID)
+
+		replacement := newSession(user.UserID)
+		if err := repos.Sessions.ReplaceUserSession(ctx, replacement); err != nil {
+			t.Fatalf("ReplaceUserSession: %v", err)
+		}
+		if replacement.SessionID == uuid.Nil || replacement.SessionID == old.SessionID {
+			t.Fatalf("ReplaceUserSession did not assign a new id: %s", replacement.SessionID)
+		}
+
+		_, err := repos.Sessions.GetByRefreshToken(ctx, old.RefreshToken)
+		assertCode(t, err, apperrors.ErrCodeNotFound, "session not found")
+
+		all, err := repos.Sessions.GetAllByUserID(ctx, user.UserID)
+		if err != nil {
+			t.Fatalf("GetAllByUserID: %v", err)
+		}
+		if len(all) != 1 || all[0].SessionID != replacement.SessionID {
+			t.Errorf("GetAllByUserID after replace = %d sessions, want only the replacement", len(all))
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		user := mustCreateUser(t, repos.Users)
+		session := mustCreateSession(t, repos.Sessions, user.UserID)
+
+		if err := repos.Sessions.DeleteByID(ctx, session.SessionID); err != nil {
+			t.Fatalf("DeleteByID: %v", err)
+		}
+		_, err := repos.Sessions.GetByRefreshToken(ctx, session.RefreshToken)
+		assertCode(t, err, apperrors.ErrCodeNotFound, "session not found")
+
+		mustCreateSession(t, repos.Sessions, user.UserID)
+		if err := repos.Sessions.DeleteByUserID(ctx, user.UserID); err != nil {
+			t.Fatalf("DeleteByUserID: %v", err)
+		}
+		if err := repos.Sessions.DeleteByUserID(ctx, user.UserID); err != nil {
+			t.Errorf("DeleteByUserID with no sessions: %v", err)
+		}
+		_, err = repos.Sessions.GetByUserID(ctx, user.UserID)
+		assertCode(t, err, apperrors.ErrCodeNotFound, "session not found")
+	})
+}
+
+func newUser() *domain.User {
+	suffix := strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+	return &domain.User{
+		TenantID:     domain.DefaultTenantID,
+		Username:     "user_" + suffix,
+		Email:        suffix + "@example.com",
+		PasswordHash: "$2a$10$" + suffix,
+		FullName:     "Test User",
+		Role:         domain.RoleUser,
+		IsActive:     true,
+	}
+}
+
+func mustCreateUser(t *testing.T, users repository.UserRepository) *domain.User {
+	t.Helper()
+	user := newUser()
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	return user
+}
+
+func newSession(userID uuid.UUID) *domain.Session {
+	return &domain.Session{
+		UserID:       userID,
+		RefreshToken: uuid.NewString(),
+		DeviceInfo:   "test device",
+		IPAddress:    "192.0.2.1",
+		UserAgent:    "repotest",
+		ExpiresAt:    time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+}
+
+func mustCreateSession(t *testing.T, sessions repository.SessionRepository, userID uuid.UUID) *domain.Session {
+	t.Helper()
+	session := newSession(userID)
+	if err := sessions.Create(context.Background(), session); err != nil {
+		t.Fatalf("Create session: %v", err)
+	}
+	return session
+}
+
+func assertUser(t *testing.T, got, want *domain.User) {
+	t.Helper()
+	if got.UserID != want.UserID || got.TenantID != want.TenantID || got.Username != want.Username ||
+		got.Email != want.Email || got.PasswordHash != want.PasswordHash || got.FullName != want.FullName ||
+		got.Role != want.Role || got.IsActive != want.IsActive {
+		t.Errorf("user = %+v, want %+v", got, want)
+	}
+}
+
+func assertSession(t *testing.T, got, want *domain.Session) {
+	t.Helper()
+	if got.SessionID != want.SessionID || got.UserID != want.UserID || got.RefreshToken != want.RefreshToken ||
+		got.DeviceInfo != want.DeviceInfo || got.IPAddress != want.IPAddress || got.UserAgent != want.UserAgent ||
+		got.IsRevoked || got.RevokedAt != nil {
+		t.Errorf("session = %+v, want %+v", got, want)
+	}
+	if !got.ExpiresAt.Equal(want.ExpiresAt) {
+		t.Errorf("ExpiresAt = %v, want %v", got.ExpiresAt, want.ExpiresAt)
+	}
+}
+
+func assertCode(t *testing.T, err error, code apperrors.ErrorCode, message string) {
+	t.Helper()
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		t.Errorf("error = %v, want %s %q", err, code, message)
+		return
+	}
+	if appErr.Code != code || appErr.Message != message {
+		t.Errorf("error = %s %q, want %s %q", appErr.Code, appErr.Message, code, message)
+	}
+}
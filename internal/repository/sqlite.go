@@ -0,0 +1,599 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type SQLiteUserRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteUserRepository(db *sql.DB) *SQLiteUserRepository {
+	return &SQLiteUserRepository{db: db}
+}
+
+func (r *SQLiteUserRepository) Create(ctx context.Context, user *domain.User) error {
+	query := `
+		INSERT INTO users (user_id, tenant_id, username, email, password_hash, full_name, role, is_active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	if user.TenantID == uuid.Nil {
+		user.TenantID = domain.DefaultTenantID
+	}
+	if user.Role == "" {
+		user.Role = domain.RoleUser
+	}
+
+	now := sqliteNow()
+	userID := uuid.New()
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		userID,
+		user.TenantID,
+		user.Username,
+		user.Email,
+		user.PasswordHash,
+		user.FullName,
+		user.Role,
+		user.IsActive,
+		now,
+		now,
+	)
+
+	if err != nil {
+		if isSQLiteUniqueViolation(err, "users.tenant_id, users.username") {
+			return apperrors.AlreadyExists("username")
+		}
+		if isSQLiteUniqueViolation(err, "users.tenant_id, users.email") {
+			return apperrors.AlreadyExists("email")
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	user.UserID = userID
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
+	return nil
+}
+
+func (r *SQLiteUserRepository) GetByID(ctx context.Context, tenantID, userID uuid.UUID) (*domain.User, error) {
+	query := `
+		SELECT user_id, tenant_id, username, email, password_hash, full_name, role, is_active, created_at, updated_at
+		FROM users
+		WHERE tenant_id = ? AND user_id = ?
+	`
+
+	user, err := scanSQLiteUser(r.db.QueryRowContext(ctx, query, tenantID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("user")
+		}
+		return nil, fmt.Errorf("failed to get user by id: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *SQLiteUserRepository) GetByUsername(ctx context.Context, tenantID uuid.UUID, username string) (*domain.User, error) {
+	query := `
+		SELECT user_id, tenant_id, username, email, password_hash, full_name, role, is_active, created_at, updated_at
+		FROM users
+		WHERE tenant_id = ? AND username = ?
+	`
+
+	user, err := scanSQLiteUser(r.db.QueryRowContext(ctx, query, tenantID, username))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("user")
+		}
+		return nil, fmt.Errorf("failed to get user by username: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *SQLiteUserRepository) GetByEmail(ctx context.Context, tenantID uuid.UUID, email string) (*domain.User, error) {
+	query := `
+		SELECT user_id, tenant_id, username, email, password_hash, full_name, role, is_active, created_at, updated_at
+		FROM users
+		WHERE tenant_id = ? AND email = ?
+	`
+
+	user, err := scanSQLiteUser(r.db.QueryRowContext(ctx, query, tenantID, email))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("user")
+		}
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *SQLiteUserRepository) Update(ctx context.Context, user *domain.User) error {
+	query := `
+		UPDATE users
+		SET username = ?, email = ?, password_hash = ?, full_name = ?, role = ?, is_active = ?, updated_at = ?
+		WHERE tenant_id = ? AND user_id = ?
+	`
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		user.Username,
+		user.Email,
+		user.PasswordHash,
+		user.FullName,
+		user.Role,
+		user.IsActive,
+		sqliteNow(),
+		user.TenantID,
+		user.UserID,
+	)
+
+	if err != nil {
+		if isSQLiteUniqueViolation(err, "users.tenant_id, users.username") {
+			return apperrors.AlreadyExists("username")
+		}
+		if isSQLiteUniqueViolation(err, "users.tenant_id, users.email") {
+			return apperrors.AlreadyExists("email")
+		}
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return requireRows(result, "user")
+}
+
+func (r *SQLiteUserRepository) Delete(ctx context.Context, tenantID, userID uuid.UUID) error {
+	query := `DELETE FROM users WHERE tenant_id = ? AND user_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, tenantID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	return requireRows(result, "user")
+}
+
+func scanSQLiteUser(row *sql.Row) (*domain.User, error) {
+	user := &domain.User{}
+	err := row.Scan(
+		&user.UserID,
+		&user.TenantID,
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
+		&user.FullName,
+		&user.Role,
+		&user.IsActive,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+const sqliteSessionColumns = `
+	session_id, user_id, refresh_token, COALESCE(device_info, ''),
+	COALESCE(ip_address, ''), COALESCE(user_agent, ''), last_activity_at, expires_at,
+	created_at, updated_at, is_revoked, revoked_at,
+	lifetime_profile, remember_me, access_token_expiry, refresh_token_expiry
+`
+
+type SQLiteSessionRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteSessionRepository(db *sql.DB) *SQLiteSessionRepository {
+	return &SQLiteSessionRepository{db: db}
+}
+
+func (r *SQLiteSessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	if err := insertSQLiteSession(ctx, r.db, session); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteSessionRepository) GetByRefreshToken(ctx context.Context, refreshToken string) (*domain.Session, error) {
+	query := `SELECT ` + sqliteSessionColumns + ` FROM sessions WHERE refresh_token = ? AND expires_at > ?`
+
+	session, err := scanSQLiteSession(r.db.QueryRowContext(ctx, query, refreshToken, sqliteNow()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("session")
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (r *SQLiteSessionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.Session, error) {
+	query := `
+		SELECT ` + sqliteSessionColumns + `
+		FROM sessions
+		WHERE user_id = ? AND expires_at > ?
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	session, err := scanSQLiteSession(r.db.QueryRowContext(ctx, query, userID, sqliteNow()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("session")
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (r *SQLiteSessionRepository) GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Session, error) {
+	query := `SELECT ` + sqliteSessionColumns + ` FROM sessions WHERE user_id = ? ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*domain.Session
+	for rows.Next() {
+		session, err := scanSQLiteSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *SQLiteSessionRepository) UpdateLastActivity(ctx context.Context, sessionID uuid.UUID) error {
+	query := `UPDATE sessions SET last_activity_at = ?, updated_at = ? WHERE session_id = ?`
+
+	now := sqliteNow()
+	result, err := r.db.ExecContext(ctx, query, now, now, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to update session activity: %w", err)
+	}
+
+	return requireRows(result, "session")
+}
+
+func (r *SQLiteSessionRepository) Touch(ctx context.Context, sessionID uuid.UUID, activeSince time.Time) error {
+	query := `
+		UPDATE sessions
+		SET last_activity_at = ?, updated_at = ?
+		WHERE session_id = ? AND is_revoked = 0 AND expires_at > ? AND last_activity_at > ?
+	`
+
+	now := sqliteNow()
+	result, err := r.db.ExecContext(ctx, query, now, now, sessionID, now, activeSince.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to update session activity: %w", err)
+	}
+
+	return requireRows(result, "session")
+}
+
+func (r *SQLiteSessionRepository) Rotate(ctx context.Context, session *domain.Session, previousRefreshToken string) error {
+	query := `
+		UPDATE sessions
+		SET refresh_token = ?, device_info = ?, ip_address = ?, user_agent = ?,
+			expires_at = ?, last_activity_at = ?, updated_at = ?
+		WHERE session_id = ? AND refresh_token = ? AND is_revoked = 0
+	`
+
+	now := sqliteNow()
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		session.RefreshToken,
+		nullableString(session.DeviceInfo),
+		nullableString(session.IPAddress),
+		nullableString(session.UserAgent),
+		session.ExpiresAt.UTC(),
+		now,
+		now,
+		session.SessionID,
+		previousRefreshToken,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to rotate session: %w", err)
+	}
+	if err := requireRows(result, "session"); err != nil {
+		return err
+	}
+
+	session.LastActivityAt = now
+	session.UpdatedAt = now
+	return nil
+}
+
+func (r *SQLiteSessionRepository) Revoke(ctx context.Context, sessionID uuid.UUID) error {
+	query := `UPDATE sessions SET is_revoked = 1, revoked_at = ?, updated_at = ? WHERE session_id = ?`
+
+	now := sqliteNow()
+	result, err := r.db.ExecContext(ctx, query, now, now, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return requireRows(result, "session")
+}
+
+func (r *SQLiteSessionRepository) RevokeAllByUserID(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		UPDATE sessions
+		SET is_revoked = 1, revoked_at = ?, updated_at = ?
+		WHERE user_id = ? AND is_revoked = 0
+	`
+
+	now := sqliteNow()
+	if _, err := r.db.ExecContext(ctx, query, now, now, userID); err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteSessionRepository) DeleteByID(ctx context.Context, sessionID uuid.UUID) error {
+	query := `DELETE FROM sessions WHERE session_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return requireRows(result, "session")
+}
+
+func (r *SQLiteSessionRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	query := `DELETE FROM sessions WHERE user_id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to delete user sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteSessionRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM sessions WHERE expires_at < ?`
+
+	if _, err := r.db.ExecContext(ctx, query, sqliteNow()); err != nil {
+		return fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteSessionRepository) DeleteIdle(ctx context.Context, lastActiveBefore time.Time) error {
+	query := `DELETE FROM sessions WHERE last_activity_at < ?`
+
+	if _, err := r.db.ExecContext(ctx, query, lastActiveBefore.UTC()); err != nil {
+		return fmt.Errorf("failed to delete idle sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteSessionRepository) CountActive(ctx context.Context) (int64, error) {
+	query := `SELECT COUNT(*) FROM sessions WHERE is_revoked = 0 AND expires_at > ?`
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, query, sqliteNow()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count active sessions: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *SQLiteSessionRepository) ReplaceUserSession(ctx context.Context, session *domain.Session) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = ?`, session.UserID); err != nil {
+		return fmt.Errorf("failed to delete existing sessions: %w", err)
+	}
+
+	if err := insertSQLiteSession(ctx, tx, session); err != nil {
+		return fmt.Errorf("failed to create new session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+type sqliteExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertSQLiteSession(ctx context.Context, db sqliteExecer, session *domain.Session) error {
+	query := `
+		INSERT INTO sessions (
+			session_id, user_id, refresh_token, device_info,
+			ip_address, user_agent, last_activity_at, expires_at,
+			created_at, updated_at, is_revoked, lifetime_profile,
+			remember_me, access_token_expiry, refresh_token_expiry
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?, ?, ?, ?)
+	`
+
+	now := sqliteNow()
+	sessionID := session.SessionID
+	if sessionID == uuid.Nil {
+		sessionID = uuid.New()
+	}
+	_, err := db.ExecContext(
+		ctx,
+		query,
+		sessionID,
+		session.UserID,
+		session.RefreshToken,
+		nullableString(session.DeviceInfo),
+		nullableString(session.IPAddress),
+		nullableString(session.UserAgent),
+		now,
+		session.ExpiresAt.UTC(),
+		now,
+		now,
+		session.Lifetime.Profile,
+		session.Lifetime.RememberMe,
+		int(session.Lifetime.AccessTokenExpiry/time.Second),
+		int(session.Lifetime.RefreshTokenExpiry/time.Second),
+	)
+	if err != nil {
+		return err
+	}
+
+	session.SessionID = sessionID
+	session.CreatedAt = now
+	session.UpdatedAt = now
+	return nil
+}
+
+// sqliteScanner is satisfied by both *sql.Row and *sql.Rows.
+type sqliteScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSQLiteSession(row sqliteScanner) (*domain.Session, error) {
+	session := &domain.Session{}
+	var accessExpirySeconds, refreshExpirySeconds int
+	err := row.Scan(
+		&session.SessionID,
+		&session.UserID,
+		&session.RefreshToken,
+		&session.DeviceInfo,
+		&session.IPAddress,
+		&session.UserAgent,
+		&session.LastActivityAt,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+		&session.UpdatedAt,
+		&session.IsRevoked,
+		&session.RevokedAt,
+		&session.Lifetime.Profile,
+		&session.Lifetime.RememberMe,
+		&accessExpirySeconds,
+		&refreshExpirySeconds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	session.Lifetime.AccessTokenExpiry = time.Duration(accessExpirySeconds) * time.Second
+	session.Lifetime.RefreshTokenExpiry = time.Duration(refreshExpirySeconds) * time.Second
+	return session, nil
+}
+
+// sqliteNow is the current time in UTC. Timestamps are stored as text, so
+// every value must share a zone for comparisons and ordering to hold.
+func sqliteNow() time.Time {
+	return time.Now().UTC()
+}
+
+func sqliteTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+// isSQLiteUniqueViolation reports whether err is a UNIQUE constraint failure
+// on exactly columns, given as SQLite reports them ("table.a, table.b").
+func isSQLiteUniqueViolation(err error, columns string) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return strings.Contains(sqliteErr.Error(), "UNIQUE constraint failed: "+columns+" ")
+	}
+	return false
+}
+
+func requireRows(result sql.Result, resource string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return apperrors.NotFound(resource)
+	}
+	return nil
+}
+
+// jsonStrings stores a string list as a JSON array, standing in for the
+// TEXT[] columns of the PostgreSQL schema.
+type jsonStrings []string
+
+func (s jsonStrings) Value() (driver.Value, error) {
+	if s == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]string(s))
+	return string(data), err
+}
+
+func (s *jsonStrings) Scan(src interface{}) error {
+	return scanJSON(src, (*[]string)(s))
+}
+
+// jsonMap stores metadata as a JSON object, standing in for JSONB.
+type jsonMap map[string]string
+
+func (m jsonMap) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(map[string]string(m))
+	return string(data), err
+}
+
+func (m *jsonMap) Scan(src interface{}) error {
+	return scanJSON(src, (*map[string]string)(m))
+}
+
+func scanJSON(src interface{}, dest interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	case []byte:
+		return json.Unmarshal(v, dest)
+	default:
+		return fmt.Errorf("cannot scan %T as JSON", src)
+	}
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+type SQLiteAPIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteAPIKeyRepository(db *sql.DB) *SQLiteAPIKeyRepository {
+	return &SQLiteAPIKeyRepository{db: db}
+}
+
+func (r *SQLiteAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	query := `
+		INSERT INTO api_keys (key_id, tenant_id, user_id, name, key_prefix, key_hash, scopes, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := sqliteNow()
+	keyID := uuid.New()
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		keyID,
+		key.TenantID,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		jsonStrings(key.Scopes),
+		sqliteTime(key.ExpiresAt),
+		now,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	key.KeyID = keyID
+	key.CreatedAt = now
+
+	return nil
+}
+
+func (r *SQLiteAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = ?`
+
+	key, err := scanSQLiteAPIKey(r.db.QueryRowContext(ctx, query, keyHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("api key")
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (r *SQLiteAPIKeyRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = ? ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*domain.APIKey{}
+	for rows.Next() {
+		key, err := scanSQLiteAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *SQLiteAPIKeyRepository) UpdateLastUsed(ctx context.Context, keyID uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = ? WHERE key_id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, usedAt.UTC(), keyID); err != nil {
+		return fmt.Errorf("failed to update api key last used: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteAPIKeyRepository) Revoke(ctx context.Context, userID, keyID uuid.UUID) error {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = ?
+		WHERE key_id = ? AND user_id = ? AND revoked_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteNow(), keyID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	return requireRows(result, "api key")
+}
+
+func scanSQLiteAPIKey(row sqliteScanner) (*domain.APIKey, error) {
+	key := &domain.APIKey{}
+	var scopes jsonStrings
+	err := row.Scan(
+		&key.KeyID,
+		&key.TenantID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&scopes,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	key.Scopes = scopes
+	return key, nil
+}
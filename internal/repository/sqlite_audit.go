@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"auth-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type SQLiteAuditRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteAuditRepository(db *sql.DB) *SQLiteAuditRepository {
+	return &SQLiteAuditRepository{db: db}
+}
+
+func (r *SQLiteAuditRepository) Create(ctx context.Context, event *domain.AuditEvent) error {
+	query := `
+		INSERT INTO audit_events (
+			event_id, tenant_id, event_type, result, reason, actor_user_id, target_user_id,
+			ip_address, user_agent, request_id, metadata, created_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := sqliteNow()
+	eventID := uuid.New()
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		eventID,
+		event.TenantID,
+		event.EventType,
+		event.Result,
+		nullableString(event.Reason),
+		event.ActorUserID,
+		event.TargetUserID,
+		nullableString(event.IPAddress),
+		nullableString(event.UserAgent),
+		nullableString(event.RequestID),
+		jsonMap(event.Metadata),
+		now,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create audit event: %w", err)
+	}
+
+	event.EventID = eventID
+	event.CreatedAt = now
+
+	return nil
+}
+
+func (r *SQLiteAuditRepository) List(ctx context.Context, filter *domain.AuditEventFilter) ([]*domain.AuditEvent, error) {
+	conditions := []string{"tenant_id = ?"}
+	args := []interface{}{filter.TenantID}
+
+	if filter.UserID != nil {
+		conditions = append(conditions, "(actor_user_id = ? OR target_user_id = ?)")
+		args = append(args, *filter.UserID, *filter.UserID)
+	}
+	if len(filter.EventTypes) > 0 {
+		conditions = append(conditions, "event_type IN (?"+strings.Repeat(", ?", len(filter.EventTypes)-1)+")")
+		for _, eventType := range filter.EventTypes {
+			args = append(args, eventType)
+		}
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From.UTC())
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To.UTC())
+	}
+	args = append(args, filter.Limit, filter.Offset)
+
+	query := `
+		SELECT event_id, tenant_id, event_type, result, COALESCE(reason, ''),
+		       actor_user_id, target_user_id, COALESCE(ip_address, ''),
+		       COALESCE(user_agent, ''), COALESCE(request_id, ''), metadata, created_at
+		FROM audit_events
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*domain.AuditEvent{}
+	for rows.Next() {
+		event := &domain.AuditEvent{}
+		var metadata jsonMap
+		err := rows.Scan(
+			&event.EventID,
+			&event.TenantID,
+			&event.EventType,
+			&event.Result,
+			&event.Reason,
+			&event.ActorUserID,
+			&event.TargetUserID,
+			&event.IPAddress,
+			&event.UserAgent,
+			&event.RequestID,
+			&metadata,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		event.Metadata = metadata
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	return events, nil
+}
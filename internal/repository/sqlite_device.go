@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"auth-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type SQLiteKnownDeviceRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteKnownDeviceRepository(db *sql.DB) *SQLiteKnownDeviceRepository {
+	return &SQLiteKnownDeviceRepository{db: db}
+}
+
+func (r *SQLiteKnownDeviceRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.KnownDevice, error) {
+	query := `
+		SELECT device_id, tenant_id, user_id, fingerprint, network, COALESCE(device_info, ''),
+			COALESCE(ip_address, ''), COALESCE(location, ''), first_seen_at, last_seen_at
+		FROM known_devices
+		WHERE user_id = ?
+		ORDER BY last_seen_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list known devices: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []*domain.KnownDevice
+	for rows.Next() {
+		device := &domain.KnownDevice{}
+		err := rows.Scan(
+			&device.DeviceID,
+			&device.TenantID,
+			&device.UserID,
+			&device.Fingerprint,
+			&device.Network,
+			&device.DeviceInfo,
+			&device.IPAddress,
+			&device.Location,
+			&device.FirstSeenAt,
+			&device.LastSeenAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan known device: %w", err)
+		}
+		devices = append(devices, device)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list known devices: %w", err)
+	}
+
+	return devices, nil
+}
+
+func (r *SQLiteKnownDeviceRepository) Upsert(ctx context.Context, device *domain.KnownDevice) error {
+	query := `
+		INSERT INTO known_devices (
+			device_id, tenant_id, user_id, fingerprint, network,
+			device_info, ip_address, location, first_seen_at, last_seen_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, fingerprint, network) DO UPDATE
+		SET device_info = excluded.device_info, ip_address = excluded.ip_address,
+			location = excluded.location, last_seen_at = excluded.last_seen_at
+		RETURNING device_id, first_seen_at, last_seen_at
+	`
+
+	if device.DeviceID == uuid.Nil {
+		device.DeviceID = uuid.New()
+	}
+
+	now := sqliteNow()
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		device.DeviceID,
+		device.TenantID,
+		device.UserID,
+		device.Fingerprint,
+		device.Network,
+		nullableString(device.DeviceInfo),
+		nullableString(device.IPAddress),
+		nullableString(device.Location),
+		now,
+		now,
+	).Scan(&device.DeviceID, &device.FirstSeenAt, &device.LastSeenAt)
+	if err != nil {
+		return fmt.Errorf("failed to record known device: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteKnownDeviceRepository) Delete(ctx context.Context, tenantID, deviceID uuid.UUID) error {
+	query := `DELETE FROM known_devices WHERE device_id = ? AND tenant_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, deviceID, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete known device: %w", err)
+	}
+
+	return requireRows(result, "device")
+}
+
+func (r *SQLiteKnownDeviceRepository) DeleteStale(ctx context.Context, lastSeenBefore time.Time) error {
+	query := `DELETE FROM known_devices WHERE last_seen_at < ?`
+
+	if _, err := r.db.ExecContext(ctx, query, lastSeenBefore.UTC()); err != nil {
+		return fmt.Errorf("failed to delete stale known devices: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+type SQLiteIdentityRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteIdentityRepository(db *sql.DB) *SQLiteIdentityRepository {
+	return &SQLiteIdentityRepository{db: db}
+}
+
+func (r *SQLiteIdentityRepository) Create(ctx context.Context, identity *domain.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (identity_id, tenant_id, user_id, provider, subject, email, created_at)
+		VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), ?)
+	`
+
+	now := sqliteNow()
+	identityID := uuid.New()
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		identityID,
+		identity.TenantID,
+		identity.UserID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+		now,
+	)
+
+	if err != nil {
+		if isSQLiteUniqueViolation(err, "user_identities.tenant_id, user_identities.provider, user_identities.subject") {
+			return apperrors.AlreadyExists("identity")
+		}
+		return fmt.Errorf("failed to create identity: %w", err)
+	}
+
+	identity.IdentityID = identityID
+	identity.CreatedAt = now
+
+	return nil
+}
+
+func (r *SQLiteIdentityRepository) GetByProviderSubject(ctx context.Context, tenantID uuid.UUID, provider, subject string) (*domain.UserIdentity, error) {
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE tenant_id = ? AND provider = ? AND subject = ?`
+
+	identity, err := scanSQLiteIdentity(r.db.QueryRowContext(ctx, query, tenantID, provider, subject))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("identity")
+		}
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	return identity, nil
+}
+
+func (r *SQLiteIdentityRepository) ListByUserID(ctx context.Context, tenantID, userID uuid.UUID) ([]*domain.UserIdentity, error) {
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE tenant_id = ? AND user_id = ? ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+	defer rows.Close()
+
+	identities := []*domain.UserIdentity{}
+	for rows.Next() {
+		identity, err := scanSQLiteIdentity(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan identity: %w", err)
+		}
+		identities = append(identities, identity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+
+	return identities, nil
+}
+
+func (r *SQLiteIdentityRepository) UpdateLastUsed(ctx context.Context, identityID uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE user_identities SET last_used_at = ? WHERE identity_id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, usedAt.UTC(), identityID); err != nil {
+		return fmt.Errorf("failed to update identity last used: %w", err)
+	}
+
+	return nil
+}
+
+// Delete checks for another login method in the DELETE itself: SQLite runs
+// one writer at a time, so no concurrent removal can interleave with it.
+func (r *SQLiteIdentityRepository) Delete(ctx context.Context, tenantID, userID, identityID uuid.UUID) error {
+	query := `
+		DELETE FROM user_identities
+		WHERE tenant_id = ? AND user_id = ? AND identity_id = ?
+		AND (
+			EXISTS (SELECT 1 FROM users WHERE tenant_id = ? AND user_id = ? AND password_hash <> '')
+			OR EXISTS (SELECT 1 FROM user_identities WHERE tenant_id = ? AND user_id = ? AND identity_id <> ?)
+		)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, tenantID, userID, identityID, tenantID, userID, tenantID, userID, identityID)
+	if err != nil {
+		return fmt.Errorf("failed to delete identity: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete identity: %w", err)
+	}
+	if rows > 0 {
+		return nil
+	}
+
+	var exists bool
+	existsQuery := `SELECT EXISTS (SELECT 1 FROM user_identities WHERE tenant_id = ? AND user_id = ? AND identity_id = ?)`
+	if err := r.db.QueryRowContext(ctx, existsQuery, tenantID, userID, identityID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to get identity: %w", err)
+	}
+	if exists {
+		return ErrLastLoginMethod
+	}
+	return apperrors.NotFound("identity")
+}
+
+// DeletePassword checks for a linked identity in the UPDATE itself, as
+// Delete does.
+func (r *SQLiteIdentityRepository) DeletePassword(ctx context.Context, tenantID, userID uuid.UUID) error {
+	query := `
+		UPDATE users SET password_hash = '', updated_at = ?
+		WHERE tenant_id = ? AND user_id = ? AND password_hash <> ''
+		AND EXISTS (SELECT 1 FROM user_identities WHERE tenant_id = ? AND user_id = ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteNow(), tenantID, userID, tenantID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete password: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete password: %w", err)
+	}
+	if rows > 0 {
+		return nil
+	}
+
+	var hasPassword bool
+	passwordQuery := `SELECT password_hash <> '' FROM users WHERE tenant_id = ? AND user_id = ?`
+	if err := r.db.QueryRowContext(ctx, passwordQuery, tenantID, userID).Scan(&hasPassword); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperrors.NotFound("user")
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if hasPassword {
+		return ErrLastLoginMethod
+	}
+	return apperrors.NotFound("password")
+}
+
+func scanSQLiteIdentity(row sqliteScanner) (*domain.UserIdentity, error) {
+	identity := &domain.UserIdentity{}
+	err := row.Scan(
+		&identity.IdentityID,
+		&identity.TenantID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		&identity.CreatedAt,
+		&identity.LastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+type SQLiteOIDCStateRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteOIDCStateRepository(db *sql.DB) *SQLiteOIDCStateRepository {
+	return &SQLiteOIDCStateRepository{db: db}
+}
+
+func (r *SQLiteOIDCStateRepository) Create(ctx context.Context, state *domain.OIDCLoginState) error {
+	query := `
+		INSERT INTO oidc_login_states (state_hash, tenant_id, user_id, provider, nonce, code_verifier, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	state.CreatedAt = sqliteNow()
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		state.StateHash,
+		state.TenantID,
+		state.UserID,
+		state.Provider,
+		state.Nonce,
+		state.CodeVerifier,
+		state.ExpiresAt.UTC(),
+		state.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create oidc login state: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteOIDCStateRepository) Consume(ctx context.Context, stateHash string) (*domain.OIDCLoginState, error) {
+	query := `
+		DELETE FROM oidc_login_states
+		WHERE state_hash = ?
+		RETURNING state_hash, tenant_id, user_id, provider, nonce, code_verifier, expires_at, created_at
+	`
+
+	state := &domain.OIDCLoginState{}
+	err := r.db.QueryRowContext(ctx, query, stateHash).Scan(
+		&state.StateHash,
+		&state.TenantID,
+		&state.UserID,
+		&state.Provider,
+		&state.Nonce,
+		&state.CodeVerifier,
+		&state.ExpiresAt,
+		&state.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("login state")
+		}
+		return nil, fmt.Errorf("failed to consume oidc login state: %w", err)
+	}
+
+	return state, nil
+}
+
+func (r *SQLiteOIDCStateRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM oidc_login_states WHERE expires_at < ?`
+
+	if _, err := r.db.ExecContext(ctx, query, sqliteNow()); err != nil {
+		return fmt.Errorf("failed to delete expired oidc login states: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+type SQLiteMagicLinkRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteMagicLinkRepository(db *sql.DB) *SQLiteMagicLinkRepository {
+	return &SQLiteMagicLinkRepository{db: db}
+}
+
+func (r *SQLiteMagicLinkRepository) Create(ctx context.Context, link *domain.MagicLink) error {
+	query := `
+		INSERT INTO magic_links (link_id, tenant_id, user_id, nonce_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	link.LinkID = uuid.New()
+	link.CreatedAt = sqliteNow()
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		link.LinkID,
+		link.TenantID,
+		link.UserID,
+		link.NonceHash,
+		link.ExpiresAt.UTC(),
+		link.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create magic link: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteMagicLinkRepository) Consume(ctx context.Context, tenantID, linkID uuid.UUID) (*domain.MagicLink, error) {
+	query := `
+		DELETE FROM magic_links
+		WHERE link_id = ? AND tenant_id = ?
+		RETURNING link_id, tenant_id, user_id, nonce_hash, expires_at, created_at
+	`
+
+	link := &domain.MagicLink{}
+	err := r.db.QueryRowContext(ctx, query, linkID, tenantID).Scan(
+		&link.LinkID,
+		&link.TenantID,
+		&link.UserID,
+		&link.NonceHash,
+		&link.ExpiresAt,
+		&link.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("magic link")
+		}
+		return nil, fmt.Errorf("failed to consume magic link: %w", err)
+	}
+
+	return link, nil
+}
+
+func (r *SQLiteMagicLinkRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM magic_links WHERE expires_at < ?`
+
+	if _, err := r.db.ExecContext(ctx, query, sqliteNow()); err != nil {
+		return fmt.Errorf("failed to delete expired magic links: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"auth-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type SQLiteNotificationRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteNotificationRepository(db *sql.DB) *SQLiteNotificationRepository {
+	return &SQLiteNotificationRepository{db: db}
+}
+
+func (r *SQLiteNotificationRepository) Create(ctx context.Context, notification *domain.Notification) error {
+	query := `
+		INSERT INTO notifications (
+			notification_id, tenant_id, user_id, template, locale, recipient, subject,
+			status, attempts, created_at, updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, 0, ?, ?)
+	`
+
+	now := sqliteNow()
+	notificationID := uuid.New()
+	if notification.Status == "" {
+		notification.Status = domain.NotificationQueued
+	}
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		notificationID,
+		notification.TenantID,
+		notification.UserID,
+		notification.Template,
+		notification.Locale,
+		notification.Recipient,
+		notification.Subject,
+		notification.Status,
+		now,
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	notification.NotificationID = notificationID
+	notification.CreatedAt = now
+	notification.UpdatedAt = now
+
+	return nil
+}
+
+func (r *SQLiteNotificationRepository) Update(ctx context.Context, notification *domain.Notification) error {
+	query := `
+		UPDATE notifications
+		SET status = ?, attempts = ?, last_error = ?, sent_at = ?, updated_at = ?
+		WHERE notification_id = ?
+	`
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		notification.Status,
+		notification.Attempts,
+		nullableString(notification.LastError),
+		sqliteTime(notification.SentAt),
+		sqliteNow(),
+		notification.NotificationID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update notification: %w", err)
+	}
+
+	return requireRows(result, "notification")
+}
+
+func (r *SQLiteNotificationRepository) List(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID, limit int) ([]*domain.Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE tenant_id = ?`
+	args := []interface{}{tenantID}
+	if userID != nil {
+		query += ` AND user_id = ?`
+		args = append(args, *userID)
+	}
+	query += ` ORDER BY created_at DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []*domain.Notification{}
+	for rows.Next() {
+		notification := &domain.Notification{}
+		if err := rows.Scan(notificationFields(notification)...); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, notification)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	return notifications, nil
+}
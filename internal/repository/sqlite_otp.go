@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+type SQLiteOTPRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteOTPRepository(db *sql.DB) *SQLiteOTPRepository {
+	return &SQLiteOTPRepository{db: db}
+}
+
+func (r *SQLiteOTPRepository) Create(ctx context.Context, otp *domain.OTPCode) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	deleteQuery := `DELETE FROM otp_codes WHERE tenant_id = ? AND user_id = ? AND purpose = ?`
+	if _, err := tx.ExecContext(ctx, deleteQuery, otp.TenantID, otp.UserID, otp.Purpose); err != nil {
+		return fmt.Errorf("failed to delete pending otp codes: %w", err)
+	}
+
+	insertQuery := `
+		INSERT INTO otp_codes (` + otpColumns + `)
+		VALUES (?, ?, ?, ?, ?, 0, ?, ?)
+	`
+
+	otp.Attempts = 0
+	otp.CreatedAt = sqliteNow()
+	_, err = tx.ExecContext(
+		ctx,
+		insertQuery,
+		otp.OTPID,
+		otp.TenantID,
+		otp.UserID,
+		otp.Purpose,
+		otp.CodeHash,
+		otp.ExpiresAt.UTC(),
+		otp.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create otp code: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteOTPRepository) RecordAttempt(ctx context.Context, tenantID, otpID uuid.UUID) (*domain.OTPCode, error) {
+	query := `
+		UPDATE otp_codes
+		SET attempts = attempts + 1
+		WHERE otp_id = ? AND tenant_id = ?
+		RETURNING ` + otpColumns
+
+	otp := &domain.OTPCode{}
+	err := r.db.QueryRowContext(ctx, query, otpID, tenantID).Scan(
+		&otp.OTPID,
+		&otp.TenantID,
+		&otp.UserID,
+		&otp.Purpose,
+		&otp.CodeHash,
+		&otp.Attempts,
+		&otp.ExpiresAt,
+		&otp.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("otp code")
+		}
+		return nil, fmt.Errorf("failed to record otp attempt: %w", err)
+	}
+
+	return otp, nil
+}
+
+func (r *SQLiteOTPRepository) Delete(ctx context.Context, tenantID, otpID uuid.UUID) error {
+	query := `DELETE FROM otp_codes WHERE otp_id = ? AND tenant_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, otpID, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete otp code: %w", err)
+	}
+
+	return requireRows(result, "otp code")
+}
+
+func (r *SQLiteOTPRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM otp_codes WHERE expires_at < ?`
+
+	if _, err := r.db.ExecContext(ctx, query, sqliteNow()); err != nil {
+		return fmt.Errorf("failed to delete expired otp codes: %w", err)
+	}
+
+	return nil
+}
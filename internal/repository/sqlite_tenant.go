@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+type SQLiteTenantRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteTenantRepository(db *sql.DB) *SQLiteTenantRepository {
+	return &SQLiteTenantRepository{db: db}
+}
+
+func (r *SQLiteTenantRepository) Create(ctx context.Context, tenant *domain.Tenant) error {
+	query := `
+		INSERT INTO tenants (
+			tenant_id, slug, name, domain, is_active,
+			access_token_expiry_seconds, refresh_token_expiry_seconds,
+			registration_enabled, allowed_email_domains, created_at, updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := sqliteNow()
+	tenantID := uuid.New()
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		tenantID,
+		tenant.Slug,
+		tenant.Name,
+		nullableString(tenant.Domain),
+		tenant.IsActive,
+		int(tenant.Settings.AccessTokenExpiry/time.Second),
+		int(tenant.Settings.RefreshTokenExpiry/time.Second),
+		tenant.Settings.RegistrationEnabled,
+		jsonStrings(tenant.Settings.AllowedEmailDomains),
+		now,
+		now,
+	)
+
+	if err != nil {
+		if isSQLiteUniqueViolation(err, "tenants.slug") {
+			return apperrors.AlreadyExists("tenant slug")
+		}
+		if isSQLiteUniqueViolation(err, "tenants.domain") {
+			return apperrors.AlreadyExists("tenant domain")
+		}
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	tenant.TenantID = tenantID
+	tenant.CreatedAt = now
+	tenant.UpdatedAt = now
+
+	return nil
+}
+
+func (r *SQLiteTenantRepository) GetByID(ctx context.Context, tenantID uuid.UUID) (*domain.Tenant, error) {
+	query := `SELECT ` + tenantColumns + ` FROM tenants WHERE tenant_id = ?`
+
+	tenant, err := scanSQLiteTenant(r.db.QueryRowContext(ctx, query, tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("tenant")
+		}
+		return nil, fmt.Errorf("failed to get tenant by id: %w", err)
+	}
+
+	return tenant, nil
+}
+
+func (r *SQLiteTenantRepository) GetBySlug(ctx context.Context, slug string) (*domain.Tenant, error) {
+	query := `SELECT ` + tenantColumns + ` FROM tenants WHERE slug = ?`
+
+	tenant, err := scanSQLiteTenant(r.db.QueryRowContext(ctx, query, slug))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("tenant")
+		}
+		return nil, fmt.Errorf("failed to get tenant by slug: %w", err)
+	}
+
+	return tenant, nil
+}
+
+func (r *SQLiteTenantRepository) GetByDomain(ctx context.Context, host string) (*domain.Tenant, error) {
+	query := `SELECT ` + tenantColumns + ` FROM tenants WHERE domain = ?`
+
+	tenant, err := scanSQLiteTenant(r.db.QueryRowContext(ctx, query, host))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("tenant")
+		}
+		return nil, fmt.Errorf("failed to get tenant by domain: %w", err)
+	}
+
+	return tenant, nil
+}
+
+func (r *SQLiteTenantRepository) Update(ctx context.Context, tenant *domain.Tenant) error {
+	query := `
+		UPDATE tenants
+		SET slug = ?, name = ?, domain = ?, is_active = ?,
+		    access_token_expiry_seconds = ?, refresh_token_expiry_seconds = ?,
+		    registration_enabled = ?, allowed_email_domains = ?, updated_at = ?
+		WHERE tenant_id = ?
+	`
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		tenant.Slug,
+		tenant.Name,
+		nullableString(tenant.Domain),
+		tenant.IsActive,
+		int(tenant.Settings.AccessTokenExpiry/time.Second),
+		int(tenant.Settings.RefreshTokenExpiry/time.Second),
+		tenant.Settings.RegistrationEnabled,
+		jsonStrings(tenant.Settings.AllowedEmailDomains),
+		sqliteNow(),
+		tenant.TenantID,
+	)
+
+	if err != nil {
+		if isSQLiteUniqueViolation(err, "tenants.slug") {
+			return apperrors.AlreadyExists("tenant slug")
+		}
+		if isSQLiteUniqueViolation(err, "tenants.domain") {
+			return apperrors.AlreadyExists("tenant domain")
+		}
+		return fmt.Errorf("failed to update tenant: %w", err)
+	}
+
+	return requireRows(result, "tenant")
+}
+
+func (r *SQLiteTenantRepository) List(ctx context.Context) ([]*domain.Tenant, error) {
+	query := `SELECT ` + tenantColumns + ` FROM tenants ORDER BY slug`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	tenants := []*domain.Tenant{}
+	for rows.Next() {
+		tenant, err := scanSQLiteTenant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		tenants = append(tenants, tenant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	return tenants, nil
+}
+
+func scanSQLiteTenant(row sqliteScanner) (*domain.Tenant, error) {
+	tenant := &domain.Tenant{}
+	var accessExpirySeconds, refreshExpirySeconds int
+	var allowedEmailDomains jsonStrings
+
+	err := row.Scan(
+		&tenant.TenantID,
+		&tenant.Slug,
+		&tenant.Name,
+		&tenant.Domain,
+		&tenant.IsActive,
+		&accessExpirySeconds,
+		&refreshExpirySeconds,
+		&tenant.Settings.RegistrationEnabled,
+		&allowedEmailDomains,
+		&tenant.CreatedAt,
+		&tenant.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant.Settings.AccessTokenExpiry = time.Duration(accessExpirySeconds) * time.Second
+	tenant.Settings.RefreshTokenExpiry = time.Duration(refreshExpirySeconds) * time.Second
+	tenant.Settings.AllowedEmailDomains = allowedEmailDomains
+
+	return tenant, nil
+}
@@ -0,0 +1,43 @@
+package repository_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"auth-service/internal/config"
+	"auth-service/internal/migrate"
+	"auth-service/internal/repository"
+	"auth-service/internal/repository/repotest"
+	"auth-service/migrations"
+	"auth-service/pkg/logger"
+)
+
+func TestSQLite(t *testing.T) {
+	db, err := config.NewSQLiteConnection(&config.DatabaseConfig{
+		SQLitePath: filepath.Join(t.TempDir(), "auth.db"),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.NewSQLite(db, migrations.SQLite, logger.New("error", "json", ""))
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repotest.Run(t, func(t *testing.T) repotest.Repos {
+		return repotest.Repos{
+			Users:    repository.NewSQLiteUserRepository(db),
+			Sessions: repository.NewSQLiteSessionRepository(db),
+		}
+	})
+
+	t.Run("LoginMethods", func(t *testing.T) {
+		testLoginMethods(t, repository.NewSQLiteUserRepository(db), repository.NewSQLiteIdentityRepository(db))
+	})
+}
@@ -0,0 +1,334 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+type SQLiteWebhookRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteWebhookRepository(db *sql.DB) *SQLiteWebhookRepository {
+	return &SQLiteWebhookRepository{db: db}
+}
+
+func (r *SQLiteWebhookRepository) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (subscription_id, tenant_id, url, secret, event_types, description, is_active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := sqliteNow()
+	subscriptionID := uuid.New()
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		subscriptionID,
+		sub.TenantID,
+		sub.URL,
+		sub.Secret,
+		jsonStrings(sub.EventTypes),
+		nullableString(sub.Description),
+		sub.IsActive,
+		now,
+		now,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	sub.SubscriptionID = subscriptionID
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+
+	return nil
+}
+
+func (r *SQLiteWebhookRepository) GetSubscription(ctx context.Context, tenantID, subscriptionID uuid.UUID) (*domain.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE tenant_id = ? AND subscription_id = ?`
+
+	sub, err := scanSQLiteWebhookSubscription(r.db.QueryRowContext(ctx, query, tenantID, subscriptionID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("webhook subscription")
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+func (r *SQLiteWebhookRepository) ListSubscriptions(ctx context.Context, tenantID uuid.UUID) ([]*domain.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE tenant_id = ? ORDER BY created_at DESC`
+
+	return r.querySubscriptions(ctx, query, tenantID)
+}
+
+func (r *SQLiteWebhookRepository) ListSubscriptionsForEvent(ctx context.Context, tenantID uuid.UUID, eventType string) ([]*domain.WebhookSubscription, error) {
+	query := `
+		SELECT ` + webhookSubscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE tenant_id = ? AND is_active = 1
+		  AND EXISTS (SELECT 1 FROM json_each(event_types) WHERE value = ?)
+	`
+
+	return r.querySubscriptions(ctx, query, tenantID, eventType)
+}
+
+func (r *SQLiteWebhookRepository) DeleteSubscription(ctx context.Context, tenantID, subscriptionID uuid.UUID) error {
+	query := `DELETE FROM webhook_subscriptions WHERE tenant_id = ? AND subscription_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, tenantID, subscriptionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	return requireRows(result, "webhook subscription")
+}
+
+func (r *SQLiteWebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (
+			delivery_id, subscription_id, tenant_id, event_id, event_type, payload,
+			status, attempts, next_attempt_at, created_at, updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 0, ?, ?, ?)
+	`
+
+	now := sqliteNow()
+	if delivery.Status == "" {
+		delivery.Status = domain.WebhookDeliveryPending
+	}
+	if delivery.NextAttemptAt.IsZero() {
+		delivery.NextAttemptAt = now
+	}
+
+	deliveryID := uuid.New()
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		deliveryID,
+		delivery.SubscriptionID,
+		delivery.TenantID,
+		delivery.EventID,
+		delivery.EventType,
+		string(delivery.Payload),
+		delivery.Status,
+		delivery.NextAttemptAt.UTC(),
+		now,
+		now,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	delivery.DeliveryID = deliveryID
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+
+	return nil
+}
+
+func (r *SQLiteWebhookRepository) GetDelivery(ctx context.Context, tenantID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries d WHERE d.tenant_id = ? AND d.delivery_id = ?`
+
+	delivery := &domain.WebhookDelivery{}
+	err := r.db.QueryRowContext(ctx, query, tenantID, deliveryID).Scan(webhookDeliveryFields(delivery)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("webhook delivery")
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+func (r *SQLiteWebhookRepository) ListDeliveries(ctx context.Context, tenantID, subscriptionID uuid.UUID, limit int) ([]*domain.WebhookDelivery, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE d.tenant_id = ? AND d.subscription_id = ?
+		ORDER BY d.created_at DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*domain.WebhookDelivery{}
+	for rows.Next() {
+		delivery := &domain.WebhookDelivery{}
+		if err := rows.Scan(webhookDeliveryFields(delivery)...); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries leases pending deliveries whose next attempt is due by
+// pushing next_attempt_at forward. The select and update share a write
+// transaction, which SQLite serialises, so no delivery is claimed twice.
+func (r *SQLiteWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `, s.url, s.secret
+		FROM webhook_deliveries d
+		JOIN webhook_subscriptions s ON s.subscription_id = d.subscription_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= ?
+		ORDER BY d.next_attempt_at
+		LIMIT ?
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := sqliteNow()
+	rows, err := tx.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		delivery := &domain.WebhookDelivery{}
+		err := rows.Scan(append(webhookDeliveryFields(delivery), &delivery.URL, &delivery.Secret)...)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+
+	args := []interface{}{now.Add(lease), now}
+	for _, delivery := range deliveries {
+		args = append(args, delivery.DeliveryID)
+	}
+	update := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = ?, updated_at = ?
+		WHERE delivery_id IN (?` + strings.Repeat(", ?", len(deliveries)-1) + `)
+	`
+	if _, err := tx.ExecContext(ctx, update, args...); err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		delivery.NextAttemptAt = now.Add(lease)
+		delivery.UpdatedAt = now
+	}
+
+	return deliveries, nil
+}
+
+func (r *SQLiteWebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_status_code = ?,
+		    last_error = ?, delivered_at = ?, updated_at = ?
+		WHERE delivery_id = ?
+	`
+
+	var statusCode interface{}
+	if delivery.LastStatusCode != 0 {
+		statusCode = delivery.LastStatusCode
+	}
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt.UTC(),
+		statusCode,
+		nullableString(delivery.LastError),
+		sqliteTime(delivery.DeliveredAt),
+		sqliteNow(),
+		delivery.DeliveryID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	return requireRows(result, "webhook delivery")
+}
+
+func (r *SQLiteWebhookRepository) querySubscriptions(ctx context.Context, query string, args ...interface{}) ([]*domain.WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []*domain.WebhookSubscription{}
+	for rows.Next() {
+		sub, err := scanSQLiteWebhookSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+func scanSQLiteWebhookSubscription(row sqliteScanner) (*domain.WebhookSubscription, error) {
+	sub := &domain.WebhookSubscription{}
+	var eventTypes jsonStrings
+	err := row.Scan(
+		&sub.SubscriptionID,
+		&sub.TenantID,
+		&sub.URL,
+		&sub.Secret,
+		&eventTypes,
+		&sub.Description,
+		&sub.IsActive,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	sub.EventTypes = eventTypes
+	return sub, nil
+}
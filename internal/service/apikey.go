@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/repository"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	apiKeySecretBytes      = 24
+	apiKeyDisplayPrefixLen = len(domain.APIKeyPrefix) + 8
+	apiKeyLastUsedInterval = time.Minute
+)
+
+type APIKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	userRepo   repository.UserRepository
+	logger     *logger.Logger
+}
+
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, userRepo repository.UserRepository, log *logger.Logger) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		logger:     log,
+	}
+}
+
+func (s *APIKeyService) Create(ctx context.Context, claims *domain.Claims, req *domain.CreateAPIKeyRequest) (*domain.CreateAPIKeyResponse, error) {
+	log := s.logger.WithContext(ctx).WithField("user_id", claims.UserID)
+
+	// A key could otherwise mint a successor that outlives its own expiry
+	// and revocation.
+	if claims.Type == "api_key" {
+		log.Warn("api key creation failed: caller is an api key")
+		return nil, apperrors.Forbidden("api keys cannot create api keys")
+	}
+
+	for _, scope := range req.Scopes {
+		if !claims.HasScope(scope) {
+			log.WithField("scope", scope).Warn("api key creation failed: scope exceeds caller's scopes")
+			return nil, apperrors.Forbidden("cannot grant a scope the caller does not have")
+		}
+	}
+
+	secret, err := generateAPIKeySecret()
+	if err != nil {
+		log.WithError(err).Error("failed to generate api key")
+		return nil, apperrors.Internal("failed to generate api key")
+	}
+
+	key := &domain.APIKey{
+		TenantID: claims.TenantID,
+		UserID:   claims.UserID,
+		Name:     req.Name,
+		Prefix:   secret[:apiKeyDisplayPrefixLen],
+		KeyHash:  hashAPIKey(secret),
+		Scopes:   req.Scopes,
+	}
+
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresInDays) * 24 * time.Hour)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		log.WithError(err).Error("failed to store api key")
+		return nil, apperrors.Internal("failed to create api key")
+	}
+
+	log.WithField("key_id", key.KeyID).Info("api key created")
+
+	return &domain.CreateAPIKeyResponse{
+		APIKey: key,
+		Key:    secret,
+	}, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context, userID uuid.UUID) ([]*domain.APIKey, error) {
+	keys, err := s.apiKeyRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("failed to list api keys")
+		return nil, apperrors.Internal("failed to list api keys")
+	}
+	return keys, nil
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, userID, keyID uuid.UUID) error {
+	log := s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"user_id": userID,
+		"key_id":  keyID,
+	})
+
+	if err := s.apiKeyRepo.Revoke(ctx, userID, keyID); err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			return appErr
+		}
+		log.WithError(err).Error("failed to revoke api key")
+		return apperrors.Internal("failed to revoke api key")
+	}
+
+	log.Info("api key revoked")
+	return nil
+}
+
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, rawKey string) (*domain.Claims, error) {
+	log := s.logger.WithContext(ctx)
+
+	key, err := s.apiKeyRepo.GetByHash(ctx, hashAPIKey(rawKey))
+	if err != nil {
+		log.Warn("api key authentication failed: key not found")
+		return nil, apperrors.TokenInvalid()
+	}
+
+	if !key.IsValid() {
+		log.WithField("key_id", key.KeyID).Warn("api key authentication failed: key expired or revoked")
+		return nil, apperrors.TokenInvalid().WithDetails(map[string]string{
+			"reason": "api key expired or revoked",
+		})
+	}
+
+	user, err := s.userRepo.GetByID(ctx, key.TenantID, key.UserID)
+	if err != nil {
+		log.WithError(err).Warn("api key authentication failed: user not found")
+		return nil, apperrors.TokenInvalid()
+	}
+
+	if !user.IsActive {
+		log.WithField("user_id", user.UserID).Warn("api key rejected: user is inactive")
+		return nil, apperrors.Unauthorized("account is inactive")
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyLastUsedInterval {
+		if err := s.apiKeyRepo.UpdateLastUsed(ctx, key.KeyID, now); err != nil {
+			log.WithError(err).Warn("failed to update api key last used")
+		}
+	}
+
+	return &domain.Claims{
+		UserID:   user.UserID,
+		TenantID: user.TenantID,
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     user.Role,
+		Type:     "api_key",
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+func generateAPIKeySecret() (string, error) {
+	b := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return domain.APIKeyPrefix + hex.EncodeToString(b), nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
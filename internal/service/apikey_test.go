@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/repository"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// memoryAPIKeyRepository keeps API keys in memory. Methods the tests do not
+// need are left to the embedded nil interface.
+type memoryAPIKeyRepository struct {
+	repository.APIKeyRepository
+
+	mu   sync.Mutex
+	keys map[string]domain.APIKey // by hash
+}
+
+func (r *memoryAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key.KeyID = uuid.New()
+	r.keys[key.KeyHash] = *key
+	return nil
+}
+
+func (r *memoryAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[keyHash]
+	if !ok {
+		return nil, apperrors.NotFound("api key")
+	}
+	return &key, nil
+}
+
+func (r *memoryAPIKeyRepository) UpdateLastUsed(ctx context.Context, keyID uuid.UUID, usedAt time.Time) error {
+	return nil
+}
+
+// An API key must not mint a successor that outlives its expiry or
+// revocation.
+func TestAPIKeyCannotCreateAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	user := domain.User{UserID: uuid.New(), TenantID: domain.DefaultTenantID, Username: "alice", Role: domain.RoleUser, IsActive: true}
+	users := &memoryUserRepository{users: map[uuid.UUID]domain.User{user.UserID: user}}
+	keys := &memoryAPIKeyRepository{keys: make(map[string]domain.APIKey)}
+	svc := NewAPIKeyService(keys, users, logger.New("error", "json", ""))
+
+	session := &domain.Claims{UserID: user.UserID, TenantID: user.TenantID, Type: "access"}
+	created, err := svc.Create(ctx, session, &domain.CreateAPIKeyRequest{
+		Name:          "ci",
+		Scopes:        []string{domain.ScopeAPIKeysWrite},
+		ExpiresInDays: 1,
+	})
+	if err != nil {
+		t.Fatalf("Create with a session: %v", err)
+	}
+
+	keyClaims, err := svc.AuthenticateAPIKey(ctx, created.Key)
+	if err != nil {
+		t.Fatalf("AuthenticateAPIKey: %v", err)
+	}
+	_, err = svc.Create(ctx, keyClaims, &domain.CreateAPIKeyRequest{
+		Name:   "successor",
+		Scopes: []string{domain.ScopeAPIKeysWrite},
+	})
+	appErr, ok := err.(*apperrors.AppError)
+	if !ok || appErr.Code != apperrors.ErrCodeForbidden {
+		t.Fatalf("Create with an API key = %v, want forbidden", err)
+	}
+	if len(keys.keys) != 1 {
+		t.Fatalf("have %d keys, want only the first", len(keys.keys))
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/repository"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+	maxSignInPageSize    = 100
+)
+
+var signInEventTypes = []string{domain.AuditEventLogin}
+
+type AuditService struct {
+	auditRepo repository.AuditRepository
+	logger    *logger.Logger
+}
+
+func NewAuditService(auditRepo repository.AuditRepository, log *logger.Logger) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+		logger:    log,
+	}
+}
+
+// Record persists an audit event enriched with the request metadata and any
+// impersonating actor found in ctx. Failures are logged rather than returned
+// so that auditing never blocks the operation being audited.
+func (s *AuditService) Record(ctx context.Context, event *domain.AuditEvent) {
+	metadata := domain.SessionMetadataFromContext(ctx)
+	if event.IPAddress == "" {
+		event.IPAddress = metadata.IPAddress
+	}
+	if event.UserAgent == "" {
+		event.UserAgent = metadata.UserAgent
+	}
+	if event.RequestID == "" {
+		event.RequestID = domain.RequestIDFromContext(ctx)
+	}
+	if event.Result == "" {
+		event.Result = domain.AuditResultSuccess
+	}
+	// Actions taken with an impersonation token name the admin behind them.
+	if actor := domain.ActorFromContext(ctx); actor != nil {
+		if event.Metadata == nil {
+			event.Metadata = make(map[string]string)
+		}
+		event.Metadata["impersonated_by"] = actor.UserID.String()
+	}
+
+	if err := s.auditRepo.Create(context.WithoutCancel(ctx), event); err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithFields(map[string]interface{}{
+			"event_type": event.EventType,
+			"result":     event.Result,
+		}).Error("failed to record audit event")
+	}
+}
+
+func (s *AuditService) List(ctx context.Context, filter *domain.AuditEventFilter) ([]*domain.AuditEvent, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditPageSize
+	}
+	if filter.Limit > maxAuditPageSize {
+		filter.Limit = maxAuditPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	events, err := s.auditRepo.List(ctx, filter)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("failed to list audit events")
+		return nil, apperrors.Internal("failed to list audit events")
+	}
+
+	return events, nil
+}
+
+func (s *AuditService) RecentSignIns(ctx context.Context, tenantID, userID uuid.UUID, limit int) ([]*domain.AuditEvent, error) {
+	since := time.Now().AddDate(0, 0, -90)
+	if limit > maxSignInPageSize {
+		limit = maxSignInPageSize
+	}
+
+	return s.List(ctx, &domain.AuditEventFilter{
+		TenantID:   tenantID,
+		UserID:     &userID,
+		EventTypes: signInEventTypes,
+		From:       &since,
+		Limit:      limit,
+	})
+}
+
+func userRef(userID uuid.UUID) *uuid.UUID {
+	return &userID
+}
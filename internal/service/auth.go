@@ -3,9 +3,14 @@ package service
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
+	"auth-service/internal/config"
 	"auth-service/internal/domain"
+	"auth-service/internal/metrics"
 	"auth-service/internal/repository"
+	"auth-service/internal/tracing"
 	apperrors "auth-service/pkg/errors"
 	"auth-service/pkg/logger"
 
@@ -13,132 +18,446 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// sessionActivityInterval is how often requests made with a session's access
+// tokens record its activity. Requests in between are not checked either.
+const sessionActivityInterval = time.Minute
+
 type AuthService struct {
-	userRepo    repository.UserRepository
-	sessionRepo repository.SessionRepository
-	jwtService  *JWTService
-	logger      *logger.Logger
+	userRepo       repository.UserRepository
+	sessionRepo    repository.SessionRepository
+	identityRepo   repository.IdentityRepository
+	authenticators []Authenticator
+	jwtService     *JWTService
+	loginAlerts    *LoginAlertService
+	audit          *AuditService
+	webhooks       *WebhookService
+	logger         *logger.Logger
+
+	mu          sync.RWMutex
+	idleTimeout time.Duration
+	maxLifetime time.Duration
+
+	// activity maps a session ID to when this replica last recorded its
+	// activity.
+	activity sync.Map
 }
 
+// NewAuthService creates the service. Login tries authenticators in order.
+// loginAlerts may be nil where no logins are alerted on.
 func NewAuthService(
 	userRepo repository.UserRepository,
 	sessionRepo repository.SessionRepository,
+	identityRepo repository.IdentityRepository,
+	authenticators []Authenticator,
 	jwtService *JWTService,
+	sessionCfg *config.SessionConfig,
+	loginAlerts *LoginAlertService,
+	audit *AuditService,
+	webhooks *WebhookService,
 	log *logger.Logger,
 ) *AuthService {
 	return &AuthService{
-		userRepo:    userRepo,
-		sessionRepo: sessionRepo,
-		jwtService:  jwtService,
-		logger:      log,
+		userRepo:       userRepo,
+		sessionRepo:    sessionRepo,
+		identityRepo:   identityRepo,
+		authenticators: authenticators,
+		jwtService:     jwtService,
+		loginAlerts:    loginAlerts,
+		audit:          audit,
+		webhooks:       webhooks,
+		logger:         log,
+		idleTimeout:    sessionCfg.IdleTimeout,
+		maxLifetime:    sessionCfg.MaxLifetime,
 	}
 }
 
-func (s *AuthService) Register(ctx context.Context, req *domain.RegisterRequest) (*domain.AuthResponse, error) {
-	log := s.logger.WithContext(ctx)
+// SetSessionLimits changes the idle timeout and maximum lifetime of
+// sessions. Existing sessions get the new idle timeout at once and the new
+// maximum lifetime when they are next refreshed.
+func (s *AuthService) SetSessionLimits(idleTimeout, maxLifetime time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.idleTimeout = idleTimeout
+	s.maxLifetime = maxLifetime
+}
+
+func (s *AuthService) sessionLimits() (time.Duration, time.Duration) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.idleTimeout, s.maxLifetime
+}
+
+func (s *AuthService) Register(ctx context.Context, tenant *domain.Tenant, req *domain.RegisterRequest) (_ *domain.AuthResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Register")
+	defer func() { tracing.End(span, err) }()
+
+	log := s.logger.WithContext(ctx).WithField("tenant_id", tenant.TenantID)
+
+	failed := func(reason string) {
+		metrics.Registrations.WithLabelValues(metrics.ResultFailure).Inc()
+		s.audit.Record(ctx, &domain.AuditEvent{
+			TenantID:  tenant.TenantID,
+			EventType: domain.AuditEventRegister,
+			Result:    domain.AuditResultFailure,
+			Reason:    reason,
+			Metadata:  map[string]string{"username": req.Username, "email": req.Email},
+		})
+	}
 
-	existingUser, err := s.userRepo.GetByUsername(ctx, req.Username)
+	if !tenant.Settings.RegistrationEnabled {
+		log.Warn("registration failed: registration is disabled for tenant")
+		failed("registration_disabled")
+		return nil, apperrors.Forbidden("registration is disabled")
+	}
+
+	if !tenant.Settings.AllowsEmail(req.Email) {
+		log.Warn("registration failed: email domain not allowed for tenant")
+		failed("email_domain_not_allowed")
+		return nil, apperrors.Forbidden("email domain is not allowed")
+	}
+
+	existingUser, err := s.userRepo.GetByUsername(ctx, tenant.TenantID, req.Username)
 	if err == nil && existingUser != nil {
 		log.Warn("registration failed: username already exists")
+		failed("username_taken")
 		return nil, apperrors.AlreadyExists("username")
 	}
 
-	existingUser, err = s.userRepo.GetByEmail(ctx, req.Email)
+	existingUser, err = s.userRepo.GetByEmail(ctx, tenant.TenantID, req.Email)
 	if err == nil && existingUser != nil {
 		log.Warn("registration failed: email already exists")
+		failed("email_taken")
 		return nil, apperrors.AlreadyExists("email")
 	}
 
-	hashedPassword, err := hashPassword(req.Password)
+	hashedPassword, err := hashPassword(ctx, req.Password)
 	if err != nil {
 		log.WithError(err).Error("failed to hash password")
 		return nil, apperrors.Internal("failed to process password")
 	}
 
 	user := &domain.User{
+		TenantID:     tenant.TenantID,
 		Username:     req.Username,
 		Email:        req.Email,
 		PasswordHash: hashedPassword,
 		FullName:     req.FullName,
+		Role:         domain.RoleUser,
 		IsActive:     true,
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrCodeAlreadyExists {
+			log.Warn("registration failed: " + appErr.Message)
+			failed("already_exists")
+			return nil, appErr
+		}
 		log.WithError(err).Error("failed to create user")
 		return nil, apperrors.Internal("failed to create user")
 	}
 
-	metadata := s.getSessionMetadataFromContext(ctx)
+	metadata := domain.SessionMetadataFromContext(ctx)
 
-	tokens, err := s.generateAndStoreTokensWithSession(ctx, user, metadata)
+	tokens, session, err := s.generateAndStoreTokensWithSession(ctx, tenant, user, metadata, false)
 	if err != nil {
 		log.WithError(err).Error("failed to generate tokens after registration")
 		return nil, err
 	}
+	s.loginAlerts.Check(ctx, user, session)
+
+	s.audit.Record(ctx, &domain.AuditEvent{
+		TenantID:     tenant.TenantID,
+		EventType:    domain.AuditEventRegister,
+		ActorUserID:  userRef(user.UserID),
+		TargetUserID: userRef(user.UserID),
+	})
+	metrics.Registrations.WithLabelValues(metrics.ResultSuccess).Inc()
+	s.webhooks.Publish(ctx, tenant.TenantID, domain.WebhookEventUserRegistered, userEventData(user))
 
 	return &domain.AuthResponse{
-		User: &domain.UserResponse{
-			UserID:   user.UserID,
-			Username: user.Username,
-			Email:    user.Email,
-			FullName: user.FullName,
-		},
+		User:   domain.NewUserResponse(user),
 		Tokens: tokens,
 	}, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, req *domain.LoginRequest) (*domain.AuthResponse, error) {
-	log := s.logger.WithContext(ctx)
+func (s *AuthService) Login(ctx context.Context, tenant *domain.Tenant, req *domain.LoginRequest) (_ *domain.AuthResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	defer func() { tracing.End(span, err) }()
+
+	log := s.logger.WithContext(ctx).WithField("tenant_id", tenant.TenantID)
+
+	failed := func(target *uuid.UUID, reason string) {
+		metrics.Logins.WithLabelValues(metrics.ResultFailure).Inc()
+		metrics.LoginFailures.WithLabelValues(reason).Inc()
+		s.audit.Record(ctx, &domain.AuditEvent{
+			TenantID:     tenant.TenantID,
+			EventType:    domain.AuditEventLogin,
+			Result:       domain.AuditResultFailure,
+			Reason:       reason,
+			TargetUserID: target,
+			Metadata:     map[string]string{"username": req.Username},
+		})
+	}
 
-	user, err := s.userRepo.GetByUsername(ctx, req.Username)
+	reject := func(failure *AuthFailure) error {
+		log.WithFields(map[string]interface{}{
+			"reason":  failure.Reason,
+			"user_id": failure.UserID,
+		}).Warn("login failed")
+		failed(failure.UserID, failure.Reason)
+		return failure.Err
+	}
+
+	var pending *AuthFailure
+	for _, authenticator := range s.authenticators {
+		result, err := authenticator.Authenticate(ctx, tenant, req.Username, req.Password)
+		if err != nil {
+			failure, ok := err.(*AuthFailure)
+			if !ok {
+				log.WithError(err).WithField("authenticator", authenticator.Name()).Error("login failed: authenticator unavailable")
+				return nil, apperrors.ServiceUnavailable("authentication backend is unavailable")
+			}
+			if failure.Fallthrough {
+				if pending == nil {
+					pending = failure
+				}
+				continue
+			}
+			return nil, reject(failure)
+		}
+
+		user := result.User
+		if result.Directory != nil {
+			user, err = s.syncDirectoryUser(ctx, tenant, result.Directory)
+			if err != nil {
+				if failure, ok := err.(*AuthFailure); ok {
+					return nil, reject(failure)
+				}
+				log.WithError(err).Error("failed to map directory user")
+				return nil, apperrors.Internal("login failed")
+			}
+		}
+
+		return s.completeLogin(ctx, tenant, user, req.RememberMe, map[string]string{"provider": authenticator.Name()})
+	}
+
+	if pending == nil {
+		pending = &AuthFailure{Reason: "user_not_found", Err: apperrors.InvalidCredentials()}
+	}
+	return nil, reject(pending)
+}
+
+// syncDirectoryUser returns the local user linked to a directory account,
+// creating it on first login. Directory accounts are never linked to
+// existing local users by username or email.
+func (s *AuthService) syncDirectoryUser(ctx context.Context, tenant *domain.Tenant, dir *DirectoryUser) (*domain.User, error) {
+	log := s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"tenant_id": tenant.TenantID,
+		"provider":  dir.Provider,
+	})
+
+	identity, err := s.identityRepo.GetByProviderSubject(ctx, tenant.TenantID, dir.Provider, dir.Subject)
 	if err != nil {
-		log.Warn("login failed: user not found")
-		return nil, apperrors.InvalidCredentials()
+		if appErr, ok := err.(*apperrors.AppError); !ok || appErr.Code != apperrors.ErrCodeNotFound {
+			return nil, err
+		}
+		return s.provisionDirectoryUser(ctx, tenant, dir)
 	}
 
+	user, err := s.userRepo.GetByID(ctx, tenant.TenantID, identity.UserID)
+	if err != nil {
+		return nil, err
+	}
 	if !user.IsActive {
-		log.WithField("user_id", user.UserID).Warn("login failed: user is inactive")
-		return nil, apperrors.Unauthorized("account is inactive")
+		return nil, &AuthFailure{Reason: "account_inactive", UserID: userRef(user.UserID), Err: apperrors.Unauthorized("account is inactive")}
+	}
+
+	updated := *user
+	updated.Email = dir.Email
+	if dir.FullName != "" {
+		updated.FullName = dir.FullName
+	}
+	if dir.Role != "" {
+		updated.Role = dir.Role
+	}
+	if updated.Email != user.Email || updated.FullName != user.FullName || updated.Role != user.Role {
+		if err := s.userRepo.Update(ctx, &updated); err != nil {
+			// Keep the login working on the stale profile, e.g. when the new
+			// email belongs to another local user.
+			log.WithError(err).WithField("user_id", user.UserID).Warn("failed to sync directory attributes")
+		} else {
+			if updated.Role != user.Role {
+				log.WithFields(map[string]interface{}{
+					"user_id": user.UserID,
+					"role":    updated.Role,
+				}).Info("role synced from directory groups")
+			}
+			user = &updated
+		}
+	}
+
+	if err := s.identityRepo.UpdateLastUsed(ctx, identity.IdentityID, time.Now()); err != nil {
+		log.WithError(err).Warn("failed to update identity last used")
 	}
 
-	if err := verifyPassword(user.PasswordHash, req.Password); err != nil {
-		log.WithField("user_id", user.UserID).Warn("login failed: invalid password")
-		return nil, apperrors.InvalidCredentials()
+	return user, nil
+}
+
+func (s *AuthService) provisionDirectoryUser(ctx context.Context, tenant *domain.Tenant, dir *DirectoryUser) (*domain.User, error) {
+	if existing, err := s.userRepo.GetByUsername(ctx, tenant.TenantID, dir.Username); err == nil && existing != nil {
+		return nil, &AuthFailure{Reason: "username_taken", UserID: userRef(existing.UserID), Err: apperrors.AlreadyExists("username").WithDetails(map[string]string{
+			"reason": "a local account with this username already exists",
+		})}
+	}
+	if existing, err := s.userRepo.GetByEmail(ctx, tenant.TenantID, dir.Email); err == nil && existing != nil {
+		return nil, &AuthFailure{Reason: "email_taken", UserID: userRef(existing.UserID), Err: apperrors.AlreadyExists("email").WithDetails(map[string]string{
+			"reason": "a local account with this email already exists",
+		})}
+	}
+
+	role := dir.Role
+	if role == "" {
+		role = domain.RoleUser
+	}
+	fullName := dir.FullName
+	if fullName == "" {
+		fullName = dir.Username
 	}
 
-	metadata := s.getSessionMetadataFromContext(ctx)
+	user := &domain.User{
+		TenantID: tenant.TenantID,
+		Username: dir.Username,
+		Email:    dir.Email,
+		FullName: fullName,
+		Role:     role,
+		IsActive: true,
+	}
+	if err := s.provisionUser(ctx, tenant, user, map[string]string{"provider": dir.Provider}); err != nil {
+		return nil, err
+	}
+
+	identity := &domain.UserIdentity{
+		TenantID: tenant.TenantID,
+		UserID:   user.UserID,
+		Provider: dir.Provider,
+		Subject:  dir.Subject,
+		Email:    dir.Email,
+	}
+	if err := s.identityRepo.Create(ctx, identity); err != nil {
+		// A concurrent login for the same account won; discard the
+		// duplicate and use theirs.
+		if delErr := s.userRepo.Delete(ctx, tenant.TenantID, user.UserID); delErr != nil {
+			s.logger.WithContext(ctx).WithError(delErr).Error("failed to remove user after identity link failed")
+		}
+		if appErr, ok := err.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrCodeAlreadyExists {
+			return s.syncDirectoryUser(ctx, tenant, dir)
+		}
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"user_id":  user.UserID,
+		"provider": dir.Provider,
+	}).Info("user provisioned from directory")
+
+	return user, nil
+}
 
-	tokens, err := s.generateAndStoreTokensWithSession(ctx, user, metadata)
+// completeLogin starts a session for an authenticated user and records the
+// login. rememberMe asks for a long-lived refresh token; auditMetadata
+// describes how the user authenticated.
+func (s *AuthService) completeLogin(ctx context.Context, tenant *domain.Tenant, user *domain.User, rememberMe bool, auditMetadata map[string]string) (*domain.AuthResponse, error) {
+	log := s.logger.WithContext(ctx).WithField("tenant_id", tenant.TenantID)
+
+	metadata := domain.SessionMetadataFromContext(ctx)
+
+	tokens, session, err := s.generateAndStoreTokensWithSession(ctx, tenant, user, metadata, rememberMe)
 	if err != nil {
 		log.WithError(err).Error("failed to generate tokens after login")
 		return nil, err
 	}
+	s.loginAlerts.Check(ctx, user, session)
 
 	log.WithField("user_id", user.UserID).Info("user logged in successfully, previous session replaced")
 
+	s.audit.Record(ctx, &domain.AuditEvent{
+		TenantID:     tenant.TenantID,
+		EventType:    domain.AuditEventLogin,
+		ActorUserID:  userRef(user.UserID),
+		TargetUserID: userRef(user.UserID),
+		Metadata:     auditMetadata,
+	})
+	metrics.Logins.WithLabelValues(metrics.ResultSuccess).Inc()
+	s.webhooks.Publish(ctx, tenant.TenantID, domain.WebhookEventUserLoggedIn, userEventData(user))
+
 	return &domain.AuthResponse{
-		User: &domain.UserResponse{
-			UserID:   user.UserID,
-			Username: user.Username,
-			Email:    user.Email,
-			FullName: user.FullName,
-		},
+		User:   domain.NewUserResponse(user),
 		Tokens: tokens,
 	}, nil
 }
 
-func (s *AuthService) RefreshToken(ctx context.Context, refreshTokenStr string) (*domain.TokenPair, error) {
-	log := s.logger.WithContext(ctx)
+// provisionUser creates a user that signed up through an external identity
+// provider and records the registration.
+func (s *AuthService) provisionUser(ctx context.Context, tenant *domain.Tenant, user *domain.User, auditMetadata map[string]string) error {
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, &domain.AuditEvent{
+		TenantID:     tenant.TenantID,
+		EventType:    domain.AuditEventRegister,
+		ActorUserID:  userRef(user.UserID),
+		TargetUserID: userRef(user.UserID),
+		Metadata:     auditMetadata,
+	})
+	metrics.Registrations.WithLabelValues(metrics.ResultSuccess).Inc()
+	s.webhooks.Publish(ctx, tenant.TenantID, domain.WebhookEventUserRegistered, userEventData(user))
+
+	return nil
+}
+
+func (s *AuthService) RefreshToken(ctx context.Context, tenant *domain.Tenant, refreshTokenStr string) (_ *domain.TokenPair, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.RefreshToken")
+	defer func() { tracing.End(span, err) }()
+
+	log := s.logger.WithContext(ctx).WithField("tenant_id", tenant.TenantID)
 
 	claims, err := s.jwtService.ValidateRefreshToken(refreshTokenStr)
 	if err != nil {
 		log.WithError(err).Warn("refresh token validation failed")
+		metrics.TokenRefreshes.WithLabelValues(metrics.ResultFailure).Inc()
+		s.audit.Record(ctx, &domain.AuditEvent{
+			TenantID:  tenant.TenantID,
+			EventType: domain.AuditEventTokenRefresh,
+			Result:    domain.AuditResultFailure,
+			Reason:    "invalid_token",
+		})
 		return nil, err
 	}
 
+	failed := func(reason string) {
+		metrics.TokenRefreshes.WithLabelValues(metrics.ResultFailure).Inc()
+		s.audit.Record(ctx, &domain.AuditEvent{
+			TenantID:     tenant.TenantID,
+			EventType:    domain.AuditEventTokenRefresh,
+			Result:       domain.AuditResultFailure,
+			Reason:       reason,
+			TargetUserID: userRef(claims.UserID),
+		})
+	}
+
+	if claims.TenantID != tenant.TenantID {
+		log.WithField("token_tenant_id", claims.TenantID).Warn("refresh token rejected: tenant mismatch")
+		failed("tenant_mismatch")
+		return nil, apperrors.TokenInvalid().WithDetails(map[string]string{
+			"reason": "token does not belong to this tenant",
+		})
+	}
+
 	session, err := s.sessionRepo.GetByRefreshToken(ctx, refreshTokenStr)
 	if err != nil {
 		log.WithError(err).Warn("session not found in database")
+		failed("session_not_found")
 		return nil, apperrors.TokenInvalid().WithDetails(map[string]string{
 			"reason": "session not found or revoked",
 		})
@@ -146,35 +465,67 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshTokenStr string)
 
 	if !session.IsValid() {
 		log.WithField("session_id", session.SessionID).Warn("session is invalid")
+		failed("session_invalid")
 		return nil, apperrors.TokenInvalid().WithDetails(map[string]string{
 			"reason": "session expired or revoked",
 		})
 	}
 
-	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if idleTimeout, _ := s.sessionLimits(); session.IsIdle(idleTimeout) {
+		log.WithField("session_id", session.SessionID).Info("session ended after inactivity")
+		failed("session_idle")
+		if err := s.sessionRepo.Revoke(ctx, session.SessionID); err != nil {
+			log.WithError(err).Error("failed to revoke idle session")
+		}
+		return nil, apperrors.TokenInvalid().WithDetails(map[string]string{
+			"reason": "session expired after inactivity",
+		})
+	}
+
+	user, err := s.userRepo.GetByID(ctx, tenant.TenantID, claims.UserID)
 	if err != nil {
 		log.WithError(err).Error("failed to get user for refresh token")
+		failed("user_not_found")
 		return nil, apperrors.NotFound("user")
 	}
 
 	if !user.IsActive {
 		log.WithField("user_id", user.UserID).Warn("refresh token rejected: user is inactive")
+		failed("account_inactive")
 		return nil, apperrors.Unauthorized("account is inactive")
 	}
 
-	if err := s.sessionRepo.Revoke(ctx, session.SessionID); err != nil {
-		log.WithError(err).Error("failed to revoke old session")
+	tokens, err := s.rotateSession(ctx, tenant, user, session)
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrCodeNotFound {
+			// Another request refreshed with the same token first.
+			log.WithField("session_id", session.SessionID).Warn("refresh token was already used")
+			failed("session_not_found")
+			return nil, apperrors.TokenInvalid().WithDetails(map[string]string{
+				"reason": "session not found or revoked",
+			})
+		}
+		return nil, err
 	}
 
-	metadata := s.getSessionMetadataFromContext(ctx)
-
 	log.WithField("user_id", user.UserID).Info("tokens refreshed successfully")
 
-	return s.generateAndStoreTokensWithSession(ctx, user, metadata)
+	s.audit.Record(ctx, &domain.AuditEvent{
+		TenantID:     tenant.TenantID,
+		EventType:    domain.AuditEventTokenRefresh,
+		ActorUserID:  userRef(user.UserID),
+		TargetUserID: userRef(user.UserID),
+	})
+	metrics.TokenRefreshes.WithLabelValues(metrics.ResultSuccess).Inc()
+
+	return tokens, nil
 }
 
-func (s *AuthService) ValidateToken(ctx context.Context, tokenStr string) (*domain.Claims, error) {
-	log := s.logger.WithContext(ctx)
+func (s *AuthService) ValidateToken(ctx context.Context, tenant *domain.Tenant, tokenStr string) (_ *domain.Claims, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ValidateToken")
+	defer func() { tracing.End(span, err) }()
+
+	log := s.logger.WithContext(ctx).WithField("tenant_id", tenant.TenantID)
 
 	claims, err := s.jwtService.ValidateAccessToken(tokenStr)
 	if err != nil {
@@ -182,7 +533,14 @@ func (s *AuthService) ValidateToken(ctx context.Context, tokenStr string) (*doma
 		return nil, err
 	}
 
-	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if claims.TenantID != tenant.TenantID {
+		log.WithField("token_tenant_id", claims.TenantID).Warn("token rejected: tenant mismatch")
+		return nil, apperrors.TokenInvalid().WithDetails(map[string]string{
+			"reason": "token does not belong to this tenant",
+		})
+	}
+
+	user, err := s.userRepo.GetByID(ctx, tenant.TenantID, claims.UserID)
 	if err != nil {
 		log.WithError(err).Warn("user not found for valid token")
 		return nil, apperrors.TokenInvalid().WithDetails(map[string]string{
@@ -192,13 +550,80 @@ func (s *AuthService) ValidateToken(ctx context.Context, tokenStr string) (*doma
 
 	if !user.IsActive {
 		log.WithField("user_id", user.UserID).Warn("token rejected: user is inactive")
+		s.audit.Record(ctx, &domain.AuditEvent{
+			TenantID:     tenant.TenantID,
+			EventType:    domain.AuditEventTokenValidate,
+			Result:       domain.AuditResultFailure,
+			Reason:       "account_inactive",
+			TargetUserID: userRef(user.UserID),
+		})
 		return nil, apperrors.Unauthorized("account is inactive")
 	}
 
+	if err := s.TouchSession(ctx, claims); err != nil {
+		return nil, apperrors.TokenInvalid().WithDetails(map[string]string{
+			"reason": "session has ended",
+		})
+	}
+
 	return claims, nil
 }
 
-func (s *AuthService) Logout(ctx context.Context, userID uuid.UUID) error {
+// TouchSession records activity on the session behind an access token and
+// returns an error once that session has ended: revoked, expired or idle for
+// longer than the idle timeout. Without an idle timeout nothing is checked,
+// nor are tokens that belong to no session, such as API keys. Impersonation
+// tokens belong to the admin's session and are always checked, so that
+// ending it ends them too. To keep this cheap enough for every request, each
+// replica writes a session's activity at most once per
+// sessionActivityInterval and skips the check in between.
+func (s *AuthService) TouchSession(ctx context.Context, claims *domain.Claims) error {
+	idleTimeout, _ := s.sessionLimits()
+
+	var sessionID uuid.UUID
+	switch {
+	case claims.Actor != nil:
+		if claims.Actor.SessionID == nil {
+			return apperrors.Unauthorized("session has ended")
+		}
+		sessionID = *claims.Actor.SessionID
+	case idleTimeout > 0 && claims.SessionID != nil:
+		sessionID = *claims.SessionID
+	default:
+		return nil
+	}
+
+	now := time.Now()
+	var activeSince time.Time
+	if idleTimeout > 0 {
+		activeSince = now.Add(-idleTimeout)
+	}
+
+	if last, ok := s.activity.Load(sessionID); ok && now.Sub(last.(time.Time)) < sessionActivityInterval {
+		return nil
+	}
+
+	if err := s.sessionRepo.Touch(ctx, sessionID, activeSince); err != nil {
+		s.activity.Delete(sessionID)
+		log := s.logger.WithContext(ctx).WithField("session_id", sessionID)
+		if appErr, ok := err.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrCodeNotFound {
+			log.Info("access token rejected: session has ended")
+			return apperrors.Unauthorized("session has ended")
+		}
+		// The token itself is valid; an unavailable database should not
+		// lock everyone out.
+		log.WithError(err).Error("failed to record session activity")
+		return nil
+	}
+
+	s.activity.Store(sessionID, now)
+	return nil
+}
+
+func (s *AuthService) Logout(ctx context.Context, tenantID, userID uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Logout")
+	defer func() { tracing.End(span, err) }()
+
 	log := s.logger.WithContext(ctx).WithField("user_id", userID)
 
 	if err := s.sessionRepo.RevokeAllByUserID(ctx, userID); err != nil {
@@ -206,34 +631,433 @@ func (s *AuthService) Logout(ctx context.Context, userID uuid.UUID) error {
 		return apperrors.Internal("failed to logout")
 	}
 
+	s.forgetActivity(ctx, userID)
+
 	log.Info("user logged out successfully, all sessions revoked")
+
+	s.audit.Record(ctx, &domain.AuditEvent{
+		TenantID:     tenantID,
+		EventType:    domain.AuditEventLogout,
+		ActorUserID:  userRef(userID),
+		TargetUserID: userRef(userID),
+	})
+
 	return nil
 }
 
-func (s *AuthService) GetUserByID(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
-	user, err := s.userRepo.GetByID(ctx, userID)
+// forgetActivity has this replica check the revoked sessions of a user on
+// their next use.
+func (s *AuthService) forgetActivity(ctx context.Context, userID uuid.UUID) {
+	if sessions, err := s.sessionRepo.GetAllByUserID(ctx, userID); err == nil {
+		for _, session := range sessions {
+			s.activity.Delete(session.SessionID)
+		}
+	}
+}
+
+// ReportLogin handles the "this wasn't me" link of a new-login alert: it ends
+// all sessions of the user and, for users who log in with a password,
+// replaces it. The link works once.
+func (s *AuthService) ReportLogin(ctx context.Context, tenant *domain.Tenant, req *domain.LoginReportRequest) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ReportLogin")
+	defer func() { tracing.End(span, err) }()
+
+	log := s.logger.WithContext(ctx).WithField("tenant_id", tenant.TenantID)
+
+	device, sessionID, err := s.loginAlerts.verify(tenant, req.Token)
 	if err != nil {
-		return nil, apperrors.NotFound("user")
+		return err
 	}
 
+	user, err := s.userRepo.GetByID(ctx, tenant.TenantID, device.UserID)
+	if err != nil {
+		return apperrors.TokenInvalid()
+	}
 	if !user.IsActive {
-		return nil, apperrors.Unauthorized("account is inactive")
+		return apperrors.Unauthorized("account is inactive")
+	}
+	// Checked before the link is spent so that the user can try again.
+	if user.HasPassword() && req.NewPassword == "" {
+		return apperrors.InvalidInput("a new password is required")
+	}
+
+	if err := s.loginAlerts.consume(ctx, device); err != nil {
+		log.WithError(err).Warn("login report failed")
+		return err
+	}
+	log = log.WithField("user_id", user.UserID)
+
+	passwordChanged := false
+	if user.HasPassword() {
+		hashedPassword, err := hashPassword(ctx, req.NewPassword)
+		if err != nil {
+			log.WithError(err).Error("failed to hash password")
+			return apperrors.Internal("failed to process password")
+		}
+		user.PasswordHash = hashedPassword
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			log.WithError(err).Error("failed to update password")
+			return apperrors.Internal("failed to change password")
+		}
+		passwordChanged = true
+	}
+
+	if err := s.sessionRepo.RevokeAllByUserID(ctx, user.UserID); err != nil {
+		log.WithError(err).Error("failed to revoke sessions")
+		return apperrors.Internal("failed to end sessions")
+	}
+	s.forgetActivity(ctx, user.UserID)
+
+	log.WithField("device_id", device.DeviceID).Warn("login reported by user, all sessions revoked")
+
+	s.audit.Record(ctx, &domain.AuditEvent{
+		TenantID:     tenant.TenantID,
+		EventType:    domain.AuditEventLoginReported,
+		ActorUserID:  userRef(user.UserID),
+		TargetUserID: userRef(user.UserID),
+		Metadata: map[string]string{
+			"device_id":  device.DeviceID.String(),
+			"session_id": sessionID.String(),
+		},
+	})
+	if passwordChanged {
+		s.audit.Record(ctx, &domain.AuditEvent{
+			TenantID:     tenant.TenantID,
+			EventType:    domain.AuditEventPasswordChange,
+			ActorUserID:  userRef(user.UserID),
+			TargetUserID: userRef(user.UserID),
+			Metadata:     map[string]string{"reason": "login_reported"},
+		})
+		s.webhooks.Publish(ctx, tenant.TenantID, domain.WebhookEventPasswordChanged, userEventData(user))
+	}
+
+	return nil
+}
+
+func (s *AuthService) ChangePassword(ctx context.Context, tenantID, userID uuid.UUID, req *domain.ChangePasswordRequest) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ChangePassword")
+	defer func() { tracing.End(span, err) }()
+
+	log := s.logger.WithContext(ctx).WithField("user_id", userID)
+
+	user, err := s.userRepo.GetByID(ctx, tenantID, userID)
+	if err != nil {
+		return apperrors.NotFound("user")
+	}
+
+	if !user.IsActive {
+		return apperrors.Unauthorized("account is inactive")
+	}
+
+	if !user.HasPassword() {
+		return apperrors.InvalidInput("account has no password to change")
 	}
 
+	if err := verifyPassword(ctx, user.PasswordHash, req.CurrentPassword); err != nil {
+		log.Warn("password change failed: invalid current password")
+		s.audit.Record(ctx, &domain.AuditEvent{
+			TenantID:     tenantID,
+			EventType:    domain.AuditEventPasswordChange,
+			Result:       domain.AuditResultFailure,
+			Reason:       "invalid_password",
+			ActorUserID:  userRef(userID),
+			TargetUserID: userRef(userID),
+		})
+		return apperrors.InvalidCredentials()
+	}
+
+	hashedPassword, err := hashPassword(ctx, req.NewPassword)
+	if err != nil {
+		log.WithError(err).Error("failed to hash password")
+		return apperrors.Internal("failed to process password")
+	}
+
+	user.PasswordHash = hashedPassword
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		log.WithError(err).Error("failed to update password")
+		return apperrors.Internal("failed to change password")
+	}
+
+	if err := s.sessionRepo.RevokeAllByUserID(ctx, userID); err != nil {
+		log.WithError(err).Error("failed to revoke sessions after password change")
+	}
+	s.forgetActivity(ctx, userID)
+
+	log.Info("password changed, all sessions revoked")
+
+	s.audit.Record(ctx, &domain.AuditEvent{
+		TenantID:     tenantID,
+		EventType:    domain.AuditEventPasswordChange,
+		ActorUserID:  userRef(userID),
+		TargetUserID: userRef(userID),
+	})
+	s.webhooks.Publish(ctx, tenantID, domain.WebhookEventPasswordChanged, userEventData(user))
+
+	return nil
+}
+
+func (s *AuthService) DeactivateUser(ctx context.Context, tenantID, actorID, userID uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.DeactivateUser")
+	defer func() { tracing.End(span, err) }()
+
+	log := s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"user_id":  userID,
+		"actor_id": actorID,
+	})
+
+	user, err := s.userRepo.GetByID(ctx, tenantID, userID)
+	if err != nil {
+		return apperrors.NotFound("user")
+	}
+
+	if !user.IsActive {
+		return nil
+	}
+
+	user.IsActive = false
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		log.WithError(err).Error("failed to deactivate user")
+		return apperrors.Internal("failed to deactivate user")
+	}
+
+	if err := s.sessionRepo.RevokeAllByUserID(ctx, userID); err != nil {
+		log.WithError(err).Error("failed to revoke sessions of deactivated user")
+	}
+	s.forgetActivity(ctx, userID)
+
+	log.Info("user deactivated, all sessions revoked")
+
+	var actor *uuid.UUID
+	if actorID != uuid.Nil {
+		actor = userRef(actorID)
+	}
+	s.audit.Record(ctx, &domain.AuditEvent{
+		TenantID:     tenantID,
+		EventType:    domain.AuditEventDeactivate,
+		ActorUserID:  actor,
+		TargetUserID: userRef(userID),
+	})
+	s.webhooks.Publish(ctx, tenantID, domain.WebhookEventUserDeactivated, userEventData(user))
+
+	return nil
+}
+
+// CreateUser provisions a user on behalf of an operator. Unlike Register it
+// ignores the tenant's registration settings, assigns role and issues no
+// session.
+func (s *AuthService) CreateUser(ctx context.Context, tenant *domain.Tenant, req *domain.RegisterRequest, role string) (_ *domain.User, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.CreateUser")
+	defer func() { tracing.End(span, err) }()
+
+	log := s.logger.WithContext(ctx).WithField("tenant_id", tenant.TenantID)
+
+	if existingUser, err := s.userRepo.GetByUsername(ctx, tenant.TenantID, req.Username); err == nil && existingUser != nil {
+		return nil, apperrors.AlreadyExists("username")
+	}
+	if existingUser, err := s.userRepo.GetByEmail(ctx, tenant.TenantID, req.Email); err == nil && existingUser != nil {
+		return nil, apperrors.AlreadyExists("email")
+	}
+
+	hashedPassword, err := hashPassword(ctx, req.Password)
+	if err != nil {
+		log.WithError(err).Error("failed to hash password")
+		return nil, apperrors.Internal("failed to process password")
+	}
+
+	user := &domain.User{
+		TenantID:     tenant.TenantID,
+		Username:     req.Username,
+		Email:        req.Email,
+		PasswordHash: hashedPassword,
+		FullName:     req.FullName,
+		Role:         role,
+		IsActive:     true,
+	}
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrCodeAlreadyExists {
+			return nil, appErr
+		}
+		log.WithError(err).Error("failed to create user")
+		return nil, apperrors.Internal("failed to create user")
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id": user.UserID,
+		"role":    role,
+	}).Info("user created")
+
+	s.audit.Record(ctx, &domain.AuditEvent{
+		TenantID:     tenant.TenantID,
+		EventType:    domain.AuditEventCreate,
+		TargetUserID: userRef(user.UserID),
+		Metadata:     map[string]string{"role": role},
+	})
+	s.webhooks.Publish(ctx, tenant.TenantID, domain.WebhookEventUserRegistered, userEventData(user))
+
 	return user, nil
 }
 
-func (s *AuthService) generateAndStoreTokensWithSession(ctx context.Context, user *domain.User, metadata *domain.SessionMetadata) (*domain.TokenPair, error) {
-	tokens, refreshExpiresAt, err := s.jwtService.GenerateTokenPair(user)
+// RevokeSessions revokes every session of userID. actorID may be uuid.Nil for
+// operator actions.
+func (s *AuthService) RevokeSessions(ctx context.Context, tenantID, actorID, userID uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.RevokeSessions")
+	defer func() { tracing.End(span, err) }()
+
+	log := s.logger.WithContext(ctx).WithField("user_id", userID)
+
+	if _, err := s.userRepo.GetByID(ctx, tenantID, userID); err != nil {
+		return apperrors.NotFound("user")
+	}
+
+	if err := s.sessionRepo.RevokeAllByUserID(ctx, userID); err != nil {
+		log.WithError(err).Error("failed to revoke sessions")
+		return apperrors.Internal("failed to revoke sessions")
+	}
+	s.forgetActivity(ctx, userID)
+
+	log.Info("all sessions revoked")
+
+	var actor *uuid.UUID
+	if actorID != uuid.Nil {
+		actor = userRef(actorID)
+	}
+	s.audit.Record(ctx, &domain.AuditEvent{
+		TenantID:     tenantID,
+		EventType:    domain.AuditEventSessionsRevoke,
+		ActorUserID:  actor,
+		TargetUserID: userRef(userID),
+	})
+
+	return nil
+}
+
+// Impersonate exchanges an admin's access token for a short-lived access
+// token whose subject is the requested user and whose act claim names the
+// admin. No session or refresh token is created.
+func (s *AuthService) Impersonate(ctx context.Context, tenant *domain.Tenant, actor *domain.Claims, req *domain.TokenExchangeRequest) (_ *domain.TokenExchangeResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Impersonate")
+	defer func() { tracing.End(span, err) }()
+
+	targetID, err := uuid.Parse(req.SubjectToken)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate tokens: %w", err)
+		return nil, apperrors.InvalidInput("invalid subject_token")
 	}
 
+	log := s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"tenant_id": tenant.TenantID,
+		"actor_id":  actor.UserID,
+		"user_id":   targetID,
+	})
+
+	failed := func(reason string) {
+		s.audit.Record(ctx, &domain.AuditEvent{
+			TenantID:     tenant.TenantID,
+			EventType:    domain.AuditEventImpersonate,
+			Result:       domain.AuditResultFailure,
+			Reason:       reason,
+			ActorUserID:  userRef(actor.UserID),
+			TargetUserID: userRef(targetID),
+		})
+	}
+
+	// Only an admin's own interactive token may be exchanged: not an API
+	// key, and not a token that is itself impersonating someone. The new
+	// token is bound to the admin's session and ends with it.
+	if actor.Type != "access" || actor.IsImpersonated() || actor.SessionID == nil {
+		log.Warn("impersonation refused: actor token cannot be exchanged")
+		failed("actor_token_not_allowed")
+		return nil, apperrors.Forbidden("this token cannot be used for impersonation").WithDetails(map[string]string{
+			"reason": "actor_token_not_allowed",
+		})
+	}
+
+	if targetID == actor.UserID {
+		return nil, apperrors.InvalidInput("cannot impersonate yourself")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, tenant.TenantID, targetID)
+	if err != nil {
+		return nil, apperrors.NotFound("user")
+	}
+
+	if !user.IsActive {
+		log.Warn("impersonation refused: user is inactive")
+		failed("account_inactive")
+		return nil, apperrors.Forbidden("cannot impersonate an inactive user")
+	}
+
+	if user.Role == domain.RoleAdmin {
+		log.Warn("impersonation refused: target is an admin")
+		failed("target_is_admin")
+		return nil, apperrors.Forbidden("cannot impersonate an administrator")
+	}
+
+	token, expiresAt, err := s.jwtService.GenerateImpersonationToken(user, &domain.Actor{
+		UserID:    actor.UserID,
+		Username:  actor.Username,
+		SessionID: actor.SessionID,
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to generate impersonation token")
+		return nil, apperrors.Internal("failed to generate token")
+	}
+
+	log.Info("impersonation token issued")
+
+	metadata := map[string]string{"expires_at": expiresAt.UTC().Format(time.RFC3339)}
+	if req.Reason != "" {
+		metadata["reason"] = req.Reason
+	}
+	s.audit.Record(ctx, &domain.AuditEvent{
+		TenantID:     tenant.TenantID,
+		EventType:    domain.AuditEventImpersonate,
+		ActorUserID:  userRef(actor.UserID),
+		TargetUserID: userRef(user.UserID),
+		Metadata:     metadata,
+	})
+
+	return &domain.TokenExchangeResponse{
+		AccessToken:     token,
+		IssuedTokenType: domain.TokenTypeAccessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       int(time.Until(expiresAt).Round(time.Second).Seconds()),
+	}, nil
+}
+
+func (s *AuthService) GetUserByID(ctx context.Context, tenantID, userID uuid.UUID) (_ *domain.User, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.GetUserByID")
+	defer func() { tracing.End(span, err) }()
+
+	user, err := s.userRepo.GetByID(ctx, tenantID, userID)
+	if err != nil {
+		return nil, apperrors.NotFound("user")
+	}
+
+	if !user.IsActive {
+		return nil, apperrors.Unauthorized("account is inactive")
+	}
+
+	return user, nil
+}
+
+func (s *AuthService) generateAndStoreTokensWithSession(ctx context.Context, tenant *domain.Tenant, user *domain.User, metadata *domain.SessionMetadata, rememberMe bool) (_ *domain.TokenPair, _ *domain.Session, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.generateAndStoreTokensWithSession")
+	defer func() { tracing.End(span, err) }()
+
+	// The ID is chosen here so that the access token can name the session.
 	session := &domain.Session{
-		UserID:       user.UserID,
-		RefreshToken: tokens.RefreshToken,
-		ExpiresAt:    refreshExpiresAt,
+		SessionID: uuid.New(),
+		UserID:    user.UserID,
+		Lifetime:  s.tokenLifetime(ctx, tenant, user, rememberMe),
+	}
+
+	tokens, refreshExpiresAt, err := s.jwtService.GenerateTokenPair(user, session.SessionID, session.Lifetime, s.sessionDeadline(time.Now()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate tokens: %w", err)
 	}
+	session.RefreshToken = tokens.RefreshToken
+	session.ExpiresAt = refreshExpiresAt
 
 	if metadata != nil {
 		session.DeviceInfo = metadata.DeviceInfo
@@ -243,31 +1067,65 @@ func (s *AuthService) generateAndStoreTokensWithSession(ctx context.Context, use
 
 	if err := s.sessionRepo.ReplaceUserSession(ctx, session); err != nil {
 		s.logger.WithError(err).Error("failed to replace user session")
-		return nil, fmt.Errorf("failed to create session: %w", err)
+		return nil, nil, fmt.Errorf("failed to create session: %w", err)
 	}
 
-	return tokens, nil
+	return tokens, session, nil
 }
 
-func (s *AuthService) getSessionMetadataFromContext(ctx context.Context) *domain.SessionMetadata {
-	metadata := &domain.SessionMetadata{}
-
-	if ipAddr, ok := ctx.Value("ip_address").(string); ok {
-		metadata.IPAddress = ipAddr
+// rotateSession issues new tokens for an existing session. The session keeps
+// its ID, so access tokens issued before stay bound to it, its token
+// lifetimes, and its start, so its expiry slides forward only up to the
+// maximum lifetime. Sessions started before lifetimes were recorded get the
+// current defaults.
+func (s *AuthService) rotateSession(ctx context.Context, tenant *domain.Tenant, user *domain.User, session *domain.Session) (_ *domain.TokenPair, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.rotateSession")
+	defer func() { tracing.End(span, err) }()
+
+	lifetime := session.Lifetime
+	if lifetime.AccessTokenExpiry == 0 {
+		lifetime = s.jwtService.Lifetime(tenant, user.Role, "", false)
+	}
+	tokens, refreshExpiresAt, err := s.jwtService.GenerateTokenPair(user, session.SessionID, lifetime, s.sessionDeadline(session.CreatedAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate tokens: %w", err)
 	}
 
-	if userAgent, ok := ctx.Value("user_agent").(string); ok {
-		metadata.UserAgent = userAgent
+	previousRefreshToken := session.RefreshToken
+	session.RefreshToken = tokens.RefreshToken
+	session.ExpiresAt = refreshExpiresAt
+	if metadata := domain.SessionMetadataFromContext(ctx); metadata != nil {
+		session.DeviceInfo = metadata.DeviceInfo
+		session.IPAddress = metadata.IPAddress
+		session.UserAgent = metadata.UserAgent
 	}
 
-	if deviceInfo, ok := ctx.Value("device_info").(string); ok {
-		metadata.DeviceInfo = deviceInfo
+	if err := s.sessionRepo.Rotate(ctx, session, previousRefreshToken); err != nil {
+		return nil, err
 	}
 
-	return metadata
+	return tokens, nil
 }
 
-func (s *AuthService) ValidateSession(ctx context.Context, refreshToken string) (*domain.Session, error) {
+// tokenLifetime picks the token lifetimes of a new session of user started by
+// the request in ctx, whose client ID can select a profile.
+func (s *AuthService) tokenLifetime(ctx context.Context, tenant *domain.Tenant, user *domain.User, rememberMe bool) domain.TokenLifetime {
+	return s.jwtService.Lifetime(tenant, user.Role, domain.SessionMetadataFromContext(ctx).ClientID, rememberMe)
+}
+
+// sessionDeadline is when a session started at start must end, or the zero
+// time if sessions have no maximum lifetime.
+func (s *AuthService) sessionDeadline(start time.Time) time.Time {
+	if _, maxLifetime := s.sessionLimits(); maxLifetime > 0 {
+		return start.Add(maxLifetime)
+	}
+	return time.Time{}
+}
+
+func (s *AuthService) ValidateSession(ctx context.Context, refreshToken string) (_ *domain.Session, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ValidateSession")
+	defer func() { tracing.End(span, err) }()
+
 	log := s.logger.WithContext(ctx)
 
 	session, err := s.sessionRepo.GetByRefreshToken(ctx, refreshToken)
@@ -276,8 +1134,8 @@ func (s *AuthService) ValidateSession(ctx context.Context, refreshToken string)
 		return nil, apperrors.NotFound("session")
 	}
 
-	if !session.IsValid() {
-		log.WithField("session_id", session.SessionID).Warn("session is expired or revoked")
+	if idleTimeout, _ := s.sessionLimits(); !session.IsValid() || session.IsIdle(idleTimeout) {
+		log.WithField("session_id", session.SessionID).Warn("session is expired, revoked or idle")
 		return nil, apperrors.TokenInvalid().WithDetails(map[string]string{
 			"reason": "session expired or revoked",
 		})
@@ -290,11 +1148,17 @@ func (s *AuthService) ValidateSession(ctx context.Context, refreshToken string)
 	return session, nil
 }
 
-func (s *AuthService) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]*domain.Session, error) {
+func (s *AuthService) GetUserSessions(ctx context.Context, userID uuid.UUID) (_ []*domain.Session, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.GetUserSessions")
+	defer func() { tracing.End(span, err) }()
+
 	return s.sessionRepo.GetAllByUserID(ctx, userID)
 }
 
-func (s *AuthService) CleanupExpiredSessions(ctx context.Context) error {
+func (s *AuthService) CleanupExpiredSessions(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.CleanupExpiredSessions")
+	defer func() { tracing.End(span, err) }()
+
 	log := s.logger.WithContext(ctx)
 
 	if err := s.sessionRepo.DeleteExpired(ctx); err != nil {
@@ -302,11 +1166,29 @@ func (s *AuthService) CleanupExpiredSessions(ctx context.Context) error {
 		return err
 	}
 
+	now := time.Now()
+	if idleTimeout, _ := s.sessionLimits(); idleTimeout > 0 {
+		if err := s.sessionRepo.DeleteIdle(ctx, now.Add(-idleTimeout)); err != nil {
+			log.WithError(err).Error("failed to cleanup idle sessions")
+			return err
+		}
+	}
+	s.activity.Range(func(sessionID, last interface{}) bool {
+		if now.Sub(last.(time.Time)) >= sessionActivityInterval {
+			s.activity.Delete(sessionID)
+		}
+		return true
+	})
+
 	log.Info("expired sessions cleaned up successfully")
 	return nil
 }
 
-func hashPassword(password string) (string, error) {
+func hashPassword(ctx context.Context, password string) (string, error) {
+	_, span := tracing.Start(ctx, "bcrypt.hash")
+	defer span.End()
+	defer metrics.ObservePasswordHash("hash", time.Now())
+
 	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return "", err
@@ -314,6 +1196,20 @@ func hashPassword(password string) (string, error) {
 	return string(hashedBytes), nil
 }
 
-func verifyPassword(hashedPassword, password string) error {
+func verifyPassword(ctx context.Context, hashedPassword, password string) error {
+	_, span := tracing.Start(ctx, "bcrypt.verify")
+	defer span.End()
+	defer metrics.ObservePasswordHash("verify", time.Now())
+
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+func userEventData(user *domain.User) map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":   user.UserID,
+		"username":  user.Username,
+		"email":     user.Email,
+		"full_name": user.FullName,
+		"is_active": user.IsActive,
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/repository"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// memoryUserRepository keeps users in memory. Methods the tests do not
+// need are left to the embedded nil interface.
+type memoryUserRepository struct {
+	repository.UserRepository
+
+	mu    sync.Mutex
+	users map[uuid.UUID]domain.User
+}
+
+func (r *memoryUserRepository) GetByID(ctx context.Context, tenantID, userID uuid.UUID) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userID]
+	if !ok || user.TenantID != tenantID {
+		return nil, apperrors.NotFound("user")
+	}
+	return &user, nil
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.UserID] = *user
+	return nil
+}
+
+// memorySessionRepository keeps sessions in memory. Methods the tests do
+// not need are left to the embedded nil interface.
+type memorySessionRepository struct {
+	repository.SessionRepository
+
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*domain.Session
+}
+
+func (r *memorySessionRepository) GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sessions := []*domain.Session{}
+	for _, session := range r.sessions {
+		if session.UserID == userID {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *memorySessionRepository) Touch(ctx context.Context, sessionID uuid.UUID, activeSince time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[sessionID]
+	if !ok || session.IsRevoked || time.Now().After(session.ExpiresAt) || !session.LastActivityAt.After(activeSince) {
+		return apperrors.NotFound("session")
+	}
+	session.LastActivityAt = time.Now()
+	return nil
+}
+
+func (r *memorySessionRepository) RevokeAllByUserID(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, session := range r.sessions {
+		if session.UserID == userID {
+			session.IsRevoked = true
+		}
+	}
+	return nil
+}
+
+func newTestAuthService(t *testing.T) (*AuthService, *domain.User, *domain.Claims) {
+	t.Helper()
+	log := logger.New("error", "json", "")
+
+	hash, err := hashPassword(context.Background(), "Passw0rd!x")
+	if err != nil {
+		t.Fatalf("hashPassword: %v", err)
+	}
+	user := domain.User{
+		UserID:       uuid.New(),
+		TenantID:     domain.DefaultTenantID,
+		Username:     "alice",
+		Email:        "alice@example.org",
+		PasswordHash: hash,
+		Role:         domain.RoleUser,
+		IsActive:     true,
+	}
+	session := &domain.Session{
+		SessionID:      uuid.New(),
+		UserID:         user.UserID,
+		LastActivityAt: time.Now(),
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+
+	users := &memoryUserRepository{users: map[uuid.UUID]domain.User{user.UserID: user}}
+	sessions := &memorySessionRepository{sessions: map[uuid.UUID]*domain.Session{session.SessionID: session}}
+	webhooks := NewWebhookService(newMemoryWebhookRepository(), &config.WebhookConfig{}, nil, log)
+	svc := NewAuthService(users, sessions, nil, nil, nil,
+		&config.SessionConfig{IdleTimeout: 30 * time.Minute},
+		nil, NewAuditService(&memoryAuditRepository{}, log), webhooks, log)
+
+	claims := &domain.Claims{UserID: user.UserID, TenantID: user.TenantID, Type: "access", SessionID: &session.SessionID}
+	return svc, &user, claims
+}
+
+// Ending a user's sessions must take effect on this replica at once, not
+// when its cached activity for them expires.
+func TestEndingSessionsForgetsActivity(t *testing.T) {
+	tests := map[string]func(svc *AuthService, user *domain.User) error{
+		"Logout": func(svc *AuthService, user *domain.User) error {
+			return svc.Logout(context.Background(), user.TenantID, user.UserID)
+		},
+		"ChangePassword": func(svc *AuthService, user *domain.User) error {
+			return svc.ChangePassword(context.Background(), user.TenantID, user.UserID, &domain.ChangePasswordRequest{
+				CurrentPassword: "Passw0rd!x",
+				NewPassword:     "N3wPassw0rd!x",
+			})
+		},
+		"DeactivateUser": func(svc *AuthService, user *domain.User) error {
+			return svc.DeactivateUser(context.Background(), user.TenantID, uuid.New(), user.UserID)
+		},
+		"RevokeSessions": func(svc *AuthService, user *domain.User) error {
+			return svc.RevokeSessions(context.Background(), user.TenantID, uuid.New(), user.UserID)
+		},
+	}
+	for name, end := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, user, claims := newTestAuthService(t)
+
+			if err := svc.TouchSession(ctx, claims); err != nil {
+				t.Fatalf("TouchSession before %s: %v", name, err)
+			}
+			if err := end(svc, user); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			err := svc.TouchSession(ctx, claims)
+			appErr, ok := err.(*apperrors.AppError)
+			if !ok || appErr.Code != apperrors.ErrCodeUnauthorized {
+				t.Fatalf("TouchSession after %s = %v, want the session to have ended", name, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/repository"
+	apperrors "auth-service/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// Authenticator checks a username and password for AuthService.Login, which
+// tries each configured authenticator in order until one accepts or rejects
+// the credentials.
+type Authenticator interface {
+	// Name identifies the authenticator in audit events, e.g. "password".
+	Name() string
+	// Authenticate returns an *AuthFailure when it rejects the credentials
+	// and any other error when it cannot tell.
+	Authenticate(ctx context.Context, tenant *domain.Tenant, username, password string) (*Authentication, error)
+}
+
+// Authentication identifies the user whose credentials were accepted:
+// either a local User, or a DirectoryUser that Login maps to a local user.
+type Authentication struct {
+	User      *domain.User
+	Directory *DirectoryUser
+}
+
+// DirectoryUser is an account verified by an external directory. Login finds
+// the local user linked to (Provider, Subject), creating it on first login,
+// and keeps Email, FullName and Role in sync with the directory.
+type DirectoryUser struct {
+	Provider string
+	Subject  string
+	Username string
+	Email    string
+	FullName string
+	Role     string // empty leaves the local role unchanged
+}
+
+// AuthFailure rejects a login. Reason is recorded in the audit log and login
+// failure metrics; Err is returned to the client.
+type AuthFailure struct {
+	Reason string
+	UserID *uuid.UUID
+	Err    *apperrors.AppError
+	// Fallthrough lets later authenticators try the credentials, e.g. when
+	// the username is unknown to this one.
+	Fallthrough bool
+}
+
+func (f *AuthFailure) Error() string {
+	return f.Reason
+}
+
+// PasswordAuthenticator checks passwords stored with local users. It falls
+// through for unknown and password-less users.
+type PasswordAuthenticator struct {
+	userRepo repository.UserRepository
+}
+
+func NewPasswordAuthenticator(userRepo repository.UserRepository) *PasswordAuthenticator {
+	return &PasswordAuthenticator{userRepo: userRepo}
+}
+
+func (a *PasswordAuthenticator) Name() string {
+	return domain.IdentityProviderPassword
+}
+
+func (a *PasswordAuthenticator) Authenticate(ctx context.Context, tenant *domain.Tenant, username, password string) (*Authentication, error) {
+	user, err := a.userRepo.GetByUsername(ctx, tenant.TenantID, username)
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrCodeNotFound {
+			return nil, &AuthFailure{Reason: "user_not_found", Err: apperrors.InvalidCredentials(), Fallthrough: true}
+		}
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, &AuthFailure{Reason: "account_inactive", UserID: userRef(user.UserID), Err: apperrors.Unauthorized("account is inactive")}
+	}
+
+	if !user.HasPassword() {
+		return nil, &AuthFailure{Reason: "no_password", UserID: userRef(user.UserID), Err: apperrors.InvalidCredentials(), Fallthrough: true}
+	}
+
+	if err := verifyPassword(ctx, user.PasswordHash, password); err != nil {
+		return nil, &AuthFailure{Reason: "invalid_password", UserID: userRef(user.UserID), Err: apperrors.InvalidCredentials()}
+	}
+
+	return &Authentication{User: user}, nil
+}
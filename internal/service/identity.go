@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/repository"
+	"auth-service/internal/tracing"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// IdentityService manages a user's login methods: the password and the
+// identities linked at external providers. A user always keeps at least one.
+type IdentityService struct {
+	identities repository.IdentityRepository
+	userRepo   repository.UserRepository
+	audit      *AuditService
+	logger     *logger.Logger
+}
+
+func NewIdentityService(
+	identities repository.IdentityRepository,
+	userRepo repository.UserRepository,
+	audit *AuditService,
+	log *logger.Logger,
+) *IdentityService {
+	return &IdentityService{
+		identities: identities,
+		userRepo:   userRepo,
+		audit:      audit,
+		logger:     log,
+	}
+}
+
+func (s *IdentityService) LoginMethods(ctx context.Context, tenantID, userID uuid.UUID) (_ *domain.LoginMethods, err error) {
+	ctx, span := tracing.Start(ctx, "IdentityService.LoginMethods")
+	defer func() { tracing.End(span, err) }()
+
+	user, identities, err := s.load(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.LoginMethods{
+		Password:   user.HasPassword(),
+		Identities: identities,
+	}, nil
+}
+
+// SetPassword adds a password to an account that has none. Existing
+// passwords are changed through AuthService.ChangePassword, which requires
+// the current one.
+func (s *IdentityService) SetPassword(ctx context.Context, tenantID, userID uuid.UUID, req *domain.SetPasswordRequest) (err error) {
+	ctx, span := tracing.Start(ctx, "IdentityService.SetPassword")
+	defer func() { tracing.End(span, err) }()
+
+	log := s.logger.WithContext(ctx).WithField("user_id", userID)
+
+	user, err := s.userRepo.GetByID(ctx, tenantID, userID)
+	if err != nil {
+		return apperrors.NotFound("user")
+	}
+	if user.HasPassword() {
+		return apperrors.AlreadyExists("password")
+	}
+
+	hashedPassword, err := hashPassword(ctx, req.Password)
+	if err != nil {
+		log.WithError(err).Error("failed to hash password")
+		return apperrors.Internal("failed to process password")
+	}
+
+	user.PasswordHash = hashedPassword
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		log.WithError(err).Error("failed to set password")
+		return apperrors.Internal("failed to set password")
+	}
+
+	log.Info("password added")
+	s.record(ctx, tenantID, userID, domain.AuditEventIdentityLink, domain.IdentityProviderPassword)
+
+	return nil
+}
+
+// RemovePassword makes the account password-less. It fails when no identity
+// is linked, as the user could no longer sign in; the repository checks this
+// atomically with the removal.
+func (s *IdentityService) RemovePassword(ctx context.Context, tenantID, userID uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "IdentityService.RemovePassword")
+	defer func() { tracing.End(span, err) }()
+
+	log := s.logger.WithContext(ctx).WithField("user_id", userID)
+
+	if err := s.identities.DeletePassword(ctx, tenantID, userID); err != nil {
+		if errors.Is(err, repository.ErrLastLoginMethod) {
+			log.Warn("password removal refused: it is the last login method")
+			return lastLoginMethod()
+		}
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			return appErr
+		}
+		log.WithError(err).Error("failed to remove password")
+		return apperrors.Internal("failed to remove password")
+	}
+
+	log.Info("password removed")
+	s.record(ctx, tenantID, userID, domain.AuditEventIdentityUnlink, domain.IdentityProviderPassword)
+
+	return nil
+}
+
+// Unlink removes a linked identity unless it is the user's last login
+// method.
+func (s *IdentityService) Unlink(ctx context.Context, tenantID, userID, identityID uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "IdentityService.Unlink")
+	defer func() { tracing.End(span, err) }()
+
+	log := s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"user_id":     userID,
+		"identity_id": identityID,
+	})
+
+	_, identities, err := s.load(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	var target *domain.UserIdentity
+	for _, identity := range identities {
+		if identity.IdentityID == identityID {
+			target = identity
+		}
+	}
+	if target == nil {
+		return apperrors.NotFound("identity")
+	}
+
+	if err := s.identities.Delete(ctx, tenantID, userID, identityID); err != nil {
+		if errors.Is(err, repository.ErrLastLoginMethod) {
+			log.Warn("identity unlink refused: it is the last login method")
+			return lastLoginMethod()
+		}
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			return appErr
+		}
+		log.WithError(err).Error("failed to unlink identity")
+		return apperrors.Internal("failed to unlink identity")
+	}
+
+	log.Info("identity unlinked")
+	s.record(ctx, tenantID, userID, domain.AuditEventIdentityUnlink, target.Provider)
+
+	return nil
+}
+
+func (s *IdentityService) load(ctx context.Context, tenantID, userID uuid.UUID) (*domain.User, []*domain.UserIdentity, error) {
+	user, err := s.userRepo.GetByID(ctx, tenantID, userID)
+	if err != nil {
+		return nil, nil, apperrors.NotFound("user")
+	}
+
+	identities, err := s.identities.ListByUserID(ctx, tenantID, userID)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("failed to list identities")
+		return nil, nil, apperrors.Internal("failed to list login methods")
+	}
+
+	return user, identities, nil
+}
+
+func (s *IdentityService) record(ctx context.Context, tenantID, userID uuid.UUID, eventType, provider string) {
+	s.audit.Record(ctx, &domain.AuditEvent{
+		TenantID:     tenantID,
+		EventType:    eventType,
+		ActorUserID:  userRef(userID),
+		TargetUserID: userRef(userID),
+		Metadata:     map[string]string{"provider": provider},
+	})
+}
+
+func lastLoginMethod() *apperrors.AppError {
+	return apperrors.Forbidden("cannot remove the last login method")
+}
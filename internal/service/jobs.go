@@ -0,0 +1,10 @@
+package service
+
+import "time"
+
+// JobReporter receives the outcome of each run of a background job so that
+// readiness can report on it.
+type JobReporter interface {
+	Register(name string, interval time.Duration)
+	Report(name string, err error)
+}
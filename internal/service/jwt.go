@@ -1,9 +1,13 @@
 package service
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"auth-service/internal/config"
@@ -16,29 +20,158 @@ import (
 
 type JWTService struct {
 	config *config.JWTConfig
+
+	mu            sync.RWMutex
+	accessExpiry  time.Duration
+	refreshExpiry time.Duration
 }
 
 func NewJWTService(cfg *config.JWTConfig) *JWTService {
 	return &JWTService{
-		config: cfg,
+		config:        cfg,
+		accessExpiry:  cfg.AccessTokenExpiry,
+		refreshExpiry: cfg.RefreshTokenExpiry,
+	}
+}
+
+// SetExpiries changes the default token lifetimes for tokens issued from now
+// on. Tenant overrides and lifetime profiles still take precedence.
+func (s *JWTService) SetExpiries(access, refresh time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accessExpiry = access
+	s.refreshExpiry = refresh
+}
+
+// CheckKeys signs a short-lived probe token with each signing key and
+// verifies it the way real tokens are verified, so that a replica whose keys
+// cannot issue tokens it accepts is reported as not ready.
+func (s *JWTService) CheckKeys() error {
+	probe := &domain.User{UserID: uuid.New(), TenantID: domain.DefaultTenantID, Username: "readiness-probe"}
+	expiresAt := time.Now().Add(time.Minute)
+
+	keys := []struct {
+		tokenType string
+		secret    string
+		validate  func(string) (*domain.Claims, error)
+	}{
+		{"access", s.config.AccessTokenSecret, s.ValidateAccessToken},
+		{"refresh", s.config.RefreshTokenSecret, s.ValidateRefreshToken},
+	}
+	for _, key := range keys {
+		if len(key.secret) < 32 {
+			return fmt.Errorf("%s signing key is not loaded", key.tokenType)
+		}
+		token, err := s.signToken(probe, key.tokenType, uuid.Nil, nil, nil, expiresAt, key.secret)
+		if err != nil {
+			return fmt.Errorf("%s signing key: %w", key.tokenType, err)
+		}
+		claims, err := key.validate(token)
+		if err != nil {
+			return fmt.Errorf("%s signing key: probe token did not verify: %w", key.tokenType, err)
+		}
+		if claims.UserID != probe.UserID {
+			return fmt.Errorf("%s signing key: probe token verified with the wrong claims", key.tokenType)
+		}
 	}
+	return nil
 }
 
 type customClaims struct {
-	UserID   uuid.UUID `json:"user_id"`
-	Username string    `json:"username"`
-	Email    string    `json:"email"`
-	Type     string    `json:"type"`
+	UserID    uuid.UUID     `json:"user_id"`
+	TenantID  uuid.UUID     `json:"tenant_id"`
+	Username  string        `json:"username"`
+	Email     string        `json:"email"`
+	Role      string        `json:"role,omitempty"`
+	Type      string        `json:"type"`
+	Actor     *domain.Actor `json:"act,omitempty"`
+	AMR       []string      `json:"amr,omitempty"`
+	SessionID string        `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func (s *JWTService) GenerateTokenPair(user *domain.User) (*domain.TokenPair, time.Time, error) {
-	accessToken, _, err := s.generateToken(user, "access", s.config.AccessTokenExpiry, s.config.AccessTokenSecret)
+// Lifetime picks the token lifetimes of a new session for a user with role,
+// logging in through clientID. A profile for the role takes precedence over
+// one for the client, so that clients cannot lengthen the sessions of roles
+// with a profile. Lifetimes a profile leaves unset are the tenant's, then the
+// global ones. rememberMe extends the refresh token to the remember-me
+// lifetime unless that is disabled; a tenant or profile that sets a refresh
+// lifetime but no remember-me lifetime is not extended past its refresh
+// lifetime.
+func (s *JWTService) Lifetime(tenant *domain.Tenant, role, clientID string, rememberMe bool) domain.TokenLifetime {
+	accessExpiry, refreshExpiry := s.tokenExpiries(tenant)
+	rememberMeExpiry := s.config.RememberMeExpiry
+	// The global remember-me lifetime would undo a tenant that shortens
+	// refresh tokens, so it extends them no further than the tenant's.
+	if tenant != nil && tenant.Settings.RefreshTokenExpiry > 0 && rememberMeExpiry > 0 {
+		rememberMeExpiry = min(rememberMeExpiry, tenant.Settings.RefreshTokenExpiry)
+	}
+
+	var lifetime domain.TokenLifetime
+	if profile := s.profileFor(role, clientID); profile != nil {
+		lifetime.Profile = profile.Name
+		if profile.AccessTokenExpiry > 0 {
+			accessExpiry = profile.AccessTokenExpiry
+		}
+		if profile.RefreshTokenExpiry > 0 {
+			refreshExpiry = profile.RefreshTokenExpiry
+			// The global remember-me lifetime would undo a profile that
+			// shortens refresh tokens, so it only extends them this far.
+			if rememberMeExpiry > 0 {
+				rememberMeExpiry = profile.RefreshTokenExpiry
+			}
+		}
+		if profile.RememberMeExpiry > 0 {
+			rememberMeExpiry = profile.RememberMeExpiry
+		}
+	}
+
+	if rememberMe && rememberMeExpiry > 0 {
+		lifetime.RememberMe = true
+		refreshExpiry = max(refreshExpiry, rememberMeExpiry)
+	}
+	lifetime.AccessTokenExpiry = accessExpiry
+	lifetime.RefreshTokenExpiry = refreshExpiry
+	return lifetime
+}
+
+func (s *JWTService) profileFor(role, clientID string) *config.LifetimeProfile {
+	for i := range s.config.Profiles {
+		if slices.Contains(s.config.Profiles[i].Roles, role) {
+			return &s.config.Profiles[i]
+		}
+	}
+	if clientID == "" {
+		return nil
+	}
+	for i := range s.config.Profiles {
+		if slices.Contains(s.config.Profiles[i].Clients, clientID) {
+			return &s.config.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// GenerateTokenPair issues the tokens of a login session with the given
+// lifetime. Neither token outlives notAfter unless it is zero.
+func (s *JWTService) GenerateTokenPair(user *domain.User, sessionID uuid.UUID, lifetime domain.TokenLifetime, notAfter time.Time) (*domain.TokenPair, time.Time, error) {
+	now := time.Now()
+	accessExpiresAt, refreshExpiresAt := now.Add(lifetime.AccessTokenExpiry), now.Add(lifetime.RefreshTokenExpiry)
+	if !notAfter.IsZero() {
+		if accessExpiresAt.After(notAfter) {
+			accessExpiresAt = notAfter
+		}
+		if refreshExpiresAt.After(notAfter) {
+			refreshExpiresAt = notAfter
+		}
+	}
+
+	accessToken, err := s.signToken(user, "access", sessionID, nil, nil, accessExpiresAt, s.config.AccessTokenSecret)
 	if err != nil {
 		return nil, time.Time{}, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	refreshToken, refreshExpiresAt, err := s.generateToken(user, "refresh", s.config.RefreshTokenExpiry, s.config.RefreshTokenSecret)
+	refreshToken, err := s.signToken(user, "refresh", sessionID, nil, nil, refreshExpiresAt, s.config.RefreshTokenSecret)
 	if err != nil {
 		return nil, time.Time{}, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
@@ -49,15 +182,179 @@ func (s *JWTService) GenerateTokenPair(user *domain.User) (*domain.TokenPair, ti
 	}, refreshExpiresAt, nil
 }
 
-func (s *JWTService) generateToken(user *domain.User, tokenType string, expiry time.Duration, secret string) (string, time.Time, error) {
+// GenerateImpersonationToken issues an access token for user on behalf of
+// actor. No refresh token is issued, so impersonation ends when it expires.
+func (s *JWTService) GenerateImpersonationToken(user *domain.User, actor *domain.Actor) (string, time.Time, error) {
+	expiresAt := time.Now().Add(s.config.ImpersonationExpiry)
+	token, err := s.signToken(user, "access", uuid.Nil, actor, nil, expiresAt, s.config.AccessTokenSecret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate impersonation token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// GenerateStepUpToken issues an access token whose amr claim lists the
+// methods the user just re-authenticated with. Like impersonation tokens it
+// comes without a refresh token, but it stays bound to the caller's session
+// and lasts as long as its access tokens.
+func (s *JWTService) GenerateStepUpToken(user *domain.User, sessionID uuid.UUID, lifetime domain.TokenLifetime, amr []string) (string, time.Time, error) {
+	expiresAt := time.Now().Add(lifetime.AccessTokenExpiry)
+	token, err := s.signToken(user, "access", sessionID, nil, amr, expiresAt, s.config.AccessTokenSecret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate step-up token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// GenerateMagicLinkToken signs the token carried by an emailed login link.
+// Its ID names the stored link, which is what makes the token single-use.
+func (s *JWTService) GenerateMagicLinkToken(link *domain.MagicLink) (string, error) {
+	claims := customClaims{
+		UserID:   link.UserID,
+		TenantID: link.TenantID,
+		Type:     "magic_link",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(link.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(link.CreatedAt),
+			NotBefore: jwt.NewNumericDate(link.CreatedAt),
+			Issuer:    s.config.Issuer,
+			ID:        link.LinkID.String(),
+		},
+	}
+
+	signedToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.purposeKey("magic_link")))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign magic link token: %w", err)
+	}
+	return signedToken, nil
+}
+
+// ValidateMagicLinkToken checks a token from GenerateMagicLinkToken and
+// returns the tenant and ID of the link it names.
+func (s *JWTService) ValidateMagicLinkToken(tokenString string) (tenantID, linkID uuid.UUID, err error) {
+	claims, err := s.parseToken(tokenString, "magic_link", s.purposeKeys("magic_link"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	linkID, err = uuid.Parse(claims.ID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, apperrors.TokenInvalid().WithDetails(map[string]string{
+			"reason": "invalid token id",
+		})
+	}
+	return claims.TenantID, linkID, nil
+}
+
+// GenerateLoginAlertToken signs the token carried by the "this wasn't me"
+// link of a new-login email. Its ID names the known device the login came
+// from; the device is forgotten when the link is used, which makes the token
+// single-use.
+func (s *JWTService) GenerateLoginAlertToken(device *domain.KnownDevice, sessionID uuid.UUID, expiresAt time.Time) (string, error) {
+	now := time.Now()
+	claims := customClaims{
+		UserID:    device.UserID,
+		TenantID:  device.TenantID,
+		Type:      "login_alert",
+		SessionID: sessionID.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    s.config.Issuer,
+			ID:        device.DeviceID.String(),
+		},
+	}
+
+	signedToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.purposeKey("login_alert")))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign login alert token: %w", err)
+	}
+	return signedToken, nil
+}
+
+// ValidateLoginAlertToken checks a token from GenerateLoginAlertToken and
+// returns the device it names, with only its IDs set, and the session the
+// reported login created.
+func (s *JWTService) ValidateLoginAlertToken(tokenString string) (*domain.KnownDevice, uuid.UUID, error) {
+	claims, err := s.parseToken(tokenString, "login_alert", s.purposeKeys("login_alert"))
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	deviceID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, uuid.Nil, apperrors.TokenInvalid().WithDetails(map[string]string{
+			"reason": "invalid token id",
+		})
+	}
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return nil, uuid.Nil, apperrors.TokenInvalid().WithDetails(map[string]string{
+			"reason": "invalid session id",
+		})
+	}
+	return &domain.KnownDevice{
+		DeviceID: deviceID,
+		TenantID: claims.TenantID,
+		UserID:   claims.UserID,
+	}, sessionID, nil
+}
+
+// purposeKey derives a signing key for tokens that must never pass as access
+// tokens, which the auth middleware accepts by signature alone.
+func (s *JWTService) purposeKey(purpose string) string {
+	return derivePurposeKey(s.config.AccessTokenSecret, purpose)
+}
+
+// purposeKeys returns the keys tokens signed with purposeKey are verified
+// with, including the one derived from the previous access secret.
+func (s *JWTService) purposeKeys(purpose string) []string {
+	var keys []string
+	for _, secret := range s.config.AccessTokenKeys() {
+		keys = append(keys, derivePurposeKey(secret, purpose))
+	}
+	return keys
+}
+
+func derivePurposeKey(secret, purpose string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return string(mac.Sum(nil))
+}
+
+func (s *JWTService) tokenExpiries(tenant *domain.Tenant) (time.Duration, time.Duration) {
+	s.mu.RLock()
+	accessExpiry := s.accessExpiry
+	refreshExpiry := s.refreshExpiry
+	s.mu.RUnlock()
+
+	if tenant != nil {
+		if tenant.Settings.AccessTokenExpiry > 0 {
+			accessExpiry = tenant.Settings.AccessTokenExpiry
+		}
+		if tenant.Settings.RefreshTokenExpiry > 0 {
+			refreshExpiry = tenant.Settings.RefreshTokenExpiry
+		}
+	}
+
+	return accessExpiry, refreshExpiry
+}
+
+// signToken signs a token for user. sessionID is uuid.Nil for tokens that
+// belong to no session.
+func (s *JWTService) signToken(user *domain.User, tokenType string, sessionID uuid.UUID, actor *domain.Actor, amr []string, expiresAt time.Time, secret string) (string, error) {
 	now := time.Now()
-	expiresAt := now.Add(expiry)
 
 	claims := customClaims{
 		UserID:   user.UserID,
+		TenantID: user.TenantID,
 		Username: user.Username,
 		Email:    user.Email,
+		Role:     user.Role,
 		Type:     tokenType,
+		Actor:    actor,
+		AMR:      amr,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -67,33 +364,75 @@ func (s *JWTService) generateToken(user *domain.User, tokenType string, expiry t
 			ID:        generateJTI(),
 		},
 	}
+	if sessionID != uuid.Nil {
+		claims.SessionID = sessionID.String()
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	signedToken, err := token.SignedString([]byte(secret))
 	if err != nil {
-		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
+		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
 
-	return signedToken, expiresAt, nil
+	return signedToken, nil
 }
 
 func (s *JWTService) ValidateAccessToken(tokenString string) (*domain.Claims, error) {
-	return s.validateToken(tokenString, "access", s.config.AccessTokenSecret)
+	return s.validateToken(tokenString, "access", s.config.AccessTokenKeys())
 }
 
 func (s *JWTService) ValidateRefreshToken(tokenString string) (*domain.Claims, error) {
-	return s.validateToken(tokenString, "refresh", s.config.RefreshTokenSecret)
+	return s.validateToken(tokenString, "refresh", s.config.RefreshTokenKeys())
+}
+
+func (s *JWTService) validateToken(tokenString, expectedType string, secrets []string) (*domain.Claims, error) {
+	claims, err := s.parseToken(tokenString, expectedType, secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens issued before tenants existed carry no tenant_id and belong to
+	// the default tenant.
+	tenantID := claims.TenantID
+	if tenantID == uuid.Nil {
+		tenantID = domain.DefaultTenantID
+	}
+
+	var sessionID *uuid.UUID
+	if claims.SessionID != "" {
+		id, err := uuid.Parse(claims.SessionID)
+		if err != nil {
+			return nil, apperrors.TokenInvalid().WithDetails(map[string]string{
+				"reason": "invalid session id",
+			})
+		}
+		sessionID = &id
+	}
+
+	return &domain.Claims{
+		UserID:    claims.UserID,
+		TenantID:  tenantID,
+		Username:  claims.Username,
+		Email:     claims.Email,
+		Role:      claims.Role,
+		Type:      claims.Type,
+		Actor:     claims.Actor,
+		AMR:       claims.AMR,
+		SessionID: sessionID,
+	}, nil
 }
 
-func (s *JWTService) validateToken(tokenString, expectedType, secret string) (*domain.Claims, error) {
+// parseToken verifies a token signed with any of secrets, the current one
+// first.
+func (s *JWTService) parseToken(tokenString, expectedType string, secrets []string) (*customClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &customClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, apperrors.TokenInvalid().WithDetails(map[string]string{
 				"reason": "invalid signing method",
 			})
 		}
-		return []byte(secret), nil
+		return verificationKeys(secrets), nil
 	})
 
 	if err != nil {
@@ -130,12 +469,16 @@ func (s *JWTService) validateToken(tokenString, expectedType, secret string) (*d
 		})
 	}
 
-	return &domain.Claims{
-		UserID:   claims.UserID,
-		Username: claims.Username,
-		Email:    claims.Email,
-		Type:     claims.Type,
-	}, nil
+	return claims, nil
+}
+
+// verificationKeys is the key set of HMAC secrets, tried in order.
+func verificationKeys(secrets []string) jwt.VerificationKeySet {
+	keys := make([]jwt.VerificationKey, 0, len(secrets))
+	for _, secret := range secrets {
+		keys = append(keys, []byte(secret))
+	}
+	return jwt.VerificationKeySet{Keys: keys}
 }
 
 func generateJTI() string {
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func newTestJWTConfig() *config.JWTConfig {
+	return &config.JWTConfig{
+		AccessTokenSecret:  strings.Repeat("a", 32),
+		RefreshTokenSecret: strings.Repeat("r", 32),
+		AccessTokenExpiry:  15 * time.Minute,
+		RefreshTokenExpiry: 24 * time.Hour,
+		Issuer:             "auth-service-test",
+	}
+}
+
+func TestCheckKeys(t *testing.T) {
+	if err := NewJWTService(newTestJWTConfig()).CheckKeys(); err != nil {
+		t.Fatalf("CheckKeys: %v", err)
+	}
+
+	cfg := newTestJWTConfig()
+	cfg.RefreshTokenSecret = ""
+	if err := NewJWTService(cfg).CheckKeys(); err == nil || !strings.Contains(err.Error(), "refresh") {
+		t.Fatalf("CheckKeys without a refresh key = %v, want an error naming it", err)
+	}
+}
+
+// rotate moves cfg's secrets to the previous ones and sets new secrets.
+func rotate(cfg *config.JWTConfig) {
+	cfg.AccessTokenPreviousSecret = cfg.AccessTokenSecret
+	cfg.RefreshTokenPreviousSecret = cfg.RefreshTokenSecret
+	cfg.AccessTokenSecret = strings.Repeat("A", 32)
+	cfg.RefreshTokenSecret = strings.Repeat("R", 32)
+}
+
+func TestTokensVerifyAfterKeyRotation(t *testing.T) {
+	cfg := newTestJWTConfig()
+	svc := NewJWTService(cfg)
+	user := &domain.User{UserID: uuid.New(), TenantID: domain.DefaultTenantID, Username: "alice", Role: domain.RoleUser}
+	lifetime := svc.Lifetime(nil, user.Role, "", false)
+
+	pair, _, err := svc.GenerateTokenPair(user, uuid.New(), lifetime, time.Time{})
+	if err != nil {
+		t.Fatalf("GenerateTokenPair: %v", err)
+	}
+	link := &domain.MagicLink{LinkID: uuid.New(), TenantID: user.TenantID, UserID: user.UserID, CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Minute)}
+	linkToken, err := svc.GenerateMagicLinkToken(link)
+	if err != nil {
+		t.Fatalf("GenerateMagicLinkToken: %v", err)
+	}
+
+	rotate(cfg)
+
+	if _, err := svc.ValidateAccessToken(pair.AccessToken); err != nil {
+		t.Fatalf("access token after rotation: %v", err)
+	}
+	if _, err := svc.ValidateRefreshToken(pair.RefreshToken); err != nil {
+		t.Fatalf("refresh token after rotation: %v", err)
+	}
+	if _, linkID, err := svc.ValidateMagicLinkToken(linkToken); err != nil || linkID != link.LinkID {
+		t.Fatalf("magic link token after rotation: %v", err)
+	}
+
+	fresh, _, err := svc.GenerateTokenPair(user, uuid.New(), lifetime, time.Time{})
+	if err != nil {
+		t.Fatalf("GenerateTokenPair: %v", err)
+	}
+	if err := svc.CheckKeys(); err != nil {
+		t.Fatalf("CheckKeys after rotation: %v", err)
+	}
+
+	// Once the previous secrets are unset, only tokens signed since the
+	// rotation verify.
+	cfg.AccessTokenPreviousSecret = ""
+	cfg.RefreshTokenPreviousSecret = ""
+
+	if _, err := svc.ValidateAccessToken(pair.AccessToken); err == nil {
+		t.Fatal("access token signed with a retired secret verified")
+	}
+	if _, err := svc.ValidateRefreshToken(pair.RefreshToken); err == nil {
+		t.Fatal("refresh token signed with a retired secret verified")
+	}
+	if _, _, err := svc.ValidateMagicLinkToken(linkToken); err == nil {
+		t.Fatal("magic link token signed with a retired secret verified")
+	}
+	if _, err := svc.ValidateAccessToken(fresh.AccessToken); err != nil {
+		t.Fatalf("access token signed after rotation: %v", err)
+	}
+}
+
+func TestPurposeTokensDoNotPassAsAccessTokens(t *testing.T) {
+	cfg := newTestJWTConfig()
+	svc := NewJWTService(cfg)
+	link := &domain.MagicLink{LinkID: uuid.New(), TenantID: domain.DefaultTenantID, UserID: uuid.New(), CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Minute)}
+	token, err := svc.GenerateMagicLinkToken(link)
+	if err != nil {
+		t.Fatalf("GenerateMagicLinkToken: %v", err)
+	}
+
+	rotate(cfg)
+	if _, err := svc.parseToken(token, "magic_link", cfg.AccessTokenKeys()); err == nil {
+		t.Fatal("magic link token verified with a raw access secret")
+	}
+}
+
+func TestLifetimeProfiles(t *testing.T) {
+	cfg := newTestJWTConfig()
+	cfg.RememberMeExpiry = 720 * time.Hour
+	cfg.Profiles = []config.LifetimeProfile{
+		{Name: "banking", AccessTokenExpiry: 5 * time.Minute, RefreshTokenExpiry: 30 * time.Minute, Clients: []string{"banking-web"}, Roles: []string{domain.RoleAdmin}},
+		{Name: "mobile", RefreshTokenExpiry: 2160 * time.Hour, RememberMeExpiry: 4320 * time.Hour, Clients: []string{"ios-app"}},
+	}
+	svc := NewJWTService(cfg)
+	shortTenant := &domain.Tenant{Settings: domain.TenantSettings{RefreshTokenExpiry: time.Hour}}
+	longTenant := &domain.Tenant{Settings: domain.TenantSettings{RefreshTokenExpiry: 2160 * time.Hour}}
+
+	tests := []struct {
+		name       string
+		tenant     *domain.Tenant
+		role       string
+		clientID   string
+		rememberMe bool
+		want       domain.TokenLifetime
+	}{
+		{"defaults", nil, domain.RoleUser, "", false, domain.TokenLifetime{AccessTokenExpiry: 15 * time.Minute, RefreshTokenExpiry: 24 * time.Hour}},
+		{"remember-me", nil, domain.RoleUser, "", true, domain.TokenLifetime{RememberMe: true, AccessTokenExpiry: 15 * time.Minute, RefreshTokenExpiry: 720 * time.Hour}},
+		{"client profile", nil, domain.RoleUser, "banking-web", false, domain.TokenLifetime{Profile: "banking", AccessTokenExpiry: 5 * time.Minute, RefreshTokenExpiry: 30 * time.Minute}},
+		{"remember-me kept to the profile's refresh lifetime", nil, domain.RoleUser, "banking-web", true, domain.TokenLifetime{Profile: "banking", RememberMe: true, AccessTokenExpiry: 5 * time.Minute, RefreshTokenExpiry: 30 * time.Minute}},
+		{"profile remember-me", nil, domain.RoleUser, "ios-app", true, domain.TokenLifetime{Profile: "mobile", RememberMe: true, AccessTokenExpiry: 15 * time.Minute, RefreshTokenExpiry: 4320 * time.Hour}},
+		{"remember-me kept to the tenant's refresh lifetime", shortTenant, domain.RoleUser, "", true, domain.TokenLifetime{RememberMe: true, AccessTokenExpiry: 15 * time.Minute, RefreshTokenExpiry: time.Hour}},
+		{"tenant refresh lifetime beyond remember-me", longTenant, domain.RoleUser, "", true, domain.TokenLifetime{RememberMe: true, AccessTokenExpiry: 15 * time.Minute, RefreshTokenExpiry: 2160 * time.Hour}},
+		{"role profile wins", nil, domain.RoleAdmin, "ios-app", true, domain.TokenLifetime{Profile: "banking", RememberMe: true, AccessTokenExpiry: 5 * time.Minute, RefreshTokenExpiry: 30 * time.Minute}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := svc.Lifetime(tt.tenant, tt.role, tt.clientID, tt.rememberMe); got != tt.want {
+				t.Fatalf("Lifetime = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/tracing"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+	"auth-service/pkg/validator"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const ldapProvider = "ldap"
+
+// LDAPAuthenticator checks passwords by binding to an LDAP or Active
+// Directory server as the user's entry, found by searching for the login
+// name. It only handles logins to the configured tenant.
+type LDAPAuthenticator struct {
+	cfg    *config.LDAPConfig
+	logger *logger.Logger
+}
+
+func NewLDAPAuthenticator(cfg *config.LDAPConfig, log *logger.Logger) *LDAPAuthenticator {
+	return &LDAPAuthenticator{cfg: cfg, logger: log}
+}
+
+func (a *LDAPAuthenticator) Name() string {
+	return ldapProvider
+}
+
+func (a *LDAPAuthenticator) Authenticate(ctx context.Context, tenant *domain.Tenant, username, password string) (_ *Authentication, err error) {
+	if tenant.Slug != a.cfg.Tenant {
+		return nil, &AuthFailure{Reason: "user_not_found", Err: apperrors.InvalidCredentials(), Fallthrough: true}
+	}
+	// Most servers treat a bind with an empty password as an anonymous bind,
+	// which succeeds for any DN.
+	if password == "" {
+		return nil, &AuthFailure{Reason: "invalid_password", Err: apperrors.InvalidCredentials()}
+	}
+
+	ctx, span := tracing.Start(ctx, "LDAPAuthenticator.Authenticate")
+	defer func() { tracing.End(span, err) }()
+
+	conn, err := a.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if a.cfg.BindDN != "" {
+		if err := conn.Bind(a.cfg.BindDN, a.cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("ldap service bind: %w", err)
+		}
+	}
+
+	entry, err := a.search(conn, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, &AuthFailure{Reason: "invalid_password", Err: apperrors.InvalidCredentials()}
+		}
+		return nil, fmt.Errorf("ldap user bind: %w", err)
+	}
+
+	dir, err := a.directoryUser(entry, username)
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).WithField("dn", entry.DN).Warn("ldap entry cannot be mapped to a user")
+		return nil, &AuthFailure{Reason: "invalid_directory_entry", Err: apperrors.Unauthorized("directory account is missing required attributes")}
+	}
+
+	return &Authentication{Directory: dir}, nil
+}
+
+func (a *LDAPAuthenticator) dial() (*ldap.Conn, error) {
+	// Config validation rejects skipping verification in production.
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: a.cfg.TLSSkipVerify,
+	}
+	if u, err := url.Parse(a.cfg.URL); err == nil {
+		tlsConfig.ServerName = u.Hostname()
+	}
+
+	conn, err := ldap.DialURL(a.cfg.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: a.cfg.Timeout}),
+		ldap.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ldap dial: %w", err)
+	}
+	conn.SetTimeout(a.cfg.Timeout)
+
+	if a.cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ldap starttls: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+func (a *LDAPAuthenticator) search(conn *ldap.Conn, username string) (*ldap.Entry, error) {
+	attributes := []string{a.cfg.AttrUsername, a.cfg.AttrEmail}
+	for _, attr := range []string{a.cfg.AttrID, a.cfg.AttrFullName, a.cfg.AttrGroups} {
+		if attr != "" {
+			attributes = append(attributes, attr)
+		}
+	}
+
+	filter := strings.ReplaceAll(a.cfg.UserFilter, "{username}", ldap.EscapeFilter(username))
+	request := ldap.NewSearchRequest(
+		a.cfg.BaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(a.cfg.Timeout.Seconds()), false,
+		filter, attributes, nil,
+	)
+
+	result, err := conn.Search(request)
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("ldap search: %w", err)
+	}
+	switch {
+	case err != nil || len(result.Entries) > 1:
+		// Refuse rather than guess which entry the password belongs to.
+		return nil, &AuthFailure{Reason: "ambiguous_directory_user", Err: apperrors.InvalidCredentials()}
+	case len(result.Entries) == 0:
+		return nil, &AuthFailure{Reason: "user_not_found", Err: apperrors.InvalidCredentials(), Fallthrough: true}
+	}
+
+	return result.Entries[0], nil
+}
+
+func (a *LDAPAuthenticator) directoryUser(entry *ldap.Entry, username string) (*DirectoryUser, error) {
+	email := strings.TrimSpace(entry.GetAttributeValue(a.cfg.AttrEmail))
+	if !validator.ValidateEmail(email) {
+		return nil, fmt.Errorf("attribute %s is not a valid email: %q", a.cfg.AttrEmail, email)
+	}
+
+	// The local username must pass the same rules as registration; fall back
+	// to the login name, which did.
+	localUsername := entry.GetAttributeValue(a.cfg.AttrUsername)
+	if !validator.ValidateUsername(localUsername) {
+		localUsername = username
+	}
+
+	dir := &DirectoryUser{
+		Provider: ldapProvider,
+		Subject:  a.subject(entry),
+		Username: localUsername,
+		Email:    email,
+		FullName: strings.TrimSpace(entry.GetAttributeValue(a.cfg.AttrFullName)),
+	}
+	if len(a.cfg.GroupRoles) > 0 {
+		dir.Role = a.role(entry.GetAttributeValues(a.cfg.AttrGroups))
+	}
+
+	return dir, nil
+}
+
+// subject returns a stable identifier for the entry. DNs change when users
+// are renamed or moved, so they are only used without an ID attribute.
+// Binary IDs such as objectGUID are hex encoded.
+func (a *LDAPAuthenticator) subject(entry *ldap.Entry) string {
+	if a.cfg.AttrID != "" {
+		raw := entry.GetRawAttributeValue(a.cfg.AttrID)
+		if len(raw) > 0 {
+			if utf8.Valid(raw) {
+				return string(raw)
+			}
+			return hex.EncodeToString(raw)
+		}
+	}
+	return strings.ToLower(entry.DN)
+}
+
+// role returns the highest role mapped from the user's groups.
+func (a *LDAPAuthenticator) role(groups []string) string {
+	role := domain.RoleUser
+	for _, group := range groups {
+		for _, mapping := range a.cfg.GroupRoles {
+			if strings.EqualFold(group, mapping.GroupDN) && mapping.Role == domain.RoleAdmin {
+				role = domain.RoleAdmin
+			}
+		}
+	}
+	return role
+}
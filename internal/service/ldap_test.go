@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/pkg/logger"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/jimlambrt/gldap"
+)
+
+const (
+	testServiceDN       = "cn=svc,dc=example,dc=org"
+	testServicePassword = "svc-secret"
+	testPeopleDN        = "ou=people,dc=example,dc=org"
+	testAdminsGroupDN   = "cn=admins,ou=groups,dc=example,dc=org"
+)
+
+type directoryEntry struct {
+	dn         string
+	password   string
+	attributes map[string][]string
+}
+
+// testDirectory is an in-process LDAP server holding a few people. Searches
+// are only answered on connections bound as the service account and support
+// the (uid=<name>) filter the tests configure, with (uid=*) matching everyone.
+type testDirectory struct {
+	server  *gldap.Server
+	url     string
+	entries []directoryEntry
+
+	mu    sync.Mutex
+	bound map[int]string // connection ID to bound DN
+}
+
+var uidFilter = regexp.MustCompile(`^\(uid=(\*|[^()*\\]*)\)$`)
+
+func newTestDirectory(t *testing.T) *testDirectory {
+	person := func(uid, password, mail string, groups ...string) directoryEntry {
+		return directoryEntry{
+			dn:       fmt.Sprintf("uid=%s,%s", uid, testPeopleDN),
+			password: password,
+			attributes: map[string][]string{
+				"uid":       {uid},
+				"entryUUID": {"uuid-" + uid},
+				"mail":      {mail},
+				"cn":        {strings.ToUpper(uid[:1]) + uid[1:] + " Example"},
+				"memberOf":  groups,
+			},
+		}
+	}
+	dir := &testDirectory{
+		bound: make(map[int]string),
+		entries: []directoryEntry{
+			person("alice", "alice-pw", "alice@example.org", "cn=staff,ou=groups,dc=example,dc=org", "CN=Admins,OU=Groups,DC=example,DC=org"),
+			person("bob", "bob-pw", "bob@example.org", "cn=staff,ou=groups,dc=example,dc=org"),
+			person("carol", "carol-pw", "not-an-email"),
+			person("twin", "twin-pw", "twin@example.org"),
+		},
+	}
+	// A second entry with the same uid in another branch.
+	twin := person("twin", "twin-pw", "twin2@example.org")
+	twin.dn = "uid=twin,ou=contractors," + testPeopleDN
+	dir.entries = append(dir.entries, twin)
+
+	server, err := gldap.NewServer(gldap.WithLogger(hclog.NewNullLogger()))
+	if err != nil {
+		t.Fatalf("create ldap server: %v", err)
+	}
+	mux, err := gldap.NewMux()
+	if err != nil {
+		t.Fatalf("create ldap mux: %v", err)
+	}
+	if err := mux.Bind(dir.bind); err != nil {
+		t.Fatalf("route binds: %v", err)
+	}
+	if err := mux.Search(dir.search); err != nil {
+		t.Fatalf("route searches: %v", err)
+	}
+	if err := server.Router(mux); err != nil {
+		t.Fatalf("set router: %v", err)
+	}
+
+	// gldap does not expose its listener, so reserve a free port for it.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("reserve port: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	go func() { _ = server.Run(addr) }()
+	t.Cleanup(func() { _ = server.Stop() })
+	for deadline := time.Now().Add(5 * time.Second); !server.Ready(); {
+		if time.Now().After(deadline) {
+			t.Fatal("ldap server did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	dir.server = server
+	dir.url = "ldap://" + addr
+	return dir
+}
+
+func (d *testDirectory) bind(w *gldap.ResponseWriter, r *gldap.Request) {
+	resp := r.NewBindResponse(gldap.WithResponseCode(gldap.ResultInvalidCredentials))
+	defer func() { _ = w.Write(resp) }()
+
+	m, err := r.GetSimpleBindMessage()
+	if err != nil {
+		return
+	}
+	ok := strings.EqualFold(m.UserName, testServiceDN) && string(m.Password) == testServicePassword
+	for _, entry := range d.entries {
+		if strings.EqualFold(m.UserName, entry.dn) && string(m.Password) == entry.password {
+			ok = true
+		}
+	}
+	if ok {
+		d.mu.Lock()
+		d.bound[r.ConnectionID()] = strings.ToLower(m.UserName)
+		d.mu.Unlock()
+		resp.SetResultCode(gldap.ResultSuccess)
+	}
+}
+
+func (d *testDirectory) search(w *gldap.ResponseWriter, r *gldap.Request) {
+	resp := r.NewSearchDoneResponse(gldap.WithResponseCode(gldap.ResultSuccess))
+	defer func() { _ = w.Write(resp) }()
+
+	d.mu.Lock()
+	boundDN := d.bound[r.ConnectionID()]
+	d.mu.Unlock()
+	if boundDN != testServiceDN {
+		resp.SetResultCode(gldap.ResultInsufficientAccessRights)
+		return
+	}
+
+	m, err := r.GetSearchMessage()
+	if err != nil {
+		resp.SetResultCode(gldap.ResultProtocolError)
+		return
+	}
+	match := uidFilter.FindStringSubmatch(m.Filter)
+	if match == nil {
+		return
+	}
+
+	var found []directoryEntry
+	for _, entry := range d.entries {
+		if strings.HasSuffix(entry.dn, ","+m.BaseDN) && (match[1] == "*" || entry.attributes["uid"][0] == match[1]) {
+			found = append(found, entry)
+		}
+	}
+	for i, entry := range found {
+		if m.SizeLimit > 0 && int64(i) >= m.SizeLimit {
+			resp.SetResultCode(gldap.ResultSizeLimitExceeded)
+			return
+		}
+		attributes := make(map[string][]string)
+		for _, name := range m.Attributes {
+			if values := entry.attributes[name]; len(values) > 0 {
+				attributes[name] = values
+			}
+		}
+		_ = w.Write(r.NewSearchResponseEntry(entry.dn, gldap.WithAttributes(attributes)))
+	}
+}
+
+func newTestLDAPAuthenticator(url string) *LDAPAuthenticator {
+	return NewLDAPAuthenticator(&config.LDAPConfig{
+		Enabled:      true,
+		URL:          url,
+		BindDN:       testServiceDN,
+		BindPassword: testServicePassword,
+		BaseDN:       testPeopleDN,
+		UserFilter:   "(uid={username})",
+		Tenant:       "default",
+		Timeout:      5 * time.Second,
+		AttrID:       "entryUUID",
+		AttrUsername: "uid",
+		AttrEmail:    "mail",
+		AttrFullName: "cn",
+		AttrGroups:   "memberOf",
+		GroupRoles:   []config.LDAPGroupRole{{GroupDN: testAdminsGroupDN, Role: domain.RoleAdmin}},
+	}, logger.New("error", "json", ""))
+}
+
+var ldapTestTenant = &domain.Tenant{TenantID: domain.DefaultTenantID, Slug: "default"}
+
+func TestLDAPAuthenticate(t *testing.T) {
+	dir := newTestDirectory(t)
+	auth := newTestLDAPAuthenticator(dir.url)
+
+	tests := []struct {
+		username, password string
+		want               DirectoryUser
+	}{
+		{"alice", "alice-pw", DirectoryUser{
+			Provider: ldapProvider,
+			Subject:  "uuid-alice",
+			Username: "alice",
+			Email:    "alice@example.org",
+			FullName: "Alice Example",
+			Role:     domain.RoleAdmin,
+		}},
+		{"bob", "bob-pw", DirectoryUser{
+			Provider: ldapProvider,
+			Subject:  "uuid-bob",
+			Username: "bob",
+			Email:    "bob@example.org",
+			FullName: "Bob Example",
+			Role:     domain.RoleUser,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			result, err := auth.Authenticate(context.Background(), ldapTestTenant, tt.username, tt.password)
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if result.Directory == nil || *result.Directory != tt.want {
+				t.Fatalf("directory user = %+v, want %+v", result.Directory, tt.want)
+			}
+		})
+	}
+}
+
+func TestLDAPAuthenticateWithoutGroupRoles(t *testing.T) {
+	dir := newTestDirectory(t)
+	auth := newTestLDAPAuthenticator(dir.url)
+	auth.cfg.GroupRoles = nil
+
+	result, err := auth.Authenticate(context.Background(), ldapTestTenant, "alice", "alice-pw")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if result.Directory.Role != "" {
+		t.Fatalf("role = %q, want the local role left alone", result.Directory.Role)
+	}
+}
+
+func TestLDAPAuthenticateFailures(t *testing.T) {
+	dir := newTestDirectory(t)
+	auth := newTestLDAPAuthenticator(dir.url)
+
+	tests := []struct {
+		name, username, password string
+		reason                   string
+		fallsThrough             bool
+	}{
+		{"wrong password", "alice", "bob-pw", "invalid_password", false},
+		{"empty password", "alice", "", "invalid_password", false},
+		{"unknown user", "mallory", "x", "user_not_found", true},
+		{"filter injection", "*", "alice-pw", "user_not_found", true},
+		{"ambiguous user", "twin", "twin-pw", "ambiguous_directory_user", false},
+		{"invalid entry", "carol", "carol-pw", "invalid_directory_entry", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := auth.Authenticate(context.Background(), ldapTestTenant, tt.username, tt.password)
+			failure, ok := err.(*AuthFailure)
+			if !ok {
+				t.Fatalf("Authenticate = %v, want an AuthFailure", err)
+			}
+			if failure.Reason != tt.reason || failure.Fallthrough != tt.fallsThrough {
+				t.Fatalf("failure = %s (fallthrough %t), want %s (fallthrough %t)", failure.Reason, failure.Fallthrough, tt.reason, tt.fallsThrough)
+			}
+		})
+	}
+}
+
+func TestLDAPAuthenticateOtherTenant(t *testing.T) {
+	// No server: logins to other tenants never reach the directory.
+	auth := newTestLDAPAuthenticator("ldap://127.0.0.1:1")
+
+	_, err := auth.Authenticate(context.Background(), &domain.Tenant{Slug: "acme"}, "alice", "alice-pw")
+	failure, ok := err.(*AuthFailure)
+	if !ok || !failure.Fallthrough {
+		t.Fatalf("Authenticate = %v, want a fallthrough failure", err)
+	}
+}
+
+func TestLDAPAuthenticateServiceBindFails(t *testing.T) {
+	dir := newTestDirectory(t)
+	auth := newTestLDAPAuthenticator(dir.url)
+	auth.cfg.BindPassword = "wrong"
+
+	_, err := auth.Authenticate(context.Background(), ldapTestTenant, "alice", "alice-pw")
+	if err == nil {
+		t.Fatal("Authenticate succeeded without a service bind")
+	}
+	if _, ok := err.(*AuthFailure); ok {
+		t.Fatalf("Authenticate = %v, want a directory error rather than a login failure", err)
+	}
+}
+
+func TestLDAPSubjectWithoutIDAttribute(t *testing.T) {
+	dir := newTestDirectory(t)
+	auth := newTestLDAPAuthenticator(dir.url)
+	auth.cfg.AttrID = ""
+
+	result, err := auth.Authenticate(context.Background(), ldapTestTenant, "bob", "bob-pw")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if want := "uid=bob," + testPeopleDN; result.Directory.Subject != want {
+		t.Fatalf("subject = %q, want %q", result.Directory.Subject, want)
+	}
+}
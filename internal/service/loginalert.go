@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/repository"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// Locator resolves a client address to an approximate location for people
+// to read, or "" when it is unknown; see internal/geoip.
+type Locator interface {
+	Locate(ip string) string
+}
+
+// versionNumbers matches the version numbers in a user agent, which change
+// with every browser or OS update.
+var versionNumbers = regexp.MustCompile(`[0-9]+([._][0-9]+)*`)
+
+// LoginAlertService remembers the devices and networks users log in from and
+// emails them when a login comes from one not seen before. The email links to
+// a page where the user can report the login, which ends their sessions.
+type LoginAlertService struct {
+	cfg           *config.LoginAlertConfig
+	devices       repository.KnownDeviceRepository
+	jwt           *JWTService
+	notifications *NotificationService
+	locator       Locator
+	logger        *logger.Logger
+}
+
+// NewLoginAlertService creates the service. locator may be nil, in which case
+// alerts show no location.
+func NewLoginAlertService(
+	cfg *config.LoginAlertConfig,
+	devices repository.KnownDeviceRepository,
+	jwt *JWTService,
+	notifications *NotificationService,
+	locator Locator,
+	log *logger.Logger,
+) *LoginAlertService {
+	return &LoginAlertService{
+		cfg:           cfg,
+		devices:       devices,
+		jwt:           jwt,
+		notifications: notifications,
+		locator:       locator,
+		logger:        log,
+	}
+}
+
+func (s *LoginAlertService) enabled() bool {
+	return s != nil && s.cfg.Enabled
+}
+
+// Check records the device and network session was started from and alerts
+// the user if either is new to them. Nothing is sent for a user's first
+// recorded login, so that enabling alerts or registering does not trigger
+// one. Failures are logged: they must not fail the login.
+func (s *LoginAlertService) Check(ctx context.Context, user *domain.User, session *domain.Session) {
+	if !s.enabled() {
+		return
+	}
+
+	log := s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"tenant_id": user.TenantID,
+		"user_id":   user.UserID,
+	})
+
+	known, err := s.devices.ListByUserID(ctx, user.UserID)
+	if err != nil {
+		log.WithError(err).Error("failed to list known devices")
+		return
+	}
+
+	device := &domain.KnownDevice{
+		TenantID:    user.TenantID,
+		UserID:      user.UserID,
+		Fingerprint: deviceFingerprint(session.UserAgent),
+		Network:     clientNetwork(session.IPAddress),
+		DeviceInfo:  session.DeviceInfo,
+		IPAddress:   session.IPAddress,
+	}
+	if s.locator != nil {
+		device.Location = s.locator.Locate(session.IPAddress)
+	}
+
+	knownDevice, knownNetwork := false, false
+	for _, d := range known {
+		knownDevice = knownDevice || d.Fingerprint == device.Fingerprint
+		knownNetwork = knownNetwork || d.Network == device.Network
+	}
+
+	if err := s.devices.Upsert(ctx, device); err != nil {
+		log.WithError(err).Error("failed to record known device")
+		return
+	}
+
+	if len(known) == 0 || (knownDevice && knownNetwork) {
+		return
+	}
+
+	s.send(ctx, log, user, device, session.SessionID)
+}
+
+func (s *LoginAlertService) send(ctx context.Context, log *logger.Logger, user *domain.User, device *domain.KnownDevice, sessionID uuid.UUID) {
+	token, err := s.jwt.GenerateLoginAlertToken(device, sessionID, time.Now().Add(s.cfg.TTL))
+	if err != nil {
+		log.WithError(err).Error("failed to sign login alert token")
+		return
+	}
+
+	target, err := url.Parse(s.cfg.URL)
+	if err != nil {
+		log.WithError(err).Error("invalid LOGIN_ALERT_URL")
+		return
+	}
+	query := target.Query()
+	query.Set("token", token)
+	target.RawQuery = query.Encode()
+
+	err = s.notifications.Send(ctx, &Notification{
+		TenantID: user.TenantID,
+		UserID:   userRef(user.UserID),
+		To:       user.Email,
+		Template: TemplateNewLogin,
+		Data: map[string]interface{}{
+			"URL":        target.String(),
+			"DeviceInfo": device.DeviceInfo,
+			"IPAddress":  device.IPAddress,
+			"Location":   device.Location,
+			"Time":       device.LastSeenAt.UTC().Format("2006-01-02 15:04 MST"),
+			"TTLDays":    int(s.cfg.TTL.Hours() / 24),
+		},
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to send login alert")
+		return
+	}
+
+	log.WithField("device_id", device.DeviceID).Info("new login alert queued")
+}
+
+// verify checks a report token for tenant. It returns the device the token
+// names, with only its IDs set, and the session the reported login started.
+func (s *LoginAlertService) verify(tenant *domain.Tenant, token string) (*domain.KnownDevice, uuid.UUID, error) {
+	if !s.enabled() {
+		return nil, uuid.Nil, apperrors.NotFound("login alerts")
+	}
+
+	device, sessionID, err := s.jwt.ValidateLoginAlertToken(token)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	if device.TenantID != tenant.TenantID {
+		return nil, uuid.Nil, apperrors.TokenInvalid()
+	}
+	return device, sessionID, nil
+}
+
+// consume forgets the device a report token names, which spends the token.
+func (s *LoginAlertService) consume(ctx context.Context, device *domain.KnownDevice) error {
+	if err := s.devices.Delete(ctx, device.TenantID, device.DeviceID); err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrCodeNotFound {
+			return apperrors.TokenInvalid().WithDetails(map[string]string{
+				"reason": "link already used",
+			})
+		}
+		return err
+	}
+	return nil
+}
+
+// CleanupStaleDevices forgets devices not seen for LOGIN_ALERT_DEVICE_MAX_AGE.
+func (s *LoginAlertService) CleanupStaleDevices(ctx context.Context) error {
+	if !s.enabled() {
+		return nil
+	}
+	return s.devices.DeleteStale(ctx, time.Now().Add(-s.cfg.DeviceMaxAge))
+}
+
+// deviceFingerprint hashes a user agent without its version numbers.
+func deviceFingerprint(userAgent string) string {
+	normalized := versionNumbers.ReplaceAllString(strings.ToLower(userAgent), "")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// clientNetwork truncates an IPv4 address to its /24 and an IPv6 address to
+// its /48, roughly the network of one provider site or household.
+func clientNetwork(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "unknown"
+	}
+	addr = addr.Unmap()
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return "unknown"
+	}
+	return prefix.String()
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/url"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/metrics"
+	"auth-service/internal/repository"
+	"auth-service/internal/tracing"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+)
+
+// magicLinkProvider names magic links in audit metadata.
+const magicLinkProvider = "magic_link"
+
+// MagicLinkService logs users in through single-use links sent by email.
+type MagicLinkService struct {
+	cfg           *config.MagicLinkConfig
+	links         repository.MagicLinkRepository
+	users         repository.UserRepository
+	auth          *AuthService
+	jwt           *JWTService
+	notifications *NotificationService
+	logger        *logger.Logger
+}
+
+func NewMagicLinkService(
+	cfg *config.MagicLinkConfig,
+	links repository.MagicLinkRepository,
+	users repository.UserRepository,
+	auth *AuthService,
+	jwt *JWTService,
+	notifications *NotificationService,
+	log *logger.Logger,
+) *MagicLinkService {
+	return &MagicLinkService{
+		cfg:           cfg,
+		links:         links,
+		users:         users,
+		auth:          auth,
+		jwt:           jwt,
+		notifications: notifications,
+		logger:        log,
+	}
+}
+
+// Request emails a login link to the active user with the given address.
+// The response is the same whether or not such a user exists, and the link
+// is created and sent in the background so that timing does not tell either.
+// The returned nonce must accompany the token from the link.
+func (s *MagicLinkService) Request(ctx context.Context, tenant *domain.Tenant, req *domain.MagicLinkRequest) (_ *domain.MagicLinkResponse, err error) {
+	ctx, span := tracing.Start(ctx, "MagicLinkService.Request")
+	defer func() { tracing.End(span, err) }()
+
+	if !s.cfg.Enabled {
+		return nil, apperrors.NotFound("magic link login")
+	}
+
+	nonce, err := randomToken()
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("failed to generate magic link nonce")
+		return nil, apperrors.Internal("failed to send login link")
+	}
+
+	go s.send(context.WithoutCancel(ctx), tenant, req.Email, hashState(nonce))
+
+	return &domain.MagicLinkResponse{
+		Message:   "if an account exists for this email, a login link has been sent",
+		Nonce:     nonce,
+		ExpiresIn: int(s.cfg.TTL.Seconds()),
+	}, nil
+}
+
+func (s *MagicLinkService) send(ctx context.Context, tenant *domain.Tenant, email, nonceHash string) {
+	log := s.logger.WithContext(ctx).WithField("tenant_id", tenant.TenantID)
+
+	user, err := s.users.GetByEmail(ctx, tenant.TenantID, email)
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); !ok || appErr.Code != apperrors.ErrCodeNotFound {
+			log.WithError(err).Error("failed to look up magic link recipient")
+		}
+		return
+	}
+	log = log.WithField("user_id", user.UserID)
+	if !user.IsActive {
+		log.Warn("magic link not sent: user is inactive")
+		return
+	}
+
+	link := &domain.MagicLink{
+		TenantID:  tenant.TenantID,
+		UserID:    user.UserID,
+		NonceHash: nonceHash,
+		ExpiresAt: time.Now().Add(s.cfg.TTL),
+	}
+	if err := s.links.Create(ctx, link); err != nil {
+		log.WithError(err).Error("failed to store magic link")
+		return
+	}
+
+	token, err := s.jwt.GenerateMagicLinkToken(link)
+	if err != nil {
+		log.WithError(err).Error("failed to sign magic link")
+		return
+	}
+
+	target, err := url.Parse(s.cfg.URL)
+	if err != nil {
+		log.WithError(err).Error("invalid MAGIC_LINK_URL")
+		return
+	}
+	query := target.Query()
+	query.Set("token", token)
+	target.RawQuery = query.Encode()
+
+	err = s.notifications.Send(ctx, &Notification{
+		TenantID: tenant.TenantID,
+		UserID:   userRef(user.UserID),
+		To:       user.Email,
+		Template: TemplateMagicLink,
+		Data: map[string]interface{}{
+			"URL":        target.String(),
+			"TTLMinutes": int(s.cfg.TTL.Minutes()),
+		},
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to send magic link")
+		return
+	}
+
+	log.Info("magic link queued")
+}
+
+// Consume logs in the user a link was sent to. It fails unless nonce is the
+// one handed out with the link. A link is spent by its first use, even a
+// failed one.
+func (s *MagicLinkService) Consume(ctx context.Context, tenant *domain.Tenant, req *domain.MagicLinkConsumeRequest) (_ *domain.AuthResponse, err error) {
+	ctx, span := tracing.Start(ctx, "MagicLinkService.Consume")
+	defer func() { tracing.End(span, err) }()
+
+	if !s.cfg.Enabled {
+		return nil, apperrors.NotFound("magic link login")
+	}
+
+	log := s.logger.WithContext(ctx).WithField("tenant_id", tenant.TenantID)
+	invalid := apperrors.Unauthorized("login link is invalid or has expired")
+
+	failed := func(target *domain.User, reason string) {
+		metrics.Logins.WithLabelValues(metrics.ResultFailure).Inc()
+		metrics.LoginFailures.WithLabelValues(reason).Inc()
+		event := &domain.AuditEvent{
+			TenantID:  tenant.TenantID,
+			EventType: domain.AuditEventLogin,
+			Result:    domain.AuditResultFailure,
+			Reason:    reason,
+			Metadata:  map[string]string{"provider": magicLinkProvider},
+		}
+		if target != nil {
+			event.TargetUserID = userRef(target.UserID)
+		}
+		s.auth.audit.Record(ctx, event)
+	}
+
+	tenantID, linkID, err := s.jwt.ValidateMagicLinkToken(req.Token)
+	if err != nil || tenantID != tenant.TenantID {
+		log.Warn("magic link login failed: invalid token")
+		failed(nil, "invalid_link")
+		return nil, invalid
+	}
+
+	link, err := s.links.Consume(ctx, tenant.TenantID, linkID)
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrCodeNotFound {
+			log.WithField("link_id", linkID).Warn("magic link login failed: link already used")
+			failed(nil, "link_used")
+			return nil, invalid
+		}
+		log.WithError(err).Error("failed to consume magic link")
+		return nil, apperrors.Internal("failed to complete login")
+	}
+
+	if time.Now().After(link.ExpiresAt) {
+		log.WithField("link_id", linkID).Warn("magic link login failed: link expired")
+		failed(nil, "link_expired")
+		return nil, invalid
+	}
+
+	user, err := s.users.GetByID(ctx, tenant.TenantID, link.UserID)
+	if err != nil {
+		failed(nil, "user_not_found")
+		return nil, invalid
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashState(req.Nonce)), []byte(link.NonceHash)) != 1 {
+		log.WithField("user_id", user.UserID).Warn("magic link login failed: nonce mismatch")
+		failed(user, "nonce_mismatch")
+		return nil, invalid
+	}
+
+	if !user.IsActive {
+		log.WithField("user_id", user.UserID).Warn("magic link login failed: user is inactive")
+		failed(user, "account_inactive")
+		return nil, apperrors.Unauthorized("account is inactive")
+	}
+
+	return s.auth.completeLogin(ctx, tenant, user, false, map[string]string{"provider": magicLinkProvider})
+}
+
+// CleanupExpiredLinks deletes links that expired unused.
+func (s *MagicLinkService) CleanupExpiredLinks(ctx context.Context) error {
+	return s.links.DeleteExpired(ctx)
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/mail"
+	"auth-service/internal/metrics"
+	"auth-service/internal/repository"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultNotificationPageSize = 50
+	maxNotificationPageSize     = 200
+)
+
+// Email templates, see internal/mail/templates.
+const (
+	TemplateMagicLink = "magic_link"
+	TemplateOTPLogin  = "otp_login"
+	TemplateOTPStepUp = "otp_step_up"
+	TemplateNewLogin  = "new_login"
+)
+
+// Notifier delivers a rendered message; see internal/mail for the SMTP,
+// file, log and in-memory implementations.
+type Notifier interface {
+	Send(ctx context.Context, msg *mail.Message) error
+}
+
+// Notification is an email to render from a template and send.
+type Notification struct {
+	TenantID uuid.UUID
+	UserID   *uuid.UUID
+	To       string
+	Template string
+	Data     map[string]interface{}
+}
+
+// NotificationService renders notifications, queues them for delivery with
+// retries and keeps a record of each one.
+//
+// Unlike webhook deliveries the queue lives in memory: messages carry login
+// links and codes, which must not be stored in plain text and are of no use
+// by the time a restarted replica could send them. Messages queued when the
+// service stops are lost and their records stay queued.
+type NotificationService struct {
+	cfg       *config.MailConfig
+	notifier  Notifier
+	templates *mail.Templates
+	records   repository.NotificationRepository
+	logger    *logger.Logger
+	queue     chan *queuedNotification
+}
+
+type queuedNotification struct {
+	ctx     context.Context // the sending request's values, for logs and traces
+	record  *domain.Notification
+	message *mail.Message
+}
+
+func NewNotificationService(
+	cfg *config.MailConfig,
+	notifier Notifier,
+	templates *mail.Templates,
+	records repository.NotificationRepository,
+	log *logger.Logger,
+) *NotificationService {
+	return &NotificationService{
+		cfg:       cfg,
+		notifier:  notifier,
+		templates: templates,
+		records:   records,
+		logger:    log,
+		queue:     make(chan *queuedNotification, cfg.QueueSize),
+	}
+}
+
+// Send renders n in the locale that best matches the Accept-Language of the
+// request in ctx, records it and queues it. Delivery happens in the
+// background; an error means the message will not be sent.
+func (s *NotificationService) Send(ctx context.Context, n *Notification) error {
+	msg, locale, err := s.templates.Render(n.Template, domain.AcceptLanguageFromContext(ctx), n.Data)
+	if err != nil {
+		return fmt.Errorf("failed to render %s email: %w", n.Template, err)
+	}
+	msg.To = n.To
+
+	record := &domain.Notification{
+		TenantID:  n.TenantID,
+		UserID:    n.UserID,
+		Template:  n.Template,
+		Locale:    locale,
+		Recipient: n.To,
+		Subject:   msg.Subject,
+		Status:    domain.NotificationQueued,
+	}
+	if err := s.records.Create(ctx, record); err != nil {
+		return err
+	}
+
+	queued := &queuedNotification{
+		ctx:     context.WithoutCancel(ctx),
+		record:  record,
+		message: msg,
+	}
+	if !s.enqueue(queued) {
+		s.fail(queued, "send queue is full")
+		return fmt.Errorf("notification queue is full")
+	}
+
+	return nil
+}
+
+// List returns the most recent notifications of the tenant, or of one user.
+func (s *NotificationService) List(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID, limit int) ([]*domain.Notification, error) {
+	if limit <= 0 {
+		limit = defaultNotificationPageSize
+	}
+	if limit > maxNotificationPageSize {
+		limit = maxNotificationPageSize
+	}
+
+	notifications, err := s.records.List(ctx, tenantID, userID, limit)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("failed to list notifications")
+		return nil, apperrors.Internal("failed to list notifications")
+	}
+	return notifications, nil
+}
+
+// Start runs the send workers until ctx is cancelled.
+func (s *NotificationService) Start(ctx context.Context) {
+	s.logger.WithFields(map[string]interface{}{
+		"transport": s.cfg.Transport,
+		"workers":   s.cfg.Workers,
+	}).Info("starting notification workers")
+
+	for i := 0; i < s.cfg.Workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case queued := <-s.queue:
+					s.attempt(ctx, queued)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+		if pending := len(s.queue); pending > 0 {
+			s.logger.WithField("pending", pending).Warn("notification workers stopped with messages unsent")
+		}
+	}()
+}
+
+func (s *NotificationService) enqueue(queued *queuedNotification) bool {
+	select {
+	case s.queue <- queued:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *NotificationService) attempt(ctx context.Context, queued *queuedNotification) {
+	record := queued.record
+	log := s.logger.WithContext(queued.ctx).WithFields(map[string]interface{}{
+		"notification_id": record.NotificationID,
+		"template":        record.Template,
+	})
+
+	err := s.notifier.Send(queued.ctx, queued.message)
+	record.Attempts++
+
+	var retryIn time.Duration
+	switch {
+	case err == nil:
+		now := time.Now()
+		record.Status = domain.NotificationSent
+		record.LastError = ""
+		record.SentAt = &now
+		metrics.Notifications.WithLabelValues(record.Template, domain.NotificationSent).Inc()
+		log.Info("notification sent")
+	case record.Attempts >= s.cfg.MaxAttempts:
+		record.Status = domain.NotificationFailed
+		record.LastError = err.Error()
+		metrics.Notifications.WithLabelValues(record.Template, domain.NotificationFailed).Inc()
+		log.WithError(err).Warn("notification failed permanently")
+	default:
+		record.LastError = err.Error()
+		retryIn = retryBackoff(s.cfg.InitialBackoff, s.cfg.MaxBackoff, record.Attempts)
+		metrics.Notifications.WithLabelValues(record.Template, "retry").Inc()
+		log.WithError(err).WithField("retry_in", retryIn).Warn("notification failed, will retry")
+	}
+
+	s.update(queued)
+
+	if retryIn > 0 {
+		time.AfterFunc(retryIn, func() {
+			if ctx.Err() == nil && !s.enqueue(queued) {
+				s.fail(queued, "send queue is full")
+			}
+		})
+	}
+}
+
+func (s *NotificationService) fail(queued *queuedNotification, reason string) {
+	queued.record.Status = domain.NotificationFailed
+	queued.record.LastError = reason
+	metrics.Notifications.WithLabelValues(queued.record.Template, domain.NotificationFailed).Inc()
+	s.logger.WithContext(queued.ctx).WithField("notification_id", queued.record.NotificationID).Error("notification dropped: " + reason)
+	s.update(queued)
+}
+
+func (s *NotificationService) update(queued *queuedNotification) {
+	if err := s.records.Update(queued.ctx, queued.record); err != nil {
+		s.logger.WithContext(queued.ctx).WithError(err).WithField("notification_id", queued.record.NotificationID).Error("failed to record notification attempt")
+	}
+}
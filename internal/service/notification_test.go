@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/mail"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// memoryNotificationRepository keeps copies of the records it is given, so
+// tests can read them while workers update the service's own.
+type memoryNotificationRepository struct {
+	mu      sync.Mutex
+	records map[uuid.UUID]domain.Notification
+}
+
+func newMemoryNotificationRepository() *memoryNotificationRepository {
+	return &memoryNotificationRepository{records: make(map[uuid.UUID]domain.Notification)}
+}
+
+func (r *memoryNotificationRepository) Create(ctx context.Context, n *domain.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n.NotificationID = uuid.New()
+	n.CreatedAt = time.Now()
+	n.UpdatedAt = n.CreatedAt
+	r.records[n.NotificationID] = *n
+	return nil
+}
+
+func (r *memoryNotificationRepository) Update(ctx context.Context, n *domain.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n.UpdatedAt = time.Now()
+	r.records[n.NotificationID] = *n
+	return nil
+}
+
+func (r *memoryNotificationRepository) List(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID, limit int) ([]*domain.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	notifications := []*domain.Notification{}
+	for _, n := range r.records {
+		if n.TenantID == tenantID && (userID == nil || (n.UserID != nil && *n.UserID == *userID)) {
+			copied := n
+			notifications = append(notifications, &copied)
+		}
+	}
+	return notifications, nil
+}
+
+func (r *memoryNotificationRepository) only(t *testing.T) domain.Notification {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.records) != 1 {
+		t.Fatalf("have %d notifications, want 1", len(r.records))
+	}
+	for _, n := range r.records {
+		return n
+	}
+	return domain.Notification{}
+}
+
+// flakyNotifier fails as many sends as failures says, then hands messages
+// to the memory sink.
+type flakyNotifier struct {
+	mu       sync.Mutex
+	failures int
+	sink     *mail.MemorySender
+}
+
+func (n *flakyNotifier) Send(ctx context.Context, msg *mail.Message) error {
+	n.mu.Lock()
+	if n.failures > 0 {
+		n.failures--
+		n.mu.Unlock()
+		return errors.New("connection refused")
+	}
+	n.mu.Unlock()
+	return n.sink.Send(ctx, msg)
+}
+
+func newTestNotificationService(t *testing.T, notifier Notifier) (*NotificationService, *memoryNotificationRepository) {
+	t.Helper()
+	templates, err := mail.LoadTemplates("", "en")
+	if err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+	cfg := &config.MailConfig{
+		Transport:      "memory",
+		QueueSize:      10,
+		Workers:        2,
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+	}
+	records := newMemoryNotificationRepository()
+	return NewNotificationService(cfg, notifier, templates, records, logger.New("error", "json", "")), records
+}
+
+func startNotificationWorkers(t *testing.T, svc *NotificationService) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	svc.Start(ctx)
+}
+
+// waitForNotification waits until the only notification leaves the queue.
+func waitForNotification(t *testing.T, records *memoryNotificationRepository, status string) domain.Notification {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		n := records.only(t)
+		if n.Status == status {
+			return n
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("notification is %s after 5s, want %s", n.Status, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func magicLinkNotification(to string) *Notification {
+	userID := uuid.New()
+	return &Notification{
+		TenantID: domain.DefaultTenantID,
+		UserID:   &userID,
+		To:       to,
+		Template: TemplateMagicLink,
+		Data: map[string]interface{}{
+			"URL":        "https://app.example.org/login?token=abc&x=<y>",
+			"TTLMinutes": 15,
+		},
+	}
+}
+
+func TestNotificationSent(t *testing.T) {
+	sink := mail.NewMemorySender()
+	svc, records := newTestNotificationService(t, sink)
+	startNotificationWorkers(t, svc)
+
+	if err := svc.Send(context.Background(), magicLinkNotification("alice@example.org")); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	record := waitForNotification(t, records, domain.NotificationSent)
+
+	messages := sink.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("sink has %d messages, want 1", len(messages))
+	}
+	msg := messages[0]
+	if msg.To != "alice@example.org" || msg.Subject != "Your login link" {
+		t.Fatalf("message to %q with subject %q", msg.To, msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "https://app.example.org/login?token=abc&x=<y>") || !strings.Contains(msg.Text, "15 minutes") {
+		t.Fatalf("text body does not carry the link:\n%s", msg.Text)
+	}
+	if msg.HTML == "" || strings.Contains(msg.HTML, "<y>") {
+		t.Fatalf("html body is missing or not escaped:\n%s", msg.HTML)
+	}
+
+	if record.Recipient != msg.To || record.Subject != msg.Subject || record.Locale != "en" ||
+		record.Attempts != 1 || record.SentAt == nil || record.LastError != "" {
+		t.Fatalf("record %+v", record)
+	}
+}
+
+func TestNotificationLocale(t *testing.T) {
+	sink := mail.NewMemorySender()
+	svc, records := newTestNotificationService(t, sink)
+	startNotificationWorkers(t, svc)
+
+	ctx := context.WithValue(context.Background(), domain.AcceptLanguageKey, "de-AT,de;q=0.9,en;q=0.5")
+	if err := svc.Send(ctx, magicLinkNotification("alice@example.org")); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	record := waitForNotification(t, records, domain.NotificationSent)
+
+	if record.Locale != "de" || sink.Messages()[0].Subject != "Ihr Anmeldelink" {
+		t.Fatalf("sent in %s with subject %q, want German", record.Locale, sink.Messages()[0].Subject)
+	}
+}
+
+func TestNotificationRetries(t *testing.T) {
+	sink := mail.NewMemorySender()
+	svc, records := newTestNotificationService(t, &flakyNotifier{failures: 2, sink: sink})
+	startNotificationWorkers(t, svc)
+
+	if err := svc.Send(context.Background(), magicLinkNotification("alice@example.org")); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	record := waitForNotification(t, records, domain.NotificationSent)
+
+	if record.Attempts != 3 || record.LastError != "" {
+		t.Fatalf("record after retries %+v, want sent on attempt 3", record)
+	}
+	if len(sink.Messages()) != 1 {
+		t.Fatalf("sink has %d messages, want 1", len(sink.Messages()))
+	}
+}
+
+func TestNotificationGivesUp(t *testing.T) {
+	sink := mail.NewMemorySender()
+	svc, records := newTestNotificationService(t, &flakyNotifier{failures: 10, sink: sink})
+	startNotificationWorkers(t, svc)
+
+	if err := svc.Send(context.Background(), magicLinkNotification("alice@example.org")); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	record := waitForNotification(t, records, domain.NotificationFailed)
+
+	if record.Attempts != 3 || record.LastError != "connection refused" || record.SentAt != nil {
+		t.Fatalf("record %+v, want failed after 3 attempts", record)
+	}
+	if len(sink.Messages()) != 0 {
+		t.Fatal("message sent after the notifier failed every attempt")
+	}
+}
+
+func TestNotificationQueueFull(t *testing.T) {
+	sink := mail.NewMemorySender()
+	svc, records := newTestNotificationService(t, sink)
+	svc.queue = make(chan *queuedNotification, 1)
+
+	// No workers run, so the second message finds the queue full.
+	if err := svc.Send(context.Background(), magicLinkNotification("alice@example.org")); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	second := magicLinkNotification("bob@example.org")
+	if err := svc.Send(context.Background(), second); err == nil {
+		t.Fatal("Send succeeded with a full queue")
+	}
+
+	failed, err := records.List(context.Background(), domain.DefaultTenantID, second.UserID, 10)
+	if err != nil || len(failed) != 1 {
+		t.Fatalf("records for the dropped message: %v, %v", failed, err)
+	}
+	if failed[0].Status != domain.NotificationFailed || failed[0].LastError != "send queue is full" {
+		t.Fatalf("dropped message record %+v", failed[0])
+	}
+}
+
+func TestNotificationUnknownTemplate(t *testing.T) {
+	svc, records := newTestNotificationService(t, mail.NewMemorySender())
+
+	n := magicLinkNotification("alice@example.org")
+	n.Template = "password_reset"
+	if err := svc.Send(context.Background(), n); err == nil {
+		t.Fatal("Send succeeded with an unknown template")
+	}
+	if len(records.records) != 0 {
+		t.Fatal("record kept for a message that could not be rendered")
+	}
+}
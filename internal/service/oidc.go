@@ -0,0 +1,566 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/metrics"
+	"auth-service/internal/repository"
+	"auth-service/internal/tracing"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+var usernameDisallowed = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// OIDCService signs users in through upstream OpenID Connect providers using
+// the authorization code flow with PKCE.
+type OIDCService struct {
+	cfg        *config.OIDCConfig
+	states     repository.OIDCStateRepository
+	identities repository.IdentityRepository
+	userRepo   repository.UserRepository
+	auth       *AuthService
+	httpClient *http.Client
+	logger     *logger.Logger
+
+	mu        sync.Mutex
+	providers map[string]*oidcProvider // discovered lazily, by name
+}
+
+type oidcProvider struct {
+	config   config.OIDCProvider
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// idTokenClaims are the ID token claims used to provision and log in users.
+type idTokenClaims struct {
+	Email             string    `json:"email"`
+	EmailVerified     claimBool `json:"email_verified"`
+	PreferredUsername string    `json:"preferred_username"`
+	Name              string    `json:"name"`
+}
+
+// claimBool accepts booleans encoded as JSON strings, which some providers
+// send for email_verified.
+type claimBool bool
+
+func (b *claimBool) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case bool:
+		*b = claimBool(v)
+	case string:
+		*b = claimBool(strings.EqualFold(v, "true"))
+	}
+	return nil
+}
+
+func NewOIDCService(
+	cfg *config.OIDCConfig,
+	states repository.OIDCStateRepository,
+	identities repository.IdentityRepository,
+	userRepo repository.UserRepository,
+	auth *AuthService,
+	log *logger.Logger,
+) *OIDCService {
+	return &OIDCService{
+		cfg:        cfg,
+		states:     states,
+		identities: identities,
+		userRepo:   userRepo,
+		auth:       auth,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		logger:     log,
+		providers:  make(map[string]*oidcProvider),
+	}
+}
+
+// Providers lists the configured providers.
+func (s *OIDCService) Providers() []domain.OIDCProvider {
+	providers := make([]domain.OIDCProvider, 0, len(s.cfg.Providers))
+	for _, p := range s.cfg.Providers {
+		providers = append(providers, domain.OIDCProvider{Name: p.Name, DisplayName: p.DisplayName})
+	}
+	return providers
+}
+
+// Authorize starts a login with the named provider. The returned state must
+// be passed back to Callback together with the authorization code.
+func (s *OIDCService) Authorize(ctx context.Context, tenant *domain.Tenant, name string) (_ *domain.OIDCAuthorizeResponse, err error) {
+	ctx, span := tracing.Start(ctx, "OIDCService.Authorize")
+	defer func() { tracing.End(span, err) }()
+
+	return s.authorize(ctx, tenant, name, nil)
+}
+
+// AuthorizeLink starts linking an identity at the named provider to userID.
+// The returned state must be passed back to LinkCallback.
+func (s *OIDCService) AuthorizeLink(ctx context.Context, tenant *domain.Tenant, userID uuid.UUID, name string) (_ *domain.OIDCAuthorizeResponse, err error) {
+	ctx, span := tracing.Start(ctx, "OIDCService.AuthorizeLink")
+	defer func() { tracing.End(span, err) }()
+
+	return s.authorize(ctx, tenant, name, &userID)
+}
+
+func (s *OIDCService) authorize(ctx context.Context, tenant *domain.Tenant, name string, userID *uuid.UUID) (*domain.OIDCAuthorizeResponse, error) {
+	log := s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"tenant_id": tenant.TenantID,
+		"provider":  name,
+	})
+
+	provider, err := s.provider(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		log.WithError(err).Error("failed to generate oidc state")
+		return nil, apperrors.Internal("failed to start login")
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		log.WithError(err).Error("failed to generate oidc nonce")
+		return nil, apperrors.Internal("failed to start login")
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	if err := s.states.Create(ctx, &domain.OIDCLoginState{
+		StateHash:    hashState(state),
+		TenantID:     tenant.TenantID,
+		UserID:       userID,
+		Provider:     name,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(s.cfg.StateTTL),
+	}); err != nil {
+		log.WithError(err).Error("failed to store oidc login state")
+		return nil, apperrors.Internal("failed to start login")
+	}
+
+	return &domain.OIDCAuthorizeResponse{
+		AuthorizationURL: provider.oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)),
+		State:            state,
+	}, nil
+}
+
+// Callback completes a login started by Authorize. A user is provisioned for
+// an unknown identity when the provider and tenant allow sign-up; identities
+// are never linked to existing accounts by email.
+func (s *OIDCService) Callback(ctx context.Context, tenant *domain.Tenant, name string, req *domain.OIDCCallbackRequest) (_ *domain.AuthResponse, err error) {
+	ctx, span := tracing.Start(ctx, "OIDCService.Callback")
+	defer func() { tracing.End(span, err) }()
+
+	log := s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"tenant_id": tenant.TenantID,
+		"provider":  name,
+	})
+
+	failed := func(target *domain.User, reason string) {
+		metrics.Logins.WithLabelValues(metrics.ResultFailure).Inc()
+		metrics.LoginFailures.WithLabelValues(reason).Inc()
+		event := &domain.AuditEvent{
+			TenantID:  tenant.TenantID,
+			EventType: domain.AuditEventLogin,
+			Result:    domain.AuditResultFailure,
+			Reason:    reason,
+			Metadata:  map[string]string{"provider": name},
+		}
+		if target != nil {
+			event.TargetUserID = userRef(target.UserID)
+		}
+		s.auth.audit.Record(ctx, event)
+	}
+
+	provider, verified, err := s.redeem(ctx, tenant, name, req, nil)
+	if err != nil {
+		if failure, ok := err.(*oidcFailure); ok {
+			failed(nil, failure.reason)
+			return nil, failure.err
+		}
+		return nil, err
+	}
+
+	user, identity, err := s.resolveUser(ctx, tenant, provider.config, verified.subject, verified.claims)
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			log.Warn("oidc login failed: " + appErr.Message)
+			failed(nil, oidcFailureReason(appErr))
+			return nil, appErr
+		}
+		log.WithError(err).Error("failed to resolve oidc identity")
+		return nil, apperrors.Internal("failed to complete login")
+	}
+
+	if !user.IsActive {
+		log.WithField("user_id", user.UserID).Warn("oidc login failed: user is inactive")
+		failed(user, "account_inactive")
+		return nil, apperrors.Unauthorized("account is inactive")
+	}
+
+	if err := s.identities.UpdateLastUsed(ctx, identity.IdentityID, time.Now()); err != nil {
+		log.WithError(err).Warn("failed to update identity last used")
+	}
+
+	return s.auth.completeLogin(ctx, tenant, user, false, map[string]string{"provider": name})
+}
+
+// LinkCallback completes linking started by AuthorizeLink, attaching the
+// identity to userID.
+func (s *OIDCService) LinkCallback(ctx context.Context, tenant *domain.Tenant, userID uuid.UUID, name string, req *domain.OIDCCallbackRequest) (_ *domain.UserIdentity, err error) {
+	ctx, span := tracing.Start(ctx, "OIDCService.LinkCallback")
+	defer func() { tracing.End(span, err) }()
+
+	log := s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"tenant_id": tenant.TenantID,
+		"user_id":   userID,
+		"provider":  name,
+	})
+
+	failed := func(reason string) {
+		s.auth.audit.Record(ctx, &domain.AuditEvent{
+			TenantID:     tenant.TenantID,
+			EventType:    domain.AuditEventIdentityLink,
+			Result:       domain.AuditResultFailure,
+			Reason:       reason,
+			ActorUserID:  userRef(userID),
+			TargetUserID: userRef(userID),
+			Metadata:     map[string]string{"provider": name},
+		})
+	}
+
+	_, verified, err := s.redeem(ctx, tenant, name, req, &userID)
+	if err != nil {
+		if failure, ok := err.(*oidcFailure); ok {
+			failed(failure.reason)
+			return nil, failure.err
+		}
+		return nil, err
+	}
+
+	identity := &domain.UserIdentity{
+		TenantID: tenant.TenantID,
+		UserID:   userID,
+		Provider: name,
+		Subject:  verified.subject,
+		Email:    verified.claims.Email,
+	}
+	if err := s.identities.Create(ctx, identity); err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrCodeAlreadyExists {
+			log.Warn("identity link failed: identity is already linked")
+			failed("identity_taken")
+			return nil, apperrors.AlreadyExists("identity").WithDetails(map[string]string{
+				"reason": "this identity is already linked to an account",
+			})
+		}
+		log.WithError(err).Error("failed to link identity")
+		return nil, apperrors.Internal("failed to link identity")
+	}
+
+	log.WithField("identity_id", identity.IdentityID).Info("identity linked")
+
+	s.auth.audit.Record(ctx, &domain.AuditEvent{
+		TenantID:     tenant.TenantID,
+		EventType:    domain.AuditEventIdentityLink,
+		ActorUserID:  userRef(userID),
+		TargetUserID: userRef(userID),
+		Metadata:     map[string]string{"provider": name},
+	})
+
+	return identity, nil
+}
+
+// oidcFailure is a rejected callback: err is returned to the caller and
+// reason recorded in the audit log.
+type oidcFailure struct {
+	reason string
+	err    *apperrors.AppError
+}
+
+func (f *oidcFailure) Error() string {
+	return f.err.Error()
+}
+
+type verifiedIdentity struct {
+	subject string
+	claims  *idTokenClaims
+}
+
+// redeem consumes the state of a callback, exchanges the code and verifies
+// the ID token. userID must match the user the state was issued to, nil for
+// logins. Rejections are returned as *oidcFailure.
+func (s *OIDCService) redeem(ctx context.Context, tenant *domain.Tenant, name string, req *domain.OIDCCallbackRequest, userID *uuid.UUID) (*oidcProvider, *verifiedIdentity, error) {
+	log := s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"tenant_id": tenant.TenantID,
+		"provider":  name,
+	})
+
+	provider, err := s.provider(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	invalidState := &oidcFailure{reason: "invalid_state", err: apperrors.Unauthorized("login request is invalid or has expired")}
+	invalidToken := &oidcFailure{reason: "invalid_id_token", err: apperrors.Unauthorized("ID token is invalid")}
+
+	state, err := s.states.Consume(ctx, hashState(req.State))
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrCodeNotFound {
+			log.Warn("oidc callback rejected: unknown state")
+			return nil, nil, invalidState
+		}
+		log.WithError(err).Error("failed to consume oidc login state")
+		return nil, nil, apperrors.Internal("failed to complete login")
+	}
+	if state.TenantID != tenant.TenantID || state.Provider != name || time.Now().After(state.ExpiresAt) {
+		log.Warn("oidc callback rejected: state expired or issued for another tenant or provider")
+		return nil, nil, invalidState
+	}
+	if (state.UserID == nil) != (userID == nil) || (userID != nil && *state.UserID != *userID) {
+		log.Warn("oidc callback rejected: state issued for another purpose or user")
+		return nil, nil, invalidState
+	}
+
+	httpCtx := oidc.ClientContext(ctx, s.httpClient)
+	token, err := provider.oauth.Exchange(httpCtx, req.Code, oauth2.VerifierOption(state.CodeVerifier))
+	if err != nil {
+		log.WithError(err).Warn("oidc callback rejected: code exchange failed")
+		return nil, nil, &oidcFailure{reason: "code_exchange_failed", err: apperrors.Unauthorized("authorization code was rejected")}
+	}
+
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" {
+		log.Warn("oidc callback rejected: token response has no id_token")
+		return nil, nil, &oidcFailure{reason: "invalid_id_token", err: apperrors.Unauthorized("identity provider returned no ID token")}
+	}
+
+	idToken, err := provider.verifier.Verify(httpCtx, rawIDToken)
+	if err != nil {
+		log.WithError(err).Warn("oidc callback rejected: id token verification failed")
+		return nil, nil, invalidToken
+	}
+	if idToken.Nonce != state.Nonce {
+		log.Warn("oidc callback rejected: nonce mismatch")
+		return nil, nil, invalidToken
+	}
+
+	var claims idTokenClaims
+	if err := idToken.Claims(&claims); err != nil {
+		log.WithError(err).Warn("oidc callback rejected: malformed id token claims")
+		return nil, nil, invalidToken
+	}
+
+	return provider, &verifiedIdentity{subject: idToken.Subject, claims: &claims}, nil
+}
+
+// CleanupExpiredStates removes login requests that were never completed.
+func (s *OIDCService) CleanupExpiredStates(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "OIDCService.CleanupExpiredStates")
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.states.DeleteExpired(ctx); err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("failed to cleanup expired oidc login states")
+		return err
+	}
+	return nil
+}
+
+// resolveUser returns the user linked to the identity, provisioning one when
+// the identity is new.
+func (s *OIDCService) resolveUser(ctx context.Context, tenant *domain.Tenant, provider config.OIDCProvider, subject string, claims *idTokenClaims) (*domain.User, *domain.UserIdentity, error) {
+	identity, err := s.identities.GetByProviderSubject(ctx, tenant.TenantID, provider.Name, subject)
+	if err == nil {
+		user, err := s.userRepo.GetByID(ctx, tenant.TenantID, identity.UserID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return user, identity, nil
+	}
+	if appErr, ok := err.(*apperrors.AppError); !ok || appErr.Code != apperrors.ErrCodeNotFound {
+		return nil, nil, err
+	}
+
+	if !provider.AllowSignup {
+		return nil, nil, apperrors.Forbidden("no account is linked to this identity")
+	}
+	if !tenant.Settings.RegistrationEnabled {
+		return nil, nil, apperrors.Forbidden("registration is disabled")
+	}
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, nil, apperrors.Forbidden("identity provider did not supply a verified email")
+	}
+	if !tenant.Settings.AllowsEmail(claims.Email) {
+		return nil, nil, apperrors.Forbidden("email domain is not allowed")
+	}
+
+	if existing, err := s.userRepo.GetByEmail(ctx, tenant.TenantID, claims.Email); err == nil && existing != nil {
+		return nil, nil, apperrors.AlreadyExists("email").WithDetails(map[string]string{
+			"reason": "an account with this email already exists; sign in to it to link this identity",
+		})
+	}
+
+	username, err := s.availableUsername(ctx, tenant, claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fullName := strings.TrimSpace(claims.Name)
+	if fullName == "" {
+		fullName = username
+	}
+
+	user := &domain.User{
+		TenantID: tenant.TenantID,
+		Username: username,
+		Email:    claims.Email,
+		FullName: fullName,
+		Role:     domain.RoleUser,
+		IsActive: true,
+	}
+	if err := s.auth.provisionUser(ctx, tenant, user, map[string]string{"provider": provider.Name}); err != nil {
+		return nil, nil, err
+	}
+
+	identity = &domain.UserIdentity{
+		TenantID: tenant.TenantID,
+		UserID:   user.UserID,
+		Provider: provider.Name,
+		Subject:  subject,
+		Email:    claims.Email,
+	}
+	if err := s.identities.Create(ctx, identity); err != nil {
+		// A concurrent callback for the same identity won; discard the
+		// duplicate account and use theirs.
+		if delErr := s.userRepo.Delete(ctx, tenant.TenantID, user.UserID); delErr != nil {
+			s.logger.WithContext(ctx).WithError(delErr).Error("failed to remove user after identity link failed")
+		}
+		if appErr, ok := err.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrCodeAlreadyExists {
+			return s.resolveUser(ctx, tenant, provider, subject, claims)
+		}
+		return nil, nil, err
+	}
+
+	s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"user_id":  user.UserID,
+		"provider": provider.Name,
+	}).Info("user provisioned from identity provider")
+
+	return user, identity, nil
+}
+
+// availableUsername derives a username from the preferred_username or email
+// claims, adding a numeric suffix while the name is taken.
+func (s *OIDCService) availableUsername(ctx context.Context, tenant *domain.Tenant, claims *idTokenClaims) (string, error) {
+	base := usernameDisallowed.ReplaceAllString(claims.PreferredUsername, "")
+	if len(base) < 3 {
+		local, _, _ := strings.Cut(claims.Email, "@")
+		base = usernameDisallowed.ReplaceAllString(local, "")
+	}
+	if len(base) < 3 {
+		base = "user"
+	}
+	if len(base) > 25 {
+		base = base[:25]
+	}
+
+	for i := 1; i <= 100; i++ {
+		candidate := base
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d", base, i)
+		}
+
+		_, err := s.userRepo.GetByUsername(ctx, tenant.TenantID, candidate)
+		if appErr, ok := err.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrCodeNotFound {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return "", apperrors.AlreadyExists("username")
+}
+
+// provider returns the named provider, running discovery on first use.
+func (s *OIDCService) provider(ctx context.Context, name string) (*oidcProvider, error) {
+	s.mu.Lock()
+	cached, ok := s.providers[name]
+	s.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	cfg, ok := s.cfg.Provider(name)
+	if !ok {
+		return nil, apperrors.NotFound("identity provider")
+	}
+
+	discovered, err := oidc.NewProvider(oidc.ClientContext(ctx, s.httpClient), cfg.Issuer)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("provider", name).Error("oidc discovery failed")
+		return nil, apperrors.ServiceUnavailable("identity provider is unavailable")
+	}
+
+	p := &oidcProvider{
+		config: cfg,
+		oauth: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     discovered.Endpoint(),
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       cfg.Scopes,
+		},
+		verifier: discovered.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}
+
+	s.mu.Lock()
+	s.providers[name] = p
+	s.mu.Unlock()
+
+	return p, nil
+}
+
+func oidcFailureReason(err *apperrors.AppError) string {
+	switch err.Code {
+	case apperrors.ErrCodeAlreadyExists:
+		return "email_taken"
+	case apperrors.ErrCodeForbidden:
+		return "signup_not_allowed"
+	default:
+		return "identity_resolution_failed"
+	}
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,446 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/repository"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// memoryOIDCStateRepository keeps login states in memory.
+type memoryOIDCStateRepository struct {
+	mu     sync.Mutex
+	states map[string]*domain.OIDCLoginState
+}
+
+func (r *memoryOIDCStateRepository) Create(ctx context.Context, state *domain.OIDCLoginState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[state.StateHash] = state
+	return nil
+}
+
+func (r *memoryOIDCStateRepository) Consume(ctx context.Context, stateHash string) (*domain.OIDCLoginState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.states[stateHash]
+	if !ok {
+		return nil, apperrors.NotFound("login state")
+	}
+	delete(r.states, stateHash)
+	return state, nil
+}
+
+func (r *memoryOIDCStateRepository) DeleteExpired(ctx context.Context) error {
+	return nil
+}
+
+// memoryIdentityRepository keeps linked identities in memory. It knows no
+// passwords, so every user is treated as password-less.
+type memoryIdentityRepository struct {
+	mu         sync.Mutex
+	identities map[uuid.UUID]*domain.UserIdentity
+}
+
+func newMemoryIdentityRepository() *memoryIdentityRepository {
+	return &memoryIdentityRepository{identities: make(map[uuid.UUID]*domain.UserIdentity)}
+}
+
+func (r *memoryIdentityRepository) Create(ctx context.Context, identity *domain.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.identities {
+		if existing.TenantID == identity.TenantID && existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return apperrors.AlreadyExists("identity")
+		}
+	}
+	identity.IdentityID = uuid.New()
+	identity.CreatedAt = time.Now()
+	r.identities[identity.IdentityID] = identity
+	return nil
+}
+
+func (r *memoryIdentityRepository) GetByProviderSubject(ctx context.Context, tenantID uuid.UUID, provider, subject string) (*domain.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, identity := range r.identities {
+		if identity.TenantID == tenantID && identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, apperrors.NotFound("identity")
+}
+
+func (r *memoryIdentityRepository) ListByUserID(ctx context.Context, tenantID, userID uuid.UUID) ([]*domain.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	identities := []*domain.UserIdentity{}
+	for _, identity := range r.identities {
+		if identity.TenantID == tenantID && identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+func (r *memoryIdentityRepository) UpdateLastUsed(ctx context.Context, identityID uuid.UUID, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if identity, ok := r.identities[identityID]; ok {
+		identity.LastUsedAt = &usedAt
+	}
+	return nil
+}
+
+func (r *memoryIdentityRepository) Delete(ctx context.Context, tenantID, userID, identityID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	identity, ok := r.identities[identityID]
+	if !ok || identity.TenantID != tenantID || identity.UserID != userID {
+		return apperrors.NotFound("identity")
+	}
+	for _, other := range r.identities {
+		if other.IdentityID != identityID && other.TenantID == tenantID && other.UserID == userID {
+			delete(r.identities, identityID)
+			return nil
+		}
+	}
+	return repository.ErrLastLoginMethod
+}
+
+func (r *memoryIdentityRepository) DeletePassword(ctx context.Context, tenantID, userID uuid.UUID) error {
+	return apperrors.NotFound("password")
+}
+
+// memoryAuditRepository records audit events in memory.
+type memoryAuditRepository struct {
+	mu     sync.Mutex
+	events []*domain.AuditEvent
+}
+
+func (r *memoryAuditRepository) Create(ctx context.Context, event *domain.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *memoryAuditRepository) List(ctx context.Context, filter *domain.AuditEventFilter) ([]*domain.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events, nil
+}
+
+func (r *memoryAuditRepository) reasons() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var reasons []string
+	for _, event := range r.events {
+		reasons = append(reasons, event.Reason)
+	}
+	return reasons
+}
+
+// stubIssuer is an OpenID provider serving discovery, a JWKS and a token
+// endpoint. Codes are issued directly by the test in place of the
+// authorization endpoint, which the user's browser would visit.
+type stubIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu          sync.Mutex
+	codes       map[string]stubGrant
+	discoveries int
+}
+
+type stubGrant struct {
+	challenge string
+	nonce     string
+	subject   string
+}
+
+const stubClientID = "auth-service"
+
+func newStubIssuer(t *testing.T) *stubIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	issuer := &stubIssuer{t: t, key: key, codes: make(map[string]stubGrant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("GET /jwks", issuer.jwks)
+	mux.HandleFunc("POST /token", issuer.token)
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (i *stubIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	i.mu.Lock()
+	i.discoveries++
+	i.mu.Unlock()
+
+	url := i.server.URL
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                                url,
+		"authorization_endpoint":                url + "/authorize",
+		"token_endpoint":                        url + "/token",
+		"jwks_uri":                              url + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (i *stubIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	encode := base64.RawURLEncoding.EncodeToString
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   encode(i.key.N.Bytes()),
+			"e":   encode(big.NewInt(int64(i.key.E)).Bytes()),
+		}},
+	})
+}
+
+// token redeems a code once, checking the PKCE verifier against the
+// challenge the code was issued for.
+func (i *stubIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	i.mu.Lock()
+	grant, ok := i.codes[r.PostForm.Get("code")]
+	delete(i.codes, r.PostForm.Get("code"))
+	i.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            i.server.URL,
+		"sub":            grant.subject,
+		"aud":            stubClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          grant.nonce,
+		"email":          grant.subject + "@example.com",
+		"email_verified": "true",
+	})
+	idToken.Header["kid"] = "test"
+	signed, err := idToken.SignedString(i.key)
+	if err != nil {
+		i.t.Errorf("sign id token: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "upstream-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+// issueCode approves an authorization request as the user's browser would
+// have, returning the code for the callback.
+func (i *stubIssuer) issueCode(authorizationURL, subject string) string {
+	i.t.Helper()
+	u, err := url.Parse(authorizationURL)
+	if err != nil {
+		i.t.Fatalf("parse authorization url: %v", err)
+	}
+	query := u.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		i.t.Fatalf("authorization url %s has no S256 code challenge", authorizationURL)
+	}
+	if query.Get("nonce") == "" {
+		i.t.Fatalf("authorization url %s has no nonce", authorizationURL)
+	}
+	return i.issueCodeFor(query.Get("code_challenge"), query.Get("nonce"), subject)
+}
+
+func (i *stubIssuer) issueCodeFor(challenge, nonce, subject string) string {
+	code := uuid.NewString()
+	i.mu.Lock()
+	i.codes[code] = stubGrant{challenge: challenge, nonce: nonce, subject: subject}
+	i.mu.Unlock()
+	return code
+}
+
+func newTestOIDCService(t *testing.T, issuerURL string) (*OIDCService, *memoryIdentityRepository, *memoryAuditRepository) {
+	log := logger.New("error", "json", "")
+	cfg := &config.OIDCConfig{
+		StateTTL: 10 * time.Minute,
+		Providers: []config.OIDCProvider{{
+			Name:        "stub",
+			Issuer:      issuerURL,
+			ClientID:    stubClientID,
+			RedirectURL: "http://localhost/callback",
+			Scopes:      []string{"openid", "email"},
+		}},
+	}
+	identities := newMemoryIdentityRepository()
+	auditRepo := &memoryAuditRepository{}
+	states := &memoryOIDCStateRepository{states: make(map[string]*domain.OIDCLoginState)}
+	auth := &AuthService{audit: NewAuditService(auditRepo, log), logger: log}
+	return NewOIDCService(cfg, states, identities, nil, auth, log), identities, auditRepo
+}
+
+func TestOIDCLinkCallback(t *testing.T) {
+	ctx := context.Background()
+	issuer := newStubIssuer(t)
+	svc, identities, _ := newTestOIDCService(t, issuer.server.URL)
+	tenant := &domain.Tenant{TenantID: uuid.New()}
+	userID := uuid.New()
+
+	start, err := svc.AuthorizeLink(ctx, tenant, userID, "stub")
+	if err != nil {
+		t.Fatalf("AuthorizeLink: %v", err)
+	}
+	code := issuer.issueCode(start.AuthorizationURL, "alice")
+
+	identity, err := svc.LinkCallback(ctx, tenant, userID, "stub", &domain.OIDCCallbackRequest{Code: code, State: start.State})
+	if err != nil {
+		t.Fatalf("LinkCallback: %v", err)
+	}
+	if identity.Subject != "alice" || identity.Email != "alice@example.com" || identity.UserID != userID {
+		t.Fatalf("linked identity %+v", identity)
+	}
+	if _, err := identities.GetByProviderSubject(ctx, tenant.TenantID, "stub", "alice"); err != nil {
+		t.Fatalf("identity not stored: %v", err)
+	}
+
+	// The state is consumed by the callback.
+	_, err = svc.LinkCallback(ctx, tenant, userID, "stub", &domain.OIDCCallbackRequest{Code: code, State: start.State})
+	assertUnauthorized(t, err)
+
+	// Discovery runs once per provider.
+	if _, err := svc.AuthorizeLink(ctx, tenant, userID, "stub"); err != nil {
+		t.Fatalf("AuthorizeLink: %v", err)
+	}
+	if issuer.discoveries != 1 {
+		t.Fatalf("discovery ran %d times, want 1", issuer.discoveries)
+	}
+}
+
+func TestOIDCCallbackRequiresCodeVerifier(t *testing.T) {
+	ctx := context.Background()
+	issuer := newStubIssuer(t)
+	svc, identities, auditRepo := newTestOIDCService(t, issuer.server.URL)
+	tenant := &domain.Tenant{TenantID: uuid.New()}
+	userID := uuid.New()
+
+	// A code issued for another request's challenge must not be redeemable
+	// with this request's verifier.
+	first, err := svc.AuthorizeLink(ctx, tenant, userID, "stub")
+	if err != nil {
+		t.Fatalf("AuthorizeLink: %v", err)
+	}
+	second, err := svc.AuthorizeLink(ctx, tenant, userID, "stub")
+	if err != nil {
+		t.Fatalf("AuthorizeLink: %v", err)
+	}
+	code := issuer.issueCode(first.AuthorizationURL, "alice")
+
+	_, err = svc.LinkCallback(ctx, tenant, userID, "stub", &domain.OIDCCallbackRequest{Code: code, State: second.State})
+	assertUnauthorized(t, err)
+	if reasons := auditRepo.reasons(); len(reasons) != 1 || reasons[0] != "code_exchange_failed" {
+		t.Fatalf("audited reasons %v, want code_exchange_failed", reasons)
+	}
+	if len(identities.identities) != 0 {
+		t.Fatal("identity linked without a valid code verifier")
+	}
+}
+
+func TestOIDCCallbackChecksNonce(t *testing.T) {
+	ctx := context.Background()
+	issuer := newStubIssuer(t)
+	svc, identities, auditRepo := newTestOIDCService(t, issuer.server.URL)
+	tenant := &domain.Tenant{TenantID: uuid.New()}
+	userID := uuid.New()
+
+	start, err := svc.AuthorizeLink(ctx, tenant, userID, "stub")
+	if err != nil {
+		t.Fatalf("AuthorizeLink: %v", err)
+	}
+	u, _ := url.Parse(start.AuthorizationURL)
+	code := issuer.issueCodeFor(u.Query().Get("code_challenge"), "replayed-nonce", "alice")
+
+	_, err = svc.LinkCallback(ctx, tenant, userID, "stub", &domain.OIDCCallbackRequest{Code: code, State: start.State})
+	assertUnauthorized(t, err)
+	if reasons := auditRepo.reasons(); len(reasons) != 1 || reasons[0] != "invalid_id_token" {
+		t.Fatalf("audited reasons %v, want invalid_id_token", reasons)
+	}
+	if len(identities.identities) != 0 {
+		t.Fatal("identity linked with a mismatched nonce")
+	}
+}
+
+func TestOIDCCallbackRejectsStateOfAnotherUser(t *testing.T) {
+	ctx := context.Background()
+	issuer := newStubIssuer(t)
+	svc, _, _ := newTestOIDCService(t, issuer.server.URL)
+	tenant := &domain.Tenant{TenantID: uuid.New()}
+
+	start, err := svc.AuthorizeLink(ctx, tenant, uuid.New(), "stub")
+	if err != nil {
+		t.Fatalf("AuthorizeLink: %v", err)
+	}
+	code := issuer.issueCode(start.AuthorizationURL, "alice")
+
+	_, err = svc.LinkCallback(ctx, tenant, uuid.New(), "stub", &domain.OIDCCallbackRequest{Code: code, State: start.State})
+	assertUnauthorized(t, err)
+}
+
+func TestOIDCDiscoveryFailure(t *testing.T) {
+	issuer := newStubIssuer(t)
+	svc, _, _ := newTestOIDCService(t, issuer.server.URL)
+	issuer.server.Close()
+
+	_, err := svc.AuthorizeLink(context.Background(), &domain.Tenant{TenantID: uuid.New()}, uuid.New(), "stub")
+	appErr, ok := err.(*apperrors.AppError)
+	if !ok || appErr.Code != apperrors.ErrCodeServiceUnavailable {
+		t.Fatalf("AuthorizeLink = %v, want service unavailable", err)
+	}
+}
+
+func assertUnauthorized(t *testing.T, err error) {
+	t.Helper()
+	appErr, ok := err.(*apperrors.AppError)
+	if !ok || appErr.Code != apperrors.ErrCodeUnauthorized {
+		t.Fatalf("err = %v, want unauthorized", err)
+	}
+}
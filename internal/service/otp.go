@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/metrics"
+	"auth-service/internal/repository"
+	"auth-service/internal/tracing"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// otpProvider names email codes in audit metadata and in the amr claim of
+// step-up tokens.
+const otpProvider = "otp"
+
+// OTPService sends six-digit codes by email and verifies them, either to log
+// in or to step up a signed-in user's token.
+type OTPService struct {
+	cfg           *config.OTPConfig
+	codes         repository.OTPRepository
+	users         repository.UserRepository
+	auth          *AuthService
+	jwt           *JWTService
+	notifications *NotificationService
+	keys          [][]byte // the first signs new codes
+	logger        *logger.Logger
+}
+
+// NewOTPService creates the service. Codes are stored as HMACs under a key
+// derived from the first of secrets; codes stored under the others still
+// verify, so that codes sent before a key rotation keep working.
+func NewOTPService(
+	cfg *config.OTPConfig,
+	codes repository.OTPRepository,
+	users repository.UserRepository,
+	auth *AuthService,
+	jwt *JWTService,
+	notifications *NotificationService,
+	secrets []string,
+	log *logger.Logger,
+) *OTPService {
+	keys := make([][]byte, 0, len(secrets))
+	for _, secret := range secrets {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte("otp"))
+		keys = append(keys, mac.Sum(nil))
+	}
+
+	return &OTPService{
+		cfg:           cfg,
+		codes:         codes,
+		users:         users,
+		auth:          auth,
+		jwt:           jwt,
+		notifications: notifications,
+		keys:          keys,
+		logger:        log,
+	}
+}
+
+// RequestLogin emails a login code to the active user with the given
+// address. As with magic links, the response does not reveal whether the
+// user exists and the code is sent in the background.
+func (s *OTPService) RequestLogin(ctx context.Context, tenant *domain.Tenant, req *domain.OTPRequest) (_ *domain.OTPChallenge, err error) {
+	ctx, span := tracing.Start(ctx, "OTPService.RequestLogin")
+	defer func() { tracing.End(span, err) }()
+
+	if !s.cfg.Enabled {
+		return nil, apperrors.NotFound("one-time code login")
+	}
+
+	challengeID := uuid.New()
+	go func(ctx context.Context) {
+		log := s.logger.WithContext(ctx).WithField("tenant_id", tenant.TenantID)
+
+		user, err := s.users.GetByEmail(ctx, tenant.TenantID, req.Email)
+		if err != nil {
+			if appErr, ok := err.(*apperrors.AppError); !ok || appErr.Code != apperrors.ErrCodeNotFound {
+				log.WithError(err).Error("failed to look up login code recipient")
+			}
+			return
+		}
+		if !user.IsActive {
+			log.WithField("user_id", user.UserID).Warn("login code not sent: user is inactive")
+			return
+		}
+
+		if err := s.send(ctx, tenant, user, challengeID, domain.OTPPurposeLogin); err != nil {
+			log.WithError(err).WithField("user_id", user.UserID).Error("failed to send login code")
+		}
+	}(context.WithoutCancel(ctx))
+
+	return &domain.OTPChallenge{
+		Message:     "if an account exists for this email, a login code has been sent",
+		ChallengeID: challengeID,
+		ExpiresIn:   int(s.cfg.TTL.Seconds()),
+	}, nil
+}
+
+// VerifyLogin logs in the user the challenge's code was sent to.
+func (s *OTPService) VerifyLogin(ctx context.Context, tenant *domain.Tenant, req *domain.OTPVerifyRequest) (_ *domain.AuthResponse, err error) {
+	ctx, span := tracing.Start(ctx, "OTPService.VerifyLogin")
+	defer func() { tracing.End(span, err) }()
+
+	if !s.cfg.Enabled {
+		return nil, apperrors.NotFound("one-time code login")
+	}
+
+	log := s.logger.WithContext(ctx).WithField("tenant_id", tenant.TenantID)
+
+	failed := func(target *uuid.UUID, reason string) {
+		metrics.Logins.WithLabelValues(metrics.ResultFailure).Inc()
+		metrics.LoginFailures.WithLabelValues(reason).Inc()
+		s.auth.audit.Record(ctx, &domain.AuditEvent{
+			TenantID:     tenant.TenantID,
+			EventType:    domain.AuditEventLogin,
+			Result:       domain.AuditResultFailure,
+			Reason:       reason,
+			TargetUserID: target,
+			Metadata:     map[string]string{"provider": otpProvider},
+		})
+	}
+
+	otp, err := s.verify(ctx, tenant, req, domain.OTPPurposeLogin)
+	if err != nil {
+		if failure, ok := err.(*AuthFailure); ok {
+			log.WithField("reason", failure.Reason).Warn("one-time code login failed")
+			failed(failure.UserID, failure.Reason)
+			return nil, failure.Err
+		}
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(ctx, tenant.TenantID, otp.UserID)
+	if err != nil {
+		failed(userRef(otp.UserID), "user_not_found")
+		return nil, apperrors.InvalidCredentials()
+	}
+	if !user.IsActive {
+		log.WithField("user_id", user.UserID).Warn("one-time code login failed: user is inactive")
+		failed(userRef(user.UserID), "account_inactive")
+		return nil, apperrors.Unauthorized("account is inactive")
+	}
+
+	return s.auth.completeLogin(ctx, tenant, user, false, map[string]string{"provider": otpProvider})
+}
+
+// RequestStepUp emails a code to the signed-in user, to be exchanged for a
+// step-up token by VerifyStepUp.
+func (s *OTPService) RequestStepUp(ctx context.Context, tenant *domain.Tenant, userID uuid.UUID) (_ *domain.OTPChallenge, err error) {
+	ctx, span := tracing.Start(ctx, "OTPService.RequestStepUp")
+	defer func() { tracing.End(span, err) }()
+
+	if !s.cfg.Enabled {
+		return nil, apperrors.NotFound("one-time code step-up")
+	}
+
+	log := s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"tenant_id": tenant.TenantID,
+		"user_id":   userID,
+	})
+
+	user, err := s.users.GetByID(ctx, tenant.TenantID, userID)
+	if err != nil {
+		return nil, apperrors.NotFound("user")
+	}
+
+	challengeID := uuid.New()
+	if err := s.send(ctx, tenant, user, challengeID, domain.OTPPurposeStepUp); err != nil {
+		log.WithError(err).Error("failed to send step-up code")
+		return nil, apperrors.ServiceUnavailable("failed to send verification code")
+	}
+
+	return &domain.OTPChallenge{
+		Message:     "a verification code has been sent to your email",
+		ChallengeID: challengeID,
+		ExpiresIn:   int(s.cfg.TTL.Seconds()),
+	}, nil
+}
+
+// VerifyStepUp checks a code sent by RequestStepUp to the same user and
+// returns an access token with "otp" in its amr claim, bound to the caller's
+// session if sessionID is not nil.
+func (s *OTPService) VerifyStepUp(ctx context.Context, tenant *domain.Tenant, userID uuid.UUID, sessionID *uuid.UUID, req *domain.OTPVerifyRequest) (_ *domain.StepUpResponse, err error) {
+	ctx, span := tracing.Start(ctx, "OTPService.VerifyStepUp")
+	defer func() { tracing.End(span, err) }()
+
+	if !s.cfg.Enabled {
+		return nil, apperrors.NotFound("one-time code step-up")
+	}
+
+	log := s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"tenant_id": tenant.TenantID,
+		"user_id":   userID,
+	})
+
+	otp, err := s.verify(ctx, tenant, req, domain.OTPPurposeStepUp)
+	if err == nil && otp.UserID != userID {
+		err = &AuthFailure{Reason: "wrong_user", Err: apperrors.InvalidInput("invalid or expired code")}
+	}
+	if err != nil {
+		if failure, ok := err.(*AuthFailure); ok {
+			log.WithField("reason", failure.Reason).Warn("step-up verification failed")
+			s.auth.audit.Record(ctx, &domain.AuditEvent{
+				TenantID:     tenant.TenantID,
+				EventType:    domain.AuditEventStepUp,
+				Result:       domain.AuditResultFailure,
+				Reason:       failure.Reason,
+				ActorUserID:  userRef(userID),
+				TargetUserID: userRef(userID),
+				Metadata:     map[string]string{"provider": otpProvider},
+			})
+			return nil, failure.Err
+		}
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(ctx, tenant.TenantID, userID)
+	if err != nil {
+		return nil, apperrors.NotFound("user")
+	}
+	if !user.IsActive {
+		return nil, apperrors.Unauthorized("account is inactive")
+	}
+
+	sid := uuid.Nil
+	if sessionID != nil {
+		sid = *sessionID
+	}
+	token, expiresAt, err := s.jwt.GenerateStepUpToken(user, sid, s.auth.tokenLifetime(ctx, tenant, user, false), []string{otpProvider})
+	if err != nil {
+		log.WithError(err).Error("failed to generate step-up token")
+		return nil, apperrors.Internal("failed to generate token")
+	}
+
+	log.Info("step-up verified")
+	s.auth.audit.Record(ctx, &domain.AuditEvent{
+		TenantID:     tenant.TenantID,
+		EventType:    domain.AuditEventStepUp,
+		ActorUserID:  userRef(userID),
+		TargetUserID: userRef(userID),
+		Metadata:     map[string]string{"provider": otpProvider},
+	})
+
+	return &domain.StepUpResponse{
+		AccessToken: token,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// CleanupExpiredCodes deletes codes that expired unverified.
+func (s *OTPService) CleanupExpiredCodes(ctx context.Context) error {
+	return s.codes.DeleteExpired(ctx)
+}
+
+func (s *OTPService) send(ctx context.Context, tenant *domain.Tenant, user *domain.User, challengeID uuid.UUID, purpose string) error {
+	code, err := randomCode()
+	if err != nil {
+		return err
+	}
+
+	otp := &domain.OTPCode{
+		OTPID:     challengeID,
+		TenantID:  tenant.TenantID,
+		UserID:    user.UserID,
+		Purpose:   purpose,
+		CodeHash:  s.hash(s.keys[0], challengeID, code),
+		ExpiresAt: time.Now().Add(s.cfg.TTL),
+	}
+	if err := s.codes.Create(ctx, otp); err != nil {
+		return err
+	}
+
+	template := TemplateOTPLogin
+	if purpose == domain.OTPPurposeStepUp {
+		template = TemplateOTPStepUp
+	}
+	err = s.notifications.Send(ctx, &Notification{
+		TenantID: tenant.TenantID,
+		UserID:   userRef(user.UserID),
+		To:       user.Email,
+		Template: template,
+		Data: map[string]interface{}{
+			"Code":       code,
+			"TTLMinutes": int(s.cfg.TTL.Minutes()),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"user_id": user.UserID,
+		"purpose": purpose,
+	}).Info("one-time code queued")
+	return nil
+}
+
+// verify checks a code against its challenge. Every call counts as an
+// attempt; a code is discarded once verified, expired or out of attempts.
+// Rejections are returned as *AuthFailure.
+func (s *OTPService) verify(ctx context.Context, tenant *domain.Tenant, req *domain.OTPVerifyRequest, purpose string) (*domain.OTPCode, error) {
+	invalid := apperrors.InvalidInput("invalid or expired code")
+
+	otp, err := s.codes.RecordAttempt(ctx, tenant.TenantID, req.ChallengeID)
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrCodeNotFound {
+			return nil, &AuthFailure{Reason: "code_not_found", Err: invalid}
+		}
+		s.logger.WithContext(ctx).WithError(err).Error("failed to record otp attempt")
+		return nil, apperrors.Internal("failed to verify code")
+	}
+
+	discard := func() {
+		if err := s.codes.Delete(ctx, tenant.TenantID, otp.OTPID); err != nil {
+			if appErr, ok := err.(*apperrors.AppError); !ok || appErr.Code != apperrors.ErrCodeNotFound {
+				s.logger.WithContext(ctx).WithError(err).Warn("failed to discard otp code")
+			}
+		}
+	}
+
+	switch {
+	case otp.Purpose != purpose:
+		return nil, &AuthFailure{Reason: "code_not_found", Err: invalid}
+	case time.Now().After(otp.ExpiresAt):
+		discard()
+		return nil, &AuthFailure{Reason: "code_expired", UserID: userRef(otp.UserID), Err: invalid}
+	case otp.Attempts > s.cfg.MaxAttempts:
+		discard()
+		return nil, &AuthFailure{Reason: "too_many_attempts", UserID: userRef(otp.UserID), Err: invalid}
+	}
+
+	if !s.matches(otp, req.Code) {
+		if otp.Attempts >= s.cfg.MaxAttempts {
+			discard()
+		}
+		return nil, &AuthFailure{Reason: "invalid_code", UserID: userRef(otp.UserID), Err: invalid}
+	}
+
+	// Deleting consumes the code; of concurrent verifications with the
+	// right code only the first gets here without an error.
+	if err := s.codes.Delete(ctx, tenant.TenantID, otp.OTPID); err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrCodeNotFound {
+			return nil, &AuthFailure{Reason: "code_not_found", UserID: userRef(otp.UserID), Err: invalid}
+		}
+		s.logger.WithContext(ctx).WithError(err).Error("failed to consume otp code")
+		return nil, apperrors.Internal("failed to verify code")
+	}
+
+	return otp, nil
+}
+
+// matches reports whether code is the one stored for otp under any key.
+func (s *OTPService) matches(otp *domain.OTPCode, code string) bool {
+	for _, key := range s.keys {
+		if hmac.Equal([]byte(s.hash(key, otp.OTPID, code)), []byte(otp.CodeHash)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *OTPService) hash(key []byte, challengeID uuid.UUID, code string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(challengeID[:])
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
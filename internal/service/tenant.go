@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/repository"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+var tenantSlug = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// maxCachedMisses bounds how many unknown tenant identifiers are remembered,
+// since clients choose them freely.
+const maxCachedMisses = 10000
+
+// cachedTenant is a lookup result; a nil tenant records that none was found.
+type cachedTenant struct {
+	tenant    *domain.Tenant
+	expiresAt time.Time
+}
+
+type TenantService struct {
+	tenantRepo repository.TenantRepository
+	config     *config.TenantConfig
+	logger     *logger.Logger
+
+	mu     sync.RWMutex
+	cache  map[string]cachedTenant
+	misses int
+}
+
+func NewTenantService(tenantRepo repository.TenantRepository, cfg *config.TenantConfig, log *logger.Logger) *TenantService {
+	return &TenantService{
+		tenantRepo: tenantRepo,
+		config:     cfg,
+		logger:     log,
+		cache:      make(map[string]cachedTenant),
+	}
+}
+
+// Resolve picks the tenant for a request: an explicit identifier (slug or id)
+// wins, then the request host when host resolution is enabled, then the
+// configured default tenant.
+func (s *TenantService) Resolve(ctx context.Context, identifier, host string) (*domain.Tenant, error) {
+	log := s.logger.WithContext(ctx)
+
+	var (
+		tenant *domain.Tenant
+		err    error
+	)
+
+	switch {
+	case identifier != "":
+		tenant, err = s.lookup(ctx, "id:"+identifier, func() (*domain.Tenant, error) {
+			if tenantID, parseErr := uuid.Parse(identifier); parseErr == nil {
+				return s.tenantRepo.GetByID(ctx, tenantID)
+			}
+			return s.tenantRepo.GetBySlug(ctx, strings.ToLower(identifier))
+		})
+	case s.config.ResolveFromHost && host != "":
+		hostname := stripPort(host)
+		tenant, err = s.lookup(ctx, "host:"+hostname, func() (*domain.Tenant, error) {
+			return s.tenantRepo.GetByDomain(ctx, hostname)
+		})
+		if isNotFound(err) {
+			tenant, err = s.defaultTenant(ctx)
+		}
+	default:
+		tenant, err = s.defaultTenant(ctx)
+	}
+
+	if err != nil {
+		if isNotFound(err) {
+			log.WithField("tenant", identifier).Warn("tenant resolution failed: tenant not found")
+			return nil, apperrors.NotFound("tenant")
+		}
+		log.WithError(err).Error("tenant resolution failed")
+		return nil, apperrors.Internal("failed to resolve tenant")
+	}
+
+	if !tenant.IsActive {
+		log.WithField("tenant_id", tenant.TenantID).Warn("tenant resolution failed: tenant is inactive")
+		return nil, apperrors.Forbidden("tenant is inactive")
+	}
+
+	return tenant, nil
+}
+
+func (s *TenantService) GetByID(ctx context.Context, tenantID uuid.UUID) (*domain.Tenant, error) {
+	return s.lookup(ctx, "id:"+tenantID.String(), func() (*domain.Tenant, error) {
+		return s.tenantRepo.GetByID(ctx, tenantID)
+	})
+}
+
+// Find returns the tenant with the given slug or id, whether or not it is
+// active, bypassing the cache.
+func (s *TenantService) Find(ctx context.Context, identifier string) (*domain.Tenant, error) {
+	if tenantID, err := uuid.Parse(identifier); err == nil {
+		return s.tenantRepo.GetByID(ctx, tenantID)
+	}
+	return s.tenantRepo.GetBySlug(ctx, strings.ToLower(identifier))
+}
+
+func (s *TenantService) List(ctx context.Context) ([]*domain.Tenant, error) {
+	return s.tenantRepo.List(ctx)
+}
+
+func (s *TenantService) Create(ctx context.Context, tenant *domain.Tenant) error {
+	if err := normalizeTenant(tenant); err != nil {
+		return err
+	}
+	if err := s.tenantRepo.Create(ctx, tenant); err != nil {
+		return err
+	}
+	s.forgetTenant(tenant)
+	return nil
+}
+
+// Update saves tenant. Other replicas see the change once their cached copy
+// expires, after at most TENANT_CACHE_TTL.
+func (s *TenantService) Update(ctx context.Context, tenant *domain.Tenant) error {
+	if err := normalizeTenant(tenant); err != nil {
+		return err
+	}
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return err
+	}
+	s.forgetTenant(tenant)
+	return nil
+}
+
+// forgetTenant drops the cache entries for tenant, under its old identifiers
+// as well, and the cached misses for its current ones.
+func (s *TenantService) forgetTenant(tenant *domain.Tenant) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, entry := range s.cache {
+		if entry.tenant != nil && entry.tenant.TenantID == tenant.TenantID {
+			s.forget(key)
+		}
+	}
+	s.forget("id:" + tenant.Slug)
+	s.forget("id:" + tenant.TenantID.String())
+	if tenant.Domain != "" {
+		s.forget("host:" + tenant.Domain)
+	}
+}
+
+func normalizeTenant(tenant *domain.Tenant) error {
+	tenant.Slug = strings.ToLower(strings.TrimSpace(tenant.Slug))
+	tenant.Name = strings.TrimSpace(tenant.Name)
+	tenant.Domain = strings.ToLower(strings.TrimSpace(tenant.Domain))
+
+	if !tenantSlug.MatchString(tenant.Slug) {
+		return apperrors.InvalidInput("slug must be 1 to 63 lowercase letters, digits and dashes, starting with a letter or digit")
+	}
+	if _, err := uuid.Parse(tenant.Slug); err == nil {
+		return apperrors.InvalidInput("slug must not be a UUID")
+	}
+	if tenant.Name == "" || len(tenant.Name) > 100 {
+		return apperrors.InvalidInput("name must be 1 to 100 characters")
+	}
+	if len(tenant.Domain) > 255 || strings.ContainsAny(tenant.Domain, "/: ") {
+		return apperrors.InvalidInput("domain must be a host name without scheme or port")
+	}
+
+	settings := &tenant.Settings
+	if settings.AccessTokenExpiry < 0 || settings.RefreshTokenExpiry < 0 {
+		return apperrors.InvalidInput("token expiries must not be negative")
+	}
+	if settings.AccessTokenExpiry > 0 && settings.RefreshTokenExpiry > 0 && settings.AccessTokenExpiry >= settings.RefreshTokenExpiry {
+		return apperrors.InvalidInput(fmt.Sprintf("refresh token expiry (%s) must be longer than access token expiry (%s)", settings.RefreshTokenExpiry, settings.AccessTokenExpiry))
+	}
+	for i, d := range settings.AllowedEmailDomains {
+		settings.AllowedEmailDomains[i] = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "@"))
+	}
+	return nil
+}
+
+func (s *TenantService) defaultTenant(ctx context.Context) (*domain.Tenant, error) {
+	return s.lookup(ctx, "id:"+s.config.DefaultSlug, func() (*domain.Tenant, error) {
+		return s.tenantRepo.GetBySlug(ctx, s.config.DefaultSlug)
+	})
+}
+
+// lookup loads a tenant through the cache. Unknown identifiers are cached as
+// well, so that requests naming them do not each reach the database; a tenant
+// created under such an identifier shows up once the entry expires.
+func (s *TenantService) lookup(ctx context.Context, key string, load func() (*domain.Tenant, error)) (*domain.Tenant, error) {
+	if s.config.CacheTTL > 0 {
+		s.mu.RLock()
+		entry, ok := s.cache[key]
+		s.mu.RUnlock()
+
+		if ok && time.Now().Before(entry.expiresAt) {
+			if entry.tenant == nil {
+				return nil, apperrors.NotFound("tenant")
+			}
+			return entry.tenant, nil
+		}
+	}
+
+	tenant, err := load()
+	if err != nil {
+		if isNotFound(err) && s.config.CacheTTL > 0 {
+			s.store(key, nil)
+		}
+		return nil, err
+	}
+
+	if s.config.CacheTTL > 0 {
+		s.store(key, tenant)
+	}
+
+	return tenant, nil
+}
+
+func (s *TenantService) store(key string, tenant *domain.Tenant) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if tenant == nil && s.misses >= maxCachedMisses {
+		for k, entry := range s.cache {
+			if !now.Before(entry.expiresAt) {
+				s.forget(k)
+			}
+		}
+		if s.misses >= maxCachedMisses {
+			return
+		}
+	}
+
+	s.forget(key)
+	s.cache[key] = cachedTenant{tenant: tenant, expiresAt: now.Add(s.config.CacheTTL)}
+	if tenant == nil {
+		s.misses++
+	}
+}
+
+// forget drops a cache entry. The caller must hold mu.
+func (s *TenantService) forget(key string) {
+	if entry, ok := s.cache[key]; ok {
+		if entry.tenant == nil {
+			s.misses--
+		}
+		delete(s.cache, key)
+	}
+}
+
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+func isNotFound(err error) bool {
+	appErr, ok := err.(*apperrors.AppError)
+	return ok && appErr.Code == apperrors.ErrCodeNotFound
+}
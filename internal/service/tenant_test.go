@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+type countingTenantRepository struct {
+	tenants map[string]*domain.Tenant
+	loads   int
+}
+
+func (r *countingTenantRepository) Create(ctx context.Context, tenant *domain.Tenant) error {
+	tenant.TenantID = uuid.New()
+	r.tenants[tenant.Slug] = tenant
+	return nil
+}
+
+func (r *countingTenantRepository) GetByID(ctx context.Context, tenantID uuid.UUID) (*domain.Tenant, error) {
+	r.loads++
+	for _, tenant := range r.tenants {
+		if tenant.TenantID == tenantID {
+			return tenant, nil
+		}
+	}
+	return nil, apperrors.NotFound("tenant")
+}
+
+func (r *countingTenantRepository) GetBySlug(ctx context.Context, slug string) (*domain.Tenant, error) {
+	r.loads++
+	if tenant, ok := r.tenants[slug]; ok {
+		return tenant, nil
+	}
+	return nil, apperrors.NotFound("tenant")
+}
+
+func (r *countingTenantRepository) GetByDomain(ctx context.Context, hostname string) (*domain.Tenant, error) {
+	r.loads++
+	return nil, apperrors.NotFound("tenant")
+}
+
+func (r *countingTenantRepository) Update(ctx context.Context, tenant *domain.Tenant) error {
+	for slug, stored := range r.tenants {
+		if stored.TenantID == tenant.TenantID {
+			delete(r.tenants, slug)
+		}
+	}
+	r.tenants[tenant.Slug] = tenant
+	return nil
+}
+
+func (r *countingTenantRepository) List(ctx context.Context) ([]*domain.Tenant, error) {
+	tenants := []*domain.Tenant{}
+	for _, tenant := range r.tenants {
+		tenants = append(tenants, tenant)
+	}
+	return tenants, nil
+}
+
+func newTestTenantService(ttl time.Duration) (*TenantService, *countingTenantRepository) {
+	repo := &countingTenantRepository{tenants: map[string]*domain.Tenant{
+		"acme": {TenantID: uuid.New(), Slug: "acme", IsActive: true},
+	}}
+	cfg := &config.TenantConfig{DefaultSlug: "default", CacheTTL: ttl}
+	return NewTenantService(repo, cfg, logger.New("error", "json", "")), repo
+}
+
+func TestTenantResolveCachesUnknownTenants(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestTenantService(time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if _, err := svc.Resolve(ctx, "missing", ""); !isNotFound(err) {
+			t.Fatalf("Resolve(missing) = %v, want not found", err)
+		}
+	}
+	if repo.loads != 1 {
+		t.Fatalf("repository loaded %d times, want 1", repo.loads)
+	}
+
+	tenant, err := svc.Resolve(ctx, "acme", "")
+	if err != nil {
+		t.Fatalf("Resolve(acme): %v", err)
+	}
+	if tenant.Slug != "acme" {
+		t.Fatalf("Resolve(acme) = %q", tenant.Slug)
+	}
+}
+
+func TestTenantResolveWithoutCache(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestTenantService(0)
+
+	for i := 0; i < 3; i++ {
+		if _, err := svc.Resolve(ctx, "missing", ""); !isNotFound(err) {
+			t.Fatalf("Resolve(missing) = %v, want not found", err)
+		}
+	}
+	if repo.loads != 3 {
+		t.Fatalf("repository loaded %d times, want 3", repo.loads)
+	}
+}
+
+func TestTenantCacheBoundsMisses(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestTenantService(time.Minute)
+
+	for i := 0; i < maxCachedMisses+10; i++ {
+		_, _ = svc.Resolve(ctx, uuid.NewString(), "")
+	}
+	if svc.misses != maxCachedMisses || len(svc.cache) != maxCachedMisses {
+		t.Fatalf("cache holds %d entries and %d misses, want %d", len(svc.cache), svc.misses, maxCachedMisses)
+	}
+}
+
+func TestTenantCreateForgetsCachedMiss(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestTenantService(time.Minute)
+
+	if _, err := svc.Resolve(ctx, "globex", ""); !isNotFound(err) {
+		t.Fatalf("Resolve(globex) = %v, want not found", err)
+	}
+	if err := svc.Create(ctx, &domain.Tenant{Slug: "Globex", Name: "Globex", IsActive: true}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := svc.Resolve(ctx, "globex", ""); err != nil {
+		t.Fatalf("Resolve(globex) after Create: %v", err)
+	}
+}
+
+func TestTenantUpdateForgetsOldSlug(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestTenantService(time.Minute)
+
+	tenant, err := svc.Resolve(ctx, "acme", "")
+	if err != nil {
+		t.Fatalf("Resolve(acme): %v", err)
+	}
+	renamed := *tenant
+	renamed.Slug = "acme-corp"
+	renamed.Name = "Acme"
+	if err := svc.Update(ctx, &renamed); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if _, err := svc.Resolve(ctx, "acme", ""); !isNotFound(err) {
+		t.Fatalf("Resolve(acme) after rename = %v, want not found", err)
+	}
+	if _, err := svc.Resolve(ctx, "acme-corp", ""); err != nil {
+		t.Fatalf("Resolve(acme-corp): %v", err)
+	}
+}
+
+func TestTenantCreateRejectsInvalidSettings(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestTenantService(time.Minute)
+
+	tests := map[string]*domain.Tenant{
+		"bad slug":  {Slug: "-acme", Name: "Acme"},
+		"uuid slug": {Slug: uuid.NewString(), Name: "Acme"},
+		"no name":   {Slug: "acme2"},
+		"domain":    {Slug: "acme2", Name: "Acme", Domain: "https://acme.example"},
+		"expiries": {Slug: "acme2", Name: "Acme", Settings: domain.TenantSettings{
+			AccessTokenExpiry:  time.Hour,
+			RefreshTokenExpiry: time.Minute,
+		}},
+	}
+	for name, tenant := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := svc.Create(ctx, tenant)
+			appErr, ok := err.(*apperrors.AppError)
+			if !ok || appErr.Code != apperrors.ErrCodeInvalidInput {
+				t.Fatalf("Create = %v, want invalid input", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,492 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	"auth-service/internal/repository"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+	webhookSecretBytes  = 32
+	webhookDeliveryPage = 50
+	maxWebhookDrainLen  = 64 << 10
+	webhookDeliveryJob  = "webhook_delivery"
+)
+
+type WebhookService struct {
+	webhookRepo repository.WebhookRepository
+	config      *config.WebhookConfig
+	client      *http.Client
+	jobs        JobReporter
+	logger      *logger.Logger
+	wake        chan struct{}
+}
+
+func NewWebhookService(webhookRepo repository.WebhookRepository, cfg *config.WebhookConfig, jobs JobReporter, log *logger.Logger) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		config:      cfg,
+		client:      newWebhookClient(cfg),
+		jobs:        jobs,
+		logger:      log,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// errWebhookAddressBlocked is returned for deliveries whose host resolves to
+// an address that WEBHOOK_ALLOW_PRIVATE_NETWORKS keeps off limits.
+var errWebhookAddressBlocked = errors.New("webhook destination address is not allowed")
+
+// newWebhookClient returns the delivery client. Tenant admins choose the
+// URLs, so unless private networks are allowed the dialer refuses internal
+// addresses. It checks the address actually dialed, after DNS resolution, so
+// a host that resolves to a public address at subscription time and to an
+// internal one later is still refused. Requests bypass any proxy, which
+// would otherwise be the address checked, and redirects are not followed.
+func newWebhookClient(cfg *config.WebhookConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.RequestTimeout}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || blockedWebhookAddr(addrPort.Addr()) {
+				return errWebhookAddressBlocked
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   cfg.RequestTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// blockedWebhookAddr reports whether addr is loopback, private, link-local
+// (which covers cloud metadata services at 169.254.169.254), multicast or
+// unspecified.
+func blockedWebhookAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || addr.IsUnspecified()
+}
+
+// checkWebhookHost refuses subscriptions to internal hosts up front. Hosts
+// that do not resolve yet are left to the delivery client's check.
+func (s *WebhookService) checkWebhookHost(ctx context.Context, host string) error {
+	if s.config.AllowPrivateNetworks {
+		return nil
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if blockedWebhookAddr(addr) {
+			return apperrors.ValidationFailed("url must not point at a private, loopback or link-local address")
+		}
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return apperrors.ValidationFailed("url must not point at a private, loopback or link-local address")
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if blockedWebhookAddr(addr) {
+			return apperrors.ValidationFailed("url must not point at a private, loopback or link-local address")
+		}
+	}
+	return nil
+}
+
+func (s *WebhookService) CreateSubscription(ctx context.Context, tenantID uuid.UUID, req *domain.CreateWebhookRequest) (*domain.CreateWebhookResponse, error) {
+	log := s.logger.WithContext(ctx).WithField("tenant_id", tenantID)
+
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return nil, apperrors.ValidationFailed("url must be an absolute http or https URL")
+	}
+	if s.config.RequireHTTPS && parsed.Scheme != "https" {
+		return nil, apperrors.ValidationFailed("url must use https")
+	}
+	if err := s.checkWebhookHost(ctx, strings.ToLower(parsed.Hostname())); err != nil {
+		return nil, err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		log.WithError(err).Error("failed to generate webhook secret")
+		return nil, apperrors.Internal("failed to create webhook subscription")
+	}
+
+	sub := &domain.WebhookSubscription{
+		TenantID:    tenantID,
+		URL:         req.URL,
+		Secret:      secret,
+		EventTypes:  req.EventTypes,
+		Description: req.Description,
+		IsActive:    true,
+	}
+
+	if err := s.webhookRepo.CreateSubscription(ctx, sub); err != nil {
+		log.WithError(err).Error("failed to create webhook subscription")
+		return nil, apperrors.Internal("failed to create webhook subscription")
+	}
+
+	log.WithField("subscription_id", sub.SubscriptionID).Info("webhook subscription created")
+
+	return &domain.CreateWebhookResponse{
+		Subscription: sub,
+		Secret:       secret,
+	}, nil
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context, tenantID uuid.UUID) ([]*domain.WebhookSubscription, error) {
+	subs, err := s.webhookRepo.ListSubscriptions(ctx, tenantID)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("failed to list webhook subscriptions")
+		return nil, apperrors.Internal("failed to list webhook subscriptions")
+	}
+	return subs, nil
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, tenantID, subscriptionID uuid.UUID) error {
+	if err := s.webhookRepo.DeleteSubscription(ctx, tenantID, subscriptionID); err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			return appErr
+		}
+		s.logger.WithContext(ctx).WithError(err).Error("failed to delete webhook subscription")
+		return apperrors.Internal("failed to delete webhook subscription")
+	}
+	return nil
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, tenantID, subscriptionID uuid.UUID) ([]*domain.WebhookDelivery, error) {
+	if _, err := s.webhookRepo.GetSubscription(ctx, tenantID, subscriptionID); err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			return nil, appErr
+		}
+		return nil, apperrors.Internal("failed to list webhook deliveries")
+	}
+
+	deliveries, err := s.webhookRepo.ListDeliveries(ctx, tenantID, subscriptionID, webhookDeliveryPage)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("failed to list webhook deliveries")
+		return nil, apperrors.Internal("failed to list webhook deliveries")
+	}
+	return deliveries, nil
+}
+
+// Redeliver queues a fresh delivery of the same event payload; the original
+// delivery row is kept as part of the delivery log.
+func (s *WebhookService) Redeliver(ctx context.Context, tenantID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	log := s.logger.WithContext(ctx).WithField("delivery_id", deliveryID)
+
+	original, err := s.webhookRepo.GetDelivery(ctx, tenantID, deliveryID)
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			return nil, appErr
+		}
+		log.WithError(err).Error("failed to load webhook delivery")
+		return nil, apperrors.Internal("failed to redeliver webhook")
+	}
+
+	delivery := &domain.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		TenantID:       original.TenantID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+	}
+
+	if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+		log.WithError(err).Error("failed to queue webhook redelivery")
+		return nil, apperrors.Internal("failed to redeliver webhook")
+	}
+
+	s.notify()
+	log.WithField("new_delivery_id", delivery.DeliveryID).Info("webhook redelivery queued")
+
+	return delivery, nil
+}
+
+// Publish queues the event for every active subscription of the tenant that
+// listens to eventType. Errors are logged and never returned to the caller.
+func (s *WebhookService) Publish(ctx context.Context, tenantID uuid.UUID, eventType string, data map[string]interface{}) {
+	if !s.config.Enabled {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	log := s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"tenant_id":  tenantID,
+		"event_type": eventType,
+	})
+
+	subs, err := s.webhookRepo.ListSubscriptionsForEvent(ctx, tenantID, eventType)
+	if err != nil {
+		log.WithError(err).Error("failed to load webhook subscriptions")
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+
+	event := &domain.WebhookEvent{
+		EventID:   uuid.New(),
+		Type:      eventType,
+		TenantID:  tenantID,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.WithError(err).Error("failed to encode webhook payload")
+		return
+	}
+
+	for _, sub := range subs {
+		delivery := &domain.WebhookDelivery{
+			SubscriptionID: sub.SubscriptionID,
+			TenantID:       tenantID,
+			EventID:        event.EventID,
+			EventType:      eventType,
+			Payload:        payload,
+		}
+		if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+			log.WithError(err).WithField("subscription_id", sub.SubscriptionID).Error("failed to queue webhook delivery")
+		}
+	}
+
+	s.notify()
+}
+
+// Start runs the delivery loop until ctx is cancelled. Deliveries are stored
+// in the database, so pending work survives restarts and is shared between
+// replicas.
+func (s *WebhookService) Start(ctx context.Context) {
+	if !s.config.Enabled {
+		s.logger.Info("webhook delivery disabled")
+		return
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"workers":       s.config.Workers,
+		"poll_interval": s.config.PollInterval,
+	}).Info("starting webhook delivery worker")
+
+	s.jobs.Register(webhookDeliveryJob, s.config.PollInterval)
+
+	go func() {
+		ticker := time.NewTicker(s.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			s.jobs.Report(webhookDeliveryJob, s.processDue(ctx))
+
+			select {
+			case <-ctx.Done():
+				s.logger.Info("webhook delivery worker stopped")
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
+
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *WebhookService) processDue(ctx context.Context) error {
+	batchSize := s.config.Workers * 4
+	lease := 2*s.config.RequestTimeout + time.Second
+
+	for ctx.Err() == nil {
+		deliveries, err := s.webhookRepo.ClaimDueDeliveries(ctx, batchSize, lease)
+		if err != nil {
+			s.logger.WithError(err).Error("failed to claim webhook deliveries")
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, s.config.Workers)
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(d *domain.WebhookDelivery) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				s.attempt(ctx, d)
+			}(delivery)
+		}
+		wg.Wait()
+
+		if len(deliveries) < batchSize {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (s *WebhookService) attempt(ctx context.Context, delivery *domain.WebhookDelivery) {
+	log := s.logger.WithFields(map[string]interface{}{
+		"delivery_id":     delivery.DeliveryID,
+		"subscription_id": delivery.SubscriptionID,
+		"event_type":      delivery.EventType,
+	})
+
+	statusCode, err := s.send(ctx, delivery)
+	now := time.Now()
+
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+
+	switch {
+	case err == nil:
+		delivery.Status = domain.WebhookDeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		log.Info("webhook delivered")
+	case delivery.Attempts >= s.config.MaxAttempts:
+		delivery.Status = domain.WebhookDeliveryFailed
+		delivery.LastError = webhookDeliveryError(statusCode, err)
+		log.WithError(err).Warn("webhook delivery failed permanently")
+	default:
+		delivery.LastError = webhookDeliveryError(statusCode, err)
+		delivery.NextAttemptAt = now.Add(s.backoff(delivery.Attempts))
+		log.WithError(err).WithField("next_attempt_at", delivery.NextAttemptAt).Warn("webhook delivery failed, will retry")
+	}
+
+	if err := s.webhookRepo.UpdateDelivery(context.WithoutCancel(ctx), delivery); err != nil {
+		log.WithError(err).Error("failed to record webhook delivery attempt")
+	}
+}
+
+func (s *WebhookService) send(ctx context.Context, delivery *domain.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "auth-service-webhooks/1.0")
+	req.Header.Set("X-Webhook-ID", delivery.DeliveryID.String())
+	req.Header.Set("X-Webhook-Event-ID", delivery.EventID.String())
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhookPayload(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// The body is drained so the connection can be reused, never kept.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookDrainLen))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// webhookDeliveryError is the error recorded on a delivery, which tenant
+// admins can list. It names the status code or a kind of failure only:
+// response bodies and dial errors would let them probe internal networks.
+func webhookDeliveryError(statusCode int, err error) string {
+	var netErr net.Error
+	switch {
+	case statusCode != 0:
+		return fmt.Sprintf("receiver responded with %d", statusCode)
+	case errors.Is(err, errWebhookAddressBlocked):
+		return "destination address is not allowed"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "request timed out"
+	default:
+		return "request failed"
+	}
+}
+
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	return retryBackoff(s.config.InitialBackoff, s.config.MaxBackoff, attempts)
+}
+
+// retryBackoff doubles initial for every attempt after the first, up to
+// maxDelay, and adds jitter.
+func retryBackoff(initial, maxDelay time.Duration, attempts int) time.Duration {
+	delay := initial
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	// Up to 20% jitter keeps retries from many deliveries from lining up.
+	jitter := time.Duration(mathrand.Int64N(int64(delay)/5 + 1))
+	return delay + jitter
+}
+
+// SignWebhookPayload returns the hex HMAC-SHA256 of "timestamp.payload".
+// Receivers recompute it with their subscription secret and compare it to the
+// X-Webhook-Signature header, rejecting stale X-Webhook-Timestamp values.
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return webhookSecretPrefix + hex.EncodeToString(b), nil
+}
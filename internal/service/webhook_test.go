@@ -0,0 +1,493 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/domain"
+	apperrors "auth-service/pkg/errors"
+	"auth-service/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// memoryWebhookRepository keeps subscriptions and deliveries in memory.
+type memoryWebhookRepository struct {
+	mu         sync.Mutex
+	subs       map[uuid.UUID]*domain.WebhookSubscription
+	deliveries map[uuid.UUID]*domain.WebhookDelivery
+}
+
+func newMemoryWebhookRepository() *memoryWebhookRepository {
+	return &memoryWebhookRepository{
+		subs:       make(map[uuid.UUID]*domain.WebhookSubscription),
+		deliveries: make(map[uuid.UUID]*domain.WebhookDelivery),
+	}
+}
+
+func (r *memoryWebhookRepository) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub.SubscriptionID = uuid.New()
+	sub.CreatedAt = time.Now()
+	sub.UpdatedAt = sub.CreatedAt
+	r.subs[sub.SubscriptionID] = sub
+	return nil
+}
+
+func (r *memoryWebhookRepository) GetSubscription(ctx context.Context, tenantID, subscriptionID uuid.UUID) (*domain.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub, ok := r.subs[subscriptionID]
+	if !ok || sub.TenantID != tenantID {
+		return nil, apperrors.NotFound("webhook subscription")
+	}
+	return sub, nil
+}
+
+func (r *memoryWebhookRepository) ListSubscriptions(ctx context.Context, tenantID uuid.UUID) ([]*domain.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subs := []*domain.WebhookSubscription{}
+	for _, sub := range r.subs {
+		if sub.TenantID == tenantID {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (r *memoryWebhookRepository) ListSubscriptionsForEvent(ctx context.Context, tenantID uuid.UUID, eventType string) ([]*domain.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subs := []*domain.WebhookSubscription{}
+	for _, sub := range r.subs {
+		if sub.TenantID == tenantID && sub.IsActive && sub.Matches(eventType) {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (r *memoryWebhookRepository) DeleteSubscription(ctx context.Context, tenantID, subscriptionID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if sub, ok := r.subs[subscriptionID]; !ok || sub.TenantID != tenantID {
+		return apperrors.NotFound("webhook subscription")
+	}
+	delete(r.subs, subscriptionID)
+	return nil
+}
+
+func (r *memoryWebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery.DeliveryID = uuid.New()
+	delivery.Status = domain.WebhookDeliveryPending
+	delivery.NextAttemptAt = time.Now()
+	delivery.CreatedAt = delivery.NextAttemptAt
+	stored := *delivery
+	r.deliveries[delivery.DeliveryID] = &stored
+	return nil
+}
+
+func (r *memoryWebhookRepository) GetDelivery(ctx context.Context, tenantID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery, ok := r.deliveries[deliveryID]
+	if !ok || delivery.TenantID != tenantID {
+		return nil, apperrors.NotFound("webhook delivery")
+	}
+	copied := *delivery
+	return &copied, nil
+}
+
+func (r *memoryWebhookRepository) ListDeliveries(ctx context.Context, tenantID, subscriptionID uuid.UUID, limit int) ([]*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deliveries := []*domain.WebhookDelivery{}
+	for _, delivery := range r.deliveries {
+		if delivery.TenantID == tenantID && delivery.SubscriptionID == subscriptionID && len(deliveries) < limit {
+			copied := *delivery
+			deliveries = append(deliveries, &copied)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *memoryWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	claimed := []*domain.WebhookDelivery{}
+	for _, delivery := range r.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if delivery.Status != domain.WebhookDeliveryPending || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		sub, ok := r.subs[delivery.SubscriptionID]
+		if !ok {
+			continue
+		}
+		delivery.NextAttemptAt = now.Add(lease)
+		copied := *delivery
+		copied.URL = sub.URL
+		copied.Secret = sub.Secret
+		claimed = append(claimed, &copied)
+	}
+	return claimed, nil
+}
+
+func (r *memoryWebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *delivery
+	stored.URL, stored.Secret = "", ""
+	r.deliveries[delivery.DeliveryID] = &stored
+	return nil
+}
+
+// makeDue lets every pending delivery be claimed again right away.
+func (r *memoryWebhookRepository) makeDue() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range r.deliveries {
+		delivery.NextAttemptAt = time.Now()
+	}
+}
+
+func (r *memoryWebhookRepository) only(t *testing.T) *domain.WebhookDelivery {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.deliveries) != 1 {
+		t.Fatalf("have %d deliveries, want 1", len(r.deliveries))
+	}
+	for _, delivery := range r.deliveries {
+		copied := *delivery
+		return &copied
+	}
+	return nil
+}
+
+// webhookReceiver records the requests it gets and answers with the queued
+// status codes, then 204.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+
+	status := http.StatusNoContent
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+	if status >= 300 {
+		_, _ = w.Write([]byte("try again later"))
+	}
+}
+
+func newTestWebhookService(t *testing.T, statuses ...int) (*WebhookService, *memoryWebhookRepository, *webhookReceiver, string) {
+	t.Helper()
+	receiver := &webhookReceiver{statuses: statuses}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	// The receiver listens on loopback.
+	cfg := &config.WebhookConfig{
+		Enabled:              true,
+		AllowPrivateNetworks: true,
+		RequestTimeout:       5 * time.Second,
+		MaxAttempts:          3,
+		InitialBackoff:       time.Minute,
+		MaxBackoff:           time.Hour,
+		PollInterval:         time.Second,
+		Workers:              2,
+	}
+	repo := newMemoryWebhookRepository()
+	return NewWebhookService(repo, cfg, nil, logger.New("error", "json", "")), repo, receiver, server.URL
+}
+
+func subscribe(t *testing.T, svc *WebhookService, tenantID uuid.UUID, url string) *domain.CreateWebhookResponse {
+	t.Helper()
+	resp, err := svc.CreateSubscription(context.Background(), tenantID, &domain.CreateWebhookRequest{
+		URL:        url,
+		EventTypes: []string{domain.WebhookEventUserRegistered},
+	})
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	return resp
+}
+
+func TestWebhookDeliverySigned(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, receiver, url := newTestWebhookService(t)
+	tenantID := uuid.New()
+	sub := subscribe(t, svc, tenantID, url)
+
+	svc.Publish(ctx, tenantID, domain.WebhookEventUserRegistered, map[string]interface{}{"username": "alice"})
+	svc.Publish(ctx, tenantID, domain.WebhookEventUserLoggedIn, map[string]interface{}{"username": "alice"})
+	if err := svc.processDue(ctx); err != nil {
+		t.Fatalf("processDue: %v", err)
+	}
+
+	if len(receiver.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(receiver.requests))
+	}
+	req, body := receiver.requests[0], receiver.bodies[0]
+
+	timestamp := req.Header.Get("X-Webhook-Timestamp")
+	want := "sha256=" + SignWebhookPayload(sub.Secret, timestamp, body)
+	if got := req.Header.Get("X-Webhook-Signature"); got != want {
+		t.Fatalf("signature = %q, want %q", got, want)
+	}
+	if got := req.Header.Get("X-Webhook-Event"); got != domain.WebhookEventUserRegistered {
+		t.Fatalf("event header = %q", got)
+	}
+
+	var event domain.WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if event.TenantID != tenantID || event.Data["username"] != "alice" {
+		t.Fatalf("payload = %s", body)
+	}
+
+	delivery := repo.only(t)
+	if delivery.Status != domain.WebhookDeliverySucceeded || delivery.Attempts != 1 || delivery.DeliveredAt == nil {
+		t.Fatalf("delivery = %+v, want succeeded after 1 attempt", delivery)
+	}
+	if req.Header.Get("X-Webhook-ID") != delivery.DeliveryID.String() {
+		t.Fatalf("X-Webhook-ID = %q, want %s", req.Header.Get("X-Webhook-ID"), delivery.DeliveryID)
+	}
+}
+
+func TestWebhookDeliveryRetries(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, receiver, url := newTestWebhookService(t, http.StatusInternalServerError)
+	tenantID := uuid.New()
+	subscribe(t, svc, tenantID, url)
+
+	svc.Publish(ctx, tenantID, domain.WebhookEventUserRegistered, nil)
+	if err := svc.processDue(ctx); err != nil {
+		t.Fatalf("processDue: %v", err)
+	}
+
+	delivery := repo.only(t)
+	if delivery.Status != domain.WebhookDeliveryPending || delivery.Attempts != 1 || delivery.LastStatusCode != http.StatusInternalServerError {
+		t.Fatalf("delivery = %+v, want pending after a 500", delivery)
+	}
+	if delivery.LastError != "receiver responded with 500" {
+		t.Fatalf("last error = %q, want the status code without the receiver's body", delivery.LastError)
+	}
+	if until := time.Until(delivery.NextAttemptAt); until < 50*time.Second || until > 73*time.Second {
+		t.Fatalf("next attempt in %s, want about the initial backoff", until)
+	}
+
+	// Not due yet: nothing is sent.
+	if err := svc.processDue(ctx); err != nil {
+		t.Fatalf("processDue: %v", err)
+	}
+	if len(receiver.requests) != 1 {
+		t.Fatalf("receiver got %d requests before the backoff elapsed, want 1", len(receiver.requests))
+	}
+
+	repo.makeDue()
+	if err := svc.processDue(ctx); err != nil {
+		t.Fatalf("processDue: %v", err)
+	}
+	delivery = repo.only(t)
+	if delivery.Status != domain.WebhookDeliverySucceeded || delivery.Attempts != 2 || delivery.LastError != "" {
+		t.Fatalf("delivery = %+v, want succeeded on the retry", delivery)
+	}
+	if receiver.requests[0].Header.Get("X-Webhook-ID") != receiver.requests[1].Header.Get("X-Webhook-ID") {
+		t.Fatal("a retry must reuse the delivery id")
+	}
+}
+
+func TestWebhookDeliveryGivesUp(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, receiver, url := newTestWebhookService(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	tenantID := uuid.New()
+	subscribe(t, svc, tenantID, url)
+
+	svc.Publish(ctx, tenantID, domain.WebhookEventUserRegistered, nil)
+	for i := 0; i < 5; i++ {
+		if err := svc.processDue(ctx); err != nil {
+			t.Fatalf("processDue: %v", err)
+		}
+		repo.makeDue()
+	}
+
+	delivery := repo.only(t)
+	if delivery.Status != domain.WebhookDeliveryFailed || delivery.Attempts != 3 {
+		t.Fatalf("delivery = %+v, want failed after 3 attempts", delivery)
+	}
+	if len(receiver.requests) != 3 {
+		t.Fatalf("receiver got %d requests, want 3", len(receiver.requests))
+	}
+}
+
+func TestWebhookRedeliver(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, receiver, url := newTestWebhookService(t)
+	tenantID := uuid.New()
+	subscribe(t, svc, tenantID, url)
+
+	svc.Publish(ctx, tenantID, domain.WebhookEventUserRegistered, nil)
+	if err := svc.processDue(ctx); err != nil {
+		t.Fatalf("processDue: %v", err)
+	}
+	original := repo.only(t)
+
+	if _, err := svc.Redeliver(ctx, uuid.New(), original.DeliveryID); !isNotFound(err) {
+		t.Fatalf("Redeliver from another tenant = %v, want not found", err)
+	}
+
+	redelivery, err := svc.Redeliver(ctx, tenantID, original.DeliveryID)
+	if err != nil {
+		t.Fatalf("Redeliver: %v", err)
+	}
+	if err := svc.processDue(ctx); err != nil {
+		t.Fatalf("processDue: %v", err)
+	}
+
+	if len(receiver.requests) != 2 {
+		t.Fatalf("receiver got %d requests, want 2", len(receiver.requests))
+	}
+	if got := receiver.requests[1].Header.Get("X-Webhook-ID"); got != redelivery.DeliveryID.String() {
+		t.Fatalf("X-Webhook-ID = %q, want the new delivery %s", got, redelivery.DeliveryID)
+	}
+	if receiver.requests[1].Header.Get("X-Webhook-Event-ID") != original.EventID.String() {
+		t.Fatal("a redelivery must keep the event id")
+	}
+	if string(receiver.bodies[0]) != string(receiver.bodies[1]) {
+		t.Fatal("a redelivery must send the same payload")
+	}
+}
+
+func TestWebhookPublishDisabled(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, _, url := newTestWebhookService(t)
+	tenantID := uuid.New()
+	subscribe(t, svc, tenantID, url)
+	svc.config.Enabled = false
+
+	svc.Publish(ctx, tenantID, domain.WebhookEventUserRegistered, nil)
+	if len(repo.deliveries) != 0 {
+		t.Fatalf("have %d deliveries with webhooks disabled, want 0", len(repo.deliveries))
+	}
+}
+
+func TestWebhookSubscriptionURL(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, _ := newTestWebhookService(t)
+	svc.config.RequireHTTPS = true
+
+	for _, url := range []string{"ftp://example.com/hook", "/relative", "http://example.com/hook"} {
+		_, err := svc.CreateSubscription(ctx, uuid.New(), &domain.CreateWebhookRequest{
+			URL:        url,
+			EventTypes: []string{domain.WebhookEventUserRegistered},
+		})
+		appErr, ok := err.(*apperrors.AppError)
+		if !ok || appErr.Code != apperrors.ErrCodeValidationFailed {
+			t.Fatalf("CreateSubscription(%q) = %v, want a validation error", url, err)
+		}
+	}
+
+	resp, err := svc.CreateSubscription(ctx, uuid.New(), &domain.CreateWebhookRequest{
+		URL:        "https://example.com/hook",
+		EventTypes: []string{domain.WebhookEventUserRegistered},
+	})
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	if !strings.HasPrefix(resp.Secret, webhookSecretPrefix) {
+		t.Fatalf("secret = %q, want the %s prefix", resp.Secret, webhookSecretPrefix)
+	}
+}
+
+func TestWebhookSubscriptionPrivateAddress(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, _ := newTestWebhookService(t)
+	svc.config.AllowPrivateNetworks = false
+
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.5/hook",
+		"http://[::1]/hook",
+		"http://[::ffff:192.168.1.1]/hook",
+		"http://0.0.0.0/hook",
+		"http://localhost/hook",
+	} {
+		_, err := svc.CreateSubscription(ctx, uuid.New(), &domain.CreateWebhookRequest{
+			URL:        url,
+			EventTypes: []string{domain.WebhookEventUserRegistered},
+		})
+		appErr, ok := err.(*apperrors.AppError)
+		if !ok || appErr.Code != apperrors.ErrCodeValidationFailed {
+			t.Fatalf("CreateSubscription(%q) = %v, want a validation error", url, err)
+		}
+	}
+}
+
+// Deliveries are checked again when dialing, which catches hosts that
+// resolved to a public address when the subscription was made.
+func TestWebhookDeliveryPrivateAddress(t *testing.T) {
+	ctx := context.Background()
+	for _, target := range []string{"loopback", "http://169.254.169.254/latest/meta-data/"} {
+		t.Run(target, func(t *testing.T) {
+			svc, repo, receiver, url := newTestWebhookService(t)
+			svc.config.AllowPrivateNetworks = false
+			svc.client = newWebhookClient(svc.config)
+			if target != "loopback" {
+				url = target
+			}
+			tenantID := uuid.New()
+			if err := repo.CreateSubscription(ctx, &domain.WebhookSubscription{
+				TenantID:   tenantID,
+				URL:        url,
+				Secret:     "whsec_test",
+				EventTypes: []string{domain.WebhookEventUserRegistered},
+				IsActive:   true,
+			}); err != nil {
+				t.Fatalf("CreateSubscription: %v", err)
+			}
+
+			svc.Publish(ctx, tenantID, domain.WebhookEventUserRegistered, nil)
+			if err := svc.processDue(ctx); err != nil {
+				t.Fatalf("processDue: %v", err)
+			}
+
+			if len(receiver.requests) != 0 {
+				t.Fatalf("receiver got %d requests, want none", len(receiver.requests))
+			}
+			delivery := repo.only(t)
+			if delivery.Status != domain.WebhookDeliveryPending || delivery.LastError != "destination address is not allowed" {
+				t.Fatalf("delivery = %+v, want refused", delivery)
+			}
+		})
+	}
+}
@@ -1,5 +1,5 @@
 CREATE TABLE IF NOT EXISTS users.sessions (
-    session_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    session_id UUID PRIMARY KEY DEFAULT public.uuid_generate_v4(),
     user_id UUID NOT NULL REFERENCES users.users(user_id) ON DELETE CASCADE,
     refresh_token TEXT NOT NULL UNIQUE,
     device_info TEXT,
@@ -19,5 +19,5 @@ CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON users.sessions(expires_at)
 CREATE INDEX IF NOT EXISTS idx_sessions_last_activity ON users.sessions(last_activity_at);
 CREATE INDEX IF NOT EXISTS idx_sessions_is_revoked ON users.sessions(is_revoked);
 
-CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_user_id_active ON users.sessions(user_id) 
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_user_id_active ON users.sessions(user_id)
 WHERE is_revoked = false;
\ No newline at end of file
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS users.idx_users_tenant_id;
+DROP INDEX IF EXISTS users.users_tenant_email_key;
+DROP INDEX IF EXISTS users.users_tenant_username_key;
+
+ALTER TABLE users.users ADD CONSTRAINT users_username_key UNIQUE (username);
+ALTER TABLE users.users ADD CONSTRAINT users_email_key UNIQUE (email);
+ALTER TABLE users.users DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS users.tenants CASCADE;
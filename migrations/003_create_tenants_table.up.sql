@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS users.tenants (
+    tenant_id UUID PRIMARY KEY DEFAULT public.uuid_generate_v4(),
+    slug VARCHAR(63) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    domain VARCHAR(255) UNIQUE,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    access_token_expiry_seconds INTEGER NOT NULL DEFAULT 0,
+    refresh_token_expiry_seconds INTEGER NOT NULL DEFAULT 0,
+    registration_enabled BOOLEAN NOT NULL DEFAULT true,
+    allowed_email_domains TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO users.tenants (tenant_id, slug, name)
+VALUES ('00000000-0000-0000-0000-000000000001', 'default', 'Default')
+ON CONFLICT (tenant_id) DO NOTHING;
+
+ALTER TABLE users.users
+    ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001'
+    REFERENCES users.tenants(tenant_id) ON DELETE CASCADE;
+
+ALTER TABLE users.users DROP CONSTRAINT IF EXISTS users_username_key;
+ALTER TABLE users.users DROP CONSTRAINT IF EXISTS users_email_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_username_key ON users.users(tenant_id, username);
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_email_key ON users.users(tenant_id, email);
+CREATE INDEX IF NOT EXISTS idx_users_tenant_id ON users.users(tenant_id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS users.api_keys CASCADE;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS users.api_keys (
+    key_id UUID PRIMARY KEY DEFAULT public.uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES users.tenants(tenant_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users.users(user_id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(32) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON users.api_keys(user_id);
@@ -0,0 +1 @@
+ALTER TABLE users.users DROP COLUMN IF EXISTS role;
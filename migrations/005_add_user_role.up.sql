@@ -0,0 +1 @@
+ALTER TABLE users.users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user';
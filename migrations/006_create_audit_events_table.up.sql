@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS users.audit_events (
+    event_id UUID PRIMARY KEY DEFAULT public.uuid_generate_v4(),
+    tenant_id UUID NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    result VARCHAR(20) NOT NULL,
+    reason VARCHAR(100),
+    actor_user_id UUID,
+    target_user_id UUID,
+    ip_address INET,
+    user_agent TEXT,
+    request_id VARCHAR(100),
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_tenant_created ON users.audit_events(tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON users.audit_events(actor_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON users.audit_events(target_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_type ON users.audit_events(event_type);
+
+CREATE OR REPLACE FUNCTION users.audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_append_only ON users.audit_events;
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON users.audit_events
+    FOR EACH ROW EXECUTE FUNCTION users.audit_events_append_only();
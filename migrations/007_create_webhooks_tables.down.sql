@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS users.webhook_deliveries CASCADE;
+DROP TABLE IF EXISTS users.webhook_subscriptions CASCADE;
@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS users.webhook_subscriptions (
+    subscription_id UUID PRIMARY KEY DEFAULT public.uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES users.tenants(tenant_id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    event_types TEXT[] NOT NULL,
+    description VARCHAR(255),
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_tenant ON users.webhook_subscriptions(tenant_id);
+
+CREATE TABLE IF NOT EXISTS users.webhook_deliveries (
+    delivery_id UUID PRIMARY KEY DEFAULT public.uuid_generate_v4(),
+    subscription_id UUID NOT NULL REFERENCES users.webhook_subscriptions(subscription_id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_status_code INTEGER,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON users.webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON users.webhook_deliveries(subscription_id, created_at DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS users.oidc_login_states CASCADE;
+DROP TABLE IF EXISTS users.user_identities CASCADE;
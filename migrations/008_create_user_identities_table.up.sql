@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS users.user_identities (
+    identity_id UUID PRIMARY KEY DEFAULT public.uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES users.tenants(tenant_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users.users(user_id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    CONSTRAINT user_identities_provider_subject_key UNIQUE (tenant_id, provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON users.user_identities(user_id);
+
+-- In-flight authorization requests to upstream OIDC providers. Rows are
+-- consumed by the callback; state_hash is the SHA-256 of the state parameter.
+CREATE TABLE IF NOT EXISTS users.oidc_login_states (
+    state_hash CHAR(64) PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES users.tenants(tenant_id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_oidc_login_states_expires_at ON users.oidc_login_states(expires_at);
@@ -0,0 +1,4 @@
+ALTER TABLE users.oidc_login_states DROP COLUMN IF EXISTS user_id;
+
+UPDATE users.users SET password_hash = '!' WHERE password_hash IS NULL;
+ALTER TABLE users.users ALTER COLUMN password_hash SET NOT NULL;
@@ -0,0 +1,8 @@
+-- A NULL password_hash marks an account that signs in only through linked
+-- identities.
+ALTER TABLE users.users ALTER COLUMN password_hash DROP NOT NULL;
+UPDATE users.users SET password_hash = NULL WHERE password_hash = '!';
+
+-- Set when the authorization request links an identity to a signed-in user
+-- rather than logging in.
+ALTER TABLE users.oidc_login_states ADD COLUMN IF NOT EXISTS user_id UUID REFERENCES users.users(user_id) ON DELETE CASCADE;
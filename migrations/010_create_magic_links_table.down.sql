@@ -0,0 +1 @@
+DROP TABLE IF EXISTS users.magic_links CASCADE;
@@ -0,0 +1,13 @@
+-- Outstanding email login links. The link carries a signed token naming
+-- link_id; nonce_hash is the SHA-256 of the nonce kept by the device that
+-- asked for it. Rows are deleted when the link is used.
+CREATE TABLE IF NOT EXISTS users.magic_links (
+    link_id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES users.tenants(tenant_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users.users(user_id) ON DELETE CASCADE,
+    nonce_hash CHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_magic_links_expires_at ON users.magic_links(expires_at);
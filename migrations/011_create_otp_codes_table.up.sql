@@ -0,0 +1,16 @@
+-- One-time codes sent by email, for login or step-up. code_hash is an HMAC
+-- of the code under a server key, as six digits are easy to brute force
+-- from a plain hash. A user has at most one pending code per purpose.
+CREATE TABLE IF NOT EXISTS users.otp_codes (
+    otp_id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES users.tenants(tenant_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users.users(user_id) ON DELETE CASCADE,
+    purpose VARCHAR(20) NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_otp_codes_user_purpose ON users.otp_codes(user_id, purpose);
+CREATE INDEX IF NOT EXISTS idx_otp_codes_expires_at ON users.otp_codes(expires_at);
@@ -0,0 +1,21 @@
+-- Record of outgoing notifications. Bodies are not stored, as they carry
+-- login links and codes; the queue holding them until delivery lives in
+-- memory.
+CREATE TABLE IF NOT EXISTS users.notifications (
+    notification_id UUID PRIMARY KEY DEFAULT public.uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES users.tenants(tenant_id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users.users(user_id) ON DELETE SET NULL,
+    template VARCHAR(50) NOT NULL,
+    locale VARCHAR(35) NOT NULL,
+    recipient VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_tenant_created ON users.notifications(tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON users.notifications(user_id, created_at DESC);
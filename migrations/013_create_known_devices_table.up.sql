@@ -0,0 +1,19 @@
+-- Devices and networks users have logged in from, so that logins from
+-- anywhere else can be reported to them. fingerprint is a hash of the user
+-- agent without version numbers; network is the client address truncated to
+-- its /24 or /48.
+CREATE TABLE IF NOT EXISTS users.known_devices (
+    device_id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES users.tenants(tenant_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users.users(user_id) ON DELETE CASCADE,
+    fingerprint CHAR(64) NOT NULL,
+    network VARCHAR(64) NOT NULL,
+    device_info TEXT,
+    ip_address INET,
+    location TEXT,
+    first_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, fingerprint, network)
+);
+
+CREATE INDEX IF NOT EXISTS idx_known_devices_last_seen_at ON users.known_devices(last_seen_at);
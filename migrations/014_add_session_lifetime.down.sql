@@ -0,0 +1,4 @@
+ALTER TABLE users.sessions DROP COLUMN IF EXISTS refresh_token_expiry;
+ALTER TABLE users.sessions DROP COLUMN IF EXISTS access_token_expiry;
+ALTER TABLE users.sessions DROP COLUMN IF EXISTS remember_me;
+ALTER TABLE users.sessions DROP COLUMN IF EXISTS lifetime_profile;
@@ -0,0 +1,6 @@
+-- The token lifetimes chosen at login, in seconds, and why. Zero means the
+-- defaults at the time of each refresh, as for sessions started before.
+ALTER TABLE users.sessions ADD COLUMN IF NOT EXISTS lifetime_profile VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE users.sessions ADD COLUMN IF NOT EXISTS remember_me BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users.sessions ADD COLUMN IF NOT EXISTS access_token_expiry INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users.sessions ADD COLUMN IF NOT EXISTS refresh_token_expiry INTEGER NOT NULL DEFAULT 0;
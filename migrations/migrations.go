@@ -0,0 +1,21 @@
+// Package migrations embeds the versioned SQL migrations applied by
+// internal/migrate. Files are named NNN_description.up.sql and
+// NNN_description.down.sql.
+//
+// The SQLite set starts at version 7 with the schema the PostgreSQL set had
+// reached by then; later changes add a file with the same version to both.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed *.sql
+var Postgres embed.FS
+
+//go:embed sqlite/*.sql
+var sqlite embed.FS
+
+// SQLite holds the migrations for DB_DRIVER=sqlite.
+var SQLite, _ = fs.Sub(sqlite, "sqlite")
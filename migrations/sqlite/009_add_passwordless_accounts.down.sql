@@ -0,0 +1,3 @@
+ALTER TABLE oidc_login_states DROP COLUMN user_id;
+
+UPDATE users SET password_hash = '!' WHERE password_hash = '';
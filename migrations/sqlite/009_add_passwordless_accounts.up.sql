@@ -0,0 +1,6 @@
+-- SQLite cannot drop NOT NULL without rebuilding users, which would cascade
+-- to every table referencing it, so an empty password_hash marks an account
+-- that signs in only through linked identities.
+UPDATE users SET password_hash = '' WHERE password_hash = '!';
+
+ALTER TABLE oidc_login_states ADD COLUMN user_id TEXT REFERENCES users(user_id) ON DELETE CASCADE;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS known_devices (
+    device_id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL REFERENCES tenants(tenant_id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    fingerprint CHAR(64) NOT NULL,
+    network VARCHAR(64) NOT NULL,
+    device_info TEXT,
+    ip_address TEXT,
+    location TEXT,
+    first_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, fingerprint, network)
+);
+
+CREATE INDEX IF NOT EXISTS idx_known_devices_last_seen_at ON known_devices(last_seen_at);
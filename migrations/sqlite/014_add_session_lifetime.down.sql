@@ -0,0 +1,4 @@
+ALTER TABLE sessions DROP COLUMN refresh_token_expiry;
+ALTER TABLE sessions DROP COLUMN access_token_expiry;
+ALTER TABLE sessions DROP COLUMN remember_me;
+ALTER TABLE sessions DROP COLUMN lifetime_profile;
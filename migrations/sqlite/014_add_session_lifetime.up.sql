@@ -0,0 +1,6 @@
+-- The token lifetimes chosen at login, in seconds, and why. Zero means the
+-- defaults at the time of each refresh, as for sessions started before.
+ALTER TABLE sessions ADD COLUMN lifetime_profile TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN remember_me BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN access_token_expiry INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN refresh_token_expiry INTEGER NOT NULL DEFAULT 0;
@@ -13,6 +13,7 @@ const (
 	ErrCodeTokenExpired       ErrorCode = "TOKEN_EXPIRED"
 	ErrCodeTokenInvalid       ErrorCode = "TOKEN_INVALID"
 	ErrCodeTokenMissing       ErrorCode = "TOKEN_MISSING"
+	ErrCodeForbidden          ErrorCode = "FORBIDDEN"
 
 	ErrCodeValidationFailed ErrorCode = "VALIDATION_FAILED"
 	ErrCodeInvalidInput     ErrorCode = "INVALID_INPUT"
@@ -78,6 +79,10 @@ func Unauthorized(message string) *AppError {
 	return New(ErrCodeUnauthorized, message, http.StatusUnauthorized)
 }
 
+func Forbidden(message string) *AppError {
+	return New(ErrCodeForbidden, message, http.StatusForbidden)
+}
+
 func InvalidCredentials() *AppError {
 	return New(ErrCodeInvalidCredentials, "Invalid username or password", http.StatusUnauthorized)
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type ContextKey string
@@ -17,6 +18,9 @@ const (
 	TimeFormat = "2006-01-02 15:04:05.000"
 )
 
+// RequestIDKey is the context key under which the request id is stored.
+const RequestIDKey ContextKey = "request_id"
+
 type Logger struct {
 	logger zerolog.Logger
 }
@@ -35,7 +39,7 @@ func (w *orderedWriter) Write(p []byte) (n int, err error) {
 	first := true
 
 	fieldOrder := []string{
-		"time", "level", "request_id", "method", "path", "status",
+		"time", "level", "request_id", "trace_id", "span_id", "method", "path", "status",
 		"duration_ms", "remote_addr", "user_agent", "user_id",
 		"message", "environment", "port", "address", "error",
 	}
@@ -62,7 +66,12 @@ func (w *orderedWriter) Write(p []byte) (n int, err error) {
 	}
 
 	orderedJSON += "}\n"
-	return w.writer.Write([]byte(orderedJSON))
+	// Report all of p as written: the rewritten line differs in length, and
+	// io.MultiWriter stops at the first writer that reports a short write.
+	if _, err := w.writer.Write([]byte(orderedJSON)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
 }
 
 func New(level, format, filePath string) *Logger {
@@ -93,6 +102,11 @@ func New(level, format, filePath string) *Logger {
 	return &Logger{logger: logger}
 }
 
+// SetLevel changes the minimum level of every logger in the process.
+func SetLevel(level string) {
+	zerolog.SetGlobalLevel(parseLevel(level))
+}
+
 func parseLevel(level string) zerolog.Level {
 	switch level {
 	case "debug":
@@ -108,8 +122,29 @@ func parseLevel(level string) zerolog.Level {
 	}
 }
 
+// WithContext attaches the request id and, when a span is recording, the
+// trace and span ids so log lines can be joined with traces.
 func (l *Logger) WithContext(ctx context.Context) *Logger {
-	return l
+	if ctx == nil {
+		return l
+	}
+
+	requestID, _ := ctx.Value(RequestIDKey).(string)
+	spanContext := trace.SpanContextFromContext(ctx)
+	if requestID == "" && !spanContext.IsValid() {
+		return l
+	}
+
+	logger := l.logger.With()
+	if requestID != "" {
+		logger = logger.Str("request_id", requestID)
+	}
+	if spanContext.IsValid() {
+		logger = logger.
+			Str("trace_id", spanContext.TraceID().String()).
+			Str("span_id", spanContext.SpanID().String())
+	}
+	return &Logger{logger: logger.Logger()}
 }
 
 func (l *Logger) WithField(key string, value interface{}) *Logger {
@@ -168,7 +203,7 @@ func (l *Logger) Fatalf(format string, args ...interface{}) {
 	l.logger.Fatal().Msgf(format, args...)
 }
 
-func (l *Logger) HTTPRequest(method, path string, status int, durationMs int64, remoteAddr, userAgent, requestID string, userID interface{}) {
+func (l *Logger) HTTPRequest(method, path string, status int, durationMs int64, remoteAddr, userAgent string, userID interface{}) {
 	event := l.logger.Info().
 		Str("method", method).
 		Str("path", path).
@@ -177,10 +212,21 @@ func (l *Logger) HTTPRequest(method, path string, status int, durationMs int64,
 		Str("remote_addr", remoteAddr).
 		Str("user_agent", userAgent)
 
-	if requestID != "" {
-		event = event.Str("request_id", requestID)
+	if userID != nil {
+		event = event.Interface("user_id", userID)
 	}
 
+	event.Msg("")
+}
+
+func (l *Logger) GRPCRequest(method, code string, durationMs int64, peer, userAgent string, userID interface{}) {
+	event := l.logger.Info().
+		Str("grpc_method", method).
+		Str("grpc_code", code).
+		Int64("duration_ms", durationMs).
+		Str("remote_addr", peer).
+		Str("user_agent", userAgent)
+
 	if userID != nil {
 		event = event.Interface("user_id", userID)
 	}
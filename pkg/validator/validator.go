@@ -88,6 +88,12 @@ func formatFieldError(e validator.FieldError) string {
 		return fmt.Sprintf("%s must be 3-30 characters and contain only letters, numbers, underscores, or hyphens", field)
 	case "password":
 		return fmt.Sprintf("%s must be at least 8 characters and contain uppercase, lowercase, number, and special character", field)
+	case "url":
+		return fmt.Sprintf("%s must be a valid URL", field)
+	case "nefield":
+		return fmt.Sprintf("%s must differ from %s", field, strings.ToLower(e.Param()))
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, e.Param())
 	case "eqfield":
 		return fmt.Sprintf("%s must match %s", field, e.Param())
 	default: